@@ -9,6 +9,9 @@ import (
 	"strings"
 )
 
+// GetGCPDefaults reads the project and the region of the active gcloud
+// configuration. The region is returned as the name of its regional instance
+// config, which is not checked against the configs the project can use.
 func GetGCPDefaults() (string, string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -41,10 +44,7 @@ func GetGCPDefaults() (string, string, error) {
 			projectId = strings.TrimPrefix(s, "project = ")
 		}
 		if strings.HasPrefix(s, "region = ") {
-			instanceConfig = fmt.Sprintf("regional-%s", strings.Trim(s, "region = "))
-			if FindInstanceConfigByName(instanceConfig) == InstanceConfigUndefined {
-				instanceConfig = GetRandomInstanceConfig()
-			}
+			instanceConfig = fmt.Sprintf("regional-%s", strings.TrimPrefix(s, "region = "))
 		}
 	}
 	return projectId, instanceConfig, nil
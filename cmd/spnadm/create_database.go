@@ -1,6 +1,9 @@
 package main
 
-import "github.com/spf13/cobra"
+import (
+	"github.com/katsew/spanner-operator/pkg/operator"
+	"github.com/spf13/cobra"
+)
 
 var createDatabaseCommand = cobra.Command{
 	Use:  "create [instanceId] [databaseName]",
@@ -14,7 +17,17 @@ var createDatabaseCommand = cobra.Command{
 		if databaseName == "" {
 			panic("No databaseName provided")
 		}
-		if err := op.CreateDatabase(instanceId, databaseName); err != nil {
+		if err := operator.ValidateInstanceId(instanceId); err != nil {
+			panic(err)
+		}
+		if err := operator.ValidateDatabaseId(databaseName); err != nil {
+			panic(err)
+		}
+		opName, err := op.CreateDatabase(ctx, instanceId, databaseName, nil)
+		if err != nil {
+			panic(err)
+		}
+		if err := op.WaitOperation(ctx, opName); err != nil {
 			panic(err)
 		}
 	},
@@ -1,6 +1,13 @@
 package main
 
-import "github.com/spf13/cobra"
+import (
+	"github.com/katsew/spanner-operator/pkg/operator"
+	"github.com/spf13/cobra"
+)
+
+var displayName string
+var nodeCount int32
+var processingUnits int32
 
 var createInstanceCommand = cobra.Command{
 	Use:  "create [instanceId] [instanceConfig]",
@@ -14,10 +21,38 @@ var createInstanceCommand = cobra.Command{
 		if instanceConfig == "" {
 			panic("No instanceConfig provided")
 		}
-		displayName := cmd.Flags().String("display-name", instanceId, "Display name for UI")
-		nodeCount := cmd.Flags().Int32P("node-count", "n", 1, "Number of nodes to allocate")
-		if err := op.CreateInstance(*displayName, instanceId, instanceConfig, *nodeCount); err != nil {
+		if err := operator.ValidateInstanceId(instanceId); err != nil {
+			panic(err)
+		}
+		name := displayName
+		if name == "" {
+			name = instanceId
+		}
+		capacity := operator.Nodes(nodeCount)
+		if processingUnits > 0 {
+			if cmd.Flags().Changed("node-count") {
+				panic("--node-count and --processing-units are mutually exclusive")
+			}
+			capacity = operator.ProcessingUnits(processingUnits)
+		}
+		if err := capacity.Validate(); err != nil {
+			panic(err)
+		}
+		if err := operator.CheckInstanceConfig(ctx, op, instanceConfig); err != nil {
+			panic(err)
+		}
+		opName, err := op.CreateInstance(ctx, name, instanceId, instanceConfig, capacity)
+		if err != nil {
+			panic(err)
+		}
+		if err := op.WaitOperation(ctx, opName); err != nil {
 			panic(err)
 		}
 	},
 }
+
+func init() {
+	createInstanceCommand.Flags().StringVar(&displayName, "display-name", "", "Display name for UI, defaults to instanceId")
+	createInstanceCommand.Flags().Int32VarP(&nodeCount, "node-count", "n", 1, "Number of nodes to allocate")
+	createInstanceCommand.Flags().Int32Var(&processingUnits, "processing-units", 0, "Number of processing units to allocate instead of nodes")
+}
@@ -10,7 +10,7 @@ var deleteInstanceCommand = cobra.Command{
 		if instanceId == "" {
 			panic("No instanceId provided")
 		}
-		if err := op.DeleteInstance(instanceId); err != nil {
+		if err := op.DeleteInstance(ctx, instanceId); err != nil {
 			panic(err)
 		}
 	},
@@ -14,7 +14,7 @@ var dropDatabaseCommand = cobra.Command{
 		if databaseName == "" {
 			panic("No databaseName provided")
 		}
-		if err := op.DropDatabase(instanceId, databaseName); err != nil {
+		if err := op.DropDatabase(ctx, instanceId, databaseName); err != nil {
 			panic(err)
 		}
 	},
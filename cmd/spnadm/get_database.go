@@ -17,7 +17,7 @@ var getDatabaseCommand = cobra.Command{
 		if databaseName == "" {
 			panic("No databaseName provided")
 		}
-		database, err := op.GetDatabase(instanceId, databaseName)
+		database, err := op.GetDatabase(ctx, instanceId, databaseName)
 		if err != nil && op.IsNotFoundError(err) {
 			log.Printf("Database does not exists, should create first")
 			return
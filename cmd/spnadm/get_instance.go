@@ -13,7 +13,7 @@ var getInstanceCommand = cobra.Command{
 		if instanceId == "" {
 			panic("No instanceId provided")
 		}
-		instance, err := op.GetInstance(instanceId)
+		instance, err := op.GetInstance(ctx, instanceId)
 		if err != nil && op.IsNotFoundError(err) {
 			log.Print("Instance does not exists, should create first")
 			return
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+	"log"
+)
+
+var iamDatabase string
+
+var getIamPolicyCommand = cobra.Command{
+	Use:  "get [instanceId]",
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		instanceId := args[0]
+		if instanceId == "" {
+			panic("No instanceId provided")
+		}
+		policy, err := op.GetIamPolicy(ctx, instanceId, iamDatabase)
+		if err != nil {
+			panic(err)
+		}
+		for _, b := range policy.Bindings {
+			log.Printf("%s: %v", b.Role, b.Members)
+		}
+	},
+}
+
+var testIamPermissionsCommand = cobra.Command{
+	Use:  "test [instanceId] [permission...]",
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		instanceId := args[0]
+		if instanceId == "" {
+			panic("No instanceId provided")
+		}
+		granted, err := op.TestIamPermissions(ctx, instanceId, iamDatabase, args[1:])
+		if err != nil {
+			panic(err)
+		}
+		log.Printf("Granted permissions: %v", granted)
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{&getIamPolicyCommand, &testIamPermissionsCommand} {
+		c.Flags().StringVar(&iamDatabase, "database", "", "Database in the instance, the instance itself when empty")
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+	"log"
+)
+
+var listPageSize int32
+
+var listInstancesCommand = cobra.Command{
+	Use: "list",
+	Run: func(cmd *cobra.Command, args []string) {
+		pageToken := ""
+		for {
+			instances, next, err := op.ListInstances(ctx, listPageSize, pageToken)
+			if err != nil {
+				panic(err)
+			}
+			for _, instance := range instances {
+				log.Printf("%s: %s", instance.Name, instance.State)
+			}
+			if next == "" {
+				return
+			}
+			pageToken = next
+		}
+	},
+}
+
+var listDatabasesCommand = cobra.Command{
+	Use:  "list [instanceId]",
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		instanceId := args[0]
+		if instanceId == "" {
+			panic("No instanceId provided")
+		}
+		pageToken := ""
+		for {
+			databases, next, err := op.ListDatabases(ctx, instanceId, listPageSize, pageToken)
+			if err != nil {
+				panic(err)
+			}
+			for _, database := range databases {
+				log.Printf("%s: %s", database.Name, database.State)
+			}
+			if next == "" {
+				return
+			}
+			pageToken = next
+		}
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{&listInstancesCommand, &listDatabasesCommand} {
+		c.Flags().Int32Var(&listPageSize, "page-size", 0, "Number of resources fetched per request, the server default when 0")
+	}
+}
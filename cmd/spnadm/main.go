@@ -1,21 +1,40 @@
 package main
 
 import (
+	"context"
 	"github.com/katsew/spanner-operator/cmd/helper"
 	"github.com/katsew/spanner-operator/pkg/operator"
+	"github.com/katsew/spanner-operator/pkg/signals"
 	"github.com/spf13/cobra"
 	"log"
+	"os"
+	"time"
 )
 
 var useMock bool
+var mockDataPath string
 var projectId string
 var serviceAccountPath string
+var emulatorHost string
+var timeout time.Duration
 var op operator.Operator
+var ctx context.Context
 
 func main() {
+	var cancel context.CancelFunc
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	signals.SetupSignalHandler(cancel)
+
+	// cancelTimeout releases the deadline of --timeout once the command is
+	// done.
+	cancelTimeout := func() {}
 	var cli = &cobra.Command{
 		Use: "spnadm",
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if timeout > 0 {
+				ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+			}
 			builder := operator.NewBuilder()
 			if projectId != "" {
 				builder.ProjectId(projectId)
@@ -25,21 +44,43 @@ func main() {
 			if serviceAccountPath != "" {
 				builder.ServiceAccountPath(serviceAccountPath)
 			}
+			if emulatorHost != "" {
+				builder.EmulatorHost(emulatorHost)
+			}
+			var err error
 			if useMock {
 				log.Print("Using mock client to execute")
-				op = builder.BuildMock("/tmp/spnadm")
+				op, err = builder.BuildMock(mockDataPath)
 			} else {
-				op = builder.Build()
+				op, err = builder.Build()
+			}
+			if err != nil {
+				panic(err)
+			}
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			defer cancelTimeout()
+			if err := op.Close(); err != nil {
+				log.Printf("Closing the operator: %s", err)
 			}
 		},
 	}
+	// Without a gcloud configuration, e.g. against the emulator, the
+	// project has to be given with --project-id.
 	pid, _, err := helper.GetGCPDefaults()
 	if err != nil {
-		panic(err)
+		log.Printf("No gcloud defaults: %s", err)
 	}
 	cli.PersistentFlags().BoolVar(&useMock, "use-mock", false, "Use mock client")
+	dataPath := os.Getenv(operator.MockDataPathEnv)
+	if dataPath == "" {
+		dataPath = operator.DefaultMockDataPath
+	}
+	cli.PersistentFlags().StringVar(&mockDataPath, "mock-data-path", dataPath, "Directory the mock client keeps its data in, shared with the controller")
 	cli.PersistentFlags().StringVarP(&projectId, "project-id", "p", pid, "GCP project ID")
 	cli.PersistentFlags().StringVarP(&serviceAccountPath, "service-account-path", "s", "", "Path to GCP ServiceAccount")
+	cli.PersistentFlags().StringVar(&emulatorHost, "emulator-host", "", "Address of a Cloud Spanner emulator, defaults to $SPANNER_EMULATOR_HOST")
+	cli.PersistentFlags().DurationVar(&timeout, "timeout", 10*time.Minute, "Deadline for the whole command, 0 to wait forever")
 	instanceCommand := cobra.Command{
 		Use: "instance",
 	}
@@ -48,6 +89,7 @@ func main() {
 		&deleteInstanceCommand,
 		&scaleCommand,
 		&getInstanceCommand,
+		&listInstancesCommand,
 	)
 	databaseCommand := cobra.Command{
 		Use: "database",
@@ -55,11 +97,20 @@ func main() {
 	databaseCommand.AddCommand(
 		&createDatabaseCommand,
 		&getDatabaseCommand,
+		&listDatabasesCommand,
 		&dropDatabaseCommand,
 	)
+	iamCommand := cobra.Command{
+		Use: "iam",
+	}
+	iamCommand.AddCommand(
+		&getIamPolicyCommand,
+		&testIamPermissionsCommand,
+	)
 	cli.AddCommand(
 		&instanceCommand,
 		&databaseCommand,
+		&iamCommand,
 	)
 
 	if err := cli.Execute(); err != nil {
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"github.com/katsew/spanner-operator/pkg/operator"
 	"github.com/spf13/cobra"
 	"strconv"
 )
 
+var scaleInProcessingUnits bool
+
 var scaleCommand = cobra.Command{
 	Use:  "scale [instanceId] [nodeCount]",
 	Args: cobra.MinimumNArgs(2),
@@ -13,12 +16,27 @@ var scaleCommand = cobra.Command{
 		if instanceId == "" {
 			panic("No instanceId provided")
 		}
-		nodeCount, err := strconv.ParseInt(args[1], 10, 32)
+		count, err := strconv.ParseInt(args[1], 10, 32)
 		if err != nil {
 			panic(err)
 		}
-		if err := op.Scale(args[0], int32(nodeCount)); err != nil {
+		capacity := operator.Nodes(int32(count))
+		if scaleInProcessingUnits {
+			capacity = operator.ProcessingUnits(int32(count))
+		}
+		if err := capacity.Validate(); err != nil {
+			panic(err)
+		}
+		opName, err := op.Scale(ctx, args[0], capacity)
+		if err != nil {
+			panic(err)
+		}
+		if err := op.WaitOperation(ctx, opName); err != nil {
 			panic(err)
 		}
 	},
 }
+
+func init() {
+	scaleCommand.Flags().BoolVar(&scaleInProcessingUnits, "processing-units", false, "Read the count as processing units instead of nodes")
+}
@@ -35,5 +35,15 @@ CODEGEN_PKG=${CODEGEN_PKG:-$(cd "${SCRIPT_ROOT}"; ls -d -1 ./vendor/k8s.io/code-
   databaseadmins:v1alpha1 \
   --go-header-file "${SCRIPT_ROOT}"/hack/custom-boilerplate.go.txt
 
+"${CODEGEN_PKG}"/generate-groups.sh "deepcopy,client,informer,lister" \
+  github.com/katsew/spanner-operator/pkg/generated/backupadmins github.com/katsew/spanner-operator/pkg/apis \
+  backupadmins:v1alpha1 \
+  --go-header-file "${SCRIPT_ROOT}"/hack/custom-boilerplate.go.txt
+
+"${CODEGEN_PKG}"/generate-groups.sh "deepcopy,client,informer,lister" \
+  github.com/katsew/spanner-operator/pkg/generated/iamadmins github.com/katsew/spanner-operator/pkg/apis \
+  iamadmins:v1alpha1 \
+  --go-header-file "${SCRIPT_ROOT}"/hack/custom-boilerplate.go.txt
+
 # To use your own boilerplate text append:
 #   --go-header-file "${SCRIPT_ROOT}"/hack/custom-boilerplate.go.txt
@@ -34,13 +34,22 @@ import (
 	// Uncomment the following line to load the gcp plugin (only required to authenticate against GKE clusters).
 	// _ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 
+	backupadminsClientset "github.com/katsew/spanner-operator/pkg/generated/backupadmins/clientset/versioned"
+	backupadminsInformers "github.com/katsew/spanner-operator/pkg/generated/backupadmins/informers/externalversions"
 	databaseadminsClientset "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/clientset/versioned"
 	databaseadminsInformers "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/informers/externalversions"
+	iamadminsClientset "github.com/katsew/spanner-operator/pkg/generated/iamadmins/clientset/versioned"
+	iamadminsInformers "github.com/katsew/spanner-operator/pkg/generated/iamadmins/informers/externalversions"
 	instanceadminsClientset "github.com/katsew/spanner-operator/pkg/generated/instanceadmins/clientset/versioned"
 	instanceadminsInformers "github.com/katsew/spanner-operator/pkg/generated/instanceadmins/informers/externalversions"
 	"github.com/katsew/spanner-operator/pkg/signals"
+	"github.com/katsew/spanner-operator/pkg/webhook"
 
+	"github.com/katsew/spanner-operator/pkg/controllers/backupadmins"
+	"github.com/katsew/spanner-operator/pkg/controllers/backupschedules"
 	_ "github.com/katsew/spanner-operator/pkg/controllers/databaseadmins"
+	"github.com/katsew/spanner-operator/pkg/controllers/databaseroles"
+	"github.com/katsew/spanner-operator/pkg/controllers/iamadmins"
 	"github.com/katsew/spanner-operator/pkg/controllers/instanceadmins"
 )
 
@@ -51,7 +60,10 @@ var (
 	mockEnabled        bool
 	projectId          string
 	serviceAccountPath string
-	op                 operator.Operator
+	emulatorHost       string
+	webhookAddr        string
+	tlsCertFile        string
+	tlsPrivateKeyFile  string
 )
 
 func main() {
@@ -62,31 +74,49 @@ kubeconfig: %s
 masterURL: %s
 projectId: %s
 serviceAccountPath: %s
+emulatorHost: %s
 mockEnabled: %v
 debuggable: %v
-`, kubeconfig, masterURL, projectId, serviceAccountPath, mockEnabled, debuggable)
+`, kubeconfig, masterURL, projectId, serviceAccountPath, emulatorHost, mockEnabled, debuggable)
 	if debuggable {
 		log.Print("Enable debugging!")
 		klog.InitFlags(nil)
 	}
-	b := operator.NewBuilder()
 	projectId, err := metadata.ProjectID()
 	if err != nil {
 		log.Print("No projectId got from metadata server, get it from environment variables")
 		projectId = os.Getenv("GCP_PROJECT_ID")
 	}
-	b.ProjectId(projectId)
 	serviceAccountPath = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
-	b.ServiceAccountPath(serviceAccountPath)
-	if !mockEnabled {
-		op = b.Build()
-	} else {
-		dataPath := os.Getenv("MOCK_DATA_PATH")
-		if dataPath == "" {
-			dataPath = "/tmp/spanner-operator"
+	dataPath := os.Getenv(operator.MockDataPathEnv)
+	if dataPath == "" {
+		dataPath = operator.DefaultMockDataPath
+	}
+	// Spanner resources may name another project than the one the
+	// controller runs for, and a service account key of their own, which
+	// get their own Operator.
+	operators := operator.NewPool(projectId, func(projectId string, key []byte) (operator.Operator, error) {
+		b := operator.NewBuilder()
+		b.ProjectId(projectId)
+		if key != nil {
+			b.ServiceAccountKey(key)
+		} else {
+			b.ServiceAccountPath(serviceAccountPath)
+		}
+		if emulatorHost != "" {
+			b.EmulatorHost(emulatorHost)
 		}
-		log.Printf("Mock client enabled, building mock with dataPath: %s", dataPath)
-		op = b.BuildMock(dataPath)
+		if mockEnabled {
+			log.Printf("Mock client enabled, building mock of project %s with dataPath: %s", projectId, dataPath)
+			return b.BuildMock(dataPath)
+		}
+		return b.Build()
+	})
+	defer operators.Close()
+	// Build the Operator of the default project up front, so broken default
+	// credentials fail the controller at startup.
+	if _, err := operators.Get(projectId); err != nil {
+		klog.Fatalf("Error building operator: %s", err.Error())
 	}
 
 	ctx, cancelFunc := context.WithCancel(context.Background())
@@ -114,11 +144,24 @@ debuggable: %v
 		klog.Fatalf("Error building example clientset: %s", err.Error())
 	}
 
+	backupadminsCtrl, err := backupadminsClientset.NewForConfig(cfg)
+	if err != nil {
+		klog.Fatalf("Error building example clientset: %s", err.Error())
+	}
+
+	iamadminsCtrl, err := iamadminsClientset.NewForConfig(cfg)
+	if err != nil {
+		klog.Fatalf("Error building example clientset: %s", err.Error())
+	}
+
 	var wg sync.WaitGroup
 
 	instanceadminsInformerFactory := instanceadminsInformers.NewSharedInformerFactory(instanceadminsCtrl, time.Second*30)
+	databaseadminsInformerFactory := databaseadminsInformers.NewSharedInformerFactory(databaseadminsCtrl, time.Second*30)
 	instanceadminsController := instanceadmins.NewController(kubeClient, instanceadminsCtrl,
-		instanceadminsInformerFactory.Instanceadmins().V1alpha1().SpannerInstances(), op)
+		instanceadminsInformerFactory.Instanceadmins().V1alpha1().SpannerInstances(),
+		databaseadminsCtrl, databaseadminsInformerFactory.Databaseadmins().V1alpha1().SpannerDatabases(),
+		kubeInformerFactory.Core().V1().Secrets(), operators)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -126,9 +169,36 @@ debuggable: %v
 			klog.Fatalf("Error running controller: %s", err.Error())
 		}
 	}()
-	databaseadminsInformerFactory := databaseadminsInformers.NewSharedInformerFactory(databaseadminsCtrl, time.Second*30)
+	backupadminsInformerFactory := backupadminsInformers.NewSharedInformerFactory(backupadminsCtrl, time.Second*30)
+	backupadminsController := backupadmins.NewController(kubeClient, backupadminsCtrl,
+		backupadminsInformerFactory.Backupadmins().V1alpha1().SpannerBackups(),
+		kubeInformerFactory.Core().V1().Secrets(), operators)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err = backupadminsController.Run(2, ctx.Done()); err != nil {
+			klog.Fatalf("Error running controller: %s", err.Error())
+		}
+	}()
+
+	backupschedulesController := backupschedules.NewController(kubeClient, backupadminsCtrl,
+		backupadminsInformerFactory.Backupadmins().V1alpha1().SpannerBackupSchedules(),
+		databaseadminsInformerFactory.Databaseadmins().V1alpha1().SpannerDatabases(),
+		kubeInformerFactory.Core().V1().Secrets(), operators)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err = backupschedulesController.Run(2, ctx.Done()); err != nil {
+			klog.Fatalf("Error running controller: %s", err.Error())
+		}
+	}()
+
 	databaseadminsController := databaseadmins.NewController(kubeClient, databaseadminsCtrl,
-		databaseadminsInformerFactory.Databaseadmins().V1alpha1().SpannerDatabases(), op)
+		databaseadminsInformerFactory.Databaseadmins().V1alpha1().SpannerDatabases(),
+		kubeInformerFactory.Core().V1().ConfigMaps(),
+		backupadminsInformerFactory.Backupadmins().V1alpha1().SpannerBackups(),
+		instanceadminsInformerFactory.Instanceadmins().V1alpha1().SpannerInstances(),
+		kubeInformerFactory.Core().V1().Secrets(), operators)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -137,11 +207,50 @@ debuggable: %v
 		}
 	}()
 
+	databaserolesController := databaseroles.NewController(kubeClient, databaseadminsCtrl,
+		databaseadminsInformerFactory.Databaseadmins().V1alpha1().SpannerDatabaseRoles(),
+		databaseadminsInformerFactory.Databaseadmins().V1alpha1().SpannerDatabases(),
+		kubeInformerFactory.Core().V1().Secrets(), operators)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err = databaserolesController.Run(2, ctx.Done()); err != nil {
+			klog.Fatalf("Error running controller: %s", err.Error())
+		}
+	}()
+
+	iamadminsInformerFactory := iamadminsInformers.NewSharedInformerFactory(iamadminsCtrl, time.Second*30)
+	iamadminsController := iamadmins.NewController(kubeClient, iamadminsCtrl,
+		iamadminsInformerFactory.Iamadmins().V1alpha1().SpannerIAMPolicyMembers(),
+		kubeInformerFactory.Core().V1().Secrets(), operators)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err = iamadminsController.Run(2, ctx.Done()); err != nil {
+			klog.Fatalf("Error running controller: %s", err.Error())
+		}
+	}()
+
+	if tlsCertFile != "" && tlsPrivateKeyFile != "" {
+		validator := webhook.NewValidator(operators, kubeInformerFactory.Core().V1().Secrets().Lister())
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := webhook.Run(ctx, webhookAddr, tlsCertFile, tlsPrivateKeyFile, webhook.NewHandler(validator)); err != nil {
+				klog.Fatalf("Error running webhook: %s", err.Error())
+			}
+		}()
+	} else {
+		log.Print("No TLS certificate given, the validating webhook is disabled")
+	}
+
 	// notice that there is no need to run Start methods in a separate goroutine. (i.e. go kubeInformerFactory.Start(stopCh)
 	// Start method is non-blocking and runs all registered informers in a dedicated goroutine.
 	go kubeInformerFactory.Start(ctx.Done())
 	go instanceadminsInformerFactory.Start(ctx.Done())
 	go databaseadminsInformerFactory.Start(ctx.Done())
+	go backupadminsInformerFactory.Start(ctx.Done())
+	go iamadminsInformerFactory.Start(ctx.Done())
 
 	<-ctx.Done()
 
@@ -155,5 +264,9 @@ func init() {
 	flag.StringVar(&masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
 	flag.BoolVar(&debuggable, "debuggable", false, "Enable debug flag.")
 	flag.BoolVar(&mockEnabled, "use-mock", false, "Enable mock client.")
+	flag.StringVar(&emulatorHost, "emulator-host", "", "Address of a Cloud Spanner emulator. Defaults to $SPANNER_EMULATOR_HOST.")
+	flag.StringVar(&webhookAddr, "webhook-addr", ":8443", "Address the validating webhook listens on.")
+	flag.StringVar(&tlsCertFile, "tls-cert-file", "", "Path to the TLS certificate of the validating webhook. The webhook is disabled without it.")
+	flag.StringVar(&tlsPrivateKeyFile, "tls-private-key-file", "", "Path to the TLS private key of the validating webhook.")
 
 }
@@ -0,0 +1,145 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SpannerBackup is a specification for a SpannerBackup resource
+type SpannerBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SpannerBackupSpec   `json:"spec"`
+	Status SpannerBackupStatus `json:"status"`
+}
+
+// SpannerBackupSpec is the spec for a SpannerBackup resource
+type SpannerBackupSpec struct {
+	// ProjectId is the GCP project of the backup. It defaults to the
+	// project the controller runs for.
+	ProjectId  string `json:"projectId,omitempty"`
+	InstanceId string `json:"instanceId"`
+	// Database is the name of the database to back up, in InstanceId.
+	Database string `json:"database"`
+	// ExpireTime is when Spanner deletes the backup. It can be changed
+	// after the backup is created.
+	ExpireTime metav1.Time `json:"expireTime"`
+	// VersionTime is the point in time the backup is consistent with.
+	// The backup is taken of the current state of the database when unset.
+	VersionTime *metav1.Time `json:"versionTime,omitempty"`
+	// CredentialsSecretRef selects a service account key, in JSON, held by
+	// a Secret in the namespace of the SpannerBackup. The controller's own
+	// credentials are used when it is not set.
+	CredentialsSecretRef *corev1.SecretKeySelector `json:"credentialsSecretRef,omitempty"`
+}
+
+// SpannerBackupStatus is the status for a SpannerBackup resource
+type SpannerBackupStatus struct {
+	// PendingOperation is the name of the long-running operation the
+	// controller is waiting on, empty when none is in flight.
+	PendingOperation string `json:"pendingOperation,omitempty"`
+	// BackupName is the fully qualified name of the backup, like
+	// projects/p/instances/i/backups/b. It is recorded before the backup is
+	// created, and only the backup it names is managed.
+	BackupName string `json:"backupName,omitempty"`
+	// State is the state of the backup reported by Spanner.
+	State       string       `json:"state,omitempty"`
+	SizeBytes   int64        `json:"sizeBytes,omitempty"`
+	CreateTime  *metav1.Time `json:"createTime,omitempty"`
+	VersionTime *metav1.Time `json:"versionTime,omitempty"`
+	ExpireTime  *metav1.Time `json:"expireTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SpannerBackupList is a list of SpannerBackup resources
+type SpannerBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []SpannerBackup `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SpannerBackupSchedule is a specification for a SpannerBackupSchedule resource
+type SpannerBackupSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SpannerBackupScheduleSpec   `json:"spec"`
+	Status SpannerBackupScheduleStatus `json:"status"`
+}
+
+// SpannerBackupScheduleSpec is the spec for a SpannerBackupSchedule resource
+type SpannerBackupScheduleSpec struct {
+	// Schedule is a standard cron expression evaluated in UTC, like "0 3 * * *".
+	Schedule string `json:"schedule"`
+	// DatabaseRef names the SpannerDatabase, in the same namespace, to back
+	// up. The backups then follow the project, instance, database ID and
+	// credentials of the SpannerDatabase, and ProjectId, InstanceId,
+	// Database and CredentialsSecretRef are ignored.
+	DatabaseRef *corev1.LocalObjectReference `json:"databaseRef,omitempty"`
+	// ProjectId is the GCP project of the backups. It defaults to the
+	// project the controller runs for.
+	ProjectId  string `json:"projectId,omitempty"`
+	InstanceId string `json:"instanceId,omitempty"`
+	// Database is the name of the database to back up, in InstanceId.
+	Database  string                       `json:"database,omitempty"`
+	Retention SpannerBackupRetentionPolicy `json:"retention,omitempty"`
+	// CredentialsSecretRef selects a service account key, in JSON, held by
+	// a Secret in the namespace of the SpannerBackupSchedule. The
+	// controller's own credentials are used when it is not set.
+	CredentialsSecretRef *corev1.SecretKeySelector `json:"credentialsSecretRef,omitempty"`
+}
+
+// SpannerBackupRetentionPolicy tells which scheduled backups to keep. Both
+// limits apply when both are set.
+type SpannerBackupRetentionPolicy struct {
+	// Count is the number of newest backups to keep, 0 for no limit.
+	Count int32 `json:"count,omitempty"`
+	// MaxAge is how long a backup is kept after it was scheduled.
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+}
+
+// SpannerBackupScheduleStatus is the status for a SpannerBackupSchedule resource
+type SpannerBackupScheduleStatus struct {
+	// PendingOperation is the name of the long-running operation the
+	// controller is waiting on, empty when none is in flight.
+	PendingOperation string `json:"pendingOperation,omitempty"`
+	// LastScheduleTime is the scheduled time of the last run.
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// LastBackup is the ID of the backup taken by the last run.
+	LastBackup string `json:"lastBackup,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SpannerBackupScheduleList is a list of SpannerBackupSchedule resources
+type SpannerBackupScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []SpannerBackupSchedule `json:"items"`
+}
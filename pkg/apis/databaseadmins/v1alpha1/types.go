@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -30,13 +31,198 @@ type SpannerDatabase struct {
 	Status SpannerDatabaseStatus `json:"status"`
 }
 
+// DatabaseId returns the ID of the Spanner database of the SpannerDatabase,
+// its name unless the spec sets one.
+func (in *SpannerDatabase) DatabaseId() string {
+	if in.Spec.DatabaseId != "" {
+		return in.Spec.DatabaseId
+	}
+	return in.Name
+}
+
+// AdoptAnnotation, set to "true" on a SpannerDatabase, lets the controller
+// take over a Spanner database that already exists. Without it the
+// controller refuses to manage a database it did not create.
+const AdoptAnnotation = "databaseadmins.spanner-operator.io/adopt"
+
 // SpannerInstanceSpec is the spec for a SpannerInstance resource
 type SpannerDatabaseSpec struct {
-	InstanceId string `json:"instanceId"`
+	// ProjectId is the GCP project of the database. It defaults to the
+	// project the controller runs for.
+	ProjectId string `json:"projectId,omitempty"`
+	// InstanceId is the Spanner instance the database is created in. It is
+	// ignored when InstanceRef is set.
+	InstanceId string `json:"instanceId,omitempty"`
+	// InstanceRef names the SpannerInstance the database is created in. The
+	// database then follows the project and instance ID of the
+	// SpannerInstance, which owns it when both share a namespace.
+	InstanceRef *SpannerInstanceReference `json:"instanceRef,omitempty"`
+	// DatabaseId is the ID of the Spanner database. It defaults to the name
+	// of the SpannerDatabase.
+	DatabaseId string `json:"databaseId,omitempty"`
+	// CredentialsSecretRef selects a service account key, in JSON, held by
+	// a Secret in the namespace of the SpannerDatabase. The controller's own
+	// credentials are used when it is not set.
+	CredentialsSecretRef *corev1.SecretKeySelector `json:"credentialsSecretRef,omitempty"`
+	// Ddl is the schema of the database. Statements are sent along with
+	// CREATE DATABASE, and statements added later are applied in order.
+	Ddl *SpannerDatabaseDdl `json:"ddl,omitempty"`
+	// Migrations are numbered schema changes applied in version order, once
+	// each. An applied migration must not be edited.
+	Migrations []SpannerDatabaseMigration `json:"migrations,omitempty"`
+	// MigrationsConfigMapRef names a ConfigMap whose keys are migrations
+	// named "<version>_<description>.sql", holding ';' separated statements.
+	MigrationsConfigMapRef *corev1.LocalObjectReference `json:"migrationsConfigMapRef,omitempty"`
+	// RestoreFrom makes the database be restored from a backup instead of
+	// created empty. It only matters while the database does not exist.
+	RestoreFrom *SpannerDatabaseRestoreSource `json:"restoreFrom,omitempty"`
+	// DeletionPolicy tells what happens to the database once the
+	// SpannerDatabase is deleted, DeletionPolicyDelete when empty.
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+	// DeletionProtection keeps the SpannerDatabase, and so its database,
+	// from being deleted until it is turned off. It is mirrored to the
+	// enable_drop_protection field of the database.
+	DeletionProtection bool `json:"deletionProtection,omitempty"`
+}
+
+// SpannerInstanceReference names a SpannerInstance
+type SpannerInstanceReference struct {
+	Name string `json:"name"`
+	// Namespace of the SpannerInstance, the namespace of the referrer when
+	// empty.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// Deletion policies of a SpannerDatabase
+const (
+	// DeletionPolicyDelete drops the database.
+	DeletionPolicyDelete = "Delete"
+	// DeletionPolicyRetain leaves the database in Spanner.
+	DeletionPolicyRetain = "Retain"
+	// DeletionPolicyBackupThenDelete backs the database up in its instance
+	// before dropping it.
+	DeletionPolicyBackupThenDelete = "BackupThenDelete"
+)
+
+// SpannerDatabaseRestoreSource names the backup a SpannerDatabase is restored
+// from. Exactly one of Backup and BackupRef must be set.
+type SpannerDatabaseRestoreSource struct {
+	// Backup is the ID of a Spanner backup.
+	Backup string `json:"backup,omitempty"`
+	// InstanceId is the instance holding Backup. It defaults to the
+	// instance of the database.
+	InstanceId string `json:"instanceId,omitempty"`
+	// BackupRef names a SpannerBackup in the namespace of the SpannerDatabase.
+	BackupRef *corev1.LocalObjectReference `json:"backupRef,omitempty"`
+}
+
+// SpannerDatabaseDdl lists the DDL statements of a SpannerDatabase
+type SpannerDatabaseDdl struct {
+	// Statements are DDL statements written inline, without a trailing ';'.
+	Statements []string `json:"statements,omitempty"`
+	// ConfigMapRef selects a ConfigMap key holding ';' separated DDL
+	// statements, which are applied after Statements.
+	ConfigMapRef *corev1.ConfigMapKeySelector `json:"configMapRef,omitempty"`
+}
+
+// SpannerDatabaseMigration is a numbered schema change of a SpannerDatabase
+type SpannerDatabaseMigration struct {
+	// Version orders the migrations. It must be positive and unique.
+	Version     int64  `json:"version"`
+	Description string `json:"description,omitempty"`
+	// Statements are DDL statements applied together, without a trailing ';'.
+	Statements []string `json:"statements"`
+}
+
+// SpannerDatabaseMigrationRecord identifies a migration applied to a SpannerDatabase
+type SpannerDatabaseMigrationRecord struct {
+	Version     int64  `json:"version"`
+	Description string `json:"description,omitempty"`
+	Checksum    string `json:"checksum"`
 }
 
 // SpannerDatabaseStatus is the status for a SpannerDatabase resource
 type SpannerDatabaseStatus struct {
+	// DatabaseName is the fully qualified name of the Spanner database the
+	// SpannerDatabase manages, set once the controller created, restored or
+	// adopted it.
+	DatabaseName string `json:"databaseName,omitempty"`
+	// PendingOperation is the name of the long-running operation the
+	// controller is waiting on, empty when none is in flight.
+	PendingOperation string `json:"pendingOperation,omitempty"`
+	// PendingDdl are the DDL statements the pending operation is applying.
+	PendingDdl []string `json:"pendingDdl,omitempty"`
+	// AppliedDdl are the DDL statements applied to the database so far.
+	AppliedDdl []string `json:"appliedDdl,omitempty"`
+	// PendingMigration is the migration the pending operation is applying.
+	PendingMigration *SpannerDatabaseMigrationRecord `json:"pendingMigration,omitempty"`
+	// MigrationVersion is the version of the last applied migration.
+	MigrationVersion int64 `json:"migrationVersion,omitempty"`
+	// Restore tracks the restore of the database from Spec.RestoreFrom.
+	Restore *SpannerDatabaseRestoreStatus `json:"restore,omitempty"`
+	// ObservedGeneration is the generation of the spec the status was last
+	// written for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the latest observations of the state of the database.
+	Conditions []SpannerDatabaseCondition `json:"conditions,omitempty"`
+}
+
+// SpannerDatabaseConditionType is the type of a SpannerDatabaseCondition
+type SpannerDatabaseConditionType string
+
+// Condition types of a SpannerDatabase. A database is never scaled, its
+// instance is.
+const (
+	// SpannerDatabaseReady is true once the database exists and serves. A
+	// restored database serves while it is being optimized.
+	SpannerDatabaseReady SpannerDatabaseConditionType = "Ready"
+	// SpannerDatabaseProvisioning is true while the database is being
+	// created or restored.
+	SpannerDatabaseProvisioning SpannerDatabaseConditionType = "Provisioning"
+	// SpannerDatabaseDegraded is true when the database serves, but DDL or a
+	// migration failed to be applied. It is retried.
+	SpannerDatabaseDegraded SpannerDatabaseConditionType = "Degraded"
+	// SpannerDatabaseError is true when the controller cannot sync the
+	// database, e.g. because a migration was edited or the database failed
+	// to be created.
+	SpannerDatabaseError SpannerDatabaseConditionType = "Error"
+	// SpannerDatabaseDeletionBlocked is true while the deleted SpannerDatabase
+	// is kept by its deletion protection.
+	SpannerDatabaseDeletionBlocked SpannerDatabaseConditionType = "DeletionBlocked"
+)
+
+// SpannerDatabaseCondition describes the state of a SpannerDatabase at a
+// certain point
+type SpannerDatabaseCondition struct {
+	Type   SpannerDatabaseConditionType `json:"type"`
+	Status corev1.ConditionStatus       `json:"status"`
+	// LastTransitionTime is when Status last changed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason is a CamelCase word explaining Status, Message a sentence.
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Restore phases of a SpannerDatabase
+const (
+	// RestorePhaseRestoring is the phase while the backup is copied into the database.
+	RestorePhaseRestoring = "Restoring"
+	// RestorePhaseOptimizing is the phase while Spanner optimizes the restored
+	// database. The database can already be used.
+	RestorePhaseOptimizing = "Optimizing"
+	// RestorePhaseDone is the phase once the restored database is fully optimized.
+	RestorePhaseDone = "Done"
+)
+
+// SpannerDatabaseRestoreStatus is the progress of restoring a SpannerDatabase
+type SpannerDatabaseRestoreStatus struct {
+	// Backup is the fully qualified name of the backup restored from, known
+	// once the database exists.
+	Backup string `json:"backup,omitempty"`
+	Phase  string `json:"phase"`
+	// ProgressPercent is the progress of copying the backup, reaching 100
+	// once the database is optimized.
+	ProgressPercent int32 `json:"progressPercent"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -47,3 +233,58 @@ type SpannerDatabaseList struct {
 
 	Items []SpannerDatabase `json:"items"`
 }
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SpannerDatabaseRole is a fine-grained access control role of a SpannerDatabase
+type SpannerDatabaseRole struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SpannerDatabaseRoleSpec   `json:"spec"`
+	Status SpannerDatabaseRoleStatus `json:"status"`
+}
+
+// SpannerDatabaseRoleSpec is the spec for a SpannerDatabaseRole resource
+type SpannerDatabaseRoleSpec struct {
+	// DatabaseRef names the SpannerDatabase, in the same namespace, the role
+	// is created in.
+	DatabaseRef corev1.LocalObjectReference `json:"databaseRef"`
+	// RoleName is the name of the role in the database. It can only hold
+	// letters, digits and underscores.
+	RoleName string `json:"roleName"`
+	// Grants are every privilege of the role. Privileges missing from Grants
+	// are revoked.
+	Grants []SpannerDatabaseRoleGrant `json:"grants,omitempty"`
+}
+
+// SpannerDatabaseRoleGrant lists privileges on a table
+type SpannerDatabaseRoleGrant struct {
+	// Privileges are SELECT, INSERT, UPDATE or DELETE.
+	Privileges []string `json:"privileges"`
+	Table      string   `json:"table"`
+	// Columns restricts the privileges to these columns of Table. DELETE
+	// cannot be restricted to columns.
+	Columns []string `json:"columns,omitempty"`
+}
+
+// SpannerDatabaseRoleStatus is the status for a SpannerDatabaseRole resource
+type SpannerDatabaseRoleStatus struct {
+	// PendingOperation is the name of the long-running operation the
+	// controller is waiting on, empty when none is in flight.
+	PendingOperation string `json:"pendingOperation,omitempty"`
+	// AppliedGrants are the privileges the role held in the database at the
+	// last sync.
+	AppliedGrants []SpannerDatabaseRoleGrant `json:"appliedGrants,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SpannerDatabaseRoleList is a list of SpannerDatabaseRole resources
+type SpannerDatabaseRoleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []SpannerDatabaseRole `json:"items"`
+}
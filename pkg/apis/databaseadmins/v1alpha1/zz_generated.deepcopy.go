@@ -21,6 +21,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	v1 "k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -29,8 +30,8 @@ func (in *SpannerDatabase) DeepCopyInto(out *SpannerDatabase) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -52,6 +53,49 @@ func (in *SpannerDatabase) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpannerDatabaseCondition) DeepCopyInto(out *SpannerDatabaseCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpannerDatabaseCondition.
+func (in *SpannerDatabaseCondition) DeepCopy() *SpannerDatabaseCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(SpannerDatabaseCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpannerDatabaseDdl) DeepCopyInto(out *SpannerDatabaseDdl) {
+	*out = *in
+	if in.Statements != nil {
+		in, out := &in.Statements, &out.Statements
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpannerDatabaseDdl.
+func (in *SpannerDatabaseDdl) DeepCopy() *SpannerDatabaseDdl {
+	if in == nil {
+		return nil
+	}
+	out := new(SpannerDatabaseDdl)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SpannerDatabaseList) DeepCopyInto(out *SpannerDatabaseList) {
 	*out = *in
@@ -85,9 +129,249 @@ func (in *SpannerDatabaseList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpannerDatabaseMigration) DeepCopyInto(out *SpannerDatabaseMigration) {
+	*out = *in
+	if in.Statements != nil {
+		in, out := &in.Statements, &out.Statements
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpannerDatabaseMigration.
+func (in *SpannerDatabaseMigration) DeepCopy() *SpannerDatabaseMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(SpannerDatabaseMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpannerDatabaseMigrationRecord) DeepCopyInto(out *SpannerDatabaseMigrationRecord) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpannerDatabaseMigrationRecord.
+func (in *SpannerDatabaseMigrationRecord) DeepCopy() *SpannerDatabaseMigrationRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(SpannerDatabaseMigrationRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpannerDatabaseRestoreSource) DeepCopyInto(out *SpannerDatabaseRestoreSource) {
+	*out = *in
+	if in.BackupRef != nil {
+		in, out := &in.BackupRef, &out.BackupRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpannerDatabaseRestoreSource.
+func (in *SpannerDatabaseRestoreSource) DeepCopy() *SpannerDatabaseRestoreSource {
+	if in == nil {
+		return nil
+	}
+	out := new(SpannerDatabaseRestoreSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpannerDatabaseRestoreStatus) DeepCopyInto(out *SpannerDatabaseRestoreStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpannerDatabaseRestoreStatus.
+func (in *SpannerDatabaseRestoreStatus) DeepCopy() *SpannerDatabaseRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SpannerDatabaseRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpannerDatabaseRole) DeepCopyInto(out *SpannerDatabaseRole) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpannerDatabaseRole.
+func (in *SpannerDatabaseRole) DeepCopy() *SpannerDatabaseRole {
+	if in == nil {
+		return nil
+	}
+	out := new(SpannerDatabaseRole)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SpannerDatabaseRole) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpannerDatabaseRoleGrant) DeepCopyInto(out *SpannerDatabaseRoleGrant) {
+	*out = *in
+	if in.Privileges != nil {
+		in, out := &in.Privileges, &out.Privileges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Columns != nil {
+		in, out := &in.Columns, &out.Columns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpannerDatabaseRoleGrant.
+func (in *SpannerDatabaseRoleGrant) DeepCopy() *SpannerDatabaseRoleGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(SpannerDatabaseRoleGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpannerDatabaseRoleList) DeepCopyInto(out *SpannerDatabaseRoleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SpannerDatabaseRole, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpannerDatabaseRoleList.
+func (in *SpannerDatabaseRoleList) DeepCopy() *SpannerDatabaseRoleList {
+	if in == nil {
+		return nil
+	}
+	out := new(SpannerDatabaseRoleList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SpannerDatabaseRoleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpannerDatabaseRoleSpec) DeepCopyInto(out *SpannerDatabaseRoleSpec) {
+	*out = *in
+	out.DatabaseRef = in.DatabaseRef
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]SpannerDatabaseRoleGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpannerDatabaseRoleSpec.
+func (in *SpannerDatabaseRoleSpec) DeepCopy() *SpannerDatabaseRoleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SpannerDatabaseRoleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpannerDatabaseRoleStatus) DeepCopyInto(out *SpannerDatabaseRoleStatus) {
+	*out = *in
+	if in.AppliedGrants != nil {
+		in, out := &in.AppliedGrants, &out.AppliedGrants
+		*out = make([]SpannerDatabaseRoleGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpannerDatabaseRoleStatus.
+func (in *SpannerDatabaseRoleStatus) DeepCopy() *SpannerDatabaseRoleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SpannerDatabaseRoleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SpannerDatabaseSpec) DeepCopyInto(out *SpannerDatabaseSpec) {
 	*out = *in
+	if in.InstanceRef != nil {
+		in, out := &in.InstanceRef, &out.InstanceRef
+		*out = new(SpannerInstanceReference)
+		**out = **in
+	}
+	if in.CredentialsSecretRef != nil {
+		in, out := &in.CredentialsSecretRef, &out.CredentialsSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Ddl != nil {
+		in, out := &in.Ddl, &out.Ddl
+		*out = new(SpannerDatabaseDdl)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Migrations != nil {
+		in, out := &in.Migrations, &out.Migrations
+		*out = make([]SpannerDatabaseMigration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MigrationsConfigMapRef != nil {
+		in, out := &in.MigrationsConfigMapRef, &out.MigrationsConfigMapRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.RestoreFrom != nil {
+		in, out := &in.RestoreFrom, &out.RestoreFrom
+		*out = new(SpannerDatabaseRestoreSource)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -104,6 +388,33 @@ func (in *SpannerDatabaseSpec) DeepCopy() *SpannerDatabaseSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SpannerDatabaseStatus) DeepCopyInto(out *SpannerDatabaseStatus) {
 	*out = *in
+	if in.PendingDdl != nil {
+		in, out := &in.PendingDdl, &out.PendingDdl
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AppliedDdl != nil {
+		in, out := &in.AppliedDdl, &out.AppliedDdl
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PendingMigration != nil {
+		in, out := &in.PendingMigration, &out.PendingMigration
+		*out = new(SpannerDatabaseMigrationRecord)
+		**out = **in
+	}
+	if in.Restore != nil {
+		in, out := &in.Restore, &out.Restore
+		*out = new(SpannerDatabaseRestoreStatus)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]SpannerDatabaseCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -116,3 +427,19 @@ func (in *SpannerDatabaseStatus) DeepCopy() *SpannerDatabaseStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpannerInstanceReference) DeepCopyInto(out *SpannerInstanceReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpannerInstanceReference.
+func (in *SpannerInstanceReference) DeepCopy() *SpannerInstanceReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SpannerInstanceReference)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SpannerIAMPolicyMember grants a single role to a single member on a Spanner
+// instance or database. Other members of the IAM policy are left alone.
+type SpannerIAMPolicyMember struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SpannerIAMPolicyMemberSpec   `json:"spec"`
+	Status SpannerIAMPolicyMemberStatus `json:"status"`
+}
+
+// SpannerIAMPolicyMemberSpec is the spec for a SpannerIAMPolicyMember resource
+type SpannerIAMPolicyMemberSpec struct {
+	SpannerIAMBinding `json:",inline"`
+	// CredentialsSecretRef selects a service account key, in JSON, held by
+	// a Secret in the namespace of the SpannerIAMPolicyMember. The
+	// controller's own credentials are used when it is not set.
+	CredentialsSecretRef *corev1.SecretKeySelector `json:"credentialsSecretRef,omitempty"`
+}
+
+// SpannerIAMBinding is a role granted to a member on a Spanner resource.
+type SpannerIAMBinding struct {
+	// ProjectId is the GCP project of InstanceId. It defaults to the project
+	// the controller runs for.
+	ProjectId  string `json:"projectId,omitempty"`
+	InstanceId string `json:"instanceId"`
+	// Database is the name of the database in InstanceId to grant Role on.
+	// When empty, Role is granted on the instance.
+	Database string `json:"database,omitempty"`
+	// Role is the IAM role to grant, e.g. roles/spanner.databaseReader.
+	Role string `json:"role"`
+	// Member is the identity to grant Role to, e.g.
+	// serviceAccount:app@project.iam.gserviceaccount.com.
+	Member string `json:"member"`
+}
+
+// SpannerIAMPolicyMemberStatus is the status for a SpannerIAMPolicyMember resource
+type SpannerIAMPolicyMemberStatus struct {
+	// Applied is the binding the controller last granted. It is revoked when
+	// the spec changes or the SpannerIAMPolicyMember is deleted, if Granted
+	// or Granting.
+	Applied *SpannerIAMBinding `json:"applied,omitempty"`
+	// Granted reports whether the controller added the member of Applied to
+	// the policy, rather than finding it there already. Members added outside
+	// the operator are never revoked.
+	Granted bool `json:"granted,omitempty"`
+	// Granting is set before the controller writes the member of Applied to
+	// the policy, and cleared once Granted is recorded. A member the
+	// controller may have added is revoked even if the write was never
+	// recorded.
+	Granting bool `json:"granting,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SpannerIAMPolicyMemberList is a list of SpannerIAMPolicyMember resources
+type SpannerIAMPolicyMemberList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []SpannerIAMPolicyMember `json:"items"`
+}
@@ -0,0 +1,146 @@
+// +build !ignore_autogenerated
+
+/*
+Copyright 2019 The Kubernetes spanner-controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by deepcopy-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpannerIAMBinding) DeepCopyInto(out *SpannerIAMBinding) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpannerIAMBinding.
+func (in *SpannerIAMBinding) DeepCopy() *SpannerIAMBinding {
+	if in == nil {
+		return nil
+	}
+	out := new(SpannerIAMBinding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpannerIAMPolicyMember) DeepCopyInto(out *SpannerIAMPolicyMember) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpannerIAMPolicyMember.
+func (in *SpannerIAMPolicyMember) DeepCopy() *SpannerIAMPolicyMember {
+	if in == nil {
+		return nil
+	}
+	out := new(SpannerIAMPolicyMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SpannerIAMPolicyMember) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpannerIAMPolicyMemberList) DeepCopyInto(out *SpannerIAMPolicyMemberList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SpannerIAMPolicyMember, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpannerIAMPolicyMemberList.
+func (in *SpannerIAMPolicyMemberList) DeepCopy() *SpannerIAMPolicyMemberList {
+	if in == nil {
+		return nil
+	}
+	out := new(SpannerIAMPolicyMemberList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SpannerIAMPolicyMemberList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpannerIAMPolicyMemberSpec) DeepCopyInto(out *SpannerIAMPolicyMemberSpec) {
+	*out = *in
+	out.SpannerIAMBinding = in.SpannerIAMBinding
+	if in.CredentialsSecretRef != nil {
+		in, out := &in.CredentialsSecretRef, &out.CredentialsSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpannerIAMPolicyMemberSpec.
+func (in *SpannerIAMPolicyMemberSpec) DeepCopy() *SpannerIAMPolicyMemberSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SpannerIAMPolicyMemberSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpannerIAMPolicyMemberStatus) DeepCopyInto(out *SpannerIAMPolicyMemberStatus) {
+	*out = *in
+	if in.Applied != nil {
+		in, out := &in.Applied, &out.Applied
+		*out = new(SpannerIAMBinding)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpannerIAMPolicyMemberStatus.
+func (in *SpannerIAMPolicyMemberStatus) DeepCopy() *SpannerIAMPolicyMemberStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SpannerIAMPolicyMemberStatus)
+	in.DeepCopyInto(out)
+	return out
+}
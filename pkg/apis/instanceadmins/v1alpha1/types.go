@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -34,19 +35,120 @@ type SpannerInstance struct {
 	Status SpannerInstanceStatus `json:"status"`
 }
 
+// InstanceId returns the ID of the Spanner instance of the SpannerInstance,
+// its name unless the spec sets one.
+func (in *SpannerInstance) InstanceId() string {
+	if in.Spec.InstanceId != "" {
+		return in.Spec.InstanceId
+	}
+	return in.Name
+}
+
+// AdoptAnnotation, set to "true" on a SpannerInstance, lets the controller
+// take over a Spanner instance that already exists. Without it the
+// controller refuses to manage an instance it did not create.
+const AdoptAnnotation = "instanceadmins.spanner-operator.io/adopt"
+
 // SpannerInstanceSpec is the spec for a SpannerInstance resource
 type SpannerInstanceSpec struct {
-	DisplayName    string `json:"displayName"`
-	InstanceConfig string `json:"instanceConfig"`
-	NodeCount      int32  `json:"nodeCount"`
+	// ProjectId is the GCP project of the instance. It defaults to the
+	// project the controller runs for.
+	ProjectId string `json:"projectId,omitempty"`
+	// InstanceId is the ID of the Spanner instance. It defaults to the name
+	// of the SpannerInstance.
+	InstanceId string `json:"instanceId,omitempty"`
+	// CredentialsSecretRef selects a service account key, in JSON, held by
+	// a Secret in the namespace of the SpannerInstance. The controller's own
+	// credentials are used when it is not set.
+	CredentialsSecretRef *corev1.SecretKeySelector `json:"credentialsSecretRef,omitempty"`
+	DisplayName          string                    `json:"displayName"`
+	InstanceConfig       string                    `json:"instanceConfig"`
+	// NodeCount and ProcessingUnits are mutually exclusive. ProcessingUnits
+	// allows instances smaller than one node: 100 to 900 in steps of 100, or
+	// a multiple of 1000.
+	NodeCount       int32 `json:"nodeCount,omitempty"`
+	ProcessingUnits int32 `json:"processingUnits,omitempty"`
+	// DeletionPolicy tells what happens to the instance once the
+	// SpannerInstance is deleted, DeletionPolicyDelete when empty.
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+	// DeletionProtection keeps the SpannerInstance, and so its instance,
+	// from being deleted until it is turned off.
+	DeletionProtection bool `json:"deletionProtection,omitempty"`
 }
 
+// Deletion policies of a SpannerInstance. Backups live in their instance, so
+// SpannerDatabase's BackupThenDelete has no counterpart.
+const (
+	// DeletionPolicyDelete deletes the instance along with its databases.
+	DeletionPolicyDelete = "Delete"
+	// DeletionPolicyRetain leaves the instance in Spanner.
+	DeletionPolicyRetain = "Retain"
+)
+
 // SpannerInstanceStatus is the status for a SpannerInstance resource
 type SpannerInstanceStatus struct {
+	// InstanceName is the fully qualified name of the Spanner instance the
+	// SpannerInstance manages, set once the controller created or adopted it.
+	InstanceName   string            `json:"instanceName,omitempty"`
 	AvailableNodes int32             `json:"availableNodes"`
 	InstanceLabels map[string]string `json:"instanceLabels"`
+	// PendingOperation is the name of the long-running operation the
+	// controller is waiting on, empty when none is in flight.
+	PendingOperation string `json:"pendingOperation,omitempty"`
+	// Capacity is the actual compute capacity of the instance, counted in
+	// CapacityUnit, the unit chosen in the spec.
+	Capacity     int32  `json:"capacity"`
+	CapacityUnit string `json:"capacityUnit,omitempty"`
+	// ObservedGeneration is the generation of the spec the status was last
+	// written for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the latest observations of the state of the instance.
+	Conditions []SpannerInstanceCondition `json:"conditions,omitempty"`
+}
+
+// SpannerInstanceConditionType is the type of a SpannerInstanceCondition
+type SpannerInstanceConditionType string
+
+// Condition types of a SpannerInstance
+const (
+	// SpannerInstanceReady is true once the instance exists and serves.
+	SpannerInstanceReady SpannerInstanceConditionType = "Ready"
+	// SpannerInstanceProvisioning is true while the instance is being created.
+	SpannerInstanceProvisioning SpannerInstanceConditionType = "Provisioning"
+	// SpannerInstanceScaling is true while the capacity of the instance is
+	// being changed to match the spec.
+	SpannerInstanceScaling SpannerInstanceConditionType = "Scaling"
+	// SpannerInstanceDegraded is true when the instance serves, but a change
+	// to match the spec failed. The change is retried.
+	SpannerInstanceDegraded SpannerInstanceConditionType = "Degraded"
+	// SpannerInstanceError is true when the controller cannot sync the
+	// instance, e.g. because the spec is invalid or the instance failed to be
+	// created.
+	SpannerInstanceError SpannerInstanceConditionType = "Error"
+	// SpannerInstanceDeletionBlocked is true while the deleted SpannerInstance
+	// is kept by its deletion protection.
+	SpannerInstanceDeletionBlocked SpannerInstanceConditionType = "DeletionBlocked"
+)
+
+// SpannerInstanceCondition describes the state of a SpannerInstance at a
+// certain point
+type SpannerInstanceCondition struct {
+	Type   SpannerInstanceConditionType `json:"type"`
+	Status corev1.ConditionStatus       `json:"status"`
+	// LastTransitionTime is when Status last changed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason is a CamelCase word explaining Status, Message a sentence.
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
 }
 
+const (
+	// CapacityUnitNodes reports Capacity as a number of nodes.
+	CapacityUnitNodes = "Nodes"
+	// CapacityUnitProcessingUnits reports Capacity as processing units.
+	CapacityUnitProcessingUnits = "ProcessingUnits"
+)
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // SpannerInstanceList is a list of SpannerInstance resources
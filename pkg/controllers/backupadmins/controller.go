@@ -0,0 +1,499 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backupadmins
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	backupv1alpha1 "github.com/katsew/spanner-operator/pkg/apis/backupadmins/v1alpha1"
+	clientset "github.com/katsew/spanner-operator/pkg/generated/backupadmins/clientset/versioned"
+	spannerscheme "github.com/katsew/spanner-operator/pkg/generated/backupadmins/clientset/versioned/scheme"
+	informers "github.com/katsew/spanner-operator/pkg/generated/backupadmins/informers/externalversions/backupadmins/v1alpha1"
+	listers "github.com/katsew/spanner-operator/pkg/generated/backupadmins/listers/backupadmins/v1alpha1"
+
+	"github.com/katsew/spanner-operator/pkg/credentials"
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+const controllerAgentName = "spanner-controller"
+
+// syncTimeout bounds a single syncHandler call, including every Spanner admin
+// call it makes, so a hung long-running operation cannot pin a worker forever.
+const syncTimeout = 10 * time.Minute
+
+// operationPollInterval is how long to wait before polling a pending
+// long-running operation again.
+const operationPollInterval = 10 * time.Second
+
+const (
+	// SuccessSynced is used as part of the Event 'reason' when a SpannerBackup is synced
+	SuccessSynced = "Synced"
+
+	// ErrOperationFailed is used as part of the Event 'reason' when a long-running
+	// operation started for a SpannerBackup finishes with an error.
+	ErrOperationFailed = "ErrOperationFailed"
+
+	// ErrCredentials is used as part of the Event 'reason' when the
+	// credentials a SpannerBackup selects cannot be read.
+	ErrCredentials = "ErrCredentials"
+
+	// ErrInvalidId is used as part of the Event 'reason' when the name or
+	// spec of a SpannerBackup makes an ID Spanner refuses.
+	ErrInvalidId = "ErrInvalidId"
+
+	// ErrResourceExists is used as part of the Event 'reason' when the backup
+	// of a SpannerBackup already exists and was not created by it.
+	ErrResourceExists = "ErrResourceExists"
+
+	// MessageResourceSynced is the message used for an Event fired when a Spanner
+	// is synced successfully
+	MessageResourceSynced = "SpannerBackup synced successfully"
+	// MessageOperationFailed is the message used for an Event fired when a
+	// long-running operation fails
+	MessageOperationFailed = "Operation %s failed: %v"
+	// MessageCredentials is the message used for an Event fired when the
+	// credentials in the spec cannot be read
+	MessageCredentials = "Invalid credentialsSecretRef: %v"
+	// MessageInvalidId is the message used for an Event fired when an ID in
+	// the name or spec is refused
+	MessageInvalidId = "Invalid Spanner ID: %v"
+	// MessageResourceExists is the message used for an Event fired when the
+	// backup exists and was not created by the SpannerBackup
+	MessageResourceExists = "Resource %q already exists and is not managed by this SpannerBackup"
+	// MessageBackupOfOtherDatabase is the message used for an Event fired
+	// when the backup exists and is of another database than the spec names
+	MessageBackupOfOtherDatabase = "Resource %q is a backup of %s, not of %s"
+)
+
+// Controller is the controller implementation for SpannerBackup resources
+type Controller struct {
+	// kubeclientset is a standard kubernetes clientset
+	kubeclientset kubernetes.Interface
+	// spannerclientset is a clientset for our own API group
+	spannerclientset clientset.Interface
+
+	spannerBackupLister  listers.SpannerBackupLister
+	spannerBackupsSynced cache.InformerSynced
+	secretLister         corelisters.SecretLister
+	secretsSynced        cache.InformerSynced
+
+	// workqueue is a rate limited work queue. This is used to queue work to be
+	// processed instead of performing it as soon as a change happens. This
+	// means we can ensure we only process a fixed amount of resources at a
+	// time, and makes it easy to ensure we are never processing the same item
+	// simultaneously in two different workers.
+	workqueue workqueue.RateLimitingInterface
+	// recorder is an event recorder for recording Event resources to the
+	// Kubernetes API.
+	recorder record.EventRecorder
+
+	// operators hands out the Operator of the project of a SpannerBackup.
+	operators *operator.Pool
+
+	// syncTimeout is the deadline applied to each syncHandler call.
+	syncTimeout time.Duration
+}
+
+// NewController returns a new spanner backup controller
+func NewController(
+	kubeclientset kubernetes.Interface,
+	spannerclientset clientset.Interface,
+	spannerBackupInformer informers.SpannerBackupInformer,
+	secretInformer coreinformers.SecretInformer,
+	operators *operator.Pool) *Controller {
+
+	// Create event broadcaster
+	// Add spanner-controller types to the default Kubernetes Scheme so Events can be
+	// logged for spanner-controller types.
+	utilruntime.Must(spannerscheme.AddToScheme(scheme.Scheme))
+	klog.V(4).Info("Creating event broadcaster")
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(klog.Infof)
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
+
+	controller := &Controller{
+		kubeclientset:        kubeclientset,
+		spannerclientset:     spannerclientset,
+		spannerBackupLister:  spannerBackupInformer.Lister(),
+		spannerBackupsSynced: spannerBackupInformer.Informer().HasSynced,
+		secretLister:         secretInformer.Lister(),
+		secretsSynced:        secretInformer.Informer().HasSynced,
+		workqueue:            workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "SpannerBackups"),
+		recorder:             recorder,
+		operators:            operators,
+		syncTimeout:          syncTimeout,
+	}
+
+	klog.Info("Setting up event handlers")
+	// Set up an event handler for when SpannerBackup resources change
+	spannerBackupInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueSpannerBackup,
+		UpdateFunc: func(old, new interface{}) {
+			controller.enqueueSpannerBackup(new)
+		},
+		DeleteFunc: controller.enqueueSpannerBackup,
+	})
+
+	return controller
+}
+
+// Run will set up the event handlers for types we are interested in, as well
+// as syncing informer caches and starting workers. It will block until stopCh
+// is closed, at which point it will shutdown the workqueue and wait for
+// workers to finish processing their current work items.
+func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
+	defer utilruntime.HandleCrash()
+	defer c.workqueue.ShutDown()
+
+	// Start the informer factories to begin populating the informer caches
+	klog.Info("Starting SpannerBackup controller")
+
+	// Wait for the caches to be synced before starting workers
+	klog.Info("Waiting for informer caches to sync")
+	if ok := cache.WaitForCacheSync(stopCh, c.spannerBackupsSynced, c.secretsSynced); !ok {
+		return fmt.Errorf("failed to wait for caches to sync")
+	}
+
+	// ctx is cancelled once stopCh is closed, which aborts in-flight Spanner
+	// admin calls made by the workers.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	klog.Info("Starting workers")
+	// Launch two workers to process SpannerBackup resources
+	for i := 0; i < threadiness; i++ {
+		go wait.Until(func() { c.runWorker(ctx) }, time.Second, stopCh)
+	}
+
+	klog.Info("Started workers")
+	<-stopCh
+	klog.Info("Shutting down workers")
+
+	return nil
+}
+
+// runWorker is a long-running function that will continually call the
+// processNextWorkItem function in order to read and process a message on the
+// workqueue.
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+// processNextWorkItem will read a single work item off the workqueue and
+// attempt to process it, by calling the syncHandler.
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	obj, shutdown := c.workqueue.Get()
+
+	if shutdown {
+		return false
+	}
+
+	// We wrap this block in a func so we can defer c.workqueue.Done.
+	err := func(obj interface{}) error {
+		// We call Done here so the workqueue knows we have finished
+		// processing this item. We also must remember to call Forget if we
+		// do not want this work item being re-queued. For example, we do
+		// not call Forget if a transient error occurs, instead the item is
+		// put back on the workqueue and attempted again after a back-off
+		// period.
+		defer c.workqueue.Done(obj)
+		var key string
+		var ok bool
+		// We expect strings to come off the workqueue. These are of the
+		// form namespace/name. We do this as the delayed nature of the
+		// workqueue means the items in the informer cache may actually be
+		// more up to date that when the item was initially put onto the
+		// workqueue.
+		if key, ok = obj.(string); !ok {
+			// As the item in the workqueue is actually invalid, we call
+			// Forget here else we'd go into a loop of attempting to
+			// process a work item that is invalid.
+			c.workqueue.Forget(obj)
+			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
+			return nil
+		}
+		// Run the syncHandler, passing it the namespace/name string of the
+		// SpannerBackup resource to be synced.
+		syncCtx, cancel := context.WithTimeout(ctx, c.syncTimeout)
+		defer cancel()
+		if err := c.syncHandler(syncCtx, key); err != nil {
+			// Put the item back on the workqueue to handle any transient errors.
+			c.workqueue.AddRateLimited(key)
+			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
+		}
+		// Finally, if no error occurs we Forget this item so it does not
+		// get queued again until another change happens.
+		c.workqueue.Forget(obj)
+		klog.Infof("Successfully synced '%s'", key)
+		return nil
+	}(obj)
+
+	if err != nil {
+		utilruntime.HandleError(err)
+		return true
+	}
+
+	return true
+}
+
+// syncHandler compares the actual state with the desired, and attempts to
+// converge the two. It then updates the Status block of the SpannerBackup resource
+// with the current status of the resource.
+func (c *Controller) syncHandler(ctx context.Context, key string) error {
+
+	log.Printf("Get key: %s", key)
+	// Convert the namespace/name string into a distinct namespace and name
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	// Get the SpannerBackup resource with this namespace/name
+	spannerBackup, err := c.spannerBackupLister.SpannerBackups(namespace).Get(name)
+	if err != nil {
+		// The SpannerBackup resource may no longer exist, in which case we stop
+		// processing. The backup itself is left to expire on its own.
+		if errors.IsNotFound(err) {
+			utilruntime.HandleError(fmt.Errorf("spannerBackup '%s' in work queue no longer exists", key))
+			return nil
+		}
+		log.Printf("Error: %s", err.Error())
+		return err
+	}
+
+	// A previous sync started a long-running operation. Poll it instead of
+	// issuing another request; this also resumes waiting after a restart.
+	if spannerBackup.Status.PendingOperation != "" {
+		spannerBackup, err = c.pollPendingOperation(ctx, key, spannerBackup)
+		if err != nil || spannerBackup == nil {
+			return err
+		}
+	}
+
+	if err := validateIds(spannerBackup); err != nil {
+		return c.rejectSpec(key, spannerBackup, ErrInvalidId, fmt.Sprintf(MessageInvalidId, err))
+	}
+	spec := spannerBackup.Spec
+	op, err := c.operatorFor(spannerBackup, spec.ProjectId)
+	if err != nil {
+		c.recorder.Event(spannerBackup, corev1.EventTypeWarning, ErrCredentials, fmt.Sprintf(MessageCredentials, err))
+		return err
+	}
+	projectId := c.operators.ProjectId(spec.ProjectId)
+	backup, err := op.GetBackup(ctx, spec.InstanceId, name)
+	if err != nil && op.IsNotFoundError(err) {
+		// Record the backup as ours before creating it, so it is not
+		// mistaken for somebody else's should the sync fail half way.
+		if backupName := operator.BackupName(projectId, spec.InstanceId, name); spannerBackup.Status.BackupName != backupName {
+			spannerBackupCopy := spannerBackup.DeepCopy()
+			spannerBackupCopy.Status.BackupName = backupName
+			spannerBackup, err = c.updateSpannerBackupStatus(spannerBackupCopy)
+			if err != nil {
+				return err
+			}
+		}
+		log.Printf("SpannerBackup does not exists on GCP, create new one with name: %s", name)
+		var versionTime time.Time
+		if spec.VersionTime != nil {
+			versionTime = spec.VersionTime.Time
+		}
+		opName, err := op.CreateBackup(ctx, spec.InstanceId, name, spec.Database, spec.ExpireTime.Time, versionTime)
+		if err != nil {
+			return err
+		}
+		return c.trackOperation(key, spannerBackup, opName)
+	} else if err != nil {
+		return err
+	}
+
+	// Backup IDs are only unique within an instance, so a SpannerBackup of
+	// the same name in another namespace may have taken the backup.
+	if spannerBackup.Status.BackupName != backup.GetName() {
+		return c.rejectSpec(key, spannerBackup, ErrResourceExists, fmt.Sprintf(MessageResourceExists, backup.GetName()))
+	}
+	if databaseName := operator.DatabaseName(projectId, spec.InstanceId, spec.Database); backup.GetDatabase() != databaseName {
+		return c.rejectSpec(key, spannerBackup, ErrResourceExists, fmt.Sprintf(MessageBackupOfOtherDatabase, backup.GetName(), backup.GetDatabase(), databaseName))
+	}
+
+	if !backup.GetExpireTime().AsTime().Equal(spec.ExpireTime.Time) {
+		log.Printf("spannerBackup expireTime: %s is different from actual backup expireTime: %s, fit to spannerBackup spec", spec.ExpireTime, backup.GetExpireTime().AsTime())
+		backup, err = op.UpdateBackupExpireTime(ctx, spec.InstanceId, name, spec.ExpireTime.Time)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Finally, we update the status block of the SpannerBackup resource to reflect the
+	// current state of the world
+	spannerBackupCopy := spannerBackup.DeepCopy()
+	spannerBackupCopy.Status.BackupName = backup.GetName()
+	spannerBackupCopy.Status.State = backup.GetState().String()
+	spannerBackupCopy.Status.SizeBytes = backup.GetSizeBytes()
+	spannerBackupCopy.Status.CreateTime = timeFromProto(backup.GetCreateTime())
+	spannerBackupCopy.Status.VersionTime = timeFromProto(backup.GetVersionTime())
+	spannerBackupCopy.Status.ExpireTime = timeFromProto(backup.GetExpireTime())
+	_, err = c.updateSpannerBackupStatus(spannerBackupCopy)
+	if err != nil {
+		return err
+	}
+
+	c.recorder.Event(spannerBackup, corev1.EventTypeNormal, SuccessSynced, MessageResourceSynced)
+	return nil
+}
+
+// trackOperation records opName as the pending operation of spannerBackup
+// and schedules the key to be polled until the operation finishes.
+func (c *Controller) trackOperation(key string, spannerBackup *backupv1alpha1.SpannerBackup, opName string) error {
+	spannerBackupCopy := spannerBackup.DeepCopy()
+	spannerBackupCopy.Status.PendingOperation = opName
+	if _, err := c.updateSpannerBackupStatus(spannerBackupCopy); err != nil {
+		return err
+	}
+	c.workqueue.AddAfter(key, operationPollInterval)
+	return nil
+}
+
+// pollPendingOperation checks the pending operation of spannerBackup. While
+// it is running, the key is requeued and nil is returned. Once it is done, the
+// pending operation is cleared and the updated SpannerBackup is returned so
+// the sync can carry on.
+func (c *Controller) pollPendingOperation(ctx context.Context, key string, spannerBackup *backupv1alpha1.SpannerBackup) (*backupv1alpha1.SpannerBackup, error) {
+	// The operation runs in the project it was started in, even if the spec
+	// names another one since.
+	name := spannerBackup.Status.PendingOperation
+	o, err := c.operatorFor(spannerBackup, operator.ProjectIdOf(name))
+	if err != nil {
+		return nil, err
+	}
+	op, err := o.GetOperation(ctx, name)
+	if err != nil && o.IsNotFoundError(err) {
+		// Spanner keeps operations for a limited time only. One that is gone
+		// can no longer be polled, so it is cleared and the sync carries on
+		// from what Spanner reports.
+		log.Printf("Operation %s no longer exists, reconcile again", name)
+		spannerBackupCopy := spannerBackup.DeepCopy()
+		spannerBackupCopy.Status.PendingOperation = ""
+		return c.updateSpannerBackupStatus(spannerBackupCopy)
+	} else if err != nil {
+		return nil, err
+	}
+	if !op.Done {
+		log.Printf("Operation %s is still running", op.Name)
+		c.workqueue.AddAfter(key, operationPollInterval)
+		return nil, nil
+	}
+	spannerBackupCopy := spannerBackup.DeepCopy()
+	spannerBackupCopy.Status.PendingOperation = ""
+	updated, err := c.updateSpannerBackupStatus(spannerBackupCopy)
+	if err != nil {
+		return nil, err
+	}
+	if op.Err != nil {
+		c.recorder.Event(spannerBackup, corev1.EventTypeWarning, ErrOperationFailed, fmt.Sprintf(MessageOperationFailed, op.Name, op.Err))
+		return nil, op.Err
+	}
+	return updated, nil
+}
+
+// validateIds checks the Spanner IDs of spannerBackup. Its name is the ID of
+// the backup.
+func validateIds(spannerBackup *backupv1alpha1.SpannerBackup) error {
+	if err := operator.ValidateBackupId(spannerBackup.Name); err != nil {
+		return err
+	}
+	if err := operator.ValidateInstanceId(spannerBackup.Spec.InstanceId); err != nil {
+		return err
+	}
+	return operator.ValidateDatabaseId(spannerBackup.Spec.Database)
+}
+
+// rejectSpec reports an error in the spec of spannerBackup as a warning Event
+// without requeuing key. No retry can succeed before the spec is updated, and
+// updating it enqueues the SpannerBackup again.
+func (c *Controller) rejectSpec(key string, spannerBackup *backupv1alpha1.SpannerBackup, reason, message string) error {
+	c.recorder.Event(spannerBackup, corev1.EventTypeWarning, reason, message)
+	utilruntime.HandleError(fmt.Errorf("%s: %s", key, message))
+	return nil
+}
+
+// operatorFor returns the Operator of projectId, built with the credentials
+// the spec of spannerBackup selects.
+func (c *Controller) operatorFor(spannerBackup *backupv1alpha1.SpannerBackup, projectId string) (operator.Operator, error) {
+	return credentials.Operator(c.operators, c.secretLister, spannerBackup.Namespace, projectId, spannerBackup.Spec.CredentialsSecretRef)
+}
+
+func (c *Controller) updateSpannerBackupStatus(spannerBackup *backupv1alpha1.SpannerBackup) (*backupv1alpha1.SpannerBackup, error) {
+	// NEVER modify objects from the store. It's a read-only, local cache.
+	// You can use DeepCopy() to make a deep copy of original object and modify this copy
+	// Or create a copy manually for better performance
+	spannerBackupCopy := spannerBackup.DeepCopy()
+	// The SpannerBackup CRD enables the status subresource, so the Status
+	// block must be written through UpdateStatus.
+	return c.spannerclientset.BackupadminsV1alpha1().SpannerBackups(spannerBackup.Namespace).UpdateStatus(spannerBackupCopy)
+}
+
+// timeFromProto converts a protobuf timestamp, returning nil when it is unset.
+func timeFromProto(ts *timestamppb.Timestamp) *metav1.Time {
+	if ts == nil {
+		return nil
+	}
+	t := metav1.NewTime(ts.AsTime())
+	return &t
+}
+
+// enqueueSpannerBackup takes a SpannerBackup resource and converts it into a namespace/name
+// string which is then put onto the work queue. This method should *not* be
+// passed resources of any type other than SpannerBackup.
+func (c *Controller) enqueueSpannerBackup(obj interface{}) {
+	var key string
+	var err error
+	if key, err = cache.MetaNamespaceKeyFunc(obj); err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.workqueue.Add(key)
+}
@@ -0,0 +1,501 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backupadmins
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/diff"
+	kubeinformers "k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	spannercontroller "github.com/katsew/spanner-operator/pkg/apis/backupadmins/v1alpha1"
+	"github.com/katsew/spanner-operator/pkg/generated/backupadmins/clientset/versioned/fake"
+	informers "github.com/katsew/spanner-operator/pkg/generated/backupadmins/informers/externalversions"
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+var (
+	alwaysReady        = func() bool { return true }
+	noResyncPeriodFunc = func() time.Duration { return 0 }
+	expireTime         = metav1.NewTime(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+)
+
+type fixture struct {
+	t *testing.T
+
+	client     *fake.Clientset
+	kubeclient *k8sfake.Clientset
+	// Operator the controller talks to Spanner through.
+	op *operator.Fake
+	// Pool handing out op for the default project, and a fake of its own
+	// for any other project.
+	operators *operator.Pool
+	// Service account keys operators were built with, in order.
+	keys []string
+	// Objects to put in the store.
+	spannerBackupLister []*spannercontroller.SpannerBackup
+	secretLister        []*corev1.Secret
+	// Actions expected to happen on the client.
+	actions []core.Action
+	// Objects from here preloaded into NewSimpleFake.
+	objects []runtime.Object
+}
+
+func newFixture(t *testing.T) *fixture {
+	f := &fixture{}
+	f.t = t
+	f.objects = []runtime.Object{}
+	f.op = operator.NewBuilder().ProjectId("test").BuildFake()
+	f.operators = operator.NewPool("test", func(projectId string, key []byte) (operator.Operator, error) {
+		if key != nil {
+			f.keys = append(f.keys, string(key))
+		}
+		if projectId == "test" {
+			return f.op, nil
+		}
+		return operator.NewBuilder().ProjectId(projectId).BuildFake(), nil
+	})
+	return f
+}
+
+func newSpannerBackup(name string, database string) *spannercontroller.SpannerBackup {
+	return &spannercontroller.SpannerBackup{
+		TypeMeta: metav1.TypeMeta{APIVersion: spannercontroller.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: spannercontroller.SpannerBackupSpec{
+			InstanceId: "test",
+			Database:   database,
+			ExpireTime: expireTime,
+		},
+	}
+}
+
+func (f *fixture) newController() (*Controller, informers.SharedInformerFactory) {
+	f.client = fake.NewSimpleClientset(f.objects...)
+	f.kubeclient = k8sfake.NewSimpleClientset()
+
+	i := informers.NewSharedInformerFactory(f.client, noResyncPeriodFunc())
+	k8sI := kubeinformers.NewSharedInformerFactory(f.kubeclient, noResyncPeriodFunc())
+
+	c := NewController(f.kubeclient, f.client, i.Backupadmins().V1alpha1().SpannerBackups(),
+		k8sI.Core().V1().Secrets(), f.operators)
+
+	c.spannerBackupsSynced = alwaysReady
+	c.secretsSynced = alwaysReady
+	c.recorder = &record.FakeRecorder{}
+
+	for _, b := range f.spannerBackupLister {
+		i.Backupadmins().V1alpha1().SpannerBackups().Informer().GetIndexer().Add(b)
+	}
+
+	for _, s := range f.secretLister {
+		k8sI.Core().V1().Secrets().Informer().GetIndexer().Add(s)
+	}
+
+	return c, i
+}
+
+func (f *fixture) run(spannerBackupName string) {
+	f.runController(spannerBackupName, true, false)
+}
+
+func (f *fixture) runExpectError(spannerBackupName string) {
+	f.runController(spannerBackupName, true, true)
+}
+
+func (f *fixture) runController(spannerBackupName string, startInformers bool, expectError bool) {
+	c, i := f.newController()
+	if startInformers {
+		stopCh := make(chan struct{})
+		defer close(stopCh)
+		i.Start(stopCh)
+	}
+
+	err := c.syncHandler(context.Background(), spannerBackupName)
+	if !expectError && err != nil {
+		f.t.Errorf("error syncing SpannerBackup: %v", err)
+	} else if expectError && err == nil {
+		f.t.Error("expected error syncing SpannerBackup, got nil")
+	}
+
+	actions := filterInformerActions(f.client.Actions())
+	for i, action := range actions {
+		if len(f.actions) < i+1 {
+			f.t.Errorf("%d unexpected actions: %+v", len(actions)-len(f.actions), actions[i:])
+			break
+		}
+
+		expectedAction := f.actions[i]
+		checkAction(expectedAction, action, f.t)
+	}
+
+	if len(f.actions) > len(actions) {
+		f.t.Errorf("%d additional expected actions:%+v", len(f.actions)-len(actions), f.actions[len(actions):])
+	}
+}
+
+// checkAction verifies that expected and actual actions are equal and both have
+// same attached resources
+func checkAction(expected, actual core.Action, t *testing.T) {
+	if !(expected.Matches(actual.GetVerb(), actual.GetResource().Resource) && actual.GetSubresource() == expected.GetSubresource()) {
+		t.Errorf("Expected\n\t%#v\ngot\n\t%#v", expected, actual)
+		return
+	}
+
+	if reflect.TypeOf(actual) != reflect.TypeOf(expected) {
+		t.Errorf("Action has wrong type. Expected: %t. Got: %t", expected, actual)
+		return
+	}
+
+	switch a := actual.(type) {
+	case core.CreateAction:
+		e, _ := expected.(core.CreateAction)
+		expObject := e.GetObject()
+		object := a.GetObject()
+
+		if !reflect.DeepEqual(expObject, object) {
+			t.Errorf("Action %s %s has wrong object\nDiff:\n %s",
+				a.GetVerb(), a.GetResource().Resource, diff.ObjectGoPrintDiff(expObject, object))
+		}
+	case core.UpdateAction:
+		e, _ := expected.(core.UpdateAction)
+		expObject := e.GetObject()
+		object := a.GetObject()
+
+		if !reflect.DeepEqual(expObject, object) {
+			t.Errorf("Action %s %s has wrong object\nDiff:\n %s",
+				a.GetVerb(), a.GetResource().Resource, diff.ObjectGoPrintDiff(expObject, object))
+		}
+	case core.PatchAction:
+		e, _ := expected.(core.PatchAction)
+		expPatch := e.GetPatch()
+		patch := a.GetPatch()
+
+		if !reflect.DeepEqual(expPatch, patch) {
+			t.Errorf("Action %s %s has wrong patch\nDiff:\n %s",
+				a.GetVerb(), a.GetResource().Resource, diff.ObjectGoPrintDiff(expPatch, patch))
+		}
+	}
+}
+
+// filterInformerActions filters list and watch actions for testing resources.
+// Since list and watch don't change resource state we can filter it to lower
+// nose level in our tests.
+func filterInformerActions(actions []core.Action) []core.Action {
+	ret := []core.Action{}
+	for _, action := range actions {
+		if len(action.GetNamespace()) == 0 &&
+			(action.Matches("list", "spannerbackups") ||
+				action.Matches("watch", "spannerbackups")) {
+			continue
+		}
+		ret = append(ret, action)
+	}
+
+	return ret
+}
+
+func (f *fixture) expectUpdateSpannerBackupStatusAction(spannerBackup *spannercontroller.SpannerBackup) {
+	action := core.NewUpdateAction(schema.GroupVersionResource{Resource: "spannerbackups"}, spannerBackup.Namespace, spannerBackup)
+	action.Subresource = "status"
+	f.actions = append(f.actions, action)
+}
+
+// createDatabase makes the operator hold the database name in the "test" instance.
+func (f *fixture) createDatabase(name string) {
+	ctx := context.Background()
+	if _, err := f.op.CreateInstance(ctx, "test", "test", "", operator.Nodes(1)); err != nil {
+		f.t.Fatal(err)
+	}
+	if _, err := f.op.CreateDatabase(ctx, "test", name, nil); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// createBackup makes the operator hold a backup matching the spec of
+// spannerBackup, and records it in the status as created by spannerBackup.
+func (f *fixture) createBackup(spannerBackup *spannercontroller.SpannerBackup) {
+	spec := spannerBackup.Spec
+	if _, err := f.op.CreateBackup(context.Background(), spec.InstanceId, spannerBackup.Name, spec.Database, spec.ExpireTime.Time, time.Time{}); err != nil {
+		f.t.Fatal(err)
+	}
+	spannerBackup.Status.BackupName = operator.BackupName("test", spec.InstanceId, spannerBackup.Name)
+}
+
+// expectClaim expects the status of spannerBackup to record the backup before
+// it is created, and returns the recorded SpannerBackup.
+func (f *fixture) expectClaim(spannerBackup *spannercontroller.SpannerBackup, projectId string) *spannercontroller.SpannerBackup {
+	claimed := spannerBackup.DeepCopy()
+	claimed.Status.BackupName = "projects/" + projectId + "/instances/" + spannerBackup.Spec.InstanceId + "/backups/" + spannerBackup.Name
+	f.expectUpdateSpannerBackupStatusAction(claimed)
+	return claimed
+}
+
+// expectSyncedStatus returns spannerBackup with the status reported for the
+// backup the operator holds.
+func (f *fixture) expectSyncedStatus(spannerBackup *spannercontroller.SpannerBackup) *spannercontroller.SpannerBackup {
+	backup, err := f.op.GetBackup(context.Background(), spannerBackup.Spec.InstanceId, spannerBackup.Name)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	expBackup := spannerBackup.DeepCopy()
+	expBackup.Status.BackupName = "projects/test/instances/" + spannerBackup.Spec.InstanceId + "/backups/" + spannerBackup.Name
+	expBackup.Status.State = "READY"
+	expBackup.Status.CreateTime = timeFromProto(backup.GetCreateTime())
+	expBackup.Status.VersionTime = timeFromProto(backup.GetVersionTime())
+	expBackup.Status.ExpireTime = timeFromProto(backup.GetExpireTime())
+	return expBackup
+}
+
+func getKey(spannerBackup *spannercontroller.SpannerBackup, t *testing.T) string {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(spannerBackup)
+	if err != nil {
+		t.Errorf("Unexpected error getting key for SpannerBackup %v: %v", spannerBackup.Name, err)
+		return ""
+	}
+	return key
+}
+
+func TestCreatesBackup(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb")
+	spannerBackup := newSpannerBackup("test", "testdb")
+
+	f.spannerBackupLister = append(f.spannerBackupLister, spannerBackup)
+	f.objects = append(f.objects, spannerBackup)
+
+	expBackup := f.expectClaim(spannerBackup, "test").DeepCopy()
+	expBackup.Status.PendingOperation = "projects/test/instances/test/backups/test/operations/mock_create_backup"
+	f.expectUpdateSpannerBackupStatusAction(expBackup)
+
+	f.run(getKey(spannerBackup, t))
+}
+
+func TestCreatesBackupInProject(t *testing.T) {
+	f := newFixture(t)
+	other, err := f.operators.Get("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := other.CreateInstance(ctx, "test", "test", "", operator.Nodes(1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.CreateDatabase(ctx, "test", "testdb", nil); err != nil {
+		t.Fatal(err)
+	}
+	spannerBackup := newSpannerBackup("test", "testdb")
+	spannerBackup.Spec.ProjectId = "other"
+
+	f.spannerBackupLister = append(f.spannerBackupLister, spannerBackup)
+	f.objects = append(f.objects, spannerBackup)
+
+	expBackup := f.expectClaim(spannerBackup, "other").DeepCopy()
+	expBackup.Status.PendingOperation = "projects/other/instances/test/backups/test/operations/mock_create_backup"
+	f.expectUpdateSpannerBackupStatusAction(expBackup)
+
+	f.run(getKey(spannerBackup, t))
+}
+
+func TestCreatesBackupWithSecretCredentials(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb")
+	spannerBackup := newSpannerBackup("test", "testdb")
+	spannerBackup.Spec.CredentialsSecretRef = &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "spanner-key"},
+		Key:                  "key.json",
+	}
+
+	f.spannerBackupLister = append(f.spannerBackupLister, spannerBackup)
+	f.objects = append(f.objects, spannerBackup)
+	f.secretLister = append(f.secretLister, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "spanner-key", Namespace: metav1.NamespaceDefault, ResourceVersion: "1"},
+		Data:       map[string][]byte{"key.json": []byte("key-1")},
+	})
+
+	expBackup := f.expectClaim(spannerBackup, "test").DeepCopy()
+	expBackup.Status.PendingOperation = "projects/test/instances/test/backups/test/operations/mock_create_backup"
+	f.expectUpdateSpannerBackupStatusAction(expBackup)
+
+	f.run(getKey(spannerBackup, t))
+
+	if !reflect.DeepEqual(f.keys, []string{"key-1"}) {
+		t.Errorf("expected an operator built with key-1, got %v", f.keys)
+	}
+}
+
+func TestMissingCredentialsSecret(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb")
+	spannerBackup := newSpannerBackup("test", "testdb")
+	spannerBackup.Spec.CredentialsSecretRef = &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "spanner-key"},
+		Key:                  "key.json",
+	}
+
+	f.spannerBackupLister = append(f.spannerBackupLister, spannerBackup)
+	f.objects = append(f.objects, spannerBackup)
+
+	f.runExpectError(getKey(spannerBackup, t))
+
+	if backups, err := f.op.ListBackups(context.Background(), "test", "testdb"); err != nil || len(backups) != 0 {
+		t.Errorf("expected no backup with the default credentials, got %v and %v", backups, err)
+	}
+}
+
+func TestSyncsBackupStatus(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb")
+	spannerBackup := newSpannerBackup("test", "testdb")
+	f.createBackup(spannerBackup)
+
+	f.spannerBackupLister = append(f.spannerBackupLister, spannerBackup)
+	f.objects = append(f.objects, spannerBackup)
+
+	f.expectUpdateSpannerBackupStatusAction(f.expectSyncedStatus(spannerBackup))
+	f.run(getKey(spannerBackup, t))
+}
+
+func TestUpdatesExpireTime(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb")
+	spannerBackup := newSpannerBackup("test", "testdb")
+	f.createBackup(spannerBackup)
+	spannerBackup.Spec.ExpireTime = metav1.NewTime(expireTime.Add(24 * time.Hour))
+
+	f.spannerBackupLister = append(f.spannerBackupLister, spannerBackup)
+	f.objects = append(f.objects, spannerBackup)
+
+	// The expected status is read after the sync, once the backup holds the
+	// new expire time.
+	c, i := f.newController()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	i.Start(stopCh)
+	if err := c.syncHandler(context.Background(), getKey(spannerBackup, t)); err != nil {
+		t.Fatalf("error syncing SpannerBackup: %v", err)
+	}
+	expBackup := f.expectSyncedStatus(spannerBackup)
+	if !expBackup.Status.ExpireTime.Equal(&spannerBackup.Spec.ExpireTime) {
+		t.Errorf("expected expire time %s, got %s", spannerBackup.Spec.ExpireTime, expBackup.Status.ExpireTime)
+	}
+	f.expectUpdateSpannerBackupStatusAction(expBackup)
+	actions := filterInformerActions(f.client.Actions())
+	if len(actions) != 1 {
+		t.Fatalf("expected 1 action, got %+v", actions)
+	}
+	checkAction(f.actions[0], actions[0], t)
+}
+
+func TestMissingDatabase(t *testing.T) {
+	f := newFixture(t)
+	spannerBackup := newSpannerBackup("test", "testdb")
+
+	f.spannerBackupLister = append(f.spannerBackupLister, spannerBackup)
+	f.objects = append(f.objects, spannerBackup)
+
+	f.expectClaim(spannerBackup, "test")
+	f.runExpectError(getKey(spannerBackup, t))
+}
+
+func TestRejectsInvalidBackupId(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb")
+	spannerBackup := newSpannerBackup("test.backup", "testdb")
+
+	f.spannerBackupLister = append(f.spannerBackupLister, spannerBackup)
+	f.objects = append(f.objects, spannerBackup)
+
+	// Rejected without a retry, and without a backup.
+	f.run(getKey(spannerBackup, t))
+
+	if backups, err := f.op.ListBackups(context.Background(), "test", "testdb"); err != nil || len(backups) != 0 {
+		t.Errorf("expected no backup with an invalid ID, got %v and %v", backups, err)
+	}
+}
+
+// A SpannerBackup of the same name in another namespace takes the same backup
+// ID, so the backup it created must be left alone.
+func TestRefusesBackupItDidNotCreate(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb")
+	other := newSpannerBackup("test", "testdb")
+	other.Namespace = "other"
+	f.createBackup(other)
+	spannerBackup := newSpannerBackup("test", "testdb")
+
+	f.spannerBackupLister = append(f.spannerBackupLister, spannerBackup)
+	f.objects = append(f.objects, spannerBackup)
+
+	f.run(getKey(spannerBackup, t))
+}
+
+func TestRefusesBackupOfOtherDatabase(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb")
+	if _, err := f.op.CreateDatabase(context.Background(), "test", "otherdb", nil); err != nil {
+		t.Fatal(err)
+	}
+	spannerBackup := newSpannerBackup("test", "otherdb")
+	f.createBackup(spannerBackup)
+	spannerBackup.Spec.Database = "testdb"
+
+	f.spannerBackupLister = append(f.spannerBackupLister, spannerBackup)
+	f.objects = append(f.objects, spannerBackup)
+
+	f.run(getKey(spannerBackup, t))
+
+	if backups, err := f.op.ListBackups(context.Background(), "test", "testdb"); err != nil || len(backups) != 0 {
+		t.Errorf("expected the backup of otherdb to be left alone, got %v and %v", backups, err)
+	}
+}
+
+// An operation Spanner no longer keeps is cleared, and the backup synced.
+func TestClearsMissingOperation(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb")
+	spannerBackup := newSpannerBackup("test", "testdb")
+	f.createBackup(spannerBackup)
+	spannerBackup.Status.PendingOperation = "projects/test/instances/test/backups/test/operations/expired"
+	f.op.FailNext("GetOperation", status.Error(codes.NotFound, "operation not found"))
+
+	f.spannerBackupLister = append(f.spannerBackupLister, spannerBackup)
+	f.objects = append(f.objects, spannerBackup)
+
+	cleared := spannerBackup.DeepCopy()
+	cleared.Status.PendingOperation = ""
+	f.expectUpdateSpannerBackupStatusAction(cleared)
+	f.expectUpdateSpannerBackupStatusAction(f.expectSyncedStatus(cleared))
+
+	f.run(getKey(spannerBackup, t))
+}
@@ -0,0 +1,507 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backupschedules
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+
+	"github.com/robfig/cron/v3"
+
+	backupv1alpha1 "github.com/katsew/spanner-operator/pkg/apis/backupadmins/v1alpha1"
+	clientset "github.com/katsew/spanner-operator/pkg/generated/backupadmins/clientset/versioned"
+	spannerscheme "github.com/katsew/spanner-operator/pkg/generated/backupadmins/clientset/versioned/scheme"
+	informers "github.com/katsew/spanner-operator/pkg/generated/backupadmins/informers/externalversions/backupadmins/v1alpha1"
+	listers "github.com/katsew/spanner-operator/pkg/generated/backupadmins/listers/backupadmins/v1alpha1"
+	databaseinformers "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/informers/externalversions/databaseadmins/v1alpha1"
+	databaselisters "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/listers/databaseadmins/v1alpha1"
+
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+const controllerAgentName = "spanner-controller"
+
+// syncTimeout bounds a single syncHandler call, including every Spanner admin
+// call it makes, so a hung long-running operation cannot pin a worker forever.
+const syncTimeout = 10 * time.Minute
+
+// operationPollInterval is how long to wait before polling a pending
+// long-running operation again.
+const operationPollInterval = 10 * time.Second
+
+const (
+	// SuccessSynced is used as part of the Event 'reason' when a SpannerBackupSchedule is synced
+	SuccessSynced = "Synced"
+	// BackupScheduled is used as part of the Event 'reason' when a
+	// SpannerBackupSchedule starts a backup.
+	BackupScheduled = "BackupScheduled"
+
+	// ErrOperationFailed is used as part of the Event 'reason' when a long-running
+	// operation started for a SpannerBackupSchedule finishes with an error.
+	ErrOperationFailed = "ErrOperationFailed"
+
+	// WaitingForDatabase is used as part of the Event 'reason' when the
+	// SpannerDatabase a SpannerBackupSchedule references has no database yet.
+	WaitingForDatabase = "WaitingForDatabase"
+
+	// ErrInvalidSchedule is used as part of the Event 'reason' when the cron
+	// expression of a SpannerBackupSchedule cannot be parsed.
+	ErrInvalidSchedule = "ErrInvalidSchedule"
+	// ErrInvalidName is used as part of the Event 'reason' when the name of a
+	// SpannerBackupSchedule cannot name its backups.
+	ErrInvalidName = "ErrInvalidName"
+
+	// ErrCredentials is used as part of the Event 'reason' when the
+	// credentials a SpannerBackupSchedule selects cannot be read.
+	ErrCredentials = "ErrCredentials"
+
+	// MessageResourceSynced is the message used for an Event fired when a Spanner
+	// is synced successfully
+	MessageResourceSynced = "SpannerBackupSchedule synced successfully"
+	// MessageBackupScheduled is the message used for an Event fired when a
+	// scheduled backup is started
+	MessageBackupScheduled = "Started backup %s scheduled at %s"
+	// MessageWaitingForDatabase is the message used for an Event fired when
+	// the referenced SpannerDatabase has no database yet
+	MessageWaitingForDatabase = "Waiting for SpannerDatabase %s to create its database"
+	// MessageInvalidSchedule is the message used for an Event fired when the
+	// cron expression is invalid
+	MessageInvalidSchedule = "Invalid schedule %q: %v"
+	// MessageInvalidName is the message used for an Event fired when the
+	// name cannot name backups
+	MessageInvalidName = "Invalid name: %v"
+	// MessageOperationFailed is the message used for an Event fired when a
+	// long-running operation fails
+	MessageOperationFailed = "Operation %s failed: %v"
+	// MessageCredentials is the message used for an Event fired when the
+	// credentials in the spec cannot be read
+	MessageCredentials = "Invalid credentialsSecretRef: %v"
+)
+
+// Controller is the controller implementation for SpannerBackupSchedule resources
+type Controller struct {
+	// kubeclientset is a standard kubernetes clientset
+	kubeclientset kubernetes.Interface
+	// spannerclientset is a clientset for our own API group
+	spannerclientset clientset.Interface
+
+	spannerBackupScheduleLister  listers.SpannerBackupScheduleLister
+	spannerBackupSchedulesSynced cache.InformerSynced
+	spannerDatabaseLister        databaselisters.SpannerDatabaseLister
+	spannerDatabasesSynced       cache.InformerSynced
+	secretLister                 corelisters.SecretLister
+	secretsSynced                cache.InformerSynced
+
+	// workqueue is a rate limited work queue. This is used to queue work to be
+	// processed instead of performing it as soon as a change happens. This
+	// means we can ensure we only process a fixed amount of resources at a
+	// time, and makes it easy to ensure we are never processing the same item
+	// simultaneously in two different workers.
+	workqueue workqueue.RateLimitingInterface
+	// recorder is an event recorder for recording Event resources to the
+	// Kubernetes API.
+	recorder record.EventRecorder
+
+	// operators hands out the Operator of the project of the database a
+	// SpannerBackupSchedule backs up.
+	operators *operator.Pool
+
+	// syncTimeout is the deadline applied to each syncHandler call.
+	syncTimeout time.Duration
+	// now returns the current time, which schedules are evaluated against.
+	now func() time.Time
+}
+
+// NewController returns a new spanner backup schedule controller
+func NewController(
+	kubeclientset kubernetes.Interface,
+	spannerclientset clientset.Interface,
+	spannerBackupScheduleInformer informers.SpannerBackupScheduleInformer,
+	spannerDatabaseInformer databaseinformers.SpannerDatabaseInformer,
+	secretInformer coreinformers.SecretInformer,
+	operators *operator.Pool) *Controller {
+
+	// Create event broadcaster
+	// Add spanner-controller types to the default Kubernetes Scheme so Events can be
+	// logged for spanner-controller types.
+	utilruntime.Must(spannerscheme.AddToScheme(scheme.Scheme))
+	klog.V(4).Info("Creating event broadcaster")
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(klog.Infof)
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
+
+	controller := &Controller{
+		kubeclientset:                kubeclientset,
+		spannerclientset:             spannerclientset,
+		spannerBackupScheduleLister:  spannerBackupScheduleInformer.Lister(),
+		spannerBackupSchedulesSynced: spannerBackupScheduleInformer.Informer().HasSynced,
+		spannerDatabaseLister:        spannerDatabaseInformer.Lister(),
+		spannerDatabasesSynced:       spannerDatabaseInformer.Informer().HasSynced,
+		secretLister:                 secretInformer.Lister(),
+		secretsSynced:                secretInformer.Informer().HasSynced,
+		workqueue:                    workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "SpannerBackupSchedules"),
+		recorder:                     recorder,
+		operators:                    operators,
+		syncTimeout:                  syncTimeout,
+		now:                          time.Now,
+	}
+
+	klog.Info("Setting up event handlers")
+	// Set up an event handler for when SpannerBackupSchedule resources change
+	spannerBackupScheduleInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueSpannerBackupSchedule,
+		UpdateFunc: func(old, new interface{}) {
+			controller.enqueueSpannerBackupSchedule(new)
+		},
+		DeleteFunc: controller.enqueueSpannerBackupSchedule,
+	})
+	// Schedules referencing a SpannerDatabase wait for it to claim its
+	// database, so requeue them when it changes.
+	spannerDatabaseInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.handleSpannerDatabase,
+		UpdateFunc: func(old, new interface{}) {
+			controller.handleSpannerDatabase(new)
+		},
+		DeleteFunc: controller.handleSpannerDatabase,
+	})
+
+	return controller
+}
+
+// Run will set up the event handlers for types we are interested in, as well
+// as syncing informer caches and starting workers. It will block until stopCh
+// is closed, at which point it will shutdown the workqueue and wait for
+// workers to finish processing their current work items.
+func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
+	defer utilruntime.HandleCrash()
+	defer c.workqueue.ShutDown()
+
+	// Start the informer factories to begin populating the informer caches
+	klog.Info("Starting SpannerBackupSchedule controller")
+
+	// Wait for the caches to be synced before starting workers
+	klog.Info("Waiting for informer caches to sync")
+	if ok := cache.WaitForCacheSync(stopCh, c.spannerBackupSchedulesSynced, c.spannerDatabasesSynced, c.secretsSynced); !ok {
+		return fmt.Errorf("failed to wait for caches to sync")
+	}
+
+	// ctx is cancelled once stopCh is closed, which aborts in-flight Spanner
+	// admin calls made by the workers.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	klog.Info("Starting workers")
+	// Launch two workers to process SpannerBackupSchedule resources
+	for i := 0; i < threadiness; i++ {
+		go wait.Until(func() { c.runWorker(ctx) }, time.Second, stopCh)
+	}
+
+	klog.Info("Started workers")
+	<-stopCh
+	klog.Info("Shutting down workers")
+
+	return nil
+}
+
+// runWorker is a long-running function that will continually call the
+// processNextWorkItem function in order to read and process a message on the
+// workqueue.
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+// processNextWorkItem will read a single work item off the workqueue and
+// attempt to process it, by calling the syncHandler.
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	obj, shutdown := c.workqueue.Get()
+
+	if shutdown {
+		return false
+	}
+
+	// We wrap this block in a func so we can defer c.workqueue.Done.
+	err := func(obj interface{}) error {
+		// We call Done here so the workqueue knows we have finished
+		// processing this item. We also must remember to call Forget if we
+		// do not want this work item being re-queued. For example, we do
+		// not call Forget if a transient error occurs, instead the item is
+		// put back on the workqueue and attempted again after a back-off
+		// period.
+		defer c.workqueue.Done(obj)
+		var key string
+		var ok bool
+		// We expect strings to come off the workqueue. These are of the
+		// form namespace/name. We do this as the delayed nature of the
+		// workqueue means the items in the informer cache may actually be
+		// more up to date that when the item was initially put onto the
+		// workqueue.
+		if key, ok = obj.(string); !ok {
+			// As the item in the workqueue is actually invalid, we call
+			// Forget here else we'd go into a loop of attempting to
+			// process a work item that is invalid.
+			c.workqueue.Forget(obj)
+			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
+			return nil
+		}
+		// Run the syncHandler, passing it the namespace/name string of the
+		// SpannerBackupSchedule resource to be synced.
+		syncCtx, cancel := context.WithTimeout(ctx, c.syncTimeout)
+		defer cancel()
+		if err := c.syncHandler(syncCtx, key); err != nil {
+			// Put the item back on the workqueue to handle any transient errors.
+			c.workqueue.AddRateLimited(key)
+			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
+		}
+		// Finally, if no error occurs we Forget this item so it does not
+		// get queued again until another change happens.
+		c.workqueue.Forget(obj)
+		klog.Infof("Successfully synced '%s'", key)
+		return nil
+	}(obj)
+
+	if err != nil {
+		utilruntime.HandleError(err)
+		return true
+	}
+
+	return true
+}
+
+// syncHandler compares the actual state with the desired, and attempts to
+// converge the two. It starts the backup of the most recent scheduled run that
+// has not been taken yet, deletes backups falling out of the retention policy
+// and requeues the key for the next run.
+//
+// A run is identified by its scheduled time, which names its backup. An
+// existing backup is never created twice, so a run interrupted by a restart is
+// neither missed nor repeated.
+func (c *Controller) syncHandler(ctx context.Context, key string) error {
+
+	log.Printf("Get key: %s", key)
+	// Convert the namespace/name string into a distinct namespace and name
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	// Get the SpannerBackupSchedule resource with this namespace/name
+	spannerBackupSchedule, err := c.spannerBackupScheduleLister.SpannerBackupSchedules(namespace).Get(name)
+	if err != nil {
+		// The SpannerBackupSchedule resource may no longer exist, in which case we stop
+		// processing. The backups it took are left to expire on their own.
+		if errors.IsNotFound(err) {
+			utilruntime.HandleError(fmt.Errorf("spannerBackupSchedule '%s' in work queue no longer exists", key))
+			return nil
+		}
+		log.Printf("Error: %s", err.Error())
+		return err
+	}
+
+	// A previous sync started a backup. Poll it instead of starting another
+	// run; this also resumes waiting after a restart.
+	if spannerBackupSchedule.Status.PendingOperation != "" {
+		spannerBackupSchedule, err = c.pollPendingOperation(ctx, key, spannerBackupSchedule)
+		if err != nil || spannerBackupSchedule == nil {
+			return err
+		}
+	}
+
+	spec := spannerBackupSchedule.Spec
+	schedule, err := cron.ParseStandard(spec.Schedule)
+	if err != nil {
+		return c.rejectSpec(key, spannerBackupSchedule, ErrInvalidSchedule, fmt.Sprintf(MessageInvalidSchedule, spec.Schedule, err))
+	}
+	if err := validateName(spannerBackupSchedule.Name); err != nil {
+		return c.rejectSpec(key, spannerBackupSchedule, ErrInvalidName, fmt.Sprintf(MessageInvalidName, err))
+	}
+
+	target, err := c.targetOf(spannerBackupSchedule)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		c.recorder.Event(spannerBackupSchedule, corev1.EventTypeNormal, WaitingForDatabase, fmt.Sprintf(MessageWaitingForDatabase, spec.DatabaseRef.Name))
+		return nil
+	}
+	op, err := c.operatorFor(target, target.projectId)
+	if err != nil {
+		c.recorder.Event(spannerBackupSchedule, corev1.EventTypeWarning, ErrCredentials, fmt.Sprintf(MessageCredentials, err))
+		return err
+	}
+
+	now := c.now().UTC()
+	earliest := spannerBackupSchedule.CreationTimestamp.Time
+	if last := spannerBackupSchedule.Status.LastScheduleTime; last != nil {
+		earliest = last.Time
+	}
+	if scheduled, ok := mostRecentRun(schedule, earliest, now); ok {
+		backupId := scheduledBackupId(spannerBackupSchedule.Namespace, spannerBackupSchedule.Name, scheduled)
+		spannerBackupScheduleCopy := spannerBackupSchedule.DeepCopy()
+		spannerBackupScheduleCopy.Status.LastScheduleTime = &metav1.Time{Time: scheduled}
+		spannerBackupScheduleCopy.Status.LastBackup = backupId
+
+		_, err := op.GetBackup(ctx, target.instanceId, backupId)
+		if err != nil && op.IsNotFoundError(err) {
+			log.Printf("Run of SpannerBackupSchedule %s scheduled at %s is due, create backup: %s", spannerBackupSchedule.Name, scheduled, backupId)
+			opName, err := op.CreateBackup(ctx, target.instanceId, backupId, target.databaseId, now.Add(backupExpiry(spec.Retention)), time.Time{})
+			if err != nil {
+				return err
+			}
+			c.recorder.Event(spannerBackupSchedule, corev1.EventTypeNormal, BackupScheduled, fmt.Sprintf(MessageBackupScheduled, backupId, scheduled))
+			return c.trackOperation(key, spannerBackupScheduleCopy, opName)
+		} else if err != nil {
+			return err
+		}
+		// The backup of this run was started before, but recording it in
+		// the status did not succeed.
+		log.Printf("Backup %s of SpannerBackupSchedule %s already exists", backupId, spannerBackupSchedule.Name)
+		spannerBackupSchedule, err = c.updateSpannerBackupScheduleStatus(spannerBackupScheduleCopy)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := collectBackups(ctx, op, target, spannerBackupSchedule, now); err != nil {
+		return err
+	}
+
+	next := schedule.Next(now)
+	log.Printf("Next run of SpannerBackupSchedule %s is scheduled at %s", spannerBackupSchedule.Name, next)
+	c.workqueue.AddAfter(key, next.Sub(now))
+	return nil
+}
+
+// trackOperation records opName as the pending operation of spannerBackupSchedule
+// and schedules the key to be polled until the operation finishes.
+func (c *Controller) trackOperation(key string, spannerBackupSchedule *backupv1alpha1.SpannerBackupSchedule, opName string) error {
+	spannerBackupScheduleCopy := spannerBackupSchedule.DeepCopy()
+	spannerBackupScheduleCopy.Status.PendingOperation = opName
+	if _, err := c.updateSpannerBackupScheduleStatus(spannerBackupScheduleCopy); err != nil {
+		return err
+	}
+	c.workqueue.AddAfter(key, operationPollInterval)
+	return nil
+}
+
+// pollPendingOperation checks the pending operation of spannerBackupSchedule. While
+// it is running, the key is requeued and nil is returned. Once it is done, the
+// pending operation is cleared and the updated SpannerBackupSchedule is returned so
+// the sync can carry on.
+func (c *Controller) pollPendingOperation(ctx context.Context, key string, spannerBackupSchedule *backupv1alpha1.SpannerBackupSchedule) (*backupv1alpha1.SpannerBackupSchedule, error) {
+	// The operation runs in the project it was started in, even if the spec
+	// names another one since. Without its SpannerDatabase, it is polled with
+	// the credentials of the spec.
+	name := spannerBackupSchedule.Status.PendingOperation
+	target, err := c.targetOf(spannerBackupSchedule)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		target = specTarget(spannerBackupSchedule)
+	}
+	o, err := c.operatorFor(target, operator.ProjectIdOf(name))
+	if err != nil {
+		return nil, err
+	}
+	op, err := o.GetOperation(ctx, name)
+	if err != nil && o.IsNotFoundError(err) {
+		// Spanner keeps operations for a limited time only. One that is gone
+		// can no longer be polled, so it is cleared and the sync carries on
+		// from what Spanner reports.
+		log.Printf("Operation %s no longer exists, reconcile again", name)
+		spannerBackupScheduleCopy := spannerBackupSchedule.DeepCopy()
+		spannerBackupScheduleCopy.Status.PendingOperation = ""
+		return c.updateSpannerBackupScheduleStatus(spannerBackupScheduleCopy)
+	} else if err != nil {
+		return nil, err
+	}
+	if !op.Done {
+		log.Printf("Operation %s is still running", op.Name)
+		c.workqueue.AddAfter(key, operationPollInterval)
+		return nil, nil
+	}
+	spannerBackupScheduleCopy := spannerBackupSchedule.DeepCopy()
+	spannerBackupScheduleCopy.Status.PendingOperation = ""
+	updated, err := c.updateSpannerBackupScheduleStatus(spannerBackupScheduleCopy)
+	if err != nil {
+		return nil, err
+	}
+	if op.Err != nil {
+		// The run is not retried, its scheduled time is already recorded.
+		c.recorder.Event(spannerBackupSchedule, corev1.EventTypeWarning, ErrOperationFailed, fmt.Sprintf(MessageOperationFailed, op.Name, op.Err))
+	}
+	return updated, nil
+}
+
+// rejectSpec reports an error in the spec of spannerBackupSchedule as a warning Event
+// without requeuing key. No retry can succeed before the spec is updated, and
+// updating it enqueues the SpannerBackupSchedule again.
+func (c *Controller) rejectSpec(key string, spannerBackupSchedule *backupv1alpha1.SpannerBackupSchedule, reason, message string) error {
+	c.recorder.Event(spannerBackupSchedule, corev1.EventTypeWarning, reason, message)
+	utilruntime.HandleError(fmt.Errorf("%s: %s", key, message))
+	return nil
+}
+
+func (c *Controller) updateSpannerBackupScheduleStatus(spannerBackupSchedule *backupv1alpha1.SpannerBackupSchedule) (*backupv1alpha1.SpannerBackupSchedule, error) {
+	// NEVER modify objects from the store. It's a read-only, local cache.
+	// You can use DeepCopy() to make a deep copy of original object and modify this copy
+	// Or create a copy manually for better performance
+	spannerBackupScheduleCopy := spannerBackupSchedule.DeepCopy()
+	// The SpannerBackupSchedule CRD enables the status subresource, so the
+	// Status block must be written through UpdateStatus.
+	return c.spannerclientset.BackupadminsV1alpha1().SpannerBackupSchedules(spannerBackupSchedule.Namespace).UpdateStatus(spannerBackupScheduleCopy)
+}
+
+// enqueueSpannerBackupSchedule takes a SpannerBackupSchedule resource and converts it into a namespace/name
+// string which is then put onto the work queue. This method should *not* be
+// passed resources of any type other than SpannerBackupSchedule.
+func (c *Controller) enqueueSpannerBackupSchedule(obj interface{}) {
+	var key string
+	var err error
+	if key, err = cache.MetaNamespaceKeyFunc(obj); err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.workqueue.Add(key)
+}
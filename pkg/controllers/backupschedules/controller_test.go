@@ -0,0 +1,581 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backupschedules
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/diff"
+	kubeinformers "k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/robfig/cron/v3"
+
+	spannercontroller "github.com/katsew/spanner-operator/pkg/apis/backupadmins/v1alpha1"
+	databasev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+	"github.com/katsew/spanner-operator/pkg/generated/backupadmins/clientset/versioned/fake"
+	informers "github.com/katsew/spanner-operator/pkg/generated/backupadmins/informers/externalversions"
+	databasefake "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/clientset/versioned/fake"
+	databaseinformers "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/informers/externalversions"
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+var (
+	alwaysReady        = func() bool { return true }
+	noResyncPeriodFunc = func() time.Duration { return 0 }
+	creationTime       = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+type fixture struct {
+	t *testing.T
+
+	client     *fake.Clientset
+	kubeclient *k8sfake.Clientset
+	// Operator the controller talks to Spanner through.
+	op *operator.Fake
+	// Pool handing out op for the default project, and a fake of its own
+	// for any other project.
+	operators *operator.Pool
+	// Service account keys operators were built with, in order.
+	keys []string
+	// Objects to put in the store.
+	spannerBackupScheduleLister []*spannercontroller.SpannerBackupSchedule
+	spannerDatabaseLister       []*databasev1alpha1.SpannerDatabase
+	secretLister                []*corev1.Secret
+	// Actions expected to happen on the client.
+	actions []core.Action
+	// Objects from here preloaded into NewSimpleFake.
+	objects []runtime.Object
+	// now is the time the controller evaluates schedules against.
+	now time.Time
+}
+
+func newFixture(t *testing.T) *fixture {
+	f := &fixture{}
+	f.t = t
+	f.objects = []runtime.Object{}
+	f.op = operator.NewBuilder().ProjectId("test").BuildFake()
+	f.operators = operator.NewPool("test", func(projectId string, key []byte) (operator.Operator, error) {
+		if key != nil {
+			f.keys = append(f.keys, string(key))
+		}
+		if projectId == "test" {
+			return f.op, nil
+		}
+		return operator.NewBuilder().ProjectId(projectId).BuildFake(), nil
+	})
+	return f
+}
+
+func newSpannerBackupSchedule(name string, database string) *spannercontroller.SpannerBackupSchedule {
+	return &spannercontroller.SpannerBackupSchedule{
+		TypeMeta: metav1.TypeMeta{APIVersion: spannercontroller.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         metav1.NamespaceDefault,
+			CreationTimestamp: metav1.NewTime(creationTime),
+		},
+		Spec: spannercontroller.SpannerBackupScheduleSpec{
+			Schedule:   "0 3 * * *",
+			InstanceId: "test",
+			Database:   database,
+		},
+	}
+}
+
+func (f *fixture) newController() (*Controller, informers.SharedInformerFactory) {
+	f.client = fake.NewSimpleClientset(f.objects...)
+	f.kubeclient = k8sfake.NewSimpleClientset()
+
+	i := informers.NewSharedInformerFactory(f.client, noResyncPeriodFunc())
+	k8sI := kubeinformers.NewSharedInformerFactory(f.kubeclient, noResyncPeriodFunc())
+	di := databaseinformers.NewSharedInformerFactory(databasefake.NewSimpleClientset(), noResyncPeriodFunc())
+
+	c := NewController(f.kubeclient, f.client, i.Backupadmins().V1alpha1().SpannerBackupSchedules(),
+		di.Databaseadmins().V1alpha1().SpannerDatabases(), k8sI.Core().V1().Secrets(), f.operators)
+
+	c.spannerBackupSchedulesSynced = alwaysReady
+	c.spannerDatabasesSynced = alwaysReady
+	c.secretsSynced = alwaysReady
+	c.recorder = &record.FakeRecorder{}
+	c.now = func() time.Time { return f.now }
+
+	for _, b := range f.spannerBackupScheduleLister {
+		i.Backupadmins().V1alpha1().SpannerBackupSchedules().Informer().GetIndexer().Add(b)
+	}
+
+	for _, d := range f.spannerDatabaseLister {
+		di.Databaseadmins().V1alpha1().SpannerDatabases().Informer().GetIndexer().Add(d)
+	}
+
+	for _, s := range f.secretLister {
+		k8sI.Core().V1().Secrets().Informer().GetIndexer().Add(s)
+	}
+
+	return c, i
+}
+
+func (f *fixture) run(spannerBackupScheduleName string) {
+	f.runController(spannerBackupScheduleName, true, false)
+}
+
+func (f *fixture) runExpectError(spannerBackupScheduleName string) {
+	f.runController(spannerBackupScheduleName, true, true)
+}
+
+func (f *fixture) runController(spannerBackupScheduleName string, startInformers bool, expectError bool) {
+	c, i := f.newController()
+	if startInformers {
+		stopCh := make(chan struct{})
+		defer close(stopCh)
+		i.Start(stopCh)
+	}
+
+	err := c.syncHandler(context.Background(), spannerBackupScheduleName)
+	if !expectError && err != nil {
+		f.t.Errorf("error syncing SpannerBackupSchedule: %v", err)
+	} else if expectError && err == nil {
+		f.t.Error("expected error syncing SpannerBackupSchedule, got nil")
+	}
+
+	actions := filterInformerActions(f.client.Actions())
+	for i, action := range actions {
+		if len(f.actions) < i+1 {
+			f.t.Errorf("%d unexpected actions: %+v", len(actions)-len(f.actions), actions[i:])
+			break
+		}
+
+		expectedAction := f.actions[i]
+		checkAction(expectedAction, action, f.t)
+	}
+
+	if len(f.actions) > len(actions) {
+		f.t.Errorf("%d additional expected actions:%+v", len(f.actions)-len(actions), f.actions[len(actions):])
+	}
+}
+
+// checkAction verifies that expected and actual actions are equal and both have
+// same attached resources
+func checkAction(expected, actual core.Action, t *testing.T) {
+	if !(expected.Matches(actual.GetVerb(), actual.GetResource().Resource) && actual.GetSubresource() == expected.GetSubresource()) {
+		t.Errorf("Expected\n\t%#v\ngot\n\t%#v", expected, actual)
+		return
+	}
+
+	if reflect.TypeOf(actual) != reflect.TypeOf(expected) {
+		t.Errorf("Action has wrong type. Expected: %t. Got: %t", expected, actual)
+		return
+	}
+
+	switch a := actual.(type) {
+	case core.CreateAction:
+		e, _ := expected.(core.CreateAction)
+		expObject := e.GetObject()
+		object := a.GetObject()
+
+		if !reflect.DeepEqual(expObject, object) {
+			t.Errorf("Action %s %s has wrong object\nDiff:\n %s",
+				a.GetVerb(), a.GetResource().Resource, diff.ObjectGoPrintDiff(expObject, object))
+		}
+	case core.UpdateAction:
+		e, _ := expected.(core.UpdateAction)
+		expObject := e.GetObject()
+		object := a.GetObject()
+
+		if !reflect.DeepEqual(expObject, object) {
+			t.Errorf("Action %s %s has wrong object\nDiff:\n %s",
+				a.GetVerb(), a.GetResource().Resource, diff.ObjectGoPrintDiff(expObject, object))
+		}
+	case core.PatchAction:
+		e, _ := expected.(core.PatchAction)
+		expPatch := e.GetPatch()
+		patch := a.GetPatch()
+
+		if !reflect.DeepEqual(expPatch, patch) {
+			t.Errorf("Action %s %s has wrong patch\nDiff:\n %s",
+				a.GetVerb(), a.GetResource().Resource, diff.ObjectGoPrintDiff(expPatch, patch))
+		}
+	}
+}
+
+// filterInformerActions filters list and watch actions for testing resources.
+// Since list and watch don't change resource state we can filter it to lower
+// nose level in our tests.
+func filterInformerActions(actions []core.Action) []core.Action {
+	ret := []core.Action{}
+	for _, action := range actions {
+		if len(action.GetNamespace()) == 0 &&
+			(action.Matches("list", "spannerbackupschedules") ||
+				action.Matches("watch", "spannerbackupschedules")) {
+			continue
+		}
+		ret = append(ret, action)
+	}
+
+	return ret
+}
+
+func (f *fixture) expectUpdateSpannerBackupScheduleStatusAction(spannerBackupSchedule *spannercontroller.SpannerBackupSchedule) {
+	action := core.NewUpdateAction(schema.GroupVersionResource{Resource: "spannerbackupschedules"}, spannerBackupSchedule.Namespace, spannerBackupSchedule)
+	action.Subresource = "status"
+	f.actions = append(f.actions, action)
+}
+
+// createDatabase makes the operator hold the database name in the "test" instance.
+func (f *fixture) createDatabase(name string) {
+	ctx := context.Background()
+	if _, err := f.op.CreateInstance(ctx, "test", "test", "", operator.Nodes(1)); err != nil {
+		f.t.Fatal(err)
+	}
+	if _, err := f.op.CreateDatabase(ctx, "test", name, nil); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// createBackup makes the operator hold the backup backupId of the database name.
+func (f *fixture) createBackup(backupId string, name string) {
+	if _, err := f.op.CreateBackup(context.Background(), "test", backupId, name, f.now.Add(24*time.Hour), time.Time{}); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// backupIds returns the IDs of the backups of the database name.
+func (f *fixture) backupIds(name string) []string {
+	backups, err := f.op.ListBackups(context.Background(), "test", name)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	var ids []string
+	for _, backup := range backups {
+		ids = append(ids, backupId(backup))
+	}
+	return ids
+}
+
+func getKey(spannerBackupSchedule *spannercontroller.SpannerBackupSchedule, t *testing.T) string {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(spannerBackupSchedule)
+	if err != nil {
+		t.Errorf("Unexpected error getting key for SpannerBackupSchedule %v: %v", spannerBackupSchedule.Name, err)
+		return ""
+	}
+	return key
+}
+
+func TestCreatesScheduledBackup(t *testing.T) {
+	f := newFixture(t)
+	f.now = creationTime.Add(27 * time.Hour)
+	f.createDatabase("testdb")
+	spannerBackupSchedule := newSpannerBackupSchedule("test", "testdb")
+
+	f.spannerBackupScheduleLister = append(f.spannerBackupScheduleLister, spannerBackupSchedule)
+	f.objects = append(f.objects, spannerBackupSchedule)
+
+	// Only the most recent of the two missed runs is taken.
+	expSchedule := spannerBackupSchedule.DeepCopy()
+	expSchedule.Status.PendingOperation = "projects/test/instances/test/backups/test-933b5bde-20190102-030000/operations/mock_create_backup"
+	expSchedule.Status.LastScheduleTime = &metav1.Time{Time: time.Date(2019, 1, 2, 3, 0, 0, 0, time.UTC)}
+	expSchedule.Status.LastBackup = "test-933b5bde-20190102-030000"
+	f.expectUpdateSpannerBackupScheduleStatusAction(expSchedule)
+
+	f.run(getKey(spannerBackupSchedule, t))
+	if ids := f.backupIds("testdb"); !reflect.DeepEqual(ids, []string{"test-933b5bde-20190102-030000"}) {
+		t.Errorf("expected one scheduled backup, got %v", ids)
+	}
+}
+
+func TestDoesNotRunTwice(t *testing.T) {
+	f := newFixture(t)
+	f.now = creationTime.Add(3*time.Hour + 30*time.Minute)
+	f.createDatabase("testdb")
+	f.createBackup("test-933b5bde-20190101-030000", "testdb")
+	spannerBackupSchedule := newSpannerBackupSchedule("test", "testdb")
+	spannerBackupSchedule.Status.LastScheduleTime = &metav1.Time{Time: creationTime.Add(3 * time.Hour)}
+	spannerBackupSchedule.Status.LastBackup = "test-933b5bde-20190101-030000"
+
+	f.spannerBackupScheduleLister = append(f.spannerBackupScheduleLister, spannerBackupSchedule)
+	f.objects = append(f.objects, spannerBackupSchedule)
+
+	f.run(getKey(spannerBackupSchedule, t))
+	if ids := f.backupIds("testdb"); len(ids) != 1 {
+		t.Errorf("expected no new backup, got %v", ids)
+	}
+}
+
+// An operation Spanner no longer keeps is cleared, and the schedule synced.
+func TestClearsMissingOperation(t *testing.T) {
+	f := newFixture(t)
+	f.now = creationTime.Add(3*time.Hour + 30*time.Minute)
+	f.createDatabase("testdb")
+	f.createBackup("test-933b5bde-20190101-030000", "testdb")
+	spannerBackupSchedule := newSpannerBackupSchedule("test", "testdb")
+	spannerBackupSchedule.Status.LastScheduleTime = &metav1.Time{Time: creationTime.Add(3 * time.Hour)}
+	spannerBackupSchedule.Status.LastBackup = "test-933b5bde-20190101-030000"
+	spannerBackupSchedule.Status.PendingOperation = "projects/test/instances/test/backups/test-933b5bde-20190101-030000/operations/expired"
+	f.op.FailNext("GetOperation", status.Error(codes.NotFound, "operation not found"))
+
+	f.spannerBackupScheduleLister = append(f.spannerBackupScheduleLister, spannerBackupSchedule)
+	f.objects = append(f.objects, spannerBackupSchedule)
+
+	expSchedule := spannerBackupSchedule.DeepCopy()
+	expSchedule.Status.PendingOperation = ""
+	f.expectUpdateSpannerBackupScheduleStatusAction(expSchedule)
+
+	f.run(getKey(spannerBackupSchedule, t))
+	if ids := f.backupIds("testdb"); len(ids) != 1 {
+		t.Errorf("expected no new backup, got %v", ids)
+	}
+}
+
+func TestRecordsExistingBackupOfRun(t *testing.T) {
+	f := newFixture(t)
+	f.now = creationTime.Add(3*time.Hour + 30*time.Minute)
+	f.createDatabase("testdb")
+	// The backup was started before a restart lost the status update.
+	f.createBackup("test-933b5bde-20190101-030000", "testdb")
+	spannerBackupSchedule := newSpannerBackupSchedule("test", "testdb")
+
+	f.spannerBackupScheduleLister = append(f.spannerBackupScheduleLister, spannerBackupSchedule)
+	f.objects = append(f.objects, spannerBackupSchedule)
+
+	expSchedule := spannerBackupSchedule.DeepCopy()
+	expSchedule.Status.LastScheduleTime = &metav1.Time{Time: creationTime.Add(3 * time.Hour)}
+	expSchedule.Status.LastBackup = "test-933b5bde-20190101-030000"
+	f.expectUpdateSpannerBackupScheduleStatusAction(expSchedule)
+
+	f.run(getKey(spannerBackupSchedule, t))
+	if ids := f.backupIds("testdb"); len(ids) != 1 {
+		t.Errorf("expected no new backup, got %v", ids)
+	}
+}
+
+func TestDeletesBackupsBeyondRetentionCount(t *testing.T) {
+	f := newFixture(t)
+	f.now = creationTime.Add(3*24*time.Hour + 30*time.Minute)
+	f.createDatabase("testdb")
+	for day := 1; day <= 3; day++ {
+		f.createBackup(fmt.Sprintf("test-933b5bde-201901%02d-030000", day), "testdb")
+	}
+	// Backups not taken by the schedule are kept.
+	f.createBackup("manual", "testdb")
+	spannerBackupSchedule := newSpannerBackupSchedule("test", "testdb")
+	spannerBackupSchedule.Spec.Retention.Count = 2
+	spannerBackupSchedule.Status.LastScheduleTime = &metav1.Time{Time: time.Date(2019, 1, 3, 3, 0, 0, 0, time.UTC)}
+	spannerBackupSchedule.Status.LastBackup = "test-933b5bde-20190103-030000"
+
+	f.spannerBackupScheduleLister = append(f.spannerBackupScheduleLister, spannerBackupSchedule)
+	f.objects = append(f.objects, spannerBackupSchedule)
+
+	f.run(getKey(spannerBackupSchedule, t))
+	exp := []string{"manual", "test-933b5bde-20190102-030000", "test-933b5bde-20190103-030000"}
+	if ids := f.backupIds("testdb"); !reflect.DeepEqual(ids, exp) {
+		t.Errorf("expected backups %v, got %v", exp, ids)
+	}
+}
+
+// A same-named schedule of another namespace backing up the same database
+// neither takes nor collects the backups of this one.
+func TestKeepsBackupsOfScheduleInOtherNamespace(t *testing.T) {
+	f := newFixture(t)
+	f.now = creationTime.Add(3*24*time.Hour + 30*time.Minute)
+	f.createDatabase("testdb")
+	for day := 1; day <= 3; day++ {
+		f.createBackup(fmt.Sprintf("test-933b5bde-201901%02d-030000", day), "testdb")
+	}
+	spannerBackupSchedule := newSpannerBackupSchedule("test", "testdb")
+	spannerBackupSchedule.Namespace = "staging"
+	spannerBackupSchedule.Spec.Retention.Count = 1
+	spannerBackupSchedule.Status.LastScheduleTime = &metav1.Time{Time: time.Date(2019, 1, 3, 3, 0, 0, 0, time.UTC)}
+	spannerBackupSchedule.Status.LastBackup = "test-9ad76926-20190103-030000"
+
+	f.spannerBackupScheduleLister = append(f.spannerBackupScheduleLister, spannerBackupSchedule)
+	f.objects = append(f.objects, spannerBackupSchedule)
+
+	f.run(getKey(spannerBackupSchedule, t))
+	exp := []string{"test-933b5bde-20190101-030000", "test-933b5bde-20190102-030000", "test-933b5bde-20190103-030000"}
+	if ids := f.backupIds("testdb"); !reflect.DeepEqual(ids, exp) {
+		t.Errorf("expected backups %v, got %v", exp, ids)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		valid bool
+	}{
+		{"daily", true},
+		{"a" + strings.Repeat("b", 34), true},
+		{"a" + strings.Repeat("b", 35), false},
+		{"orders.daily", false},
+		{"Daily", false},
+	} {
+		err := validateName(tc.name)
+		if tc.valid && err != nil {
+			t.Errorf("expected name %q to be valid, got %v", tc.name, err)
+		} else if !tc.valid && err == nil {
+			t.Errorf("expected name %q to be invalid", tc.name)
+		}
+	}
+}
+
+func TestIgnoresInvalidSchedule(t *testing.T) {
+	f := newFixture(t)
+	f.now = creationTime.Add(27 * time.Hour)
+	f.createDatabase("testdb")
+	spannerBackupSchedule := newSpannerBackupSchedule("test", "testdb")
+	spannerBackupSchedule.Spec.Schedule = "every day"
+
+	f.spannerBackupScheduleLister = append(f.spannerBackupScheduleLister, spannerBackupSchedule)
+	f.objects = append(f.objects, spannerBackupSchedule)
+
+	f.run(getKey(spannerBackupSchedule, t))
+	if ids := f.backupIds("testdb"); len(ids) != 0 {
+		t.Errorf("expected no backup, got %v", ids)
+	}
+}
+
+// newSpannerDatabase returns a SpannerDatabase that claimed the database
+// databaseId of instanceId in projectId, with credentials of its own.
+func newSpannerDatabase(name, projectId, instanceId, databaseId string) *databasev1alpha1.SpannerDatabase {
+	return &databasev1alpha1.SpannerDatabase{
+		TypeMeta: metav1.TypeMeta{APIVersion: databasev1alpha1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: databasev1alpha1.SpannerDatabaseSpec{
+			CredentialsSecretRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "spanner-key"},
+				Key:                  "key.json",
+			},
+		},
+		Status: databasev1alpha1.SpannerDatabaseStatus{
+			DatabaseName: operator.DatabaseName(projectId, instanceId, databaseId),
+		},
+	}
+}
+
+func TestBacksUpReferencedDatabase(t *testing.T) {
+	f := newFixture(t)
+	f.now = creationTime.Add(3*time.Hour + 30*time.Minute)
+	ctx := context.Background()
+	if _, err := f.op.CreateInstance(ctx, "main", "main", "", operator.Nodes(1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.op.CreateDatabase(ctx, "main", "orders_v2", nil); err != nil {
+		t.Fatal(err)
+	}
+	spannerBackupSchedule := newSpannerBackupSchedule("test", "")
+	spannerBackupSchedule.Spec.InstanceId = ""
+	spannerBackupSchedule.Spec.DatabaseRef = &corev1.LocalObjectReference{Name: "orders"}
+
+	f.spannerBackupScheduleLister = append(f.spannerBackupScheduleLister, spannerBackupSchedule)
+	f.objects = append(f.objects, spannerBackupSchedule)
+	f.spannerDatabaseLister = append(f.spannerDatabaseLister, newSpannerDatabase("orders", "test", "main", "orders_v2"))
+	f.secretLister = append(f.secretLister, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "spanner-key", Namespace: metav1.NamespaceDefault, ResourceVersion: "1"},
+		Data:       map[string][]byte{"key.json": []byte("key-1")},
+	})
+
+	expSchedule := spannerBackupSchedule.DeepCopy()
+	expSchedule.Status.PendingOperation = "projects/test/instances/main/backups/test-933b5bde-20190101-030000/operations/mock_create_backup"
+	expSchedule.Status.LastScheduleTime = &metav1.Time{Time: creationTime.Add(3 * time.Hour)}
+	expSchedule.Status.LastBackup = "test-933b5bde-20190101-030000"
+	f.expectUpdateSpannerBackupScheduleStatusAction(expSchedule)
+
+	f.run(getKey(spannerBackupSchedule, t))
+
+	if !reflect.DeepEqual(f.keys, []string{"key-1"}) {
+		t.Errorf("expected an operator built with the key of the SpannerDatabase, got %v", f.keys)
+	}
+}
+
+func TestWaitsForReferencedDatabase(t *testing.T) {
+	f := newFixture(t)
+	f.now = creationTime.Add(27 * time.Hour)
+	f.createDatabase("testdb")
+	spannerBackupSchedule := newSpannerBackupSchedule("test", "testdb")
+	spannerBackupSchedule.Spec.DatabaseRef = &corev1.LocalObjectReference{Name: "orders"}
+	spannerDatabase := newSpannerDatabase("orders", "test", "test", "testdb")
+	spannerDatabase.Status.DatabaseName = ""
+
+	f.spannerBackupScheduleLister = append(f.spannerBackupScheduleLister, spannerBackupSchedule)
+	f.objects = append(f.objects, spannerBackupSchedule)
+	f.spannerDatabaseLister = append(f.spannerDatabaseLister, spannerDatabase)
+
+	f.run(getKey(spannerBackupSchedule, t))
+	if ids := f.backupIds("testdb"); len(ids) != 0 {
+		t.Errorf("expected no backup before the database is claimed, got %v", ids)
+	}
+}
+
+func TestIgnoresNameNotMakingBackupIds(t *testing.T) {
+	for _, name := range []string{"orders.daily", "orders-" + strings.Repeat("a", 29)} {
+		f := newFixture(t)
+		f.now = creationTime.Add(27 * time.Hour)
+		f.createDatabase("testdb")
+		spannerBackupSchedule := newSpannerBackupSchedule(name, "testdb")
+
+		f.spannerBackupScheduleLister = append(f.spannerBackupScheduleLister, spannerBackupSchedule)
+		f.objects = append(f.objects, spannerBackupSchedule)
+
+		f.run(getKey(spannerBackupSchedule, t))
+		if ids := f.backupIds("testdb"); len(ids) != 0 {
+			t.Errorf("expected no backup of %s, got %v", name, ids)
+		}
+	}
+}
+
+func TestMostRecentRun(t *testing.T) {
+	now := time.Date(2019, 3, 1, 12, 30, 30, 0, time.UTC)
+	for _, tc := range []struct {
+		schedule string
+		earliest time.Time
+		last     time.Time
+	}{
+		{"0 3 * * *", creationTime, time.Date(2019, 3, 1, 3, 0, 0, 0, time.UTC)},
+		// Every minute for two months only looks at the last one.
+		{"* * * * *", creationTime, time.Date(2019, 3, 1, 12, 30, 0, 0, time.UTC)},
+		{"0 0 1 1 *", creationTime, time.Time{}},
+		{"0 0 1 1 *", time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC), creationTime},
+		{"0 3 * * *", time.Date(2019, 3, 1, 3, 0, 0, 0, time.UTC), time.Time{}},
+		// February 30th never comes.
+		{"0 0 30 2 *", creationTime, time.Time{}},
+	} {
+		schedule, err := cron.ParseStandard(tc.schedule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		last, ok := mostRecentRun(schedule, tc.earliest, now)
+		if ok != !tc.last.IsZero() || !last.Equal(tc.last) {
+			t.Errorf("expected the most recent run of %q after %s to be %s, got %s", tc.schedule, tc.earliest, tc.last, last)
+		}
+	}
+}
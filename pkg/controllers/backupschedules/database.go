@@ -0,0 +1,103 @@
+package backupschedules
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	backupv1alpha1 "github.com/katsew/spanner-operator/pkg/apis/backupadmins/v1alpha1"
+
+	"github.com/katsew/spanner-operator/pkg/credentials"
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+// backupTarget is the database a SpannerBackupSchedule backs up, and the
+// credentials to back it up with.
+type backupTarget struct {
+	projectId  string
+	instanceId string
+	databaseId string
+	// namespace holds the Secret credentialsSecretRef selects.
+	namespace            string
+	credentialsSecretRef *corev1.SecretKeySelector
+}
+
+// specTarget returns the database the spec of spannerBackupSchedule names
+// without a databaseRef.
+func specTarget(spannerBackupSchedule *backupv1alpha1.SpannerBackupSchedule) *backupTarget {
+	spec := spannerBackupSchedule.Spec
+	return &backupTarget{
+		projectId:            spec.ProjectId,
+		instanceId:           spec.InstanceId,
+		databaseId:           spec.Database,
+		namespace:            spannerBackupSchedule.Namespace,
+		credentialsSecretRef: spec.CredentialsSecretRef,
+	}
+}
+
+// targetOf returns the database spannerBackupSchedule backs up. The database
+// of a referenced SpannerDatabase is the one recorded in its status, which
+// follows its instanceRef, databaseId and projectId. nil is returned until
+// the SpannerDatabase exists and has claimed its database.
+func (c *Controller) targetOf(spannerBackupSchedule *backupv1alpha1.SpannerBackupSchedule) (*backupTarget, error) {
+	ref := spannerBackupSchedule.Spec.DatabaseRef
+	if ref == nil {
+		return specTarget(spannerBackupSchedule), nil
+	}
+	spannerDatabase, err := c.spannerDatabaseLister.SpannerDatabases(spannerBackupSchedule.Namespace).Get(ref.Name)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	projectId, instanceId, databaseId, ok := operator.ParseDatabaseName(spannerDatabase.Status.DatabaseName)
+	if !ok {
+		return nil, nil
+	}
+	return &backupTarget{
+		projectId:            projectId,
+		instanceId:           instanceId,
+		databaseId:           databaseId,
+		namespace:            spannerDatabase.Namespace,
+		credentialsSecretRef: spannerDatabase.Spec.CredentialsSecretRef,
+	}, nil
+}
+
+// operatorFor returns the Operator of projectId, built with the credentials
+// of target.
+func (c *Controller) operatorFor(target *backupTarget, projectId string) (operator.Operator, error) {
+	return credentials.Operator(c.operators, c.secretLister, target.namespace, projectId, target.credentialsSecretRef)
+}
+
+// handleSpannerDatabase enqueues the SpannerBackupSchedules referencing the
+// SpannerDatabase obj, so they pick up the database once it is claimed.
+func (c *Controller) handleSpannerDatabase(obj interface{}) {
+	object, ok := obj.(metav1.Object)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("error decoding object, invalid type"))
+			return
+		}
+		object, ok = tombstone.Obj.(metav1.Object)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("error decoding object tombstone, invalid type"))
+			return
+		}
+	}
+	spannerBackupSchedules, err := c.spannerBackupScheduleLister.SpannerBackupSchedules(object.GetNamespace()).List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, spannerBackupSchedule := range spannerBackupSchedules {
+		if ref := spannerBackupSchedule.Spec.DatabaseRef; ref != nil && ref.Name == object.GetName() {
+			c.enqueueSpannerBackupSchedule(spannerBackupSchedule)
+		}
+	}
+}
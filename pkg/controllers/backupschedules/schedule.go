@@ -0,0 +1,133 @@
+package backupschedules
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+
+	backupv1alpha1 "github.com/katsew/spanner-operator/pkg/apis/backupadmins/v1alpha1"
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+// backupTimeLayout formats the scheduled time of a run into its backup ID.
+const backupTimeLayout = "20060102-150405"
+
+// maxBackupExpiry is how long a scheduled backup is kept at most. Spanner
+// refuses expire times more than 366 days ahead.
+const maxBackupExpiry = 365 * 24 * time.Hour
+
+// mostRecentRun returns the latest time schedule fires after earliest and not
+// after now. Runs missed in between are skipped.
+//
+// The runs are searched from the shortest window, doubled from a minute, that
+// ends at now and holds one, so a frequent schedule that missed runs over a
+// long outage is not stepped through run by run.
+func mostRecentRun(schedule cron.Schedule, earliest time.Time, now time.Time) (time.Time, bool) {
+	start := earliest
+	for window := time.Minute; window > 0 && now.Add(-window).After(earliest); window *= 2 {
+		if next := schedule.Next(now.Add(-window)); !next.IsZero() && !next.After(now) {
+			start = now.Add(-window)
+			break
+		}
+	}
+	var last time.Time
+	// A schedule that never fires returns the zero time.
+	for t := schedule.Next(start); !t.IsZero() && !t.After(now); t = schedule.Next(t) {
+		last = t
+	}
+	return last, !last.IsZero()
+}
+
+// scheduledBackupPrefix returns the start of the IDs of the backups taken by
+// the schedule name of namespace: the name and a hash of the namespace, so
+// same-named schedules of other namespaces backing up the same instance
+// neither take nor collect each other's backups.
+func scheduledBackupPrefix(namespace, name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(namespace))
+	return fmt.Sprintf("%s-%08x", name, h.Sum32())
+}
+
+// scheduledBackupId returns the ID of the backup taken by the run of the
+// schedule name of namespace at scheduled.
+func scheduledBackupId(namespace, name string, scheduled time.Time) string {
+	return fmt.Sprintf("%s-%s", scheduledBackupPrefix(namespace, name), scheduled.UTC().Format(backupTimeLayout))
+}
+
+// validateName checks the backup IDs derived from the schedule name are ones
+// Spanner accepts. A Kubernetes name may hold dots and be longer than a backup
+// ID, so only names of at most 35 characters without dots are.
+func validateName(name string) error {
+	if err := operator.ValidateBackupId(scheduledBackupId("", name, time.Time{})); err != nil {
+		return fmt.Errorf("name must make a valid backup ID with the suffix -XXXXXXXX-YYYYMMDD-HHMMSS: %v", err)
+	}
+	return nil
+}
+
+// scheduledBackupPattern matches the IDs of the backups taken by the schedule
+// name of namespace.
+func scheduledBackupPattern(namespace, name string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`^%s-\d{8}-\d{6}$`, regexp.QuoteMeta(scheduledBackupPrefix(namespace, name))))
+}
+
+// backupExpiry returns how long after it is started a scheduled backup expires.
+func backupExpiry(retention backupv1alpha1.SpannerBackupRetentionPolicy) time.Duration {
+	if retention.MaxAge != nil && retention.MaxAge.Duration < maxBackupExpiry {
+		return retention.MaxAge.Duration
+	}
+	return maxBackupExpiry
+}
+
+// collectBackups deletes the ready backups of target taken by
+// spannerBackupSchedule, through op, that fall out of its retention policy.
+func collectBackups(ctx context.Context, op operator.Operator, target *backupTarget, spannerBackupSchedule *backupv1alpha1.SpannerBackupSchedule, now time.Time) error {
+	spec := spannerBackupSchedule.Spec
+	if spec.Retention.Count <= 0 && spec.Retention.MaxAge == nil {
+		return nil
+	}
+	backups, err := op.ListBackups(ctx, target.instanceId, target.databaseId)
+	if err != nil {
+		return err
+	}
+	pattern := scheduledBackupPattern(spannerBackupSchedule.Namespace, spannerBackupSchedule.Name)
+	var scheduled []*database.Backup
+	for _, backup := range backups {
+		if pattern.MatchString(backupId(backup)) {
+			scheduled = append(scheduled, backup)
+		}
+	}
+	// Backup IDs end with the scheduled time, so they sort newest first.
+	sort.Slice(scheduled, func(i, j int) bool {
+		return backupId(scheduled[i]) > backupId(scheduled[j])
+	})
+	for i, backup := range scheduled {
+		if backup.GetState() != database.Backup_READY {
+			continue
+		}
+		tooMany := spec.Retention.Count > 0 && i >= int(spec.Retention.Count)
+		tooOld := spec.Retention.MaxAge != nil && backup.GetCreateTime().AsTime().Add(spec.Retention.MaxAge.Duration).Before(now)
+		if !tooMany && !tooOld {
+			continue
+		}
+		log.Printf("Backup %s falls out of the retention of SpannerBackupSchedule %s, delete it", backup.GetName(), spannerBackupSchedule.Name)
+		err := op.DeleteBackup(ctx, target.instanceId, backupId(backup))
+		if err != nil && !op.IsNotFoundError(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// backupId returns the last segment of the fully qualified backup name.
+func backupId(backup *database.Backup) string {
+	name := backup.GetName()
+	return name[strings.LastIndex(name, "/")+1:]
+}
@@ -0,0 +1,108 @@
+package databaseadmins
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+
+	databasev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+)
+
+// Reasons of SpannerDatabase conditions. Errors are reported with the reason
+// of the Event emitted along.
+const (
+	// ReasonCreating is the condition reason while the database is created.
+	ReasonCreating = "Creating"
+	// ReasonRestoring is the condition reason while the database is restored.
+	ReasonRestoring = "Restoring"
+	// ReasonBackingUp is the condition reason while the database is backed up
+	// before being dropped.
+	ReasonBackingUp = "BackingUp"
+	// ReasonDropping is the condition reason while the database is dropped.
+	ReasonDropping = "Dropping"
+
+	// MessageCreating is the condition message while the database is created
+	MessageCreating = "Creating database %q"
+	// MessageRestoring is the condition message while the database is restored
+	MessageRestoring = "Restoring database %q from backup %s"
+	// MessageBackingUp is the condition message while the database is backed up
+	MessageBackingUp = "Backing up database %q to %s before dropping it"
+	// MessageDropping is the condition message while the database is dropped
+	MessageDropping = "Dropping database %q"
+)
+
+// setCondition sets the condition conditionType of status. Its last
+// transition time only moves to now when its status changes. It reports
+// whether the condition changed.
+func setCondition(status *databasev1alpha1.SpannerDatabaseStatus, conditionType databasev1alpha1.SpannerDatabaseConditionType, conditionStatus corev1.ConditionStatus, reason, message string, now time.Time) bool {
+	for i := range status.Conditions {
+		cond := &status.Conditions[i]
+		if cond.Type != conditionType {
+			continue
+		}
+		if cond.Status == conditionStatus && cond.Reason == reason && cond.Message == message {
+			return false
+		}
+		if cond.Status != conditionStatus {
+			cond.LastTransitionTime = metav1.NewTime(now)
+		}
+		cond.Status = conditionStatus
+		cond.Reason = reason
+		cond.Message = message
+		return true
+	}
+	status.Conditions = append(status.Conditions, databasev1alpha1.SpannerDatabaseCondition{
+		Type:               conditionType,
+		Status:             conditionStatus,
+		LastTransitionTime: metav1.NewTime(now),
+		Reason:             reason,
+		Message:            message,
+	})
+	return true
+}
+
+// conditionTrue reports whether the condition conditionType of status is true.
+func conditionTrue(status databasev1alpha1.SpannerDatabaseStatus, conditionType databasev1alpha1.SpannerDatabaseConditionType) bool {
+	for _, cond := range status.Conditions {
+		if cond.Type == conditionType {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// markProvisioning sets the conditions of status for the database being
+// created or restored, as told by reason and message.
+func markProvisioning(status *databasev1alpha1.SpannerDatabaseStatus, reason, message string, now time.Time) {
+	setCondition(status, databasev1alpha1.SpannerDatabaseReady, corev1.ConditionFalse, reason, message, now)
+	setCondition(status, databasev1alpha1.SpannerDatabaseProvisioning, corev1.ConditionTrue, reason, message, now)
+}
+
+// markSynced sets the conditions of status for db, which matches the spec.
+func markSynced(status *databasev1alpha1.SpannerDatabaseStatus, db *database.Database, now time.Time) {
+	if db.GetState() == database.Database_CREATING {
+		markProvisioning(status, ReasonCreating, fmt.Sprintf(MessageCreating, db.Name), now)
+	} else {
+		setCondition(status, databasev1alpha1.SpannerDatabaseReady, corev1.ConditionTrue, SuccessSynced, MessageResourceSynced, now)
+		setCondition(status, databasev1alpha1.SpannerDatabaseProvisioning, corev1.ConditionFalse, SuccessSynced, MessageResourceSynced, now)
+	}
+	setCondition(status, databasev1alpha1.SpannerDatabaseDegraded, corev1.ConditionFalse, SuccessSynced, MessageResourceSynced, now)
+	setCondition(status, databasev1alpha1.SpannerDatabaseError, corev1.ConditionFalse, SuccessSynced, MessageResourceSynced, now)
+}
+
+// markOperationFailed sets the conditions of status for its pending
+// operation having failed with message. A failed creation or restore is an
+// error, a failed schema change leaves the database serving, degraded.
+func markOperationFailed(status *databasev1alpha1.SpannerDatabaseStatus, message string, now time.Time) {
+	if conditionTrue(*status, databasev1alpha1.SpannerDatabaseProvisioning) {
+		setCondition(status, databasev1alpha1.SpannerDatabaseReady, corev1.ConditionFalse, ErrOperationFailed, message, now)
+		setCondition(status, databasev1alpha1.SpannerDatabaseProvisioning, corev1.ConditionFalse, ErrOperationFailed, message, now)
+		setCondition(status, databasev1alpha1.SpannerDatabaseError, corev1.ConditionTrue, ErrOperationFailed, message, now)
+		return
+	}
+	setCondition(status, databasev1alpha1.SpannerDatabaseDegraded, corev1.ConditionTrue, ErrOperationFailed, message, now)
+}
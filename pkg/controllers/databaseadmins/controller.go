@@ -17,6 +17,7 @@ limitations under the License.
 package databaseadmins
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
@@ -24,40 +25,151 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
 
+	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+
 	databasev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+	backupinformers "github.com/katsew/spanner-operator/pkg/generated/backupadmins/informers/externalversions/backupadmins/v1alpha1"
+	backuplisters "github.com/katsew/spanner-operator/pkg/generated/backupadmins/listers/backupadmins/v1alpha1"
 	clientset "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/clientset/versioned"
 	spannerscheme "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/clientset/versioned/scheme"
 	informers "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/informers/externalversions/databaseadmins/v1alpha1"
 	listers "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/listers/databaseadmins/v1alpha1"
+	instanceinformers "github.com/katsew/spanner-operator/pkg/generated/instanceadmins/informers/externalversions/instanceadmins/v1alpha1"
+	instancelisters "github.com/katsew/spanner-operator/pkg/generated/instanceadmins/listers/instanceadmins/v1alpha1"
 
+	"github.com/katsew/spanner-operator/pkg/credentials"
 	"github.com/katsew/spanner-operator/pkg/operator"
 )
 
 const controllerAgentName = "spanner-controller"
 
+// syncTimeout bounds a single syncHandler call, including every Spanner admin
+// call it makes, so a hung long-running operation cannot pin a worker forever.
+const syncTimeout = 10 * time.Minute
+
+// operationPollInterval is how long to wait before polling a pending
+// long-running operation again.
+const operationPollInterval = 10 * time.Second
+
 const (
 	// SuccessSynced is used as part of the Event 'reason' when a SpannerDatabase is synced
 	SuccessSynced = "Synced"
+	// SuccessAdopted is used as part of the Event 'reason' when a SpannerDatabase
+	// takes over a Spanner database that already existed.
+	SuccessAdopted = "Adopted"
+	// SuccessBackedUp is used as part of the Event 'reason' when the Spanner
+	// database of a deleted SpannerDatabase is backed up before being dropped.
+	SuccessBackedUp = "BackedUp"
+	// SuccessDropped is used as part of the Event 'reason' when the Spanner
+	// database of a deleted SpannerDatabase is dropped.
+	SuccessDropped = "Dropped"
+	// SuccessRetained is used as part of the Event 'reason' when the Spanner
+	// database of a deleted SpannerDatabase is kept.
+	SuccessRetained = "Retained"
 	// ErrResourceExists is used as part of the Event 'reason' when a SpannerDatabase fails
-	// to sync due to a Deployment of the same name already existing.
+	// to sync due to a Spanner database of the same name already existing.
 	ErrResourceExists = "ErrResourceExists"
 
+	// ErrOperationFailed is used as part of the Event 'reason' when a long-running
+	// operation started for a SpannerDatabase finishes with an error.
+	ErrOperationFailed = "ErrOperationFailed"
+
+	// ErrMigrationRefused is used as part of the Event 'reason' when the
+	// migrations of a SpannerDatabase conflict with the applied ones.
+	ErrMigrationRefused = "ErrMigrationRefused"
+
+	// ErrDdlRefused is used as part of the Event 'reason' when the DDL of a
+	// SpannerDatabase edits or removes an applied statement.
+	ErrDdlRefused = "ErrDdlRefused"
+
+	// ErrCredentials is used as part of the Event 'reason' when the
+	// credentials a SpannerDatabase selects cannot be read.
+	ErrCredentials = "ErrCredentials"
+
+	// ErrInvalidDeletionPolicy is used as part of the Event 'reason' when the
+	// deletionPolicy of a SpannerDatabase is not acceptable.
+	ErrInvalidDeletionPolicy = "ErrInvalidDeletionPolicy"
+	// ErrInvalidId is used as part of the Event 'reason' when the database
+	// or instance ID of a SpannerDatabase is not one Spanner accepts.
+	ErrInvalidId = "ErrInvalidId"
+	// ErrInvalidRestoreSource is used as part of the Event 'reason' when
+	// restoreFrom names no backup, or one that cannot be restored from.
+	ErrInvalidRestoreSource = "ErrInvalidRestoreSource"
+	// ErrDeletionProtected is used as part of the Event 'reason' when a
+	// deleted SpannerDatabase is kept by its deletionProtection.
+	ErrDeletionProtected = "ErrDeletionProtected"
+
+	// WaitingForBackup is used as part of the Event 'reason' when a
+	// SpannerDatabase waits for the SpannerBackup it is restored from.
+	WaitingForBackup = "WaitingForBackup"
+	// WaitingForInstance is used as part of the Event 'reason', and of the
+	// Ready condition, when a SpannerDatabase waits for its instance.
+	WaitingForInstance = "WaitingForInstance"
+
 	// MessageResourceExists is the message used for Events when a resource
-	// fails to sync due to a Deployment already existing
-	MessageResourceExists = "Resource %q already exists and is not managed by Spanner"
+	// fails to sync due to a Spanner database already existing
+	MessageResourceExists = "Resource %q already exists and is not managed by SpannerDatabase, set the %s annotation to \"true\" to adopt it"
+	// MessageResourceAdopted is the message used for an Event fired when an
+	// existing Spanner database is adopted
+	MessageResourceAdopted = "Adopted existing resource %q"
+	// MessageResourceRecorded is the message used for an Event fired when the
+	// database created by an earlier version of the controller is recorded.
+	MessageResourceRecorded = "Recorded resource %q created by an earlier version of the controller"
+	// MessageResourceBackedUp is the message used for an Event fired when the
+	// Spanner database is backed up before being dropped
+	MessageResourceBackedUp = "Backed up resource %q to %s"
+	// MessageResourceDropped is the message used for an Event fired when the
+	// Spanner database is dropped along with its SpannerDatabase
+	MessageResourceDropped = "Dropped resource %q"
+	// MessageResourceRetained is the message used for an Event fired when the
+	// Spanner database is kept after its SpannerDatabase is deleted
+	MessageResourceRetained = "Retained resource %q"
 	// MessageResourceSynced is the message used for an Event fired when a Spanner
 	// is synced successfully
 	MessageResourceSynced = "SpannerDatabase synced successfully"
+	// MessageOperationFailed is the message used for an Event fired when a
+	// long-running operation fails
+	MessageOperationFailed = "Operation %s failed: %v"
+	// MessageMigrationRefused is the message used for an Event fired when
+	// migrations are not applied because they conflict with the applied ones
+	MessageMigrationRefused = "Refusing to migrate: %v"
+	// MessageDdlRefused is the message used for an Event fired when DDL is
+	// not applied because it edits or removes an applied statement
+	MessageDdlRefused = "Refusing to apply ddl: %v"
+	// MessageWaitingForBackup is the message used for an Event fired when a
+	// SpannerDatabase waits for its backup to be ready
+	MessageWaitingForBackup = "Waiting for backup %s to be ready"
+	// MessageWaitingForInstance is the message used for an Event fired when a
+	// SpannerDatabase waits for its instance to be ready
+	MessageWaitingForInstance = "Waiting for instance %s to be ready"
+	// MessageCredentials is the message used for an Event fired when the
+	// credentials in the spec cannot be read
+	MessageCredentials = "Invalid credentialsSecretRef: %v"
+	// MessageInvalidDeletionPolicy is the message used for an Event fired when
+	// the deletion policy in the spec is rejected
+	MessageInvalidDeletionPolicy = "Invalid deletionPolicy: %v"
+	// MessageInvalidId is the message used for an Event fired when an ID in
+	// the spec, or the name the database ID defaults to, is rejected
+	MessageInvalidId = "Invalid Spanner ID: %v"
+	// MessageInvalidRestoreSource is the message used for an Event fired when
+	// restoreFrom names no backup, or one that cannot be restored from.
+	MessageInvalidRestoreSource = "Invalid restoreFrom: %v"
+	// MessageDeletionProtected is the message used for an Event fired when
+	// the deletion of a protected SpannerDatabase is refused
+	MessageDeletionProtected = "Refusing to delete %q while deletionProtection is on, set it to false first"
 )
 
 // Controller is the controller implementation for SpannerDatabase resources
@@ -69,6 +181,20 @@ type Controller struct {
 
 	spannerDatabaseLister  listers.SpannerDatabaseLister
 	spannerDatabasesSynced cache.InformerSynced
+	// spannerDatabaseIndexer finds SpannerDatabases by InstanceIndex.
+	spannerDatabaseIndexer cache.Indexer
+
+	configMapLister  corelisters.ConfigMapLister
+	configMapsSynced cache.InformerSynced
+
+	spannerBackupLister  backuplisters.SpannerBackupLister
+	spannerBackupsSynced cache.InformerSynced
+
+	spannerInstanceLister  instancelisters.SpannerInstanceLister
+	spannerInstancesSynced cache.InformerSynced
+
+	secretLister  corelisters.SecretLister
+	secretsSynced cache.InformerSynced
 
 	// workqueue is a rate limited work queue. This is used to queue work to be
 	// processed instead of performing it as soon as a change happens. This
@@ -80,7 +206,13 @@ type Controller struct {
 	// Kubernetes API.
 	recorder record.EventRecorder
 
-	operator operator.Operator
+	// operators hands out the Operator of the project of a SpannerDatabase.
+	operators *operator.Pool
+
+	// syncTimeout is the deadline applied to each syncHandler call.
+	syncTimeout time.Duration
+	// now returns the current time, which conditions are stamped with.
+	now func() time.Time
 }
 
 // NewController returns a new spanner controller
@@ -88,7 +220,11 @@ func NewController(
 	kubeclientset kubernetes.Interface,
 	spannerclientset clientset.Interface,
 	spannerDatabaseInformer informers.SpannerDatabaseInformer,
-	op operator.Operator) *Controller {
+	configMapInformer coreinformers.ConfigMapInformer,
+	spannerBackupInformer backupinformers.SpannerBackupInformer,
+	spannerInstanceInformer instanceinformers.SpannerInstanceInformer,
+	secretInformer coreinformers.SecretInformer,
+	operators *operator.Pool) *Controller {
 
 	// Create event broadcaster
 	// Add spanner-controller types to the default Kubernetes Scheme so Events can be
@@ -105,11 +241,24 @@ func NewController(
 		spannerclientset:       spannerclientset,
 		spannerDatabaseLister:  spannerDatabaseInformer.Lister(),
 		spannerDatabasesSynced: spannerDatabaseInformer.Informer().HasSynced,
+		spannerDatabaseIndexer: spannerDatabaseInformer.Informer().GetIndexer(),
+		configMapLister:        configMapInformer.Lister(),
+		configMapsSynced:       configMapInformer.Informer().HasSynced,
+		spannerBackupLister:    spannerBackupInformer.Lister(),
+		spannerBackupsSynced:   spannerBackupInformer.Informer().HasSynced,
+		spannerInstanceLister:  spannerInstanceInformer.Lister(),
+		spannerInstancesSynced: spannerInstanceInformer.Informer().HasSynced,
+		secretLister:           secretInformer.Lister(),
+		secretsSynced:          secretInformer.Informer().HasSynced,
 		workqueue:              workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Spanners"),
 		recorder:               recorder,
-		operator:               op,
+		operators:              operators,
+		syncTimeout:            syncTimeout,
+		now:                    time.Now,
 	}
 
+	utilruntime.Must(AddInstanceIndexer(spannerDatabaseInformer.Informer(), operators))
+
 	klog.Info("Setting up event handlers")
 	// Set up an event handler for when SpannerDatabase resources change
 	spannerDatabaseInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -119,6 +268,31 @@ func NewController(
 		},
 		DeleteFunc: controller.enqueueSpannerDatabase,
 	})
+	// Set up an event handler for when ConfigMaps holding DDL change, so the
+	// SpannerDatabases referencing them pick up new statements.
+	configMapInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.handleConfigMap,
+		UpdateFunc: func(old, new interface{}) {
+			controller.handleConfigMap(new)
+		},
+		DeleteFunc: controller.handleConfigMap,
+	})
+	// Set up an event handler for when SpannerBackups change, so the
+	// SpannerDatabases waiting to be restored from them are retried.
+	spannerBackupInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.handleSpannerBackup,
+		UpdateFunc: func(old, new interface{}) {
+			controller.handleSpannerBackup(new)
+		},
+		DeleteFunc: controller.handleSpannerBackup,
+	})
+	// Set up an event handler for when SpannerInstances change, so the
+	// SpannerDatabases waiting for them are retried once they are ready.
+	spannerInstanceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    controller.handleSpannerInstance,
+		UpdateFunc: controller.updateSpannerInstance,
+		DeleteFunc: controller.handleSpannerInstance,
+	})
 
 	return controller
 }
@@ -136,14 +310,26 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 
 	// Wait for the caches to be synced before starting workers
 	klog.Info("Waiting for informer caches to sync")
-	if ok := cache.WaitForCacheSync(stopCh, c.spannerDatabasesSynced); !ok {
+	if ok := cache.WaitForCacheSync(stopCh, c.spannerDatabasesSynced, c.configMapsSynced, c.spannerBackupsSynced, c.spannerInstancesSynced, c.secretsSynced); !ok {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
+	// ctx is cancelled once stopCh is closed, which aborts in-flight Spanner
+	// admin calls made by the workers.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
 	klog.Info("Starting workers")
 	// Launch two workers to process SpannerDatabase resources
 	for i := 0; i < threadiness; i++ {
-		go wait.Until(c.runWorker, time.Second, stopCh)
+		go wait.Until(func() { c.runWorker(ctx) }, time.Second, stopCh)
 	}
 
 	klog.Info("Started workers")
@@ -156,14 +342,14 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 // runWorker is a long-running function that will continually call the
 // processNextWorkItem function in order to read and process a message on the
 // workqueue.
-func (c *Controller) runWorker() {
-	for c.processNextWorkItem() {
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
 	}
 }
 
 // processNextWorkItem will read a single work item off the workqueue and
 // attempt to process it, by calling the syncHandler.
-func (c *Controller) processNextWorkItem() bool {
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 	obj, shutdown := c.workqueue.Get()
 
 	if shutdown {
@@ -196,7 +382,9 @@ func (c *Controller) processNextWorkItem() bool {
 		}
 		// Run the syncHandler, passing it the namespace/name string of the
 		// SpannerDatabase resource to be synced.
-		if err := c.syncHandler(key); err != nil {
+		syncCtx, cancel := context.WithTimeout(ctx, c.syncTimeout)
+		defer cancel()
+		if err := c.syncHandler(syncCtx, key); err != nil {
 			// Put the item back on the workqueue to handle any transient errors.
 			c.workqueue.AddRateLimited(key)
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
@@ -219,7 +407,7 @@ func (c *Controller) processNextWorkItem() bool {
 // syncHandler compares the actual state with the desired, and attempts to
 // converge the two. It then updates the Status block of the SpannerDatabase resource
 // with the current status of the resource.
-func (c *Controller) syncHandler(key string) error {
+func (c *Controller) syncHandler(ctx context.Context, key string) error {
 
 	log.Printf("Get key: %s", key)
 	// Convert the namespace/name string into a distinct namespace and name
@@ -234,7 +422,7 @@ func (c *Controller) syncHandler(key string) error {
 	log.Printf("Get spanner database %+v", spannerDatabase)
 	if err != nil {
 		// The SpannerDatabase resource may no longer exist, in which case we stop
-		// processing.
+		// processing; its finalizer already cleaned up the database.
 		if errors.IsNotFound(err) {
 			log.Printf("spannerDatabase '%s' in work queue no longer exists", key)
 			utilruntime.HandleError(fmt.Errorf("spannerDatabase '%s' in work queue no longer exists", key))
@@ -244,29 +432,193 @@ func (c *Controller) syncHandler(key string) error {
 		return err
 	}
 
+	// A previous sync started a long-running operation. Poll it instead of
+	// issuing another request; this also resumes waiting after a restart.
+	if spannerDatabase.Status.PendingOperation != "" {
+		spannerDatabase, err = c.pollPendingOperation(ctx, key, spannerDatabase)
+		if err != nil || spannerDatabase == nil {
+			return err
+		}
+	}
+
+	deleting := spannerDatabase.DeletionTimestamp != nil
+	if deleting && !HasFinalizer(spannerDatabase) {
+		return nil
+	}
+	if err := validateDeletionPolicy(spannerDatabase.Spec.DeletionPolicy); err != nil {
+		// A deleted SpannerDatabase is kept until the policy is fixed rather
+		// than guessing what to do with its database.
+		return c.rejectSpec(key, spannerDatabase, ErrInvalidDeletionPolicy, fmt.Sprintf(MessageInvalidDeletionPolicy, err))
+	}
+	if deleting && spannerDatabase.Spec.DeletionProtection {
+		// Turning the protection off requeues the key.
+		return c.blockDeletion(spannerDatabase)
+	}
+	if deleting {
+		return c.finalize(ctx, key, spannerDatabase)
+	}
+	// Add the finalizer before creating or adopting the database, so a
+	// database can never be managed without being cleaned up later.
+	if !HasFinalizer(spannerDatabase) {
+		spannerDatabaseCopy := spannerDatabase.DeepCopy()
+		spannerDatabaseCopy.Finalizers = append(spannerDatabaseCopy.Finalizers, dropDatabaseFinalizer)
+		spannerDatabase, err = c.spannerclientset.DatabaseadminsV1alpha1().SpannerDatabases(namespace).Update(spannerDatabaseCopy)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := validateIds(spannerDatabase); err != nil {
+		return c.rejectSpec(key, spannerDatabase, ErrInvalidId, fmt.Sprintf(MessageInvalidId, err))
+	}
+	if err := validateRestoreSource(spannerDatabase.Spec.RestoreFrom); err != nil {
+		return c.rejectSpec(key, spannerDatabase, ErrInvalidRestoreSource, fmt.Sprintf(MessageInvalidRestoreSource, err))
+	}
+	databaseId := spannerDatabase.DatabaseId()
+
+	spannerInstance, projectId, instanceId, ok, err := c.instanceOf(spannerDatabase)
+	if err != nil {
+		return err
+	}
+	spannerDatabase, err = c.syncOwner(spannerDatabase, spannerInstance)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return c.waitForInstance(spannerDatabase, "SpannerInstance "+instanceRefKey(spannerDatabase))
+	}
+
+	op, err := c.operatorFor(spannerDatabase, projectId)
+	if err != nil {
+		if statusErr := c.reportError(spannerDatabase, ErrCredentials, fmt.Sprintf(MessageCredentials, err)); statusErr != nil {
+			utilruntime.HandleError(statusErr)
+		}
+		return err
+	}
+
 	// First, we check the instance
-	_, err = c.operator.GetInstance(spannerDatabase.Spec.InstanceId)
-	if err != nil && c.operator.IsNotFoundError(err) {
-		log.Printf("The instance(%s) that this database(%s) is belongs to does not exists", spannerDatabase.Spec.InstanceId, spannerDatabase.Name)
-		return errors.NewBadRequest("The instance that this database is belongs to does not exists")
+	_, err = op.GetInstance(ctx, instanceId)
+	if err != nil && op.IsNotFoundError(err) {
+		log.Printf("The instance(%s) that this database(%s) is belongs to does not exists", instanceId, spannerDatabase.Name)
+		return c.waitForInstance(spannerDatabase, operator.InstanceName(c.operators.ProjectId(projectId), instanceId))
 	} else if err != nil {
 		return err
 	}
 
-	_, err = c.operator.GetDatabase(spannerDatabase.Spec.InstanceId, name)
-	if err != nil && c.operator.IsNotFoundError(err) {
-		log.Printf("SpannerDatabase does not exists on instance %s, create new one with name: %s", spannerDatabase.Spec.InstanceId, spannerDatabase.Name)
-		err := c.operator.CreateDatabase(spannerDatabase.Spec.InstanceId, spannerDatabase.Name)
+	statements, err := c.ddlStatements(spannerDatabase)
+	if err != nil {
+		return err
+	}
+
+	db, err := op.GetDatabase(ctx, instanceId, databaseId)
+	if err != nil && op.IsNotFoundError(err) {
+		// Record the database as ours before creating it, so it is not
+		// mistaken for somebody else's should the sync fail half way.
+		databaseName := operator.DatabaseName(c.operators.ProjectId(projectId), instanceId, databaseId)
+		spannerDatabase, err = c.claimDatabase(spannerDatabase, databaseName)
+		if err != nil {
+			return err
+		}
+		if spannerDatabase.Spec.RestoreFrom != nil {
+			return c.restoreDatabase(ctx, op, key, spannerDatabase, instanceId, databaseId, statements)
+		}
+		log.Printf("SpannerDatabase does not exists on instance %s, create new one with name: %s", instanceId, databaseId)
+		opName, err := op.CreateDatabase(ctx, instanceId, databaseId, statements)
 		if err != nil {
 			return err
 		}
+		spannerDatabaseCopy := spannerDatabase.DeepCopy()
+		spannerDatabaseCopy.Status.PendingDdl = statements
+		spannerDatabaseCopy.Status.AppliedDdl = nil
+		markProvisioning(&spannerDatabaseCopy.Status, ReasonCreating, fmt.Sprintf(MessageCreating, spannerDatabase.Status.DatabaseName), c.now())
+		return c.trackOperation(key, spannerDatabaseCopy, opName)
 	} else if err != nil {
 		return err
 	}
 
+	if spannerDatabase.Status.DatabaseName != db.Name {
+		message := fmt.Sprintf(MessageResourceAdopted, db.Name)
+		if c.createdByEarlierVersion(spannerDatabase, db) {
+			message = fmt.Sprintf(MessageResourceRecorded, db.Name)
+		} else if spannerDatabase.Annotations[databasev1alpha1.AdoptAnnotation] != "true" {
+			// Setting the annotation requeues the key.
+			msg := fmt.Sprintf(MessageResourceExists, db.Name, databasev1alpha1.AdoptAnnotation)
+			utilruntime.HandleError(fmt.Errorf("%s: %s", key, msg))
+			return c.reportError(spannerDatabase, ErrResourceExists, msg)
+		}
+		log.Printf("Adopt existing database %s", db.Name)
+		spannerDatabase, err = c.claimDatabase(spannerDatabase, db.Name)
+		if err != nil {
+			return err
+		}
+		c.recorder.Event(spannerDatabase, corev1.EventTypeNormal, SuccessAdopted, message)
+	}
+
+	if r := spannerDatabase.Status.Restore; r != nil && r.Phase == databasev1alpha1.RestorePhaseOptimizing {
+		spannerDatabase = c.syncRestoreOptimization(key, spannerDatabase, db)
+	}
+
+	if db.EnableDropProtection != spannerDatabase.Spec.DeletionProtection {
+		log.Printf("Set drop protection of database %s to %t", db.Name, spannerDatabase.Spec.DeletionProtection)
+		opName, err := op.SetDropProtection(ctx, instanceId, databaseId, spannerDatabase.Spec.DeletionProtection)
+		if err != nil {
+			return err
+		}
+		return c.trackOperation(key, spannerDatabase, opName)
+	}
+
+	pending, err := unappliedStatements(statements, spannerDatabase.Status.AppliedDdl)
+	if err != nil {
+		return c.rejectSpec(key, spannerDatabase, ErrDdlRefused, fmt.Sprintf(MessageDdlRefused, err))
+	}
+	if len(pending) > 0 {
+		log.Printf("SpannerDatabase %s has %d unapplied ddl statements, apply them", spannerDatabase.Name, len(pending))
+		opName, err := op.UpdateDatabaseDdl(ctx, instanceId, databaseId, pending)
+		if err != nil {
+			return err
+		}
+		spannerDatabaseCopy := spannerDatabase.DeepCopy()
+		spannerDatabaseCopy.Status.PendingDdl = pending
+		return c.trackOperation(key, spannerDatabaseCopy, opName)
+	}
+
+	migrations, err := c.declaredMigrations(spannerDatabase)
+	if err != nil {
+		return err
+	}
+	var migrationVersion int64
+	if len(migrations) > 0 {
+		applied, err := op.GetAppliedMigrations(ctx, instanceId, databaseId)
+		if err != nil {
+			return err
+		}
+		next, err := nextMigration(migrations, applied)
+		if err != nil {
+			return c.rejectSpec(key, spannerDatabase, ErrMigrationRefused, fmt.Sprintf(MessageMigrationRefused, err))
+		}
+		if next != nil {
+			log.Printf("SpannerDatabase %s is at migration version %d, apply migration %d", spannerDatabase.Name, currentMigrationVersion(applied), next.Version)
+			opName, err := op.ApplyMigration(ctx, instanceId, databaseId, *next)
+			if err != nil {
+				return err
+			}
+			spannerDatabaseCopy := spannerDatabase.DeepCopy()
+			spannerDatabaseCopy.Status.PendingMigration = &databasev1alpha1.SpannerDatabaseMigrationRecord{
+				Version:     next.Version,
+				Description: next.Description,
+				Checksum:    next.Checksum,
+			}
+			return c.trackOperation(key, spannerDatabaseCopy, opName)
+		}
+		migrationVersion = currentMigrationVersion(applied)
+	}
+
 	// Finally, we update the status block of the SpannerDatabase resource to reflect the
 	// current state of the world
-	err = c.updateSpannerDatabaseStatus(spannerDatabase)
+	spannerDatabaseCopy := spannerDatabase.DeepCopy()
+	spannerDatabaseCopy.Status.MigrationVersion = migrationVersion
+	markSynced(&spannerDatabaseCopy.Status, db, c.now())
+	_, err = c.updateSpannerDatabaseStatus(spannerDatabaseCopy)
 	if err != nil {
 		return err
 	}
@@ -275,17 +627,176 @@ func (c *Controller) syncHandler(key string) error {
 	return nil
 }
 
-func (c *Controller) updateSpannerDatabaseStatus(spannerDatabase *databasev1alpha1.SpannerDatabase) error {
+// claimDatabase records spannerDatabase as managing the Spanner database
+// databaseName, unless it already does.
+func (c *Controller) claimDatabase(spannerDatabase *databasev1alpha1.SpannerDatabase, databaseName string) (*databasev1alpha1.SpannerDatabase, error) {
+	if spannerDatabase.Status.DatabaseName == databaseName {
+		return spannerDatabase, nil
+	}
+	spannerDatabaseCopy := spannerDatabase.DeepCopy()
+	spannerDatabaseCopy.Status.DatabaseName = databaseName
+	return c.updateSpannerDatabaseStatus(spannerDatabaseCopy)
+}
+
+// validateIds checks the Spanner IDs of spannerDatabase. The instance ID of
+// a referenced SpannerInstance is checked by its own controller.
+func validateIds(spannerDatabase *databasev1alpha1.SpannerDatabase) error {
+	if spannerDatabase.Spec.InstanceRef == nil {
+		if err := operator.ValidateInstanceId(spannerDatabase.Spec.InstanceId); err != nil {
+			return err
+		}
+	}
+	return operator.ValidateDatabaseId(spannerDatabase.DatabaseId())
+}
+
+// createdByEarlierVersion reports whether db was created for spannerDatabase
+// by a controller from before the database name was recorded in the status.
+// Those named the database after the SpannerDatabase, in spec.instanceId of
+// the default project, and created it after the SpannerDatabase.
+func (c *Controller) createdByEarlierVersion(spannerDatabase *databasev1alpha1.SpannerDatabase, db *database.Database) bool {
+	if spannerDatabase.Status.DatabaseName != "" || spannerDatabase.CreationTimestamp.IsZero() || db.GetCreateTime() == nil {
+		return false
+	}
+	if db.Name != operator.DatabaseName(c.operators.ProjectId(""), spannerDatabase.Spec.InstanceId, spannerDatabase.Name) {
+		return false
+	}
+	return !db.GetCreateTime().AsTime().Before(spannerDatabase.CreationTimestamp.Time)
+}
+
+// operatorFor returns the Operator of projectId, built with the credentials
+// the spec of spannerDatabase selects.
+func (c *Controller) operatorFor(spannerDatabase *databasev1alpha1.SpannerDatabase, projectId string) (operator.Operator, error) {
+	return credentials.Operator(c.operators, c.secretLister, spannerDatabase.Namespace, projectId, spannerDatabase.Spec.CredentialsSecretRef)
+}
+
+// reportError sets the Error condition of spannerDatabase and emits a
+// warning Event, both with reason and message.
+func (c *Controller) reportError(spannerDatabase *databasev1alpha1.SpannerDatabase, reason, message string) error {
+	c.recorder.Event(spannerDatabase, corev1.EventTypeWarning, reason, message)
+	spannerDatabaseCopy := spannerDatabase.DeepCopy()
+	changed := setCondition(&spannerDatabaseCopy.Status, databasev1alpha1.SpannerDatabaseError, corev1.ConditionTrue, reason, message, c.now())
+	if !changed && spannerDatabase.Status.ObservedGeneration == spannerDatabase.Generation {
+		return nil
+	}
+	_, err := c.updateSpannerDatabaseStatus(spannerDatabaseCopy)
+	return err
+}
+
+// rejectSpec reports an error in the spec of spannerDatabase through
+// reportError without requeuing key. No retry can succeed before the spec is
+// updated, and updating it enqueues the SpannerDatabase again.
+func (c *Controller) rejectSpec(key string, spannerDatabase *databasev1alpha1.SpannerDatabase, reason, message string) error {
+	utilruntime.HandleError(fmt.Errorf("%s: %s", key, message))
+	return c.reportError(spannerDatabase, reason, message)
+}
+
+// trackOperation records opName as the pending operation of spannerDatabase
+// and schedules the key to be polled until the operation finishes.
+func (c *Controller) trackOperation(key string, spannerDatabase *databasev1alpha1.SpannerDatabase, opName string) error {
+	spannerDatabaseCopy := spannerDatabase.DeepCopy()
+	spannerDatabaseCopy.Status.PendingOperation = opName
+	if _, err := c.updateSpannerDatabaseStatus(spannerDatabaseCopy); err != nil {
+		return err
+	}
+	c.workqueue.AddAfter(key, operationPollInterval)
+	return nil
+}
+
+// pollPendingOperation checks the pending operation of spannerDatabase. While
+// it is running, the key is requeued and nil is returned. Once it is done, the
+// pending operation is cleared and the updated SpannerDatabase is returned so
+// the sync can carry on.
+func (c *Controller) pollPendingOperation(ctx context.Context, key string, spannerDatabase *databasev1alpha1.SpannerDatabase) (*databasev1alpha1.SpannerDatabase, error) {
+	// Operations are only started on the database recorded in the status.
+	projectId, instanceId, databaseId, _ := operator.ParseDatabaseName(spannerDatabase.Status.DatabaseName)
+	o, err := c.operatorFor(spannerDatabase, projectId)
+	if err != nil {
+		return nil, err
+	}
+	op, err := o.GetOperation(ctx, spannerDatabase.Status.PendingOperation)
+	if err != nil && o.IsNotFoundError(err) {
+		// Spanner keeps operations for a limited time only. One that is gone
+		// can no longer be polled, so it is cleared and the sync carries on
+		// from what Spanner reports. Its DDL is not known to be applied and
+		// is submitted again. A restore is taken as done, as the database is
+		// restored again should it be missing.
+		log.Printf("Operation %s no longer exists, reconcile again", spannerDatabase.Status.PendingOperation)
+		spannerDatabaseCopy := spannerDatabase.DeepCopy()
+		spannerDatabaseCopy.Status.PendingOperation = ""
+		spannerDatabaseCopy.Status.PendingMigration = nil
+		if r := spannerDatabaseCopy.Status.Restore; r != nil && r.Phase == databasev1alpha1.RestorePhaseRestoring {
+			spannerDatabaseCopy.Status.AppliedDdl = append(spannerDatabaseCopy.Status.AppliedDdl, spannerDatabaseCopy.Status.PendingDdl...)
+			r.Phase = databasev1alpha1.RestorePhaseOptimizing
+			r.ProgressPercent = 0
+		}
+		spannerDatabaseCopy.Status.PendingDdl = nil
+		return c.updateSpannerDatabaseStatus(spannerDatabaseCopy)
+	} else if err != nil {
+		return nil, err
+	}
+	if !op.Done {
+		log.Printf("Operation %s is still running", op.Name)
+		if r := spannerDatabase.Status.Restore; r != nil && r.ProgressPercent != op.Progress {
+			spannerDatabaseCopy := spannerDatabase.DeepCopy()
+			spannerDatabaseCopy.Status.Restore.ProgressPercent = op.Progress
+			if _, err := c.updateSpannerDatabaseStatus(spannerDatabaseCopy); err != nil {
+				return nil, err
+			}
+		}
+		c.workqueue.AddAfter(key, operationPollInterval)
+		return nil, nil
+	}
+	if m := spannerDatabase.Status.PendingMigration; m != nil && op.Err == nil {
+		err = o.RecordMigration(ctx, instanceId, databaseId, operator.Migration{
+			Version:     m.Version,
+			Description: m.Description,
+			Checksum:    m.Checksum,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	spannerDatabaseCopy := spannerDatabase.DeepCopy()
+	spannerDatabaseCopy.Status.PendingOperation = ""
+	spannerDatabaseCopy.Status.PendingMigration = nil
+	if op.Err == nil {
+		spannerDatabaseCopy.Status.AppliedDdl = append(spannerDatabaseCopy.Status.AppliedDdl, spannerDatabaseCopy.Status.PendingDdl...)
+	}
+	if r := spannerDatabaseCopy.Status.Restore; r != nil && r.Phase == databasev1alpha1.RestorePhaseRestoring {
+		if op.Err != nil {
+			// The database is restored again on the next sync.
+			spannerDatabaseCopy.Status.Restore = nil
+		} else {
+			r.Phase = databasev1alpha1.RestorePhaseOptimizing
+			r.ProgressPercent = 0
+		}
+	}
+	spannerDatabaseCopy.Status.PendingDdl = nil
+	if op.Err != nil {
+		markOperationFailed(&spannerDatabaseCopy.Status, fmt.Sprintf(MessageOperationFailed, op.Name, op.Err), c.now())
+	}
+	updated, err := c.updateSpannerDatabaseStatus(spannerDatabaseCopy)
+	if err != nil {
+		return nil, err
+	}
+	if op.Err != nil {
+		c.recorder.Event(spannerDatabase, corev1.EventTypeWarning, ErrOperationFailed, fmt.Sprintf(MessageOperationFailed, op.Name, op.Err))
+		return nil, op.Err
+	}
+	return updated, nil
+}
+
+func (c *Controller) updateSpannerDatabaseStatus(spannerDatabase *databasev1alpha1.SpannerDatabase) (*databasev1alpha1.SpannerDatabase, error) {
 	// NEVER modify objects from the store. It's a read-only, local cache.
 	// You can use DeepCopy() to make a deep copy of original object and modify this copy
 	// Or create a copy manually for better performance
 	spannerDatabaseCopy := spannerDatabase.DeepCopy()
-	// If the CustomResourceSubresources feature gate is not enabled,
-	// we must use Update instead of UpdateStatus to update the Status block of the SpannerDatabase resource.
-	// UpdateStatus will not allow changes to the Spec of the resource,
-	// which is ideal for ensuring nothing other than resource status has been updated.
-	_, err := c.spannerclientset.DatabaseadminsV1alpha1().SpannerDatabases(spannerDatabase.Namespace).Update(spannerDatabaseCopy)
-	return err
+	// Every status is written while syncing the spec of this generation.
+	spannerDatabaseCopy.Status.ObservedGeneration = spannerDatabaseCopy.Generation
+	// The SpannerDatabase CRD enables the status subresource, so that
+	// writing the status does not bump the generation. An Update would
+	// silently drop our changes to the Status block.
+	return c.spannerclientset.DatabaseadminsV1alpha1().SpannerDatabases(spannerDatabase.Namespace).UpdateStatus(spannerDatabaseCopy)
 }
 
 // enqueueSpannerDatabaseInstance takes a SpannerDatabase resource and converts it into a namespace/name
@@ -301,6 +812,69 @@ func (c *Controller) enqueueSpannerDatabase(obj interface{}) {
 	c.workqueue.Add(key)
 }
 
+// decodeObject returns the object of an informer event, unwrapping the
+// tombstone of a deleted one.
+func decodeObject(obj interface{}) (metav1.Object, bool) {
+	object, ok := obj.(metav1.Object)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("error decoding object, invalid type"))
+			return nil, false
+		}
+		object, ok = tombstone.Obj.(metav1.Object)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("error decoding object tombstone, invalid type"))
+			return nil, false
+		}
+	}
+	return object, true
+}
+
+// handleConfigMap enqueues every SpannerDatabase in the namespace of the
+// ConfigMap that reads its DDL or migrations from it.
+func (c *Controller) handleConfigMap(obj interface{}) {
+	object, ok := decodeObject(obj)
+	if !ok {
+		return
+	}
+	spannerDatabases, err := c.spannerDatabaseLister.SpannerDatabases(object.GetNamespace()).List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, spannerDatabase := range spannerDatabases {
+		ddl := spannerDatabase.Spec.Ddl
+		if ddl != nil && ddl.ConfigMapRef != nil && ddl.ConfigMapRef.Name == object.GetName() {
+			c.enqueueSpannerDatabase(spannerDatabase)
+			continue
+		}
+		if ref := spannerDatabase.Spec.MigrationsConfigMapRef; ref != nil && ref.Name == object.GetName() {
+			c.enqueueSpannerDatabase(spannerDatabase)
+		}
+	}
+}
+
+// handleSpannerBackup enqueues every SpannerDatabase in the namespace of the
+// SpannerBackup that is restored from it.
+func (c *Controller) handleSpannerBackup(obj interface{}) {
+	object, ok := decodeObject(obj)
+	if !ok {
+		return
+	}
+	spannerDatabases, err := c.spannerDatabaseLister.SpannerDatabases(object.GetNamespace()).List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, spannerDatabase := range spannerDatabases {
+		source := spannerDatabase.Spec.RestoreFrom
+		if source != nil && source.BackupRef != nil && source.BackupRef.Name == object.GetName() {
+			c.enqueueSpannerDatabase(spannerDatabase)
+		}
+	}
+}
+
 // handleObject will take any resource implementing metav1.Object and attempt
 // to find the SpannerDatabase resource that 'owns' it. It does this by looking at the
 // objects metadata.ownerReferences field for an appropriate OwnerReference.
@@ -17,14 +17,22 @@ limitations under the License.
 package databaseadmins
 
 import (
+	"context"
+	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/diff"
 	kubeinformers "k8s.io/client-go/informers"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
@@ -32,9 +40,16 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 
+	backupv1alpha1 "github.com/katsew/spanner-operator/pkg/apis/backupadmins/v1alpha1"
 	spannercontroller "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+	instancev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/instanceadmins/v1alpha1"
+	backupfake "github.com/katsew/spanner-operator/pkg/generated/backupadmins/clientset/versioned/fake"
+	backupinformers "github.com/katsew/spanner-operator/pkg/generated/backupadmins/informers/externalversions"
 	"github.com/katsew/spanner-operator/pkg/generated/databaseadmins/clientset/versioned/fake"
 	informers "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/informers/externalversions"
+	instancefake "github.com/katsew/spanner-operator/pkg/generated/instanceadmins/clientset/versioned/fake"
+	instanceinformers "github.com/katsew/spanner-operator/pkg/generated/instanceadmins/informers/externalversions"
+	"github.com/katsew/spanner-operator/pkg/operator"
 )
 
 var (
@@ -45,11 +60,26 @@ var (
 type fixture struct {
 	t *testing.T
 
-	client     *fake.Clientset
-	kubeclient *k8sfake.Clientset
+	client         *fake.Clientset
+	kubeclient     *k8sfake.Clientset
+	backupclient   *backupfake.Clientset
+	instanceclient *instancefake.Clientset
+	// Operator the controller talks to Spanner through.
+	op *operator.Fake
+	// Pool handing out op for the default project, and a fake of its own
+	// for any other project.
+	operators *operator.Pool
+	// Service account keys operators were built with, in order.
+	keys []string
+	// now is the time the controller stamps conditions with.
+	now time.Time
 	// Objects to put in the store.
 	SpannerDatabaseLister []*spannercontroller.SpannerDatabase
 	deploymentLister      []*apps.Deployment
+	configMapLister       []*corev1.ConfigMap
+	spannerBackupLister   []*backupv1alpha1.SpannerBackup
+	spannerInstanceLister []*instancev1alpha1.SpannerInstance
+	secretLister          []*corev1.Secret
 	// Actions expected to happen on the client.
 	kubeactions []core.Action
 	actions     []core.Action
@@ -63,6 +93,17 @@ func newFixture(t *testing.T) *fixture {
 	f.t = t
 	f.objects = []runtime.Object{}
 	f.kubeobjects = []runtime.Object{}
+	f.now = time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	f.op = operator.NewBuilder().ProjectId("test").BuildFake()
+	f.operators = operator.NewPool("test", func(projectId string, key []byte) (operator.Operator, error) {
+		if key != nil {
+			f.keys = append(f.keys, string(key))
+		}
+		if projectId == "test" {
+			return f.op, nil
+		}
+		return operator.NewBuilder().ProjectId(projectId).BuildFake(), nil
+	})
 	return f
 }
 
@@ -70,8 +111,9 @@ func newSpannerDatabase(name string, instanceId string) *spannercontroller.Spann
 	return &spannercontroller.SpannerDatabase{
 		TypeMeta: metav1.TypeMeta{APIVersion: spannercontroller.SchemeGroupVersion.String()},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: metav1.NamespaceDefault,
+			Name:       name,
+			Namespace:  metav1.NamespaceDefault,
+			Finalizers: []string{dropDatabaseFinalizer},
 		},
 		Spec: spannercontroller.SpannerDatabaseSpec{
 			InstanceId: instanceId,
@@ -82,14 +124,25 @@ func newSpannerDatabase(name string, instanceId string) *spannercontroller.Spann
 func (f *fixture) newController() (*Controller, informers.SharedInformerFactory, kubeinformers.SharedInformerFactory) {
 	f.client = fake.NewSimpleClientset(f.objects...)
 	f.kubeclient = k8sfake.NewSimpleClientset(f.kubeobjects...)
+	f.backupclient = backupfake.NewSimpleClientset()
+	f.instanceclient = instancefake.NewSimpleClientset()
 
 	i := informers.NewSharedInformerFactory(f.client, noResyncPeriodFunc())
 	k8sI := kubeinformers.NewSharedInformerFactory(f.kubeclient, noResyncPeriodFunc())
+	backupI := backupinformers.NewSharedInformerFactory(f.backupclient, noResyncPeriodFunc())
+	instanceI := instanceinformers.NewSharedInformerFactory(f.instanceclient, noResyncPeriodFunc())
 
-	c := NewController(f.kubeclient, f.client, i.Databaseadmins().V1alpha1().SpannerDatabases())
+	c := NewController(f.kubeclient, f.client, i.Databaseadmins().V1alpha1().SpannerDatabases(),
+		k8sI.Core().V1().ConfigMaps(), backupI.Backupadmins().V1alpha1().SpannerBackups(),
+		instanceI.Instanceadmins().V1alpha1().SpannerInstances(), k8sI.Core().V1().Secrets(), f.operators)
 
 	c.spannerDatabasesSynced = alwaysReady
+	c.configMapsSynced = alwaysReady
+	c.spannerBackupsSynced = alwaysReady
+	c.spannerInstancesSynced = alwaysReady
+	c.secretsSynced = alwaysReady
 	c.recorder = &record.FakeRecorder{}
+	c.now = func() time.Time { return f.now }
 
 	for _, f := range f.SpannerDatabaseLister {
 		i.Databaseadmins().V1alpha1().SpannerDatabases().Informer().GetIndexer().Add(f)
@@ -99,6 +152,22 @@ func (f *fixture) newController() (*Controller, informers.SharedInformerFactory,
 		k8sI.Apps().V1().Deployments().Informer().GetIndexer().Add(d)
 	}
 
+	for _, cm := range f.configMapLister {
+		k8sI.Core().V1().ConfigMaps().Informer().GetIndexer().Add(cm)
+	}
+
+	for _, s := range f.secretLister {
+		k8sI.Core().V1().Secrets().Informer().GetIndexer().Add(s)
+	}
+
+	for _, b := range f.spannerBackupLister {
+		backupI.Backupadmins().V1alpha1().SpannerBackups().Informer().GetIndexer().Add(b)
+	}
+
+	for _, si := range f.spannerInstanceLister {
+		instanceI.Instanceadmins().V1alpha1().SpannerInstances().Informer().GetIndexer().Add(si)
+	}
+
 	return c, i, k8sI
 }
 
@@ -119,7 +188,7 @@ func (f *fixture) runController(SpannerDatabaseName string, startInformers bool,
 		k8sI.Start(stopCh)
 	}
 
-	err := c.syncHandler(SpannerDatabaseName)
+	err := c.syncHandler(context.Background(), SpannerDatabaseName)
 	if !expectError && err != nil {
 		f.t.Errorf("error syncing SpannerDatabase: %v", err)
 	} else if expectError && err == nil {
@@ -211,7 +280,11 @@ func filterInformerActions(actions []core.Action) []core.Action {
 			(action.Matches("list", "SpannerDatabases") ||
 				action.Matches("watch", "SpannerDatabases") ||
 				action.Matches("list", "deployments") ||
-				action.Matches("watch", "deployments")) {
+				action.Matches("watch", "deployments") ||
+				action.Matches("list", "configmaps") ||
+				action.Matches("watch", "configmaps") ||
+				action.Matches("list", "secrets") ||
+				action.Matches("watch", "secrets")) {
 			continue
 		}
 		ret = append(ret, action)
@@ -228,13 +301,89 @@ func (f *fixture) expectUpdateDeploymentAction(d *apps.Deployment) {
 	f.kubeactions = append(f.kubeactions, core.NewUpdateAction(schema.GroupVersionResource{Resource: "deployments"}, d.Namespace, d))
 }
 
+func (f *fixture) expectUpdateAction(SpannerDatabase *spannercontroller.SpannerDatabase) {
+	f.actions = append(f.actions, core.NewUpdateAction(schema.GroupVersionResource{Resource: "SpannerDatabases"}, SpannerDatabase.Namespace, SpannerDatabase))
+}
+
 func (f *fixture) expectUpdateFooStatusAction(SpannerDatabase *spannercontroller.SpannerDatabase) {
 	action := core.NewUpdateAction(schema.GroupVersionResource{Resource: "SpannerDatabases"}, SpannerDatabase.Namespace, SpannerDatabase)
-	// TODO: Until #38113 is merged, we can't use Subresource
-	//action.Subresource = "status"
+	action.Subresource = "status"
 	f.actions = append(f.actions, action)
 }
 
+// expectClaimAction expects SpannerDatabase to be recorded as managing its
+// database in projectId, and returns a copy of the updated SpannerDatabase.
+func (f *fixture) expectClaimAction(SpannerDatabase *spannercontroller.SpannerDatabase, projectId string) *spannercontroller.SpannerDatabase {
+	claimed := SpannerDatabase.DeepCopy()
+	claimed.Status.DatabaseName = operator.DatabaseName(projectId, SpannerDatabase.Spec.InstanceId, SpannerDatabase.DatabaseId())
+	f.expectUpdateFooStatusAction(claimed)
+	return claimed.DeepCopy()
+}
+
+// manage records SpannerDatabase as managing its database in the default
+// project, as if the controller had created it.
+func manage(SpannerDatabase *spannercontroller.SpannerDatabase) {
+	SpannerDatabase.Status.DatabaseName = operator.DatabaseName("test", SpannerDatabase.Spec.InstanceId, SpannerDatabase.DatabaseId())
+}
+
+// createInstance makes the operator already hold the instance instanceId.
+func (f *fixture) createInstance(instanceId string) {
+	if _, err := f.op.CreateInstance(context.Background(), instanceId, instanceId, "regional-asia-northeast1", operator.Nodes(1)); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// applyMigration makes the operator hold the database name with migration
+// applied and recorded.
+func (f *fixture) applyMigration(name string, migration operator.Migration) {
+	ctx := context.Background()
+	if _, err := f.op.GetDatabase(ctx, "testing", name); f.op.IsNotFoundError(err) {
+		if _, err := f.op.CreateDatabase(ctx, "testing", name, nil); err != nil {
+			f.t.Fatal(err)
+		}
+	}
+	if _, err := f.op.ApplyMigration(ctx, "testing", name, migration); err != nil {
+		f.t.Fatal(err)
+	}
+	if err := f.op.RecordMigration(ctx, "testing", name, migration); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// provisioningConditions are the conditions of a SpannerDatabase creating or
+// restoring its database since now.
+func provisioningConditions(reason, message string, now time.Time) []spannercontroller.SpannerDatabaseCondition {
+	return []spannercontroller.SpannerDatabaseCondition{
+		{Type: spannercontroller.SpannerDatabaseReady, Status: corev1.ConditionFalse, LastTransitionTime: metav1.NewTime(now), Reason: reason, Message: message},
+		{Type: spannercontroller.SpannerDatabaseProvisioning, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(now), Reason: reason, Message: message},
+	}
+}
+
+// syncedConditions are the conditions of a SpannerDatabase in sync with a
+// ready database since now.
+func syncedConditions(now time.Time) []spannercontroller.SpannerDatabaseCondition {
+	condition := func(conditionType spannercontroller.SpannerDatabaseConditionType, status corev1.ConditionStatus) spannercontroller.SpannerDatabaseCondition {
+		return spannercontroller.SpannerDatabaseCondition{Type: conditionType, Status: status, LastTransitionTime: metav1.NewTime(now), Reason: SuccessSynced, Message: MessageResourceSynced}
+	}
+	return []spannercontroller.SpannerDatabaseCondition{
+		condition(spannercontroller.SpannerDatabaseReady, corev1.ConditionTrue),
+		condition(spannercontroller.SpannerDatabaseProvisioning, corev1.ConditionFalse),
+		condition(spannercontroller.SpannerDatabaseDegraded, corev1.ConditionFalse),
+		condition(spannercontroller.SpannerDatabaseError, corev1.ConditionFalse),
+	}
+}
+
+// updatedDatabases returns the SpannerDatabases the controller updated.
+func (f *fixture) updatedDatabases() []*spannercontroller.SpannerDatabase {
+	var updated []*spannercontroller.SpannerDatabase
+	for _, action := range filterInformerActions(f.client.Actions()) {
+		if update, ok := action.(core.UpdateAction); ok {
+			updated = append(updated, update.GetObject().(*spannercontroller.SpannerDatabase))
+		}
+	}
+	return updated
+}
+
 func getKey(SpannerDatabase *spannercontroller.SpannerDatabase, t *testing.T) string {
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(SpannerDatabase)
 	if err != nil {
@@ -247,34 +396,1102 @@ func getKey(SpannerDatabase *spannercontroller.SpannerDatabase, t *testing.T) st
 func TestCreatesDeployment(t *testing.T) {
 	f := newFixture(t)
 	SpannerDatabase := newSpannerDatabase("test", "testing")
+	f.createInstance("testing")
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	expDatabase := f.expectClaimAction(SpannerDatabase, "test")
+	expDatabase.Status.PendingOperation = "projects/test/instances/testing/databases/test/operations/mock_create_database"
+	expDatabase.Status.Conditions = provisioningConditions(ReasonCreating, fmt.Sprintf(MessageCreating, expDatabase.Status.DatabaseName), f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
+
+	f.run(getKey(SpannerDatabase, t))
+}
+
+func TestCreatesDatabaseInSpecProject(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	SpannerDatabase.Spec.ProjectId = "staging"
+	staging, err := f.operators.Get("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := staging.CreateInstance(context.Background(), "testing", "testing", "regional-asia-northeast1", operator.Nodes(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	expDatabase := f.expectClaimAction(SpannerDatabase, "staging")
+	expDatabase.Status.PendingOperation = "projects/staging/instances/testing/databases/test/operations/mock_create_database"
+	expDatabase.Status.Conditions = provisioningConditions(ReasonCreating, fmt.Sprintf(MessageCreating, expDatabase.Status.DatabaseName), f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
+
+	f.run(getKey(SpannerDatabase, t))
+
+	if _, err := staging.GetDatabase(context.Background(), "testing", "test"); err != nil {
+		t.Errorf("expected database in project staging, got %v", err)
+	}
+}
+
+func TestCreatesDatabaseWithSecretCredentials(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	SpannerDatabase.Spec.CredentialsSecretRef = &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "spanner-key"},
+		Key:                  "key.json",
+	}
+	f.createInstance("testing")
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+	f.secretLister = append(f.secretLister, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "spanner-key", Namespace: metav1.NamespaceDefault, ResourceVersion: "1"},
+		Data:       map[string][]byte{"key.json": []byte("key-1")},
+	})
+
+	expDatabase := f.expectClaimAction(SpannerDatabase, "test")
+	expDatabase.Status.PendingOperation = "projects/test/instances/testing/databases/test/operations/mock_create_database"
+	expDatabase.Status.Conditions = provisioningConditions(ReasonCreating, fmt.Sprintf(MessageCreating, expDatabase.Status.DatabaseName), f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
+
+	f.run(getKey(SpannerDatabase, t))
+
+	if !reflect.DeepEqual(f.keys, []string{"key-1"}) {
+		t.Errorf("expected an operator built with key-1, got %v", f.keys)
+	}
+}
+
+// newSpannerInstance returns a ready SpannerInstance that claimed the
+// instance instanceId of projectId.
+func newSpannerInstance(namespace, name, projectId, instanceId string) *instancev1alpha1.SpannerInstance {
+	return &instancev1alpha1.SpannerInstance{
+		TypeMeta: metav1.TypeMeta{APIVersion: instancev1alpha1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			UID:       types.UID(namespace + "-" + name),
+		},
+		Status: instancev1alpha1.SpannerInstanceStatus{
+			InstanceName: operator.InstanceName(projectId, instanceId),
+			Conditions: []instancev1alpha1.SpannerInstanceCondition{
+				{Type: instancev1alpha1.SpannerInstanceReady, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+}
+
+func TestCreatesDatabaseInReferencedInstance(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance(metav1.NamespaceDefault, "main", "staging", "main-instance")
+	SpannerDatabase := newSpannerDatabase("test", "")
+	SpannerDatabase.Spec.InstanceRef = &spannercontroller.SpannerInstanceReference{Name: "main"}
+	staging, err := f.operators.Get("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := staging.CreateInstance(context.Background(), "main-instance", "main-instance", "regional-asia-northeast1", operator.Nodes(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	f.spannerInstanceLister = append(f.spannerInstanceLister, SpannerInstance)
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
 
+	owned := SpannerDatabase.DeepCopy()
+	owned.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: instancev1alpha1.SchemeGroupVersion.String(),
+		Kind:       "SpannerInstance",
+		Name:       "main",
+		UID:        SpannerInstance.UID,
+	}}
+	f.expectUpdateAction(owned)
+	claimed := owned.DeepCopy()
+	claimed.Status.DatabaseName = "projects/staging/instances/main-instance/databases/test"
+	f.expectUpdateFooStatusAction(claimed)
+	expDatabase := claimed.DeepCopy()
+	expDatabase.Status.PendingOperation = "projects/staging/instances/main-instance/databases/test/operations/mock_create_database"
+	expDatabase.Status.Conditions = provisioningConditions(ReasonCreating, fmt.Sprintf(MessageCreating, expDatabase.Status.DatabaseName), f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
+
+	f.run(getKey(SpannerDatabase, t))
+
+	if _, err := staging.GetDatabase(context.Background(), "main-instance", "test"); err != nil {
+		t.Errorf("expected database in instance main-instance of project staging, got %v", err)
+	}
+}
+
+func TestDoesNotOwnAcrossNamespaces(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("spanner", "main", "test", "testing")
+	SpannerDatabase := newSpannerDatabase("test", "")
+	SpannerDatabase.Spec.InstanceRef = &spannercontroller.SpannerInstanceReference{Name: "main", Namespace: "spanner"}
+	f.createInstance("testing")
+
+	f.spannerInstanceLister = append(f.spannerInstanceLister, SpannerInstance)
 	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
 	f.objects = append(f.objects, SpannerDatabase)
 
-	f.expectUpdateFooStatusAction(SpannerDatabase)
+	claimed := SpannerDatabase.DeepCopy()
+	claimed.Status.DatabaseName = "projects/test/instances/testing/databases/test"
+	f.expectUpdateFooStatusAction(claimed)
+	expDatabase := claimed.DeepCopy()
+	expDatabase.Status.PendingOperation = "projects/test/instances/testing/databases/test/operations/mock_create_database"
+	expDatabase.Status.Conditions = provisioningConditions(ReasonCreating, fmt.Sprintf(MessageCreating, expDatabase.Status.DatabaseName), f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
 
 	f.run(getKey(SpannerDatabase, t))
 }
 
+// expectWaitingAction expects SpannerDatabase to be reported as waiting for
+// instance, and returns a copy of the updated SpannerDatabase.
+func (f *fixture) expectWaitingAction(SpannerDatabase *spannercontroller.SpannerDatabase, instance string) *spannercontroller.SpannerDatabase {
+	waiting := SpannerDatabase.DeepCopy()
+	setCondition(&waiting.Status, spannercontroller.SpannerDatabaseReady, corev1.ConditionFalse, WaitingForInstance, fmt.Sprintf(MessageWaitingForInstance, instance), f.now)
+	f.expectUpdateFooStatusAction(waiting)
+	return waiting.DeepCopy()
+}
+
+func TestWaitsForReferencedInstance(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "")
+	SpannerDatabase.Spec.InstanceRef = &spannercontroller.SpannerInstanceReference{Name: "main"}
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	f.expectWaitingAction(SpannerDatabase, "SpannerInstance default/main")
+	f.run(getKey(SpannerDatabase, t))
+}
+
+func TestWaitsForReferencedInstanceToBeReady(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance(metav1.NamespaceDefault, "main", "test", "testing")
+	SpannerInstance.Status.InstanceName = ""
+	SpannerInstance.Status.Conditions[0].Status = corev1.ConditionFalse
+	SpannerDatabase := newSpannerDatabase("test", "")
+	SpannerDatabase.Spec.InstanceRef = &spannercontroller.SpannerInstanceReference{Name: "main"}
+
+	f.spannerInstanceLister = append(f.spannerInstanceLister, SpannerInstance)
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	// The owner is set right away, so the SpannerDatabase is deleted along
+	// with a SpannerInstance that never gets ready.
+	owned := SpannerDatabase.DeepCopy()
+	owned.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: instancev1alpha1.SchemeGroupVersion.String(),
+		Kind:       "SpannerInstance",
+		Name:       "main",
+		UID:        SpannerInstance.UID,
+	}}
+	f.expectUpdateAction(owned)
+	f.expectWaitingAction(owned, "SpannerInstance default/main")
+	f.run(getKey(SpannerDatabase, t))
+}
+
+func TestEnqueuesDatabasesOfReadyInstance(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance(metav1.NamespaceDefault, "main", "test", "testing")
+	byRef := newSpannerDatabase("by-ref", "")
+	byRef.Spec.InstanceRef = &spannercontroller.SpannerInstanceReference{Name: "main"}
+	byId := newSpannerDatabase("by-id", "testing")
+	other := newSpannerDatabase("other", "staging")
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, byRef, byId, other)
+
+	c, _, _ := f.newController()
+	creating := SpannerInstance.DeepCopy()
+	creating.Status.Conditions[0].Status = corev1.ConditionFalse
+	c.updateSpannerInstance(creating, SpannerInstance)
+	// A resync of a ready SpannerInstance is no news.
+	c.updateSpannerInstance(SpannerInstance, SpannerInstance)
+
+	var keys []string
+	for c.workqueue.Len() > 0 {
+		key, _ := c.workqueue.Get()
+		keys = append(keys, key.(string))
+		c.workqueue.Done(key)
+	}
+	sort.Strings(keys)
+	if expected := []string{"default/by-id", "default/by-ref"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected %v to be enqueued, got %v", expected, keys)
+	}
+}
+
+func TestCreatesDatabasesSharingNameAcrossNamespaces(t *testing.T) {
+	f := newFixture(t)
+	teamA := newSpannerDatabase("orders", "testing")
+	teamA.Namespace = "team-a"
+	teamA.Spec.DatabaseId = "orders_a"
+	manage(teamA)
+	teamB := teamA.DeepCopy()
+	teamB.Namespace = "team-b"
+	teamB.Spec.DatabaseId = "orders_b"
+	teamB.Status = spannercontroller.SpannerDatabaseStatus{}
+	f.createInstance("testing")
+	if _, err := f.op.CreateDatabase(context.Background(), "testing", "orders_a", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, teamA, teamB)
+	f.objects = append(f.objects, teamA, teamB)
+
+	expDatabase := f.expectClaimAction(teamB, "test")
+	expDatabase.Status.PendingOperation = "projects/test/instances/testing/databases/orders_b/operations/mock_create_database"
+	expDatabase.Status.Conditions = provisioningConditions(ReasonCreating, fmt.Sprintf(MessageCreating, expDatabase.Status.DatabaseName), f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
+
+	f.run(getKey(teamB, t))
+
+	if _, err := f.op.GetDatabase(context.Background(), "testing", "orders_b"); err != nil {
+		t.Errorf("expected database orders_b, got %v", err)
+	}
+}
+
+func TestRejectsInvalidIds(t *testing.T) {
+	for name, spec := range map[string]spannercontroller.SpannerDatabaseSpec{
+		"short database":      {InstanceId: "testing", DatabaseId: "a"},
+		"long database":       {InstanceId: "testing", DatabaseId: strings.Repeat("a", 31)},
+		"leading hyphen":      {InstanceId: "testing", DatabaseId: "-orders"},
+		"trailing underscore": {InstanceId: "testing", DatabaseId: "orders_"},
+		"uppercase database":  {InstanceId: "testing", DatabaseId: "Orders"},
+		"missing instance":    {},
+		"invalid instance":    {InstanceId: "testing_1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := newFixture(t)
+			SpannerDatabase := newSpannerDatabase("test", spec.InstanceId)
+			SpannerDatabase.Spec.DatabaseId = spec.DatabaseId
+
+			f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+			f.objects = append(f.objects, SpannerDatabase)
+
+			expDatabase := SpannerDatabase.DeepCopy()
+			message := fmt.Sprintf(MessageInvalidId, validateIds(SpannerDatabase))
+			setCondition(&expDatabase.Status, spannercontroller.SpannerDatabaseError, corev1.ConditionTrue, ErrInvalidId, message, f.now)
+			f.expectUpdateFooStatusAction(expDatabase)
+			f.run(getKey(SpannerDatabase, t))
+		})
+	}
+}
+
 func TestDoNothing(t *testing.T) {
 	f := newFixture(t)
 	SpannerDatabase := newSpannerDatabase("test", "testing")
+	manage(SpannerDatabase)
+	f.createInstance("testing")
+	if _, err := f.op.CreateDatabase(context.Background(), "testing", "test", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	expDatabase := SpannerDatabase.DeepCopy()
+	expDatabase.Status.Conditions = syncedConditions(f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
+	f.run(getKey(SpannerDatabase, t))
+}
+
+func TestWaitsForMissingInstance(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	f.expectWaitingAction(SpannerDatabase, "projects/test/instances/testing")
+	f.run(getKey(SpannerDatabase, t))
+}
+
+func TestRefusesExistingDatabase(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	SpannerDatabase.Spec.Ddl = &spannercontroller.SpannerDatabaseDdl{
+		Statements: []string{"CREATE TABLE Singers (SingerId INT64) PRIMARY KEY (SingerId)"},
+	}
+	f.createInstance("testing")
+	if _, err := f.op.CreateDatabase(context.Background(), "testing", "test", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	c, _, _ := f.newController()
+	recorder := record.NewFakeRecorder(1)
+	c.recorder = recorder
+	if err := c.syncHandler(context.Background(), getKey(SpannerDatabase, t)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, ErrResourceExists) {
+			t.Errorf("expected an %s event, got %q", ErrResourceExists, event)
+		}
+	default:
+		t.Errorf("expected an %s event", ErrResourceExists)
+	}
+	updated := f.updatedDatabases()
+	if len(updated) != 1 || !conditionTrue(updated[0].Status, spannercontroller.SpannerDatabaseError) || updated[0].Status.Conditions[0].Reason != ErrResourceExists {
+		t.Errorf("expected only an Error condition with reason %s to be reported, got %+v", ErrResourceExists, updated)
+	}
+}
+
+func TestAdoptsExistingDatabase(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	SpannerDatabase.Annotations = map[string]string{spannercontroller.AdoptAnnotation: "true"}
+	f.createInstance("testing")
+	if _, err := f.op.CreateDatabase(context.Background(), "testing", "test", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	expDatabase := f.expectClaimAction(SpannerDatabase, "test")
+	expDatabase.Status.Conditions = syncedConditions(f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
+	f.run(getKey(SpannerDatabase, t))
+}
+
+func TestRecordsDatabaseOfEarlierVersion(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	SpannerDatabase.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+	f.createInstance("testing")
+	if _, err := f.op.CreateDatabase(context.Background(), "testing", "test", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	expDatabase := f.expectClaimAction(SpannerDatabase, "test")
+	expDatabase.Status.Conditions = syncedConditions(f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
+	f.run(getKey(SpannerDatabase, t))
+}
+
+// A database older than the SpannerDatabase was not created for it, and needs
+// the adopt annotation even with the ID an earlier version would have used.
+func TestRefusesDatabaseOlderThanSpannerDatabase(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	f.createInstance("testing")
+	if _, err := f.op.CreateDatabase(context.Background(), "testing", "test", nil); err != nil {
+		t.Fatal(err)
+	}
+	SpannerDatabase.CreationTimestamp = metav1.NewTime(time.Now().Add(time.Minute))
 
 	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
 	f.objects = append(f.objects, SpannerDatabase)
 
-	f.expectUpdateFooStatusAction(SpannerDatabase)
+	expDatabase := SpannerDatabase.DeepCopy()
+	message := fmt.Sprintf(MessageResourceExists, "projects/test/instances/testing/databases/test", spannercontroller.AdoptAnnotation)
+	setCondition(&expDatabase.Status, spannercontroller.SpannerDatabaseError, corev1.ConditionTrue, ErrResourceExists, message, f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
 	f.run(getKey(SpannerDatabase, t))
 }
 
-func TestNotControlledByUs(t *testing.T) {
+func TestRetriesResourceExhausted(t *testing.T) {
 	f := newFixture(t)
 	SpannerDatabase := newSpannerDatabase("test", "testing")
+	f.createInstance("testing")
+	f.op.FailNext("CreateDatabase", status.Error(codes.ResourceExhausted, "too many databases"))
 
 	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
 	f.objects = append(f.objects, SpannerDatabase)
 
+	f.expectClaimAction(SpannerDatabase, "test")
 	f.runExpectError(getKey(SpannerDatabase, t))
+
+	if _, err := f.op.GetDatabase(context.Background(), "testing", "test"); !f.op.IsNotFoundError(err) {
+		t.Errorf("expected database not to be created, got %v", err)
+	}
+}
+
+func TestCreatesDatabaseWithDdl(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	SpannerDatabase.Spec.Ddl = &spannercontroller.SpannerDatabaseDdl{
+		Statements: []string{"CREATE TABLE Singers (SingerId INT64) PRIMARY KEY (SingerId)"},
+		ConfigMapRef: &corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "schema"},
+			Key:                  "schema.sql",
+		},
+	}
+	f.createInstance("testing")
+
+	f.configMapLister = append(f.configMapLister, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "schema", Namespace: metav1.NamespaceDefault},
+		Data: map[string]string{
+			"schema.sql": "CREATE TABLE Albums (AlbumId INT64) PRIMARY KEY (AlbumId);\nCREATE INDEX AlbumsById ON Albums(AlbumId);\n",
+		},
+	})
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	expDatabase := f.expectClaimAction(SpannerDatabase, "test")
+	expDatabase.Status.PendingOperation = "projects/test/instances/testing/databases/test/operations/mock_create_database"
+	expDatabase.Status.Conditions = provisioningConditions(ReasonCreating, fmt.Sprintf(MessageCreating, expDatabase.Status.DatabaseName), f.now)
+	expDatabase.Status.PendingDdl = []string{
+		"CREATE TABLE Singers (SingerId INT64) PRIMARY KEY (SingerId)",
+		"CREATE TABLE Albums (AlbumId INT64) PRIMARY KEY (AlbumId)",
+		"CREATE INDEX AlbumsById ON Albums(AlbumId)",
+	}
+	f.expectUpdateFooStatusAction(expDatabase)
+
+	f.run(getKey(SpannerDatabase, t))
+}
+
+func TestAppliesNewDdl(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	manage(SpannerDatabase)
+	SpannerDatabase.Spec.Ddl = &spannercontroller.SpannerDatabaseDdl{
+		Statements: []string{
+			"CREATE TABLE Singers (SingerId INT64) PRIMARY KEY (SingerId)",
+			"CREATE TABLE Albums (AlbumId INT64) PRIMARY KEY (AlbumId)",
+		},
+	}
+	SpannerDatabase.Status.AppliedDdl = []string{"CREATE TABLE Singers (SingerId INT64) PRIMARY KEY (SingerId)"}
+	f.createInstance("testing")
+	if _, err := f.op.CreateDatabase(context.Background(), "testing", "test", SpannerDatabase.Status.AppliedDdl); err != nil {
+		t.Fatal(err)
+	}
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	expDatabase := SpannerDatabase.DeepCopy()
+	expDatabase.Status.PendingOperation = "projects/test/instances/testing/databases/test/operations/mock_update_database_ddl"
+	expDatabase.Status.PendingDdl = []string{"CREATE TABLE Albums (AlbumId INT64) PRIMARY KEY (AlbumId)"}
+	f.expectUpdateFooStatusAction(expDatabase)
+
+	f.run(getKey(SpannerDatabase, t))
+}
+
+// DDL cannot be rolled back, so an applied statement that is edited or
+// removed is reported once instead of being submitted again.
+func TestRefusesChangedAppliedDdl(t *testing.T) {
+	singers := "CREATE TABLE Singers (SingerId INT64) PRIMARY KEY (SingerId)"
+	albums := "CREATE TABLE Albums (AlbumId INT64) PRIMARY KEY (AlbumId)"
+	for name, statements := range map[string][]string{
+		"edited":  {"CREATE TABLE Singers (SingerId INT64, Name STRING(MAX)) PRIMARY KEY (SingerId)", albums},
+		"removed": {albums},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := newFixture(t)
+			SpannerDatabase := newSpannerDatabase("test", "testing")
+			manage(SpannerDatabase)
+			SpannerDatabase.Spec.Ddl = &spannercontroller.SpannerDatabaseDdl{Statements: statements}
+			SpannerDatabase.Status.AppliedDdl = []string{singers}
+			f.createInstance("testing")
+			if _, err := f.op.CreateDatabase(context.Background(), "testing", "test", SpannerDatabase.Status.AppliedDdl); err != nil {
+				t.Fatal(err)
+			}
+
+			f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+			f.objects = append(f.objects, SpannerDatabase)
+
+			c, _, _ := f.newController()
+			if err := c.syncHandler(context.Background(), getKey(SpannerDatabase, t)); err != nil {
+				t.Fatal(err)
+			}
+			updated := f.updatedDatabases()
+			if len(updated) != 1 || !conditionTrue(updated[0].Status, spannercontroller.SpannerDatabaseError) || updated[0].Status.Conditions[0].Reason != ErrDdlRefused {
+				t.Fatalf("expected only an Error condition with reason %s to be reported, got %+v", ErrDdlRefused, updated)
+			}
+			if updated[0].Status.PendingOperation != "" {
+				t.Errorf("expected no ddl to be applied, got %s", updated[0].Status.PendingOperation)
+			}
+			if n := c.workqueue.Len(); n != 0 {
+				t.Errorf("expected the key not to be retried, got %d queued", n)
+			}
+		})
+	}
+}
+
+// The DDL of an operation Spanner no longer keeps is not known to be applied,
+// so it is submitted again.
+func TestResubmitsDdlOfMissingOperation(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	manage(SpannerDatabase)
+	SpannerDatabase.Spec.Ddl = &spannercontroller.SpannerDatabaseDdl{
+		Statements: []string{"CREATE TABLE Singers (SingerId INT64) PRIMARY KEY (SingerId)"},
+	}
+	SpannerDatabase.Status.PendingOperation = "projects/test/instances/testing/databases/test/operations/expired"
+	SpannerDatabase.Status.PendingDdl = SpannerDatabase.Spec.Ddl.Statements
+	f.createInstance("testing")
+	if _, err := f.op.CreateDatabase(context.Background(), "testing", "test", nil); err != nil {
+		t.Fatal(err)
+	}
+	f.op.FailNext("GetOperation", status.Error(codes.NotFound, "operation not found"))
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	expDatabase := SpannerDatabase.DeepCopy()
+	expDatabase.Status.PendingOperation = ""
+	expDatabase.Status.PendingDdl = nil
+	f.expectUpdateFooStatusAction(expDatabase)
+	expDatabase = expDatabase.DeepCopy()
+	expDatabase.Status.PendingOperation = "projects/test/instances/testing/databases/test/operations/mock_update_database_ddl"
+	expDatabase.Status.PendingDdl = SpannerDatabase.Spec.Ddl.Statements
+	f.expectUpdateFooStatusAction(expDatabase)
+
+	f.run(getKey(SpannerDatabase, t))
+}
+
+func TestAppliesNextMigration(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	manage(SpannerDatabase)
+	SpannerDatabase.Spec.Migrations = []spannercontroller.SpannerDatabaseMigration{
+		{Version: 1, Description: "singers", Statements: []string{"CREATE TABLE Singers (SingerId INT64) PRIMARY KEY (SingerId)"}},
+		{Version: 2, Description: "albums", Statements: []string{"CREATE TABLE Albums (AlbumId INT64) PRIMARY KEY (AlbumId)"}},
+	}
+	f.createInstance("testing")
+	f.applyMigration("test", operator.NewMigration(1, "singers", SpannerDatabase.Spec.Migrations[0].Statements))
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	expDatabase := SpannerDatabase.DeepCopy()
+	expDatabase.Status.PendingOperation = "projects/test/instances/testing/databases/test/operations/mock_apply_migration"
+	expDatabase.Status.PendingMigration = &spannercontroller.SpannerDatabaseMigrationRecord{
+		Version:     2,
+		Description: "albums",
+		Checksum:    operator.MigrationChecksum(SpannerDatabase.Spec.Migrations[1].Statements),
+	}
+	f.expectUpdateFooStatusAction(expDatabase)
+
+	f.run(getKey(SpannerDatabase, t))
+}
+
+func TestRecordsMigrationWhenOperationIsDone(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	manage(SpannerDatabase)
+	SpannerDatabase.Spec.Migrations = []spannercontroller.SpannerDatabaseMigration{
+		{Version: 1, Description: "singers", Statements: []string{"CREATE TABLE Singers (SingerId INT64) PRIMARY KEY (SingerId)"}},
+	}
+	SpannerDatabase.Status.PendingOperation = "projects/test/instances/testing/databases/test/operations/mock_apply_migration"
+	SpannerDatabase.Status.PendingMigration = &spannercontroller.SpannerDatabaseMigrationRecord{
+		Version:     1,
+		Description: "singers",
+		Checksum:    operator.MigrationChecksum(SpannerDatabase.Spec.Migrations[0].Statements),
+	}
+	f.createInstance("testing")
+	if _, err := f.op.CreateDatabase(context.Background(), "testing", "test", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	expDatabase := SpannerDatabase.DeepCopy()
+	expDatabase.Status.PendingOperation = ""
+	expDatabase.Status.PendingMigration = nil
+	f.expectUpdateFooStatusAction(expDatabase)
+	expDatabase = expDatabase.DeepCopy()
+	expDatabase.Status.MigrationVersion = 1
+	expDatabase.Status.Conditions = syncedConditions(f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
+
+	f.run(getKey(SpannerDatabase, t))
+}
+
+func TestRefusesEditedMigration(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	manage(SpannerDatabase)
+	SpannerDatabase.Spec.Migrations = []spannercontroller.SpannerDatabaseMigration{
+		{Version: 1, Description: "singers", Statements: []string{"CREATE TABLE Singers (SingerId INT64, Name STRING(MAX)) PRIMARY KEY (SingerId)"}},
+		{Version: 2, Description: "albums", Statements: []string{"CREATE TABLE Albums (AlbumId INT64) PRIMARY KEY (AlbumId)"}},
+	}
+	f.createInstance("testing")
+	f.applyMigration("test", operator.NewMigration(1, "singers", []string{"CREATE TABLE Singers (SingerId INT64) PRIMARY KEY (SingerId)"}))
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	c, _, _ := f.newController()
+	if err := c.syncHandler(context.Background(), getKey(SpannerDatabase, t)); err != nil {
+		t.Fatal(err)
+	}
+	updated := f.updatedDatabases()
+	if len(updated) != 1 || !conditionTrue(updated[0].Status, spannercontroller.SpannerDatabaseError) || updated[0].Status.Conditions[0].Reason != ErrMigrationRefused {
+		t.Fatalf("expected only an Error condition with reason %s to be reported, got %+v", ErrMigrationRefused, updated)
+	}
+	if updated[0].Status.PendingOperation != "" {
+		t.Errorf("expected no migration to be applied, got %s", updated[0].Status.PendingOperation)
+	}
+}
+
+func TestReportsDegradedOnFailedDdl(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	manage(SpannerDatabase)
+	SpannerDatabase.Generation = 2
+	SpannerDatabase.Spec.Ddl = &spannercontroller.SpannerDatabaseDdl{
+		Statements: []string{"CREATE TABLE Singers (SingerId INT64) PRIMARY KEY (SingerId)"},
+	}
+	f.createInstance("testing")
+	if _, err := f.op.CreateDatabase(context.Background(), "testing", "test", nil); err != nil {
+		t.Fatal(err)
+	}
+	opErr := status.Error(codes.FailedPrecondition, "duplicate name in schema: Singers")
+	f.op.FailNextOperation(opErr)
+	if _, err := f.op.UpdateDatabaseDdl(context.Background(), "testing", "test", SpannerDatabase.Spec.Ddl.Statements); err != nil {
+		t.Fatal(err)
+	}
+	SpannerDatabase.Status.PendingOperation = "projects/test/instances/testing/databases/test/operations/mock_update_database_ddl"
+	SpannerDatabase.Status.PendingDdl = SpannerDatabase.Spec.Ddl.Statements
+	SpannerDatabase.Status.ObservedGeneration = 1
+	SpannerDatabase.Status.Conditions = syncedConditions(f.now.Add(-time.Hour))
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	expDatabase := SpannerDatabase.DeepCopy()
+	expDatabase.Status.PendingOperation = ""
+	expDatabase.Status.PendingDdl = nil
+	expDatabase.Status.ObservedGeneration = 2
+	message := fmt.Sprintf(MessageOperationFailed, SpannerDatabase.Status.PendingOperation, opErr)
+	setCondition(&expDatabase.Status, spannercontroller.SpannerDatabaseDegraded, corev1.ConditionTrue, ErrOperationFailed, message, f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
+
+	f.runExpectError(getKey(SpannerDatabase, t))
+}
+
+// createBackup makes the operator hold the backup backupId of the database
+// name, and returns a ready SpannerBackup for it.
+func (f *fixture) createBackup(backupId string, name string) *backupv1alpha1.SpannerBackup {
+	ctx := context.Background()
+	if _, err := f.op.GetDatabase(ctx, "testing", name); f.op.IsNotFoundError(err) {
+		if _, err := f.op.CreateDatabase(ctx, "testing", name, nil); err != nil {
+			f.t.Fatal(err)
+		}
+	}
+	if _, err := f.op.CreateBackup(ctx, "testing", backupId, name, time.Now().Add(24*time.Hour), time.Time{}); err != nil {
+		f.t.Fatal(err)
+	}
+	return &backupv1alpha1.SpannerBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: backupId, Namespace: metav1.NamespaceDefault},
+		Spec: backupv1alpha1.SpannerBackupSpec{
+			InstanceId: "testing",
+			Database:   name,
+		},
+		Status: backupv1alpha1.SpannerBackupStatus{
+			BackupName: operator.BackupName("test", "testing", backupId),
+			State:      "READY",
+		},
+	}
+}
+
+func TestRestoresDatabaseFromSpannerBackup(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	SpannerDatabase.Spec.RestoreFrom = &spannercontroller.SpannerDatabaseRestoreSource{
+		BackupRef: &corev1.LocalObjectReference{Name: "prod-backup"},
+	}
+	f.createInstance("testing")
+	f.spannerBackupLister = append(f.spannerBackupLister, f.createBackup("prod-backup", "prod"))
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	expDatabase := f.expectClaimAction(SpannerDatabase, "test")
+	expDatabase.Status.PendingOperation = "projects/test/instances/testing/databases/test/operations/mock_restore_database"
+	expDatabase.Status.Restore = &spannercontroller.SpannerDatabaseRestoreStatus{
+		Phase: spannercontroller.RestorePhaseRestoring,
+	}
+	expDatabase.Status.Conditions = provisioningConditions(ReasonRestoring, fmt.Sprintf(MessageRestoring, expDatabase.Status.DatabaseName, "prod-backup"), f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
+
+	f.run(getKey(SpannerDatabase, t))
+}
+
+func TestWaitsForSpannerBackup(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	SpannerDatabase.Spec.RestoreFrom = &spannercontroller.SpannerDatabaseRestoreSource{
+		BackupRef: &corev1.LocalObjectReference{Name: "prod-backup"},
+	}
+	f.createInstance("testing")
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	f.expectClaimAction(SpannerDatabase, "test")
+	f.run(getKey(SpannerDatabase, t))
+	if _, err := f.op.GetDatabase(context.Background(), "testing", "test"); !f.op.IsNotFoundError(err) {
+		t.Errorf("expected database not to be restored, got %v", err)
+	}
+}
+
+func TestRejectsInvalidRestoreSource(t *testing.T) {
+	for name, source := range map[string]spannercontroller.SpannerDatabaseRestoreSource{
+		"no backup":        {},
+		"two backups":      {Backup: "prod-backup", BackupRef: &corev1.LocalObjectReference{Name: "prod-backup"}},
+		"invalid backup":   {Backup: "Prod-backup"},
+		"invalid instance": {Backup: "prod-backup", InstanceId: "prod_1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := newFixture(t)
+			SpannerDatabase := newSpannerDatabase("test", "testing")
+			SpannerDatabase.Spec.RestoreFrom = source.DeepCopy()
+
+			f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+			f.objects = append(f.objects, SpannerDatabase)
+
+			expDatabase := SpannerDatabase.DeepCopy()
+			message := fmt.Sprintf(MessageInvalidRestoreSource, validateRestoreSource(&source))
+			setCondition(&expDatabase.Status, spannercontroller.SpannerDatabaseError, corev1.ConditionTrue, ErrInvalidRestoreSource, message, f.now)
+			f.expectUpdateFooStatusAction(expDatabase)
+			f.run(getKey(SpannerDatabase, t))
+		})
+	}
+}
+
+func TestRejectsSpannerBackupOfAnotherProject(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	SpannerDatabase.Spec.RestoreFrom = &spannercontroller.SpannerDatabaseRestoreSource{
+		BackupRef: &corev1.LocalObjectReference{Name: "prod-backup"},
+	}
+	f.createInstance("testing")
+	spannerBackup := f.createBackup("prod-backup", "prod")
+	spannerBackup.Spec.ProjectId = "production"
+	spannerBackup.Status.BackupName = operator.BackupName("production", "testing", "prod-backup")
+	f.spannerBackupLister = append(f.spannerBackupLister, spannerBackup)
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	expDatabase := f.expectClaimAction(SpannerDatabase, "test")
+	err := fmt.Errorf("backup %s is not in project test of the database", spannerBackup.Status.BackupName)
+	setCondition(&expDatabase.Status, spannercontroller.SpannerDatabaseError, corev1.ConditionTrue, ErrInvalidRestoreSource, fmt.Sprintf(MessageInvalidRestoreSource, err), f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
+	f.run(getKey(SpannerDatabase, t))
+
+	if _, err := f.op.GetDatabase(context.Background(), "testing", "test"); !f.op.IsNotFoundError(err) {
+		t.Errorf("expected database not to be restored, got %v", err)
+	}
+}
+
+func TestFinishesRestore(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	manage(SpannerDatabase)
+	SpannerDatabase.Spec.RestoreFrom = &spannercontroller.SpannerDatabaseRestoreSource{
+		Backup: "prod-backup",
+	}
+	SpannerDatabase.Status.PendingOperation = "projects/test/instances/testing/databases/test/operations/mock_restore_database"
+	SpannerDatabase.Status.Restore = &spannercontroller.SpannerDatabaseRestoreStatus{
+		Phase: spannercontroller.RestorePhaseRestoring,
+	}
+	f.createInstance("testing")
+	f.createBackup("prod-backup", "prod")
+	if _, err := f.op.RestoreDatabase(context.Background(), "testing", "test", "testing", "prod-backup"); err != nil {
+		t.Fatal(err)
+	}
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	expDatabase := SpannerDatabase.DeepCopy()
+	expDatabase.Status.PendingOperation = ""
+	expDatabase.Status.Restore.Phase = spannercontroller.RestorePhaseOptimizing
+	f.expectUpdateFooStatusAction(expDatabase)
+	expDatabase = expDatabase.DeepCopy()
+	expDatabase.Status.Restore = &spannercontroller.SpannerDatabaseRestoreStatus{
+		Backup:          "projects/test/instances/testing/backups/prod-backup",
+		Phase:           spannercontroller.RestorePhaseDone,
+		ProgressPercent: 100,
+	}
+	expDatabase.Status.Conditions = syncedConditions(f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
+
+	f.run(getKey(SpannerDatabase, t))
+}
+
+// A restore whose operation Spanner no longer keeps is done once its
+// database is there.
+func TestFinishesRestoreOfMissingOperation(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	manage(SpannerDatabase)
+	SpannerDatabase.Spec.RestoreFrom = &spannercontroller.SpannerDatabaseRestoreSource{
+		Backup: "prod-backup",
+	}
+	SpannerDatabase.Status.PendingOperation = "projects/test/instances/testing/databases/test/operations/expired"
+	SpannerDatabase.Status.Restore = &spannercontroller.SpannerDatabaseRestoreStatus{
+		Phase:           spannercontroller.RestorePhaseRestoring,
+		ProgressPercent: 40,
+	}
+	f.createInstance("testing")
+	f.createBackup("prod-backup", "prod")
+	if _, err := f.op.RestoreDatabase(context.Background(), "testing", "test", "testing", "prod-backup"); err != nil {
+		t.Fatal(err)
+	}
+	f.op.FinishOperations()
+	f.op.FailNext("GetOperation", status.Error(codes.NotFound, "operation not found"))
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	expDatabase := SpannerDatabase.DeepCopy()
+	expDatabase.Status.PendingOperation = ""
+	expDatabase.Status.Restore.Phase = spannercontroller.RestorePhaseOptimizing
+	expDatabase.Status.Restore.ProgressPercent = 0
+	f.expectUpdateFooStatusAction(expDatabase)
+	expDatabase = expDatabase.DeepCopy()
+	expDatabase.Status.Restore = &spannercontroller.SpannerDatabaseRestoreStatus{
+		Backup:          "projects/test/instances/testing/backups/prod-backup",
+		Phase:           spannercontroller.RestorePhaseDone,
+		ProgressPercent: 100,
+	}
+	expDatabase.Status.Conditions = syncedConditions(f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
+
+	f.run(getKey(SpannerDatabase, t))
+}
+
+func TestAddsFinalizer(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	SpannerDatabase.Finalizers = nil
+	manage(SpannerDatabase)
+	f.createInstance("testing")
+	if _, err := f.op.CreateDatabase(context.Background(), "testing", "test", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	withFinalizer := SpannerDatabase.DeepCopy()
+	withFinalizer.Finalizers = []string{dropDatabaseFinalizer}
+	f.expectUpdateAction(withFinalizer)
+	expDatabase := withFinalizer.DeepCopy()
+	expDatabase.Status.Conditions = syncedConditions(f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
+	f.run(getKey(SpannerDatabase, t))
+}
+
+// newDeletedSpannerDatabase returns a SpannerDatabase managing its database,
+// marked for deletion and still held by its finalizer.
+func (f *fixture) newDeletedSpannerDatabase(name string, deletionPolicy string) *spannercontroller.SpannerDatabase {
+	SpannerDatabase := newSpannerDatabase(name, "testing")
+	manage(SpannerDatabase)
+	deletionTimestamp := metav1.NewTime(f.now.Add(-time.Minute))
+	SpannerDatabase.DeletionTimestamp = &deletionTimestamp
+	SpannerDatabase.Spec.DeletionPolicy = deletionPolicy
+	SpannerDatabase.Status.Conditions = syncedConditions(f.now.Add(-time.Hour))
+	f.createInstance("testing")
+	if _, err := f.op.CreateDatabase(context.Background(), "testing", name, nil); err != nil {
+		f.t.Fatal(err)
+	}
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+	return SpannerDatabase
+}
+
+// expectDropActions expects the database of SpannerDatabase to be reported
+// as dropped and its finalizer to be removed.
+func (f *fixture) expectDropActions(SpannerDatabase *spannercontroller.SpannerDatabase) {
+	dropping := SpannerDatabase.DeepCopy()
+	setCondition(&dropping.Status, spannercontroller.SpannerDatabaseReady, corev1.ConditionFalse, ReasonDropping, fmt.Sprintf(MessageDropping, SpannerDatabase.Status.DatabaseName), f.now)
+	f.expectUpdateFooStatusAction(dropping)
+	released := dropping.DeepCopy()
+	released.Finalizers = nil
+	f.expectUpdateAction(released)
+}
+
+func TestDropsManagedDatabase(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := f.newDeletedSpannerDatabase("test", "")
+
+	f.expectDropActions(SpannerDatabase)
+	f.run(getKey(SpannerDatabase, t))
+
+	if _, err := f.op.GetDatabase(context.Background(), "testing", "test"); !f.op.IsNotFoundError(err) {
+		t.Errorf("expected database to be dropped, got %v", err)
+	}
+}
+
+func TestDropsDatabaseOfDeletedSpannerInstance(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := f.newDeletedSpannerDatabase("test", "")
+	// The SpannerInstance is gone, the database is found by its status.
+	SpannerDatabase.Spec.InstanceId = ""
+	SpannerDatabase.Spec.InstanceRef = &spannercontroller.SpannerInstanceReference{Name: "main"}
+
+	f.expectDropActions(SpannerDatabase)
+	f.run(getKey(SpannerDatabase, t))
+
+	if _, err := f.op.GetDatabase(context.Background(), "testing", "test"); !f.op.IsNotFoundError(err) {
+		t.Errorf("expected database to be dropped, got %v", err)
+	}
+}
+
+func TestRetainsDatabase(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := f.newDeletedSpannerDatabase("test", spannercontroller.DeletionPolicyRetain)
+
+	released := SpannerDatabase.DeepCopy()
+	released.Finalizers = nil
+	f.expectUpdateAction(released)
+	f.run(getKey(SpannerDatabase, t))
+
+	if _, err := f.op.GetDatabase(context.Background(), "testing", "test"); err != nil {
+		t.Errorf("expected database to be kept, got %v", err)
+	}
+}
+
+func TestKeepsUnmanagedDatabase(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := f.newDeletedSpannerDatabase("test", "")
+	SpannerDatabase.Status.DatabaseName = ""
+
+	released := SpannerDatabase.DeepCopy()
+	released.Finalizers = nil
+	f.expectUpdateAction(released)
+	f.run(getKey(SpannerDatabase, t))
+
+	if _, err := f.op.GetDatabase(context.Background(), "testing", "test"); err != nil {
+		t.Errorf("expected database to be kept, got %v", err)
+	}
+}
+
+func TestRejectsUnknownDeletionPolicy(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := f.newDeletedSpannerDatabase("test", "Snapshot")
+
+	expDatabase := SpannerDatabase.DeepCopy()
+	message := fmt.Sprintf(MessageInvalidDeletionPolicy, validateDeletionPolicy("Snapshot"))
+	setCondition(&expDatabase.Status, spannercontroller.SpannerDatabaseError, corev1.ConditionTrue, ErrInvalidDeletionPolicy, message, f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
+	f.run(getKey(SpannerDatabase, t))
+
+	if _, err := f.op.GetDatabase(context.Background(), "testing", "test"); err != nil {
+		t.Errorf("expected database to be kept until the policy is fixed, got %v", err)
+	}
+}
+
+func TestBacksUpBeforeDropping(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := f.newDeletedSpannerDatabase("test", spannercontroller.DeletionPolicyBackupThenDelete)
+	backupId := "test-deleted-20190531-235900"
+	backupName := "projects/test/instances/testing/backups/" + backupId
+
+	expDatabase := SpannerDatabase.DeepCopy()
+	expDatabase.Status.PendingOperation = backupName + "/operations/mock_create_backup"
+	setCondition(&expDatabase.Status, spannercontroller.SpannerDatabaseReady, corev1.ConditionFalse, ReasonBackingUp, fmt.Sprintf(MessageBackingUp, SpannerDatabase.Status.DatabaseName, backupId), f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
+	f.run(getKey(SpannerDatabase, t))
+
+	backup, err := f.op.GetBackup(context.Background(), "testing", backupId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := SpannerDatabase.DeletionTimestamp.Add(deletionBackupRetention); !backup.ExpireTime.AsTime().Equal(want) {
+		t.Errorf("expected backup to expire at %v, got %v", want, backup.ExpireTime.AsTime())
+	}
+	if _, err := f.op.GetDatabase(context.Background(), "testing", "test"); err != nil {
+		t.Errorf("expected database to be kept until backed up, got %v", err)
+	}
+}
+
+func TestDropsDatabaseOnceBackedUp(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := f.newDeletedSpannerDatabase("test", spannercontroller.DeletionPolicyBackupThenDelete)
+	backupId := deletionBackupId(SpannerDatabase, "test")
+	if _, err := f.op.CreateBackup(context.Background(), "testing", backupId, "test", f.now.Add(deletionBackupRetention), time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	f.expectDropActions(SpannerDatabase)
+	f.run(getKey(SpannerDatabase, t))
+
+	if _, err := f.op.GetDatabase(context.Background(), "testing", "test"); !f.op.IsNotFoundError(err) {
+		t.Errorf("expected database to be dropped, got %v", err)
+	}
+	if _, err := f.op.GetBackup(context.Background(), "testing", backupId); err != nil {
+		t.Errorf("expected backup to be kept, got %v", err)
+	}
+}
+
+func TestMirrorsDeletionProtection(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := newSpannerDatabase("test", "testing")
+	SpannerDatabase.Spec.DeletionProtection = true
+	manage(SpannerDatabase)
+	f.createInstance("testing")
+	if _, err := f.op.CreateDatabase(context.Background(), "testing", "test", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, SpannerDatabase)
+	f.objects = append(f.objects, SpannerDatabase)
+
+	expDatabase := SpannerDatabase.DeepCopy()
+	expDatabase.Status.PendingOperation = SpannerDatabase.Status.DatabaseName + "/operations/mock_update_database"
+	f.expectUpdateFooStatusAction(expDatabase)
+	f.run(getKey(SpannerDatabase, t))
+
+	db, err := f.op.GetDatabase(context.Background(), "testing", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !db.EnableDropProtection {
+		t.Error("expected drop protection to be enabled")
+	}
+}
+
+func TestBlocksDeletionOfProtectedDatabase(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := f.newDeletedSpannerDatabase("test", "")
+	SpannerDatabase.Spec.DeletionProtection = true
+
+	expDatabase := SpannerDatabase.DeepCopy()
+	message := fmt.Sprintf(MessageDeletionProtected, SpannerDatabase.Status.DatabaseName)
+	setCondition(&expDatabase.Status, spannercontroller.SpannerDatabaseDeletionBlocked, corev1.ConditionTrue, ErrDeletionProtected, message, f.now)
+	f.expectUpdateFooStatusAction(expDatabase)
+	f.run(getKey(SpannerDatabase, t))
+
+	if _, err := f.op.GetDatabase(context.Background(), "testing", "test"); err != nil {
+		t.Errorf("expected protected database to be kept, got %v", err)
+	}
+}
+
+func TestDisablesDropProtectionBeforeDropping(t *testing.T) {
+	f := newFixture(t)
+	SpannerDatabase := f.newDeletedSpannerDatabase("test", "")
+	if _, err := f.op.SetDropProtection(context.Background(), "testing", "test", true); err != nil {
+		t.Fatal(err)
+	}
+
+	expDatabase := SpannerDatabase.DeepCopy()
+	expDatabase.Status.PendingOperation = SpannerDatabase.Status.DatabaseName + "/operations/mock_update_database"
+	f.expectUpdateFooStatusAction(expDatabase)
+	f.run(getKey(SpannerDatabase, t))
+
+	db, err := f.op.GetDatabase(context.Background(), "testing", "test")
+	if err != nil {
+		t.Fatalf("expected database to be kept until unprotected, got %v", err)
+	}
+	if db.EnableDropProtection {
+		t.Error("expected drop protection to be disabled")
+	}
 }
 
 func int32Ptr(i int32) *int32 { return &i }
@@ -0,0 +1,76 @@
+package databaseadmins
+
+import (
+	"fmt"
+	"strings"
+
+	databasev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+)
+
+// ddlStatements resolves the DDL statements declared on spannerDatabase,
+// reading the referenced ConfigMap key if there is one.
+func (c *Controller) ddlStatements(spannerDatabase *databasev1alpha1.SpannerDatabase) ([]string, error) {
+	ddl := spannerDatabase.Spec.Ddl
+	if ddl == nil {
+		return nil, nil
+	}
+	var statements []string
+	for _, s := range ddl.Statements {
+		statements = append(statements, splitStatements(s)...)
+	}
+	if ref := ddl.ConfigMapRef; ref != nil {
+		cm, err := c.configMapLister.ConfigMaps(spannerDatabase.Namespace).Get(ref.Name)
+		if err != nil {
+			if ref.Optional != nil && *ref.Optional {
+				return statements, nil
+			}
+			return nil, err
+		}
+		script, ok := cm.Data[ref.Key]
+		if !ok {
+			if ref.Optional != nil && *ref.Optional {
+				return statements, nil
+			}
+			return nil, fmt.Errorf("key %q not found in configmap %s/%s", ref.Key, cm.Namespace, cm.Name)
+		}
+		statements = append(statements, splitStatements(script)...)
+	}
+	return statements, nil
+}
+
+// splitStatements splits a ';' separated DDL script into trimmed statements.
+// Semicolons inside string literals are not supported.
+func splitStatements(script string) []string {
+	var statements []string
+	for _, s := range strings.Split(script, ";") {
+		if s = strings.TrimSpace(s); s != "" {
+			statements = append(statements, s)
+		}
+	}
+	return statements
+}
+
+// unappliedStatements returns the statements of desired that are not in
+// applied, keeping their order. DDL cannot be rolled back, so an applied
+// statement that was edited or removed from desired is an error: submitting
+// the edited one would change the schema twice.
+func unappliedStatements(desired []string, applied []string) ([]string, error) {
+	declared := make(map[string]bool, len(desired))
+	for _, s := range desired {
+		declared[s] = true
+	}
+	done := make(map[string]bool, len(applied))
+	for _, s := range applied {
+		if !declared[s] {
+			return nil, fmt.Errorf("applied statement %q was edited or removed", s)
+		}
+		done[s] = true
+	}
+	var pending []string
+	for _, s := range desired {
+		if !done[s] {
+			pending = append(pending, s)
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,174 @@
+package databaseadmins
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+
+	databasev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+// dropDatabaseFinalizer keeps a SpannerDatabase around until its database
+// has been dropped, or kept as its deletion policy tells.
+const dropDatabaseFinalizer = "databaseadmins.spanner-operator.io/drop-database"
+
+// deletionBackupRetention is how long the backup taken of a database dropped
+// with DeletionPolicyBackupThenDelete is kept by Spanner.
+const deletionBackupRetention = 30 * 24 * time.Hour
+
+// deletionBackupTimeLayout formats the deletion time in the ID of the backup
+// taken before dropping a database.
+const deletionBackupTimeLayout = "20060102-150405"
+
+// validateDeletionPolicy checks the deletion policy of a SpannerDatabase.
+func validateDeletionPolicy(policy string) error {
+	switch policy {
+	case "", databasev1alpha1.DeletionPolicyDelete, databasev1alpha1.DeletionPolicyRetain, databasev1alpha1.DeletionPolicyBackupThenDelete:
+		return nil
+	}
+	return fmt.Errorf("%q is not one of %s, %s or %s", policy,
+		databasev1alpha1.DeletionPolicyDelete, databasev1alpha1.DeletionPolicyRetain, databasev1alpha1.DeletionPolicyBackupThenDelete)
+}
+
+// deletionBackupId returns the ID of the backup taken of the database
+// databaseId of spannerDatabase before dropping it. It only depends on the
+// deletion time, so a backup is never taken twice.
+func deletionBackupId(spannerDatabase *databasev1alpha1.SpannerDatabase, databaseId string) string {
+	return fmt.Sprintf("%s-deleted-%s", databaseId, spannerDatabase.DeletionTimestamp.UTC().Format(deletionBackupTimeLayout))
+}
+
+// finalize cleans up the Spanner database of the deleted spannerDatabase as
+// its deletion policy tells, then removes the finalizer. Databases the
+// SpannerDatabase never created nor adopted are left alone.
+func (c *Controller) finalize(ctx context.Context, key string, spannerDatabase *databasev1alpha1.SpannerDatabase) error {
+	// The database is found through the status, as the SpannerInstance
+	// named by spec.instanceRef may be deleted first.
+	databaseName := spannerDatabase.Status.DatabaseName
+	projectId, instanceId, databaseId, ok := operator.ParseDatabaseName(databaseName)
+	if !ok {
+		return c.removeFinalizer(spannerDatabase)
+	}
+	if spannerDatabase.Spec.DeletionPolicy == databasev1alpha1.DeletionPolicyRetain {
+		c.recorder.Event(spannerDatabase, corev1.EventTypeNormal, SuccessRetained, fmt.Sprintf(MessageResourceRetained, databaseName))
+		return c.removeFinalizer(spannerDatabase)
+	}
+
+	op, err := c.operatorFor(spannerDatabase, projectId)
+	if err != nil {
+		return err
+	}
+	db, err := op.GetDatabase(ctx, instanceId, databaseId)
+	if err != nil && op.IsNotFoundError(err) {
+		return c.removeFinalizer(spannerDatabase)
+	} else if err != nil {
+		return err
+	}
+	if db.EnableDropProtection {
+		// The protection was turned off in the spec before being mirrored
+		// to the database, which Spanner would refuse to drop.
+		log.Printf("Disable drop protection of database %s", databaseName)
+		opName, err := op.SetDropProtection(ctx, instanceId, databaseId, false)
+		if err != nil {
+			return err
+		}
+		return c.trackOperation(key, spannerDatabase, opName)
+	}
+
+	if spannerDatabase.Spec.DeletionPolicy == databasev1alpha1.DeletionPolicyBackupThenDelete {
+		backupId := deletionBackupId(spannerDatabase, databaseId)
+		backup, err := op.GetBackup(ctx, instanceId, backupId)
+		if err != nil && op.IsNotFoundError(err) {
+			log.Printf("Back up database %s to %s before dropping it", databaseName, backupId)
+			expireTime := spannerDatabase.DeletionTimestamp.Add(deletionBackupRetention)
+			opName, err := op.CreateBackup(ctx, instanceId, backupId, databaseId, expireTime, time.Time{})
+			if err != nil {
+				return err
+			}
+			spannerDatabaseCopy := spannerDatabase.DeepCopy()
+			setCondition(&spannerDatabaseCopy.Status, databasev1alpha1.SpannerDatabaseReady, corev1.ConditionFalse, ReasonBackingUp, fmt.Sprintf(MessageBackingUp, databaseName, backupId), c.now())
+			return c.trackOperation(key, spannerDatabaseCopy, opName)
+		} else if err != nil {
+			return err
+		}
+		if backup.GetState() != database.Backup_READY {
+			c.workqueue.AddAfter(key, operationPollInterval)
+			return nil
+		}
+		c.recorder.Event(spannerDatabase, corev1.EventTypeNormal, SuccessBackedUp, fmt.Sprintf(MessageResourceBackedUp, databaseName, backup.Name))
+	}
+
+	spannerDatabaseCopy := spannerDatabase.DeepCopy()
+	message := fmt.Sprintf(MessageDropping, databaseName)
+	changed := setCondition(&spannerDatabaseCopy.Status, databasev1alpha1.SpannerDatabaseReady, corev1.ConditionFalse, ReasonDropping, message, c.now())
+	if conditionTrue(spannerDatabaseCopy.Status, databasev1alpha1.SpannerDatabaseDeletionBlocked) {
+		changed = setCondition(&spannerDatabaseCopy.Status, databasev1alpha1.SpannerDatabaseDeletionBlocked, corev1.ConditionFalse, ReasonDropping, message, c.now()) || changed
+	}
+	if changed {
+		spannerDatabase, err = c.updateSpannerDatabaseStatus(spannerDatabaseCopy)
+		if err != nil {
+			return err
+		}
+	}
+	log.Printf("Drop database %s", databaseName)
+	if err := op.DropDatabase(ctx, instanceId, databaseId); err != nil {
+		return err
+	}
+	c.recorder.Event(spannerDatabase, corev1.EventTypeNormal, SuccessDropped, fmt.Sprintf(MessageResourceDropped, databaseName))
+	return c.removeFinalizer(spannerDatabase)
+}
+
+// blockDeletion reports that the deleted spannerDatabase is kept by its
+// deletion protection, through a warning Event and the DeletionBlocked
+// condition.
+func (c *Controller) blockDeletion(spannerDatabase *databasev1alpha1.SpannerDatabase) error {
+	databaseName := spannerDatabase.Status.DatabaseName
+	if databaseName == "" {
+		databaseName = spannerDatabase.Name
+	}
+	message := fmt.Sprintf(MessageDeletionProtected, databaseName)
+	c.recorder.Event(spannerDatabase, corev1.EventTypeWarning, ErrDeletionProtected, message)
+	spannerDatabaseCopy := spannerDatabase.DeepCopy()
+	changed := setCondition(&spannerDatabaseCopy.Status, databasev1alpha1.SpannerDatabaseDeletionBlocked, corev1.ConditionTrue, ErrDeletionProtected, message, c.now())
+	if !changed && spannerDatabase.Status.ObservedGeneration == spannerDatabase.Generation {
+		return nil
+	}
+	_, err := c.updateSpannerDatabaseStatus(spannerDatabaseCopy)
+	return err
+}
+
+// removeFinalizer lets the API server delete spannerDatabase.
+func (c *Controller) removeFinalizer(spannerDatabase *databasev1alpha1.SpannerDatabase) error {
+	spannerDatabaseCopy := spannerDatabase.DeepCopy()
+	spannerDatabaseCopy.Finalizers = removeString(spannerDatabaseCopy.Finalizers, dropDatabaseFinalizer)
+	_, err := c.spannerclientset.DatabaseadminsV1alpha1().SpannerDatabases(spannerDatabase.Namespace).Update(spannerDatabaseCopy)
+	return err
+}
+
+// HasFinalizer reports whether spannerDatabase holds dropDatabaseFinalizer,
+// i.e. its database has not been dropped or released yet.
+func HasFinalizer(spannerDatabase *databasev1alpha1.SpannerDatabase) bool {
+	for _, f := range spannerDatabase.Finalizers {
+		if f == dropDatabaseFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// removeString returns a copy of s without any occurrence of r.
+func removeString(s []string, r string) []string {
+	var result []string
+	for _, v := range s {
+		if v != r {
+			result = append(result, v)
+		}
+	}
+	return result
+}
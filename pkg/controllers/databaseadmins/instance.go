@@ -0,0 +1,189 @@
+package databaseadmins
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	databasev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+	instancev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/instanceadmins/v1alpha1"
+
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+// InstanceIndex indexes SpannerDatabases by the instance they belong to: the
+// namespace/name key of the SpannerInstance of spec.instanceRef, or else the
+// fully qualified name of the instance of spec.instanceId.
+const InstanceIndex = "instance"
+
+// AddInstanceIndexer adds InstanceIndex to informer, an informer of
+// SpannerDatabases, unless a controller sharing the informer already did.
+// Instances named without project belong to the default project of
+// operators.
+func AddInstanceIndexer(informer cache.SharedIndexInformer, operators *operator.Pool) error {
+	if _, ok := informer.GetIndexer().GetIndexers()[InstanceIndex]; ok {
+		return nil
+	}
+	return informer.AddIndexers(cache.Indexers{InstanceIndex: func(obj interface{}) ([]string, error) {
+		spannerDatabase, ok := obj.(*databasev1alpha1.SpannerDatabase)
+		if !ok {
+			return nil, nil
+		}
+		if spannerDatabase.Spec.InstanceRef != nil {
+			return []string{instanceRefKey(spannerDatabase)}, nil
+		}
+		return []string{operator.InstanceName(operators.ProjectId(spannerDatabase.Spec.ProjectId), spannerDatabase.Spec.InstanceId)}, nil
+	}})
+}
+
+// instanceRefKey returns the namespace/name key of the SpannerInstance
+// referenced by spannerDatabase.
+func instanceRefKey(spannerDatabase *databasev1alpha1.SpannerDatabase) string {
+	ref := spannerDatabase.Spec.InstanceRef
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = spannerDatabase.Namespace
+	}
+	return namespace + "/" + ref.Name
+}
+
+// instanceOf returns the project and ID of the Spanner instance the database
+// of spannerDatabase belongs to, along with the SpannerInstance referenced by
+// spec.instanceRef. The SpannerInstance is nil when the spec names the
+// instance by ID, or does not exist. It reports false while the referenced
+// SpannerInstance does not exist or is not ready yet.
+func (c *Controller) instanceOf(spannerDatabase *databasev1alpha1.SpannerDatabase) (*instancev1alpha1.SpannerInstance, string, string, bool, error) {
+	if spannerDatabase.Spec.InstanceRef == nil {
+		return nil, spannerDatabase.Spec.ProjectId, spannerDatabase.Spec.InstanceId, true, nil
+	}
+	namespace, name, err := cache.SplitMetaNamespaceKey(instanceRefKey(spannerDatabase))
+	if err != nil {
+		return nil, "", "", false, err
+	}
+	spannerInstance, err := c.spannerInstanceLister.SpannerInstances(namespace).Get(name)
+	if errors.IsNotFound(err) {
+		return nil, "", "", false, nil
+	}
+	if err != nil {
+		return nil, "", "", false, err
+	}
+	// The instance is only known once the SpannerInstance claimed it.
+	projectId, instanceId, ok := operator.ParseInstanceName(spannerInstance.Status.InstanceName)
+	if !ok || !instanceReady(spannerInstance) {
+		return spannerInstance, "", "", false, nil
+	}
+	return spannerInstance, projectId, instanceId, true, nil
+}
+
+// instanceReady reports whether the Ready condition of spannerInstance is true.
+func instanceReady(spannerInstance *instancev1alpha1.SpannerInstance) bool {
+	for _, cond := range spannerInstance.Status.Conditions {
+		if cond.Type == instancev1alpha1.SpannerInstanceReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// waitForInstance reports that spannerDatabase waits for the instance, a
+// SpannerInstance key or an instance name, through an Event and its Ready
+// condition. handleSpannerInstance enqueues it again once the instance is
+// ready.
+func (c *Controller) waitForInstance(spannerDatabase *databasev1alpha1.SpannerDatabase, instance string) error {
+	message := fmt.Sprintf(MessageWaitingForInstance, instance)
+	c.recorder.Event(spannerDatabase, corev1.EventTypeNormal, WaitingForInstance, message)
+	spannerDatabaseCopy := spannerDatabase.DeepCopy()
+	changed := setCondition(&spannerDatabaseCopy.Status, databasev1alpha1.SpannerDatabaseReady, corev1.ConditionFalse, WaitingForInstance, message, c.now())
+	if !changed && spannerDatabase.Status.ObservedGeneration == spannerDatabase.Generation {
+		return nil
+	}
+	_, err := c.updateSpannerDatabaseStatus(spannerDatabaseCopy)
+	return err
+}
+
+// handleSpannerInstance enqueues every SpannerDatabase belonging to the
+// SpannerInstance, through its key or the instance it claimed.
+func (c *Controller) handleSpannerInstance(obj interface{}) {
+	object, ok := decodeObject(obj)
+	if !ok {
+		return
+	}
+	keys := []string{object.GetNamespace() + "/" + object.GetName()}
+	if spannerInstance, ok := object.(*instancev1alpha1.SpannerInstance); ok && spannerInstance.Status.InstanceName != "" {
+		keys = append(keys, spannerInstance.Status.InstanceName)
+	}
+	for _, key := range keys {
+		spannerDatabases, err := c.spannerDatabaseIndexer.ByIndex(InstanceIndex, key)
+		if err != nil {
+			utilruntime.HandleError(err)
+			return
+		}
+		for _, spannerDatabase := range spannerDatabases {
+			c.enqueueSpannerDatabase(spannerDatabase)
+		}
+	}
+}
+
+// updateSpannerInstance enqueues the SpannerDatabases of a SpannerInstance
+// that becomes ready, or now claims another instance.
+func (c *Controller) updateSpannerInstance(old, new interface{}) {
+	oldInstance, ok := old.(*instancev1alpha1.SpannerInstance)
+	if !ok {
+		return
+	}
+	newInstance, ok := new.(*instancev1alpha1.SpannerInstance)
+	if !ok || !instanceReady(newInstance) {
+		return
+	}
+	if instanceReady(oldInstance) && oldInstance.Status.InstanceName == newInstance.Status.InstanceName {
+		return
+	}
+	c.handleSpannerInstance(newInstance)
+}
+
+// syncOwner makes spannerInstance the only SpannerInstance owning
+// spannerDatabase, so deleting it deletes the SpannerDatabase too. A nil
+// spannerInstance, or one in another namespace, which Kubernetes does not
+// allow as owner, leaves spannerDatabase without SpannerInstance owner.
+func (c *Controller) syncOwner(spannerDatabase *databasev1alpha1.SpannerDatabase, spannerInstance *instancev1alpha1.SpannerInstance) (*databasev1alpha1.SpannerDatabase, error) {
+	if spannerInstance != nil && spannerInstance.Namespace != spannerDatabase.Namespace {
+		spannerInstance = nil
+	}
+	var owners []metav1.OwnerReference
+	owned := false
+	changed := false
+	for _, owner := range spannerDatabase.OwnerReferences {
+		if !isSpannerInstanceOwner(owner) {
+			owners = append(owners, owner)
+		} else if spannerInstance != nil && owner.UID == spannerInstance.UID && !owned {
+			owners = append(owners, owner)
+			owned = true
+		} else {
+			changed = true
+		}
+	}
+	if spannerInstance != nil && !owned {
+		owners = append(owners, metav1.OwnerReference{
+			APIVersion: instancev1alpha1.SchemeGroupVersion.String(),
+			Kind:       "SpannerInstance",
+			Name:       spannerInstance.Name,
+			UID:        spannerInstance.UID,
+		})
+		changed = true
+	}
+	if !changed {
+		return spannerDatabase, nil
+	}
+	spannerDatabaseCopy := spannerDatabase.DeepCopy()
+	spannerDatabaseCopy.OwnerReferences = owners
+	return c.spannerclientset.DatabaseadminsV1alpha1().SpannerDatabases(spannerDatabase.Namespace).Update(spannerDatabaseCopy)
+}
+
+// isSpannerInstanceOwner reports whether owner references a SpannerInstance.
+func isSpannerInstanceOwner(owner metav1.OwnerReference) bool {
+	return owner.Kind == "SpannerInstance" && owner.APIVersion == instancev1alpha1.SchemeGroupVersion.String()
+}
@@ -0,0 +1,96 @@
+package databaseadmins
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	databasev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+// migrationKeyPattern matches the ConfigMap keys holding migrations.
+var migrationKeyPattern = regexp.MustCompile(`^(\d+)_(.*)\.sql$`)
+
+// declaredMigrations returns the migrations declared on spannerDatabase,
+// inline and in the referenced ConfigMap, sorted by version.
+func (c *Controller) declaredMigrations(spannerDatabase *databasev1alpha1.SpannerDatabase) ([]operator.Migration, error) {
+	var migrations []operator.Migration
+	for _, m := range spannerDatabase.Spec.Migrations {
+		var statements []string
+		for _, s := range m.Statements {
+			statements = append(statements, splitStatements(s)...)
+		}
+		migrations = append(migrations, operator.NewMigration(m.Version, m.Description, statements))
+	}
+	if ref := spannerDatabase.Spec.MigrationsConfigMapRef; ref != nil {
+		cm, err := c.configMapLister.ConfigMaps(spannerDatabase.Namespace).Get(ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		for key, script := range cm.Data {
+			match := migrationKeyPattern.FindStringSubmatch(key)
+			if match == nil {
+				return nil, fmt.Errorf("key %q in configmap %s/%s is not named <version>_<description>.sql", key, cm.Namespace, cm.Name)
+			}
+			version, err := strconv.ParseInt(match[1], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			migrations = append(migrations, operator.NewMigration(version, match[2], splitStatements(script)))
+		}
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i, m := range migrations {
+		if m.Version <= 0 {
+			return nil, fmt.Errorf("migration version must be positive, got %d", m.Version)
+		}
+		if i > 0 && migrations[i-1].Version == m.Version {
+			return nil, fmt.Errorf("migration version %d is declared twice", m.Version)
+		}
+	}
+	return migrations, nil
+}
+
+// nextMigration returns the first declared migration that has not been
+// applied, or nil when the database is up to date. It refuses to go on when
+// an applied migration was edited, or when a migration older than the
+// current version was never applied.
+func nextMigration(declared []operator.Migration, applied []operator.Migration) (*operator.Migration, error) {
+	checksums := make(map[int64]string, len(applied))
+	var current int64
+	for _, m := range applied {
+		checksums[m.Version] = m.Checksum
+		if m.Version > current {
+			current = m.Version
+		}
+	}
+	for i, m := range declared {
+		checksum, ok := checksums[m.Version]
+		if ok {
+			if checksum != m.Checksum {
+				return nil, fmt.Errorf("migration %d was edited after it was applied", m.Version)
+			}
+			continue
+		}
+		if m.Version < current {
+			return nil, fmt.Errorf("migration %d is older than the current version %d and was never applied", m.Version, current)
+		}
+		return &declared[i], nil
+	}
+	return nil, nil
+}
+
+// currentMigrationVersion returns the highest applied migration version.
+func currentMigrationVersion(applied []operator.Migration) int64 {
+	var current int64
+	for _, m := range applied {
+		if m.Version > current {
+			current = m.Version
+		}
+	}
+	return current
+}
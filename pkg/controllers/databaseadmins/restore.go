@@ -0,0 +1,118 @@
+package databaseadmins
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+
+	databasev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+// backupReady is the state of a SpannerBackup that can be restored from.
+const backupReady = "READY"
+
+// restoreDatabase starts restoring the missing database databaseId of
+// spannerDatabase in instanceId from its Spec.RestoreFrom. The declared DDL is expected to be part of the
+// restored schema, so only statements added later are applied on top.
+func (c *Controller) restoreDatabase(ctx context.Context, op operator.Operator, key string, spannerDatabase *databasev1alpha1.SpannerDatabase, instanceId, databaseId string, statements []string) error {
+	projectId, _, _, _ := operator.ParseDatabaseName(spannerDatabase.Status.DatabaseName)
+	backupName, ok, err := c.restoreSource(spannerDatabase, projectId, instanceId)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// handleSpannerBackup enqueues the SpannerDatabase again once the
+		// backup changes.
+		c.recorder.Event(spannerDatabase, corev1.EventTypeNormal, WaitingForBackup, fmt.Sprintf(MessageWaitingForBackup, backupName))
+		return nil
+	}
+	backupProjectId, backupInstanceId, backupId, _ := operator.ParseBackupName(backupName)
+	if backupProjectId != projectId {
+		// Spanner only restores a backup into its own project.
+		err := fmt.Errorf("backup %s is not in project %s of the database", backupName, projectId)
+		return c.rejectSpec(key, spannerDatabase, ErrInvalidRestoreSource, fmt.Sprintf(MessageInvalidRestoreSource, err))
+	}
+	log.Printf("SpannerDatabase does not exists on instance %s, restore it from backup %s/%s", instanceId, backupInstanceId, backupId)
+	opName, err := op.RestoreDatabase(ctx, instanceId, databaseId, backupInstanceId, backupId)
+	if err != nil {
+		return err
+	}
+	spannerDatabaseCopy := spannerDatabase.DeepCopy()
+	spannerDatabaseCopy.Status.PendingDdl = statements
+	spannerDatabaseCopy.Status.AppliedDdl = nil
+	spannerDatabaseCopy.Status.Restore = &databasev1alpha1.SpannerDatabaseRestoreStatus{
+		Phase: databasev1alpha1.RestorePhaseRestoring,
+	}
+	markProvisioning(&spannerDatabaseCopy.Status, ReasonRestoring, fmt.Sprintf(MessageRestoring, spannerDatabase.Status.DatabaseName, backupId), c.now())
+	return c.trackOperation(key, spannerDatabaseCopy, opName)
+}
+
+// validateRestoreSource checks that source, when set, names exactly one
+// backup with valid IDs.
+func validateRestoreSource(source *databasev1alpha1.SpannerDatabaseRestoreSource) error {
+	if source == nil {
+		return nil
+	}
+	if (source.Backup == "") == (source.BackupRef == nil) {
+		return fmt.Errorf("set exactly one of backup and backupRef")
+	}
+	if source.BackupRef != nil {
+		return nil
+	}
+	if source.InstanceId != "" {
+		if err := operator.ValidateInstanceId(source.InstanceId); err != nil {
+			return err
+		}
+	}
+	return operator.ValidateBackupId(source.Backup)
+}
+
+// restoreSource resolves the fully qualified name of the backup
+// spannerDatabase is restored from. A backup ID is looked up in projectId
+// and, unless restoreFrom names another, instanceId. A referenced
+// SpannerBackup gives the name Spanner reported for its backup. It reports
+// false, with the name of the SpannerBackup, while a referenced SpannerBackup
+// does not exist or is not ready yet.
+func (c *Controller) restoreSource(spannerDatabase *databasev1alpha1.SpannerDatabase, projectId, instanceId string) (string, bool, error) {
+	source := spannerDatabase.Spec.RestoreFrom
+	if source.BackupRef == nil {
+		if source.InstanceId != "" {
+			instanceId = source.InstanceId
+		}
+		return operator.BackupName(projectId, instanceId, source.Backup), true, nil
+	}
+	spannerBackup, err := c.spannerBackupLister.SpannerBackups(spannerDatabase.Namespace).Get(source.BackupRef.Name)
+	if errors.IsNotFound(err) {
+		return source.BackupRef.Name, false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	status := spannerBackup.Status
+	if status.PendingOperation != "" || status.State != backupReady || status.BackupName == "" {
+		return spannerBackup.Name, false, nil
+	}
+	return status.BackupName, true, nil
+}
+
+// syncRestoreOptimization returns a copy of spannerDatabase whose restore
+// status reflects the optimization of the restored database db. While it is
+// being optimized, the key is requeued to notice when it is done.
+func (c *Controller) syncRestoreOptimization(key string, spannerDatabase *databasev1alpha1.SpannerDatabase, db *database.Database) *databasev1alpha1.SpannerDatabase {
+	spannerDatabaseCopy := spannerDatabase.DeepCopy()
+	restore := spannerDatabaseCopy.Status.Restore
+	restore.Backup = db.GetRestoreInfo().GetBackupInfo().GetBackup()
+	if db.GetState() == database.Database_READY_OPTIMIZING {
+		c.workqueue.AddAfter(key, operationPollInterval)
+		return spannerDatabaseCopy
+	}
+	restore.Phase = databasev1alpha1.RestorePhaseDone
+	restore.ProgressPercent = 100
+	return spannerDatabaseCopy
+}
@@ -0,0 +1,598 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package databaseroles
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+
+	databasev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+	clientset "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/clientset/versioned"
+	spannerscheme "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/clientset/versioned/scheme"
+	informers "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/informers/externalversions/databaseadmins/v1alpha1"
+	listers "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/listers/databaseadmins/v1alpha1"
+
+	"github.com/katsew/spanner-operator/pkg/credentials"
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+const controllerAgentName = "spanner-controller"
+
+// syncTimeout bounds a single syncHandler call, including every Spanner admin
+// call it makes, so a hung long-running operation cannot pin a worker forever.
+const syncTimeout = 10 * time.Minute
+
+// operationPollInterval is how long to wait before polling a pending
+// long-running operation again.
+const operationPollInterval = 10 * time.Second
+
+// dropRoleFinalizer keeps a SpannerDatabaseRole around until its role has
+// been dropped from the database.
+const dropRoleFinalizer = "databaseadmins.spanner-operator.io/drop-role"
+
+const (
+	// SuccessSynced is used as part of the Event 'reason' when a SpannerDatabaseRole is synced
+	SuccessSynced = "Synced"
+	// WaitingForDatabase is used as part of the Event 'reason' when the
+	// database of a SpannerDatabaseRole does not exist yet.
+	WaitingForDatabase = "WaitingForDatabase"
+
+	// ErrInvalidRole is used as part of the Event 'reason' when the role name
+	// or grants of a SpannerDatabaseRole are not acceptable.
+	ErrInvalidRole = "ErrInvalidRole"
+	// ErrOperationFailed is used as part of the Event 'reason' when a long-running
+	// operation started for a SpannerDatabaseRole finishes with an error.
+	ErrOperationFailed = "ErrOperationFailed"
+	// ErrCredentials is used as part of the Event 'reason' when the
+	// credentials the SpannerDatabase of a SpannerDatabaseRole selects cannot
+	// be read.
+	ErrCredentials = "ErrCredentials"
+
+	// MessageResourceSynced is the message used for an Event fired when a Spanner
+	// is synced successfully
+	MessageResourceSynced = "SpannerDatabaseRole synced successfully"
+	// MessageWaitingForDatabase is the message used for an Event fired when
+	// the database of a SpannerDatabaseRole does not exist yet
+	MessageWaitingForDatabase = "Waiting for database %s to be created"
+	// MessageInvalidRole is the message used for an Event fired when the spec
+	// is rejected
+	MessageInvalidRole = "Invalid role: %v"
+	// MessageOperationFailed is the message used for an Event fired when a
+	// long-running operation fails
+	MessageOperationFailed = "Operation %s failed: %v"
+	// MessageCredentials is the message used for an Event fired when the
+	// credentials of the database cannot be read
+	MessageCredentials = "Invalid credentialsSecretRef of database %s: %v"
+)
+
+// Controller is the controller implementation for SpannerDatabaseRole resources
+type Controller struct {
+	// kubeclientset is a standard kubernetes clientset
+	kubeclientset kubernetes.Interface
+	// spannerclientset is a clientset for our own API group
+	spannerclientset clientset.Interface
+
+	spannerDatabaseRoleLister  listers.SpannerDatabaseRoleLister
+	spannerDatabaseRolesSynced cache.InformerSynced
+	spannerDatabaseLister      listers.SpannerDatabaseLister
+	spannerDatabasesSynced     cache.InformerSynced
+	secretLister               corelisters.SecretLister
+	secretsSynced              cache.InformerSynced
+
+	// workqueue is a rate limited work queue. This is used to queue work to be
+	// processed instead of performing it as soon as a change happens. This
+	// means we can ensure we only process a fixed amount of resources at a
+	// time, and makes it easy to ensure we are never processing the same item
+	// simultaneously in two different workers.
+	workqueue workqueue.RateLimitingInterface
+	// recorder is an event recorder for recording Event resources to the
+	// Kubernetes API.
+	recorder record.EventRecorder
+
+	// operators hands out the Operator of the project of a SpannerDatabase.
+	operators *operator.Pool
+
+	// syncTimeout is the deadline applied to each syncHandler call.
+	syncTimeout time.Duration
+}
+
+// NewController returns a new spanner database role controller
+func NewController(
+	kubeclientset kubernetes.Interface,
+	spannerclientset clientset.Interface,
+	spannerDatabaseRoleInformer informers.SpannerDatabaseRoleInformer,
+	spannerDatabaseInformer informers.SpannerDatabaseInformer,
+	secretInformer coreinformers.SecretInformer,
+	operators *operator.Pool) *Controller {
+
+	// Create event broadcaster
+	// Add spanner-controller types to the default Kubernetes Scheme so Events can be
+	// logged for spanner-controller types.
+	utilruntime.Must(spannerscheme.AddToScheme(scheme.Scheme))
+	klog.V(4).Info("Creating event broadcaster")
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(klog.Infof)
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
+
+	controller := &Controller{
+		kubeclientset:              kubeclientset,
+		spannerclientset:           spannerclientset,
+		spannerDatabaseRoleLister:  spannerDatabaseRoleInformer.Lister(),
+		spannerDatabaseRolesSynced: spannerDatabaseRoleInformer.Informer().HasSynced,
+		spannerDatabaseLister:      spannerDatabaseInformer.Lister(),
+		spannerDatabasesSynced:     spannerDatabaseInformer.Informer().HasSynced,
+		secretLister:               secretInformer.Lister(),
+		secretsSynced:              secretInformer.Informer().HasSynced,
+		workqueue:                  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "SpannerDatabaseRoles"),
+		recorder:                   recorder,
+		operators:                  operators,
+		syncTimeout:                syncTimeout,
+	}
+
+	klog.Info("Setting up event handlers")
+	// Set up an event handler for when SpannerDatabaseRole resources change
+	spannerDatabaseRoleInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueSpannerDatabaseRole,
+		UpdateFunc: func(old, new interface{}) {
+			controller.enqueueSpannerDatabaseRole(new)
+		},
+		DeleteFunc: controller.enqueueSpannerDatabaseRole,
+	})
+	// Roles wait for their database, so requeue them when it changes.
+	spannerDatabaseInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.handleSpannerDatabase,
+		UpdateFunc: func(old, new interface{}) {
+			controller.handleSpannerDatabase(new)
+		},
+		DeleteFunc: controller.handleSpannerDatabase,
+	})
+
+	return controller
+}
+
+// Run will set up the event handlers for types we are interested in, as well
+// as syncing informer caches and starting workers. It will block until stopCh
+// is closed, at which point it will shutdown the workqueue and wait for
+// workers to finish processing their current work items.
+func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
+	defer utilruntime.HandleCrash()
+	defer c.workqueue.ShutDown()
+
+	// Start the informer factories to begin populating the informer caches
+	klog.Info("Starting SpannerDatabaseRole controller")
+
+	// Wait for the caches to be synced before starting workers
+	klog.Info("Waiting for informer caches to sync")
+	if ok := cache.WaitForCacheSync(stopCh, c.spannerDatabaseRolesSynced, c.spannerDatabasesSynced, c.secretsSynced); !ok {
+		return fmt.Errorf("failed to wait for caches to sync")
+	}
+
+	// ctx is cancelled once stopCh is closed, which aborts in-flight Spanner
+	// admin calls made by the workers.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	klog.Info("Starting workers")
+	// Launch two workers to process SpannerDatabaseRole resources
+	for i := 0; i < threadiness; i++ {
+		go wait.Until(func() { c.runWorker(ctx) }, time.Second, stopCh)
+	}
+
+	klog.Info("Started workers")
+	<-stopCh
+	klog.Info("Shutting down workers")
+
+	return nil
+}
+
+// runWorker is a long-running function that will continually call the
+// processNextWorkItem function in order to read and process a message on the
+// workqueue.
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+// processNextWorkItem will read a single work item off the workqueue and
+// attempt to process it, by calling the syncHandler.
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	obj, shutdown := c.workqueue.Get()
+
+	if shutdown {
+		return false
+	}
+
+	// We wrap this block in a func so we can defer c.workqueue.Done.
+	err := func(obj interface{}) error {
+		// We call Done here so the workqueue knows we have finished
+		// processing this item. We also must remember to call Forget if we
+		// do not want this work item being re-queued. For example, we do
+		// not call Forget if a transient error occurs, instead the item is
+		// put back on the workqueue and attempted again after a back-off
+		// period.
+		defer c.workqueue.Done(obj)
+		var key string
+		var ok bool
+		// We expect strings to come off the workqueue. These are of the
+		// form namespace/name. We do this as the delayed nature of the
+		// workqueue means the items in the informer cache may actually be
+		// more up to date that when the item was initially put onto the
+		// workqueue.
+		if key, ok = obj.(string); !ok {
+			// As the item in the workqueue is actually invalid, we call
+			// Forget here else we'd go into a loop of attempting to
+			// process a work item that is invalid.
+			c.workqueue.Forget(obj)
+			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
+			return nil
+		}
+		// Run the syncHandler, passing it the namespace/name string of the
+		// SpannerDatabaseRole resource to be synced.
+		syncCtx, cancel := context.WithTimeout(ctx, c.syncTimeout)
+		defer cancel()
+		if err := c.syncHandler(syncCtx, key); err != nil {
+			// Put the item back on the workqueue to handle any transient errors.
+			c.workqueue.AddRateLimited(key)
+			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
+		}
+		// Finally, if no error occurs we Forget this item so it does not
+		// get queued again until another change happens.
+		c.workqueue.Forget(obj)
+		klog.Infof("Successfully synced '%s'", key)
+		return nil
+	}(obj)
+
+	if err != nil {
+		utilruntime.HandleError(err)
+		return true
+	}
+
+	return true
+}
+
+// syncHandler compares the actual state with the desired, and attempts to
+// converge the two. It then updates the Status block of the SpannerDatabaseRole resource
+// with the current status of the resource.
+func (c *Controller) syncHandler(ctx context.Context, key string) error {
+
+	log.Printf("Get key: %s", key)
+	// Convert the namespace/name string into a distinct namespace and name
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	// Get the SpannerDatabaseRole resource with this namespace/name
+	spannerDatabaseRole, err := c.spannerDatabaseRoleLister.SpannerDatabaseRoles(namespace).Get(name)
+	if err != nil {
+		// The SpannerDatabaseRole resource may no longer exist, in which case
+		// its finalizer already dropped the role.
+		if errors.IsNotFound(err) {
+			utilruntime.HandleError(fmt.Errorf("spannerDatabaseRole '%s' in work queue no longer exists", key))
+			return nil
+		}
+		log.Printf("Error: %s", err.Error())
+		return err
+	}
+
+	// A previous sync started a long-running operation. Poll it instead of
+	// issuing another request; this also resumes waiting after a restart.
+	if spannerDatabaseRole.Status.PendingOperation != "" {
+		spannerDatabaseRole, err = c.pollPendingOperation(ctx, key, spannerDatabaseRole)
+		if err != nil || spannerDatabaseRole == nil {
+			return err
+		}
+	}
+
+	deleting := spannerDatabaseRole.DeletionTimestamp != nil
+	if deleting && !hasFinalizer(spannerDatabaseRole) {
+		return nil
+	}
+	spec := spannerDatabaseRole.Spec
+	if !deleting {
+		if err := validateRole(spec); err != nil {
+			return c.rejectSpec(key, spannerDatabaseRole, ErrInvalidRole, fmt.Sprintf(MessageInvalidRole, err))
+		}
+	}
+
+	spannerDatabase, err := c.spannerDatabaseLister.SpannerDatabases(namespace).Get(spec.DatabaseRef.Name)
+	if errors.IsNotFound(err) {
+		if deleting {
+			// Without its SpannerDatabase, the database of the role is unknown.
+			return c.removeFinalizer(spannerDatabaseRole)
+		}
+		c.recorder.Event(spannerDatabaseRole, corev1.EventTypeNormal, WaitingForDatabase, fmt.Sprintf(MessageWaitingForDatabase, spec.DatabaseRef.Name))
+		return nil
+	} else if err != nil {
+		return err
+	}
+	projectId, instanceId, databaseName := spannerDatabase.Spec.ProjectId, spannerDatabase.Spec.InstanceId, spannerDatabase.Spec.DatabaseId
+	if databaseName == "" {
+		databaseName = spannerDatabase.Name
+	}
+	if spannerDatabase.Spec.InstanceRef != nil {
+		// The instance of a referenced SpannerInstance is only known once
+		// the SpannerDatabase claimed its database.
+		var ok bool
+		projectId, instanceId, databaseName, ok = operator.ParseDatabaseName(spannerDatabase.Status.DatabaseName)
+		if !ok {
+			if deleting {
+				return c.removeFinalizer(spannerDatabaseRole)
+			}
+			c.recorder.Event(spannerDatabaseRole, corev1.EventTypeNormal, WaitingForDatabase, fmt.Sprintf(MessageWaitingForDatabase, spannerDatabase.Name))
+			return nil
+		}
+	}
+	op, err := c.operatorFor(spannerDatabase, projectId)
+	if err != nil {
+		c.recorder.Event(spannerDatabaseRole, corev1.EventTypeWarning, ErrCredentials, fmt.Sprintf(MessageCredentials, spannerDatabase.Name, err))
+		return err
+	}
+	if _, err := op.GetDatabase(ctx, instanceId, databaseName); err != nil && op.IsNotFoundError(err) {
+		if deleting {
+			return c.removeFinalizer(spannerDatabaseRole)
+		}
+		// The SpannerDatabase is requeued once it is updated with the database.
+		c.recorder.Event(spannerDatabaseRole, corev1.EventTypeNormal, WaitingForDatabase, fmt.Sprintf(MessageWaitingForDatabase, databaseName))
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	actual, err := op.GetDatabaseRoleGrants(ctx, instanceId, databaseName, spec.RoleName)
+	roleExists := true
+	if err != nil && op.IsNotFoundError(err) {
+		roleExists = false
+	} else if err != nil {
+		return err
+	}
+
+	if deleting {
+		if !roleExists {
+			return c.removeFinalizer(spannerDatabaseRole)
+		}
+		log.Printf("Drop database role %s from %s", spec.RoleName, databaseName)
+		opName, err := op.UpdateDatabaseDdl(ctx, instanceId, databaseName, operator.DropDatabaseRoleDdl(spec.RoleName, actual))
+		if err != nil {
+			return err
+		}
+		return c.trackOperation(key, spannerDatabaseRole, opName)
+	}
+
+	// Add the finalizer before creating the role, so a role can never be
+	// created without being dropped later.
+	if !hasFinalizer(spannerDatabaseRole) {
+		spannerDatabaseRoleCopy := spannerDatabaseRole.DeepCopy()
+		spannerDatabaseRoleCopy.Finalizers = append(spannerDatabaseRoleCopy.Finalizers, dropRoleFinalizer)
+		spannerDatabaseRole, err = c.spannerclientset.DatabaseadminsV1alpha1().SpannerDatabaseRoles(namespace).Update(spannerDatabaseRoleCopy)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Column privileges are compared on their own only when the same
+	// privilege is not held on the whole table.
+	actual = dropCoveredGrants(actual)
+	grants, revokes := diffGrants(expandGrants(spec.Grants), actual)
+	if statements := operator.DatabaseRoleDdl(spec.RoleName, !roleExists, grants, revokes); len(statements) > 0 {
+		log.Printf("Update database role %s of %s with %d statements", spec.RoleName, databaseName, len(statements))
+		opName, err := op.UpdateDatabaseDdl(ctx, instanceId, databaseName, statements)
+		if err != nil {
+			return err
+		}
+		return c.trackOperation(key, spannerDatabaseRole, opName)
+	}
+
+	// Finally, we update the status block of the SpannerDatabaseRole resource to reflect the
+	// current state of the world
+	applied := compactGrants(actual)
+	if !reflect.DeepEqual(spannerDatabaseRole.Status.AppliedGrants, applied) {
+		spannerDatabaseRoleCopy := spannerDatabaseRole.DeepCopy()
+		spannerDatabaseRoleCopy.Status.AppliedGrants = applied
+		if _, err := c.updateSpannerDatabaseRoleStatus(spannerDatabaseRoleCopy); err != nil {
+			return err
+		}
+	}
+
+	c.recorder.Event(spannerDatabaseRole, corev1.EventTypeNormal, SuccessSynced, MessageResourceSynced)
+	return nil
+}
+
+// operatorFor returns the Operator of projectId, built with the credentials
+// spannerDatabase selects, or with the default credentials when
+// spannerDatabase is nil.
+func (c *Controller) operatorFor(spannerDatabase *databasev1alpha1.SpannerDatabase, projectId string) (operator.Operator, error) {
+	if spannerDatabase == nil {
+		return c.operators.Get(projectId)
+	}
+	return credentials.Operator(c.operators, c.secretLister, spannerDatabase.Namespace, projectId, spannerDatabase.Spec.CredentialsSecretRef)
+}
+
+// trackOperation records opName as the pending operation of spannerDatabaseRole
+// and schedules the key to be polled until the operation finishes.
+func (c *Controller) trackOperation(key string, spannerDatabaseRole *databasev1alpha1.SpannerDatabaseRole, opName string) error {
+	spannerDatabaseRoleCopy := spannerDatabaseRole.DeepCopy()
+	spannerDatabaseRoleCopy.Status.PendingOperation = opName
+	if _, err := c.updateSpannerDatabaseRoleStatus(spannerDatabaseRoleCopy); err != nil {
+		return err
+	}
+	c.workqueue.AddAfter(key, operationPollInterval)
+	return nil
+}
+
+// pollPendingOperation checks the pending operation of spannerDatabaseRole.
+// While it is running, the key is requeued and nil is returned. Once it is
+// done, the pending operation is cleared and the updated SpannerDatabaseRole
+// is returned so the sync can carry on.
+func (c *Controller) pollPendingOperation(ctx context.Context, key string, spannerDatabaseRole *databasev1alpha1.SpannerDatabaseRole) (*databasev1alpha1.SpannerDatabaseRole, error) {
+	// The operation runs in the project of the database of the role, with the
+	// credentials of the database while it is still around.
+	name := spannerDatabaseRole.Status.PendingOperation
+	spannerDatabase, err := c.spannerDatabaseLister.SpannerDatabases(spannerDatabaseRole.Namespace).Get(spannerDatabaseRole.Spec.DatabaseRef.Name)
+	if errors.IsNotFound(err) {
+		spannerDatabase = nil
+	} else if err != nil {
+		return nil, err
+	}
+	o, err := c.operatorFor(spannerDatabase, operator.ProjectIdOf(name))
+	if err != nil {
+		return nil, err
+	}
+	op, err := o.GetOperation(ctx, name)
+	if err != nil && o.IsNotFoundError(err) {
+		// Spanner keeps operations for a limited time only. One that is gone
+		// can no longer be polled, so it is cleared and the sync carries on
+		// from what Spanner reports.
+		log.Printf("Operation %s no longer exists, reconcile again", name)
+		spannerDatabaseRoleCopy := spannerDatabaseRole.DeepCopy()
+		spannerDatabaseRoleCopy.Status.PendingOperation = ""
+		return c.updateSpannerDatabaseRoleStatus(spannerDatabaseRoleCopy)
+	} else if err != nil {
+		return nil, err
+	}
+	if !op.Done {
+		log.Printf("Operation %s is still running", op.Name)
+		c.workqueue.AddAfter(key, operationPollInterval)
+		return nil, nil
+	}
+	spannerDatabaseRoleCopy := spannerDatabaseRole.DeepCopy()
+	spannerDatabaseRoleCopy.Status.PendingOperation = ""
+	updated, err := c.updateSpannerDatabaseRoleStatus(spannerDatabaseRoleCopy)
+	if err != nil {
+		return nil, err
+	}
+	if op.Err != nil {
+		c.recorder.Event(spannerDatabaseRole, corev1.EventTypeWarning, ErrOperationFailed, fmt.Sprintf(MessageOperationFailed, op.Name, op.Err))
+		return nil, op.Err
+	}
+	return updated, nil
+}
+
+func (c *Controller) removeFinalizer(spannerDatabaseRole *databasev1alpha1.SpannerDatabaseRole) error {
+	spannerDatabaseRoleCopy := spannerDatabaseRole.DeepCopy()
+	spannerDatabaseRoleCopy.Finalizers = removeString(spannerDatabaseRoleCopy.Finalizers, dropRoleFinalizer)
+	_, err := c.spannerclientset.DatabaseadminsV1alpha1().SpannerDatabaseRoles(spannerDatabaseRole.Namespace).Update(spannerDatabaseRoleCopy)
+	return err
+}
+
+// rejectSpec reports an error in the spec of spannerDatabaseRole as a warning Event
+// without requeuing key. No retry can succeed before the spec is updated, and
+// updating it enqueues the SpannerDatabaseRole again.
+func (c *Controller) rejectSpec(key string, spannerDatabaseRole *databasev1alpha1.SpannerDatabaseRole, reason, message string) error {
+	c.recorder.Event(spannerDatabaseRole, corev1.EventTypeWarning, reason, message)
+	utilruntime.HandleError(fmt.Errorf("%s: %s", key, message))
+	return nil
+}
+
+func (c *Controller) updateSpannerDatabaseRoleStatus(spannerDatabaseRole *databasev1alpha1.SpannerDatabaseRole) (*databasev1alpha1.SpannerDatabaseRole, error) {
+	// NEVER modify objects from the store. It's a read-only, local cache.
+	// You can use DeepCopy() to make a deep copy of original object and modify this copy
+	// Or create a copy manually for better performance
+	spannerDatabaseRoleCopy := spannerDatabaseRole.DeepCopy()
+	// The SpannerDatabaseRole CRD enables the status subresource, so the
+	// Status block must be written through UpdateStatus.
+	return c.spannerclientset.DatabaseadminsV1alpha1().SpannerDatabaseRoles(spannerDatabaseRole.Namespace).UpdateStatus(spannerDatabaseRoleCopy)
+}
+
+func hasFinalizer(spannerDatabaseRole *databasev1alpha1.SpannerDatabaseRole) bool {
+	for _, f := range spannerDatabaseRole.Finalizers {
+		if f == dropRoleFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// removeString returns a copy of s without any occurrence of r.
+func removeString(s []string, r string) []string {
+	var result []string
+	for _, v := range s {
+		if v != r {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// enqueueSpannerDatabaseRole takes a SpannerDatabaseRole resource and converts it into a namespace/name
+// string which is then put onto the work queue. This method should *not* be
+// passed resources of any type other than SpannerDatabaseRole.
+func (c *Controller) enqueueSpannerDatabaseRole(obj interface{}) {
+	var key string
+	var err error
+	if key, err = cache.MetaNamespaceKeyFunc(obj); err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.workqueue.Add(key)
+}
+
+// handleSpannerDatabase enqueues every SpannerDatabaseRole in the namespace
+// of the SpannerDatabase that references it.
+func (c *Controller) handleSpannerDatabase(obj interface{}) {
+	object, ok := obj.(metav1.Object)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("error decoding object, invalid type"))
+			return
+		}
+		object, ok = tombstone.Obj.(metav1.Object)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("error decoding object tombstone, invalid type"))
+			return
+		}
+	}
+	spannerDatabaseRoles, err := c.spannerDatabaseRoleLister.SpannerDatabaseRoles(object.GetNamespace()).List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, spannerDatabaseRole := range spannerDatabaseRoles {
+		if spannerDatabaseRole.Spec.DatabaseRef.Name == object.GetName() {
+			c.enqueueSpannerDatabaseRole(spannerDatabaseRole)
+		}
+	}
+}
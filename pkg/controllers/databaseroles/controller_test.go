@@ -0,0 +1,471 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package databaseroles
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/diff"
+	kubeinformers "k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	spannercontroller "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+	"github.com/katsew/spanner-operator/pkg/generated/databaseadmins/clientset/versioned/fake"
+	informers "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/informers/externalversions"
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+var (
+	alwaysReady        = func() bool { return true }
+	noResyncPeriodFunc = func() time.Duration { return 0 }
+)
+
+type fixture struct {
+	t *testing.T
+
+	client     *fake.Clientset
+	kubeclient *k8sfake.Clientset
+	// Operator the controller talks to Spanner through.
+	op *operator.Fake
+	// Pool handing out op for the default project, and a fake of its own
+	// for any other project.
+	operators *operator.Pool
+	// Objects to put in the store.
+	spannerDatabaseRoleLister []*spannercontroller.SpannerDatabaseRole
+	spannerDatabaseLister     []*spannercontroller.SpannerDatabase
+	// Actions expected to happen on the client.
+	actions []core.Action
+	// Objects from here preloaded into NewSimpleFake.
+	objects []runtime.Object
+}
+
+func newFixture(t *testing.T) *fixture {
+	f := &fixture{}
+	f.t = t
+	f.objects = []runtime.Object{}
+	f.op = operator.NewBuilder().ProjectId("test").BuildFake()
+	f.operators = operator.NewPool("test", func(projectId string, key []byte) (operator.Operator, error) {
+		if projectId == "test" {
+			return f.op, nil
+		}
+		return operator.NewBuilder().ProjectId(projectId).BuildFake(), nil
+	})
+	return f
+}
+
+func newSpannerDatabaseRole(name string, database string) *spannercontroller.SpannerDatabaseRole {
+	return &spannercontroller.SpannerDatabaseRole{
+		TypeMeta: metav1.TypeMeta{APIVersion: spannercontroller.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: spannercontroller.SpannerDatabaseRoleSpec{
+			DatabaseRef: corev1.LocalObjectReference{Name: database},
+			RoleName:    "analyst",
+			Grants: []spannercontroller.SpannerDatabaseRoleGrant{
+				{Privileges: []string{"SELECT"}, Table: "Singers", Columns: []string{"FirstName", "LastName"}},
+				{Privileges: []string{"INSERT", "UPDATE"}, Table: "Albums"},
+			},
+		},
+	}
+}
+
+func (f *fixture) newController() (*Controller, informers.SharedInformerFactory) {
+	f.client = fake.NewSimpleClientset(f.objects...)
+	f.kubeclient = k8sfake.NewSimpleClientset()
+
+	i := informers.NewSharedInformerFactory(f.client, noResyncPeriodFunc())
+	k8sI := kubeinformers.NewSharedInformerFactory(f.kubeclient, noResyncPeriodFunc())
+
+	c := NewController(f.kubeclient, f.client, i.Databaseadmins().V1alpha1().SpannerDatabaseRoles(),
+		i.Databaseadmins().V1alpha1().SpannerDatabases(), k8sI.Core().V1().Secrets(), f.operators)
+
+	c.spannerDatabaseRolesSynced = alwaysReady
+	c.spannerDatabasesSynced = alwaysReady
+	c.secretsSynced = alwaysReady
+	c.recorder = &record.FakeRecorder{}
+
+	for _, r := range f.spannerDatabaseRoleLister {
+		i.Databaseadmins().V1alpha1().SpannerDatabaseRoles().Informer().GetIndexer().Add(r)
+	}
+	for _, d := range f.spannerDatabaseLister {
+		i.Databaseadmins().V1alpha1().SpannerDatabases().Informer().GetIndexer().Add(d)
+	}
+
+	return c, i
+}
+
+func (f *fixture) run(spannerDatabaseRoleName string) {
+	f.runController(spannerDatabaseRoleName, true, false)
+}
+
+func (f *fixture) runExpectError(spannerDatabaseRoleName string) {
+	f.runController(spannerDatabaseRoleName, true, true)
+}
+
+func (f *fixture) runController(spannerDatabaseRoleName string, startInformers bool, expectError bool) {
+	c, i := f.newController()
+	if startInformers {
+		stopCh := make(chan struct{})
+		defer close(stopCh)
+		i.Start(stopCh)
+	}
+
+	err := c.syncHandler(context.Background(), spannerDatabaseRoleName)
+	if !expectError && err != nil {
+		f.t.Errorf("error syncing SpannerDatabaseRole: %v", err)
+	} else if expectError && err == nil {
+		f.t.Error("expected error syncing SpannerDatabaseRole, got nil")
+	}
+
+	actions := filterInformerActions(f.client.Actions())
+	for i, action := range actions {
+		if len(f.actions) < i+1 {
+			f.t.Errorf("%d unexpected actions: %+v", len(actions)-len(f.actions), actions[i:])
+			break
+		}
+
+		expectedAction := f.actions[i]
+		checkAction(expectedAction, action, f.t)
+	}
+
+	if len(f.actions) > len(actions) {
+		f.t.Errorf("%d additional expected actions:%+v", len(f.actions)-len(actions), f.actions[len(actions):])
+	}
+}
+
+// checkAction verifies that expected and actual actions are equal and both have
+// same attached resources
+func checkAction(expected, actual core.Action, t *testing.T) {
+	if !(expected.Matches(actual.GetVerb(), actual.GetResource().Resource) && actual.GetSubresource() == expected.GetSubresource()) {
+		t.Errorf("Expected\n\t%#v\ngot\n\t%#v", expected, actual)
+		return
+	}
+
+	if reflect.TypeOf(actual) != reflect.TypeOf(expected) {
+		t.Errorf("Action has wrong type. Expected: %t. Got: %t", expected, actual)
+		return
+	}
+
+	switch a := actual.(type) {
+	case core.CreateAction:
+		e, _ := expected.(core.CreateAction)
+		expObject := e.GetObject()
+		object := a.GetObject()
+
+		if !reflect.DeepEqual(expObject, object) {
+			t.Errorf("Action %s %s has wrong object\nDiff:\n %s",
+				a.GetVerb(), a.GetResource().Resource, diff.ObjectGoPrintDiff(expObject, object))
+		}
+	case core.UpdateAction:
+		e, _ := expected.(core.UpdateAction)
+		expObject := e.GetObject()
+		object := a.GetObject()
+
+		if !reflect.DeepEqual(expObject, object) {
+			t.Errorf("Action %s %s has wrong object\nDiff:\n %s",
+				a.GetVerb(), a.GetResource().Resource, diff.ObjectGoPrintDiff(expObject, object))
+		}
+	case core.PatchAction:
+		e, _ := expected.(core.PatchAction)
+		expPatch := e.GetPatch()
+		patch := a.GetPatch()
+
+		if !reflect.DeepEqual(expPatch, patch) {
+			t.Errorf("Action %s %s has wrong patch\nDiff:\n %s",
+				a.GetVerb(), a.GetResource().Resource, diff.ObjectGoPrintDiff(expPatch, patch))
+		}
+	}
+}
+
+// filterInformerActions filters list and watch actions for testing resources.
+// Since list and watch don't change resource state we can filter it to lower
+// nose level in our tests.
+func filterInformerActions(actions []core.Action) []core.Action {
+	ret := []core.Action{}
+	for _, action := range actions {
+		if len(action.GetNamespace()) == 0 &&
+			(action.Matches("list", "spannerdatabaseroles") ||
+				action.Matches("watch", "spannerdatabaseroles") ||
+				action.Matches("list", "spannerdatabases") ||
+				action.Matches("watch", "spannerdatabases")) {
+			continue
+		}
+		ret = append(ret, action)
+	}
+
+	return ret
+}
+
+func (f *fixture) expectUpdateSpannerDatabaseRoleAction(spannerDatabaseRole *spannercontroller.SpannerDatabaseRole) {
+	action := core.NewUpdateAction(schema.GroupVersionResource{Resource: "spannerdatabaseroles"}, spannerDatabaseRole.Namespace, spannerDatabaseRole)
+	f.actions = append(f.actions, action)
+}
+
+func (f *fixture) expectUpdateSpannerDatabaseRoleStatusAction(spannerDatabaseRole *spannercontroller.SpannerDatabaseRole) {
+	action := core.NewUpdateAction(schema.GroupVersionResource{Resource: "spannerdatabaseroles"}, spannerDatabaseRole.Namespace, spannerDatabaseRole)
+	action.Subresource = "status"
+	f.actions = append(f.actions, action)
+}
+
+// createDatabase makes the operator hold the database name in the "test"
+// instance, with statements applied, and puts its SpannerDatabase in the store.
+func (f *fixture) createDatabase(name string, statements ...string) {
+	ctx := context.Background()
+	if _, err := f.op.CreateInstance(ctx, "test", "test", "", operator.Nodes(1)); err != nil {
+		f.t.Fatal(err)
+	}
+	if _, err := f.op.CreateDatabase(ctx, "test", name, statements); err != nil {
+		f.t.Fatal(err)
+	}
+	f.spannerDatabaseLister = append(f.spannerDatabaseLister, &spannercontroller.SpannerDatabase{
+		TypeMeta: metav1.TypeMeta{APIVersion: spannercontroller.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: spannercontroller.SpannerDatabaseSpec{
+			InstanceId: "test",
+		},
+	})
+}
+
+// grants returns the grants of role in the database name.
+func (f *fixture) grants(name string, role string) []operator.DatabaseRoleGrant {
+	grants, err := f.op.GetDatabaseRoleGrants(context.Background(), "test", name, role)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return grants
+}
+
+func getKey(spannerDatabaseRole *spannercontroller.SpannerDatabaseRole, t *testing.T) string {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(spannerDatabaseRole)
+	if err != nil {
+		t.Errorf("Unexpected error getting key for SpannerDatabaseRole %v: %v", spannerDatabaseRole.Name, err)
+		return ""
+	}
+	return key
+}
+
+const pendingDdlOperation = "projects/test/instances/test/databases/testdb/operations/mock_update_database_ddl"
+
+var expGrants = []operator.DatabaseRoleGrant{
+	{Privilege: "INSERT", Table: "Albums"},
+	{Privilege: "UPDATE", Table: "Albums"},
+	{Privilege: "SELECT", Table: "Singers", Column: "FirstName"},
+	{Privilege: "SELECT", Table: "Singers", Column: "LastName"},
+}
+
+func TestCreatesRole(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb")
+	spannerDatabaseRole := newSpannerDatabaseRole("test", "testdb")
+
+	f.spannerDatabaseRoleLister = append(f.spannerDatabaseRoleLister, spannerDatabaseRole)
+	f.objects = append(f.objects, spannerDatabaseRole)
+
+	expRole := spannerDatabaseRole.DeepCopy()
+	expRole.Finalizers = []string{dropRoleFinalizer}
+	f.expectUpdateSpannerDatabaseRoleAction(expRole)
+	expRole = expRole.DeepCopy()
+	expRole.Status.PendingOperation = pendingDdlOperation
+	f.expectUpdateSpannerDatabaseRoleStatusAction(expRole)
+
+	f.run(getKey(spannerDatabaseRole, t))
+
+	if grants := f.grants("testdb", "analyst"); !reflect.DeepEqual(grants, expGrants) {
+		t.Errorf("expected grants %v, got %v", expGrants, grants)
+	}
+}
+
+func TestRecordsAppliedGrants(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb",
+		"CREATE ROLE analyst",
+		"GRANT SELECT(FirstName, LastName) ON TABLE Singers TO ROLE analyst",
+		"GRANT INSERT, UPDATE ON TABLE Albums TO ROLE analyst",
+	)
+	spannerDatabaseRole := newSpannerDatabaseRole("test", "testdb")
+	spannerDatabaseRole.Finalizers = []string{dropRoleFinalizer}
+
+	f.spannerDatabaseRoleLister = append(f.spannerDatabaseRoleLister, spannerDatabaseRole)
+	f.objects = append(f.objects, spannerDatabaseRole)
+
+	expRole := spannerDatabaseRole.DeepCopy()
+	expRole.Status.AppliedGrants = spannerDatabaseRole.Spec.Grants[1:]
+	expRole.Status.AppliedGrants = append(expRole.Status.AppliedGrants, spannerDatabaseRole.Spec.Grants[0])
+	f.expectUpdateSpannerDatabaseRoleStatusAction(expRole)
+
+	f.run(getKey(spannerDatabaseRole, t))
+}
+
+// An operation Spanner no longer keeps is cleared, and the role synced.
+func TestClearsMissingOperation(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb",
+		"CREATE ROLE analyst",
+		"GRANT SELECT(FirstName, LastName) ON TABLE Singers TO ROLE analyst",
+		"GRANT INSERT, UPDATE ON TABLE Albums TO ROLE analyst",
+	)
+	spannerDatabaseRole := newSpannerDatabaseRole("test", "testdb")
+	spannerDatabaseRole.Finalizers = []string{dropRoleFinalizer}
+	spannerDatabaseRole.Status.PendingOperation = "projects/test/instances/test/databases/testdb/operations/expired"
+	f.op.FailNext("GetOperation", status.Error(codes.NotFound, "operation not found"))
+
+	f.spannerDatabaseRoleLister = append(f.spannerDatabaseRoleLister, spannerDatabaseRole)
+	f.objects = append(f.objects, spannerDatabaseRole)
+
+	expRole := spannerDatabaseRole.DeepCopy()
+	expRole.Status.PendingOperation = ""
+	f.expectUpdateSpannerDatabaseRoleStatusAction(expRole)
+	expRole = expRole.DeepCopy()
+	expRole.Status.AppliedGrants = spannerDatabaseRole.Spec.Grants[1:]
+	expRole.Status.AppliedGrants = append(expRole.Status.AppliedGrants, spannerDatabaseRole.Spec.Grants[0])
+	f.expectUpdateSpannerDatabaseRoleStatusAction(expRole)
+
+	f.run(getKey(spannerDatabaseRole, t))
+}
+
+func TestRevokesRemovedGrants(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb",
+		"CREATE ROLE analyst",
+		"GRANT SELECT(FirstName, LastName) ON TABLE Singers TO ROLE analyst",
+		"GRANT INSERT, UPDATE, DELETE ON TABLE Albums TO ROLE analyst",
+		"GRANT SELECT ON TABLE Concerts TO ROLE analyst",
+	)
+	spannerDatabaseRole := newSpannerDatabaseRole("test", "testdb")
+	spannerDatabaseRole.Finalizers = []string{dropRoleFinalizer}
+
+	f.spannerDatabaseRoleLister = append(f.spannerDatabaseRoleLister, spannerDatabaseRole)
+	f.objects = append(f.objects, spannerDatabaseRole)
+
+	expRole := spannerDatabaseRole.DeepCopy()
+	expRole.Status.PendingOperation = pendingDdlOperation
+	f.expectUpdateSpannerDatabaseRoleStatusAction(expRole)
+
+	f.run(getKey(spannerDatabaseRole, t))
+
+	if grants := f.grants("testdb", "analyst"); !reflect.DeepEqual(grants, expGrants) {
+		t.Errorf("expected grants %v, got %v", expGrants, grants)
+	}
+}
+
+func TestWaitsForDatabase(t *testing.T) {
+	f := newFixture(t)
+	spannerDatabaseRole := newSpannerDatabaseRole("test", "testdb")
+
+	f.spannerDatabaseRoleLister = append(f.spannerDatabaseRoleLister, spannerDatabaseRole)
+	f.objects = append(f.objects, spannerDatabaseRole)
+
+	f.run(getKey(spannerDatabaseRole, t))
+}
+
+func TestCreatesRoleInReferencedInstance(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb")
+	// The instance comes from the claimed database, not the spec.
+	spannerDatabase := f.spannerDatabaseLister[0]
+	spannerDatabase.Spec.InstanceId = ""
+	spannerDatabase.Spec.InstanceRef = &spannercontroller.SpannerInstanceReference{Name: "main"}
+	spannerDatabase.Status.DatabaseName = operator.DatabaseName("test", "test", "testdb")
+	spannerDatabaseRole := newSpannerDatabaseRole("test", "testdb")
+	spannerDatabaseRole.Finalizers = []string{dropRoleFinalizer}
+
+	f.spannerDatabaseRoleLister = append(f.spannerDatabaseRoleLister, spannerDatabaseRole)
+	f.objects = append(f.objects, spannerDatabaseRole)
+
+	expRole := spannerDatabaseRole.DeepCopy()
+	expRole.Status.PendingOperation = pendingDdlOperation
+	f.expectUpdateSpannerDatabaseRoleStatusAction(expRole)
+
+	f.run(getKey(spannerDatabaseRole, t))
+
+	if grants := f.grants("testdb", "analyst"); !reflect.DeepEqual(grants, expGrants) {
+		t.Errorf("expected grants %v, got %v", expGrants, grants)
+	}
+}
+
+func TestDropsRoleOnDeletion(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb",
+		"CREATE ROLE analyst",
+		"GRANT SELECT ON TABLE Singers TO ROLE analyst",
+	)
+	spannerDatabaseRole := newSpannerDatabaseRole("test", "testdb")
+	now := metav1.Now()
+	spannerDatabaseRole.DeletionTimestamp = &now
+	spannerDatabaseRole.Finalizers = []string{dropRoleFinalizer}
+
+	f.spannerDatabaseRoleLister = append(f.spannerDatabaseRoleLister, spannerDatabaseRole)
+	f.objects = append(f.objects, spannerDatabaseRole)
+
+	expRole := spannerDatabaseRole.DeepCopy()
+	expRole.Status.PendingOperation = pendingDdlOperation
+	f.expectUpdateSpannerDatabaseRoleStatusAction(expRole)
+
+	f.run(getKey(spannerDatabaseRole, t))
+
+	if _, err := f.op.GetDatabaseRoleGrants(context.Background(), "test", "testdb", "analyst"); !f.op.IsNotFoundError(err) {
+		t.Errorf("expected role to be dropped, got %v", err)
+	}
+}
+
+func TestRemovesFinalizerOnceRoleIsDropped(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb")
+	spannerDatabaseRole := newSpannerDatabaseRole("test", "testdb")
+	now := metav1.Now()
+	spannerDatabaseRole.DeletionTimestamp = &now
+	spannerDatabaseRole.Finalizers = []string{dropRoleFinalizer}
+
+	f.spannerDatabaseRoleLister = append(f.spannerDatabaseRoleLister, spannerDatabaseRole)
+	f.objects = append(f.objects, spannerDatabaseRole)
+
+	expRole := spannerDatabaseRole.DeepCopy()
+	expRole.Finalizers = nil
+	f.expectUpdateSpannerDatabaseRoleAction(expRole)
+
+	f.run(getKey(spannerDatabaseRole, t))
+}
+
+func TestRejectsInvalidRole(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb")
+	spannerDatabaseRole := newSpannerDatabaseRole("test", "testdb")
+	spannerDatabaseRole.Spec.Grants[1].Columns = []string{"Title"}
+	spannerDatabaseRole.Spec.Grants[1].Privileges = []string{"DELETE"}
+
+	f.spannerDatabaseRoleLister = append(f.spannerDatabaseRoleLister, spannerDatabaseRole)
+	f.objects = append(f.objects, spannerDatabaseRole)
+
+	f.run(getKey(spannerDatabaseRole, t))
+}
@@ -0,0 +1,171 @@
+package databaseroles
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	databasev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+// identifierRegexp matches the role, table and column names accepted in a
+// SpannerDatabaseRole. Quoted identifiers are not supported.
+var identifierRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,127}$`)
+
+// privileges are the privileges a database role can be granted on a table.
+var privileges = map[string]bool{
+	"SELECT": true,
+	"INSERT": true,
+	"UPDATE": true,
+	"DELETE": true,
+}
+
+// validateRole checks the role name and grants of spec.
+func validateRole(spec databasev1alpha1.SpannerDatabaseRoleSpec) error {
+	if spec.DatabaseRef.Name == "" {
+		return fmt.Errorf("databaseRef.name is required")
+	}
+	if !identifierRegexp.MatchString(spec.RoleName) {
+		return fmt.Errorf("roleName must start with a letter and hold only letters, digits and underscores, got %q", spec.RoleName)
+	}
+	if strings.HasPrefix(strings.ToLower(spec.RoleName), "spanner_") {
+		return fmt.Errorf("roleName %q is reserved for system roles", spec.RoleName)
+	}
+	for _, g := range spec.Grants {
+		if !identifierRegexp.MatchString(g.Table) {
+			return fmt.Errorf("invalid table name %q", g.Table)
+		}
+		for _, c := range g.Columns {
+			if !identifierRegexp.MatchString(c) {
+				return fmt.Errorf("invalid column name %q of table %s", c, g.Table)
+			}
+		}
+		if len(g.Privileges) == 0 {
+			return fmt.Errorf("no privileges on table %s", g.Table)
+		}
+		for _, p := range g.Privileges {
+			p = strings.ToUpper(p)
+			if !privileges[p] {
+				return fmt.Errorf("unknown privilege %q on table %s", p, g.Table)
+			}
+			if p == "DELETE" && len(g.Columns) > 0 {
+				return fmt.Errorf("DELETE on table %s cannot be restricted to columns", g.Table)
+			}
+		}
+	}
+	return nil
+}
+
+// expandGrants splits grants into one operator.DatabaseRoleGrant per
+// privilege and column. Column privileges covered by the same privilege on
+// the whole table are dropped.
+func expandGrants(grants []databasev1alpha1.SpannerDatabaseRoleGrant) []operator.DatabaseRoleGrant {
+	set := map[operator.DatabaseRoleGrant]bool{}
+	for _, g := range grants {
+		for _, p := range g.Privileges {
+			grant := operator.DatabaseRoleGrant{Privilege: strings.ToUpper(p), Table: g.Table}
+			if len(g.Columns) == 0 {
+				set[grant] = true
+				continue
+			}
+			for _, c := range g.Columns {
+				grant.Column = c
+				set[grant] = true
+			}
+		}
+	}
+	expanded := make([]operator.DatabaseRoleGrant, 0, len(set))
+	for g := range set {
+		expanded = append(expanded, g)
+	}
+	return dropCoveredGrants(expanded)
+}
+
+// dropCoveredGrants returns grants, sorted, without the column privileges
+// that the same privilege on the whole table already covers.
+func dropCoveredGrants(grants []operator.DatabaseRoleGrant) []operator.DatabaseRoleGrant {
+	tables := map[operator.DatabaseRoleGrant]bool{}
+	for _, g := range grants {
+		if g.Column == "" {
+			tables[g] = true
+		}
+	}
+	var result []operator.DatabaseRoleGrant
+	for _, g := range grants {
+		if g.Column != "" && tables[operator.DatabaseRoleGrant{Privilege: g.Privilege, Table: g.Table}] {
+			continue
+		}
+		result = append(result, g)
+	}
+	operator.SortDatabaseRoleGrants(result)
+	return result
+}
+
+// diffGrants returns the grants in desired but not in actual, and the ones in
+// actual but not in desired.
+func diffGrants(desired []operator.DatabaseRoleGrant, actual []operator.DatabaseRoleGrant) ([]operator.DatabaseRoleGrant, []operator.DatabaseRoleGrant) {
+	return subtractGrants(desired, actual), subtractGrants(actual, desired)
+}
+
+func subtractGrants(a []operator.DatabaseRoleGrant, b []operator.DatabaseRoleGrant) []operator.DatabaseRoleGrant {
+	set := make(map[operator.DatabaseRoleGrant]bool, len(b))
+	for _, g := range b {
+		set[g] = true
+	}
+	var result []operator.DatabaseRoleGrant
+	for _, g := range a {
+		if !set[g] {
+			result = append(result, g)
+		}
+	}
+	return result
+}
+
+// compactGrants is the inverse of expandGrants. It merges the privileges
+// held on the same columns of a table, so the result reads like a spec.
+func compactGrants(grants []operator.DatabaseRoleGrant) []databasev1alpha1.SpannerDatabaseRoleGrant {
+	columns := map[string]map[string][]string{}
+	for _, g := range grants {
+		if columns[g.Table] == nil {
+			columns[g.Table] = map[string][]string{}
+		}
+		if g.Column == "" {
+			columns[g.Table][g.Privilege] = nil
+			continue
+		}
+		if c, ok := columns[g.Table][g.Privilege]; !ok || c != nil {
+			columns[g.Table][g.Privilege] = append(c, g.Column)
+		}
+	}
+	var compacted []databasev1alpha1.SpannerDatabaseRoleGrant
+	for table, byPrivilege := range columns {
+		merged := map[string]int{}
+		for privilege, cols := range byPrivilege {
+			sort.Strings(cols)
+			k := strings.Join(cols, ",")
+			if i, ok := merged[k]; ok {
+				compacted[i].Privileges = append(compacted[i].Privileges, privilege)
+				continue
+			}
+			merged[k] = len(compacted)
+			compacted = append(compacted, databasev1alpha1.SpannerDatabaseRoleGrant{
+				Privileges: []string{privilege},
+				Table:      table,
+				Columns:    cols,
+			})
+		}
+	}
+	for _, g := range compacted {
+		sort.Strings(g.Privileges)
+	}
+	sort.Slice(compacted, func(i, j int) bool {
+		a, b := compacted[i], compacted[j]
+		if a.Table != b.Table {
+			return a.Table < b.Table
+		}
+		return strings.Join(a.Columns, ",") < strings.Join(b.Columns, ",")
+	})
+	return compacted
+}
@@ -0,0 +1,535 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package iamadmins
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+
+	iamv1alpha1 "github.com/katsew/spanner-operator/pkg/apis/iamadmins/v1alpha1"
+	clientset "github.com/katsew/spanner-operator/pkg/generated/iamadmins/clientset/versioned"
+	spannerscheme "github.com/katsew/spanner-operator/pkg/generated/iamadmins/clientset/versioned/scheme"
+	informers "github.com/katsew/spanner-operator/pkg/generated/iamadmins/informers/externalversions/iamadmins/v1alpha1"
+	listers "github.com/katsew/spanner-operator/pkg/generated/iamadmins/listers/iamadmins/v1alpha1"
+
+	"github.com/katsew/spanner-operator/pkg/credentials"
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+const controllerAgentName = "spanner-controller"
+
+// syncTimeout bounds a single syncHandler call, including every Spanner admin
+// call it makes, so a hung call cannot pin a worker forever.
+const syncTimeout = 10 * time.Minute
+
+// revokeFinalizer keeps a SpannerIAMPolicyMember around until the member it
+// granted has been revoked.
+const revokeFinalizer = "iamadmins.spanner-operator.io/revoke-member"
+
+const (
+	// SuccessSynced is used as part of the Event 'reason' when a SpannerIAMPolicyMember is synced
+	SuccessSynced = "Synced"
+
+	// ErrInvalidBinding is used as part of the Event 'reason' when the role or
+	// member of a SpannerIAMPolicyMember is not acceptable.
+	ErrInvalidBinding = "ErrInvalidBinding"
+
+	// ErrCredentials is used as part of the Event 'reason' when the
+	// credentials a SpannerIAMPolicyMember selects cannot be read.
+	ErrCredentials = "ErrCredentials"
+
+	// MessageResourceSynced is the message used for an Event fired when a Spanner
+	// is synced successfully
+	MessageResourceSynced = "SpannerIAMPolicyMember synced successfully"
+	// MessageInvalidBinding is the message used for an Event fired when the
+	// binding in the spec is rejected
+	MessageInvalidBinding = "Invalid binding: %v"
+	// MessageCredentials is the message used for an Event fired when the
+	// credentials in the spec cannot be read
+	MessageCredentials = "Invalid credentialsSecretRef: %v"
+)
+
+// Controller is the controller implementation for SpannerIAMPolicyMember resources
+type Controller struct {
+	// kubeclientset is a standard kubernetes clientset
+	kubeclientset kubernetes.Interface
+	// spannerclientset is a clientset for our own API group
+	spannerclientset clientset.Interface
+
+	spannerIAMPolicyMemberLister  listers.SpannerIAMPolicyMemberLister
+	spannerIAMPolicyMembersSynced cache.InformerSynced
+	secretLister                  corelisters.SecretLister
+	secretsSynced                 cache.InformerSynced
+
+	// workqueue is a rate limited work queue. This is used to queue work to be
+	// processed instead of performing it as soon as a change happens. This
+	// means we can ensure we only process a fixed amount of resources at a
+	// time, and makes it easy to ensure we are never processing the same item
+	// simultaneously in two different workers.
+	workqueue workqueue.RateLimitingInterface
+	// recorder is an event recorder for recording Event resources to the
+	// Kubernetes API.
+	recorder record.EventRecorder
+
+	// operators hands out the Operator of the project of a binding.
+	operators *operator.Pool
+
+	// syncTimeout is the deadline applied to each syncHandler call.
+	syncTimeout time.Duration
+}
+
+// NewController returns a new spanner IAM policy member controller
+func NewController(
+	kubeclientset kubernetes.Interface,
+	spannerclientset clientset.Interface,
+	spannerIAMPolicyMemberInformer informers.SpannerIAMPolicyMemberInformer,
+	secretInformer coreinformers.SecretInformer,
+	operators *operator.Pool) *Controller {
+
+	// Create event broadcaster
+	// Add spanner-controller types to the default Kubernetes Scheme so Events can be
+	// logged for spanner-controller types.
+	utilruntime.Must(spannerscheme.AddToScheme(scheme.Scheme))
+	klog.V(4).Info("Creating event broadcaster")
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(klog.Infof)
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
+
+	controller := &Controller{
+		kubeclientset:                 kubeclientset,
+		spannerclientset:              spannerclientset,
+		spannerIAMPolicyMemberLister:  spannerIAMPolicyMemberInformer.Lister(),
+		spannerIAMPolicyMembersSynced: spannerIAMPolicyMemberInformer.Informer().HasSynced,
+		secretLister:                  secretInformer.Lister(),
+		secretsSynced:                 secretInformer.Informer().HasSynced,
+		workqueue:                     workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "SpannerIAMPolicyMembers"),
+		recorder:                      recorder,
+		operators:                     operators,
+		syncTimeout:                   syncTimeout,
+	}
+
+	klog.Info("Setting up event handlers")
+	// Set up an event handler for when SpannerIAMPolicyMember resources change
+	spannerIAMPolicyMemberInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueSpannerIAMPolicyMember,
+		UpdateFunc: func(old, new interface{}) {
+			controller.enqueueSpannerIAMPolicyMember(new)
+		},
+		DeleteFunc: controller.enqueueSpannerIAMPolicyMember,
+	})
+
+	return controller
+}
+
+// Run will set up the event handlers for types we are interested in, as well
+// as syncing informer caches and starting workers. It will block until stopCh
+// is closed, at which point it will shutdown the workqueue and wait for
+// workers to finish processing their current work items.
+func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
+	defer utilruntime.HandleCrash()
+	defer c.workqueue.ShutDown()
+
+	// Start the informer factories to begin populating the informer caches
+	klog.Info("Starting SpannerIAMPolicyMember controller")
+
+	// Wait for the caches to be synced before starting workers
+	klog.Info("Waiting for informer caches to sync")
+	if ok := cache.WaitForCacheSync(stopCh, c.spannerIAMPolicyMembersSynced, c.secretsSynced); !ok {
+		return fmt.Errorf("failed to wait for caches to sync")
+	}
+
+	// ctx is cancelled once stopCh is closed, which aborts in-flight Spanner
+	// admin calls made by the workers.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	klog.Info("Starting workers")
+	// Launch two workers to process SpannerIAMPolicyMember resources
+	for i := 0; i < threadiness; i++ {
+		go wait.Until(func() { c.runWorker(ctx) }, time.Second, stopCh)
+	}
+
+	klog.Info("Started workers")
+	<-stopCh
+	klog.Info("Shutting down workers")
+
+	return nil
+}
+
+// runWorker is a long-running function that will continually call the
+// processNextWorkItem function in order to read and process a message on the
+// workqueue.
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+// processNextWorkItem will read a single work item off the workqueue and
+// attempt to process it, by calling the syncHandler.
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	obj, shutdown := c.workqueue.Get()
+
+	if shutdown {
+		return false
+	}
+
+	// We wrap this block in a func so we can defer c.workqueue.Done.
+	err := func(obj interface{}) error {
+		// We call Done here so the workqueue knows we have finished
+		// processing this item. We also must remember to call Forget if we
+		// do not want this work item being re-queued. For example, we do
+		// not call Forget if a transient error occurs, instead the item is
+		// put back on the workqueue and attempted again after a back-off
+		// period.
+		defer c.workqueue.Done(obj)
+		var key string
+		var ok bool
+		// We expect strings to come off the workqueue. These are of the
+		// form namespace/name. We do this as the delayed nature of the
+		// workqueue means the items in the informer cache may actually be
+		// more up to date that when the item was initially put onto the
+		// workqueue.
+		if key, ok = obj.(string); !ok {
+			// As the item in the workqueue is actually invalid, we call
+			// Forget here else we'd go into a loop of attempting to
+			// process a work item that is invalid.
+			c.workqueue.Forget(obj)
+			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
+			return nil
+		}
+		// Run the syncHandler, passing it the namespace/name string of the
+		// SpannerIAMPolicyMember resource to be synced.
+		syncCtx, cancel := context.WithTimeout(ctx, c.syncTimeout)
+		defer cancel()
+		if err := c.syncHandler(syncCtx, key); err != nil {
+			// Put the item back on the workqueue to handle any transient errors.
+			c.workqueue.AddRateLimited(key)
+			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
+		}
+		// Finally, if no error occurs we Forget this item so it does not
+		// get queued again until another change happens.
+		c.workqueue.Forget(obj)
+		klog.Infof("Successfully synced '%s'", key)
+		return nil
+	}(obj)
+
+	if err != nil {
+		utilruntime.HandleError(err)
+		return true
+	}
+
+	return true
+}
+
+// syncHandler compares the actual state with the desired, and attempts to
+// converge the two. It then updates the Status block of the SpannerIAMPolicyMember resource
+// with the current status of the resource.
+func (c *Controller) syncHandler(ctx context.Context, key string) error {
+
+	log.Printf("Get key: %s", key)
+	// Convert the namespace/name string into a distinct namespace and name
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	// Get the SpannerIAMPolicyMember resource with this namespace/name
+	spannerIAMPolicyMember, err := c.spannerIAMPolicyMemberLister.SpannerIAMPolicyMembers(namespace).Get(name)
+	if err != nil {
+		// The SpannerIAMPolicyMember resource may no longer exist, in which
+		// case its finalizer already revoked the member.
+		if errors.IsNotFound(err) {
+			utilruntime.HandleError(fmt.Errorf("spannerIAMPolicyMember '%s' in work queue no longer exists", key))
+			return nil
+		}
+		log.Printf("Error: %s", err.Error())
+		return err
+	}
+
+	if spannerIAMPolicyMember.DeletionTimestamp != nil {
+		if !hasFinalizer(spannerIAMPolicyMember) {
+			return nil
+		}
+		if applied := spannerIAMPolicyMember.Status.Applied; applied != nil && (spannerIAMPolicyMember.Status.Granted || spannerIAMPolicyMember.Status.Granting) {
+			if err := c.release(ctx, spannerIAMPolicyMember, *applied); err != nil {
+				return err
+			}
+		}
+		spannerIAMPolicyMemberCopy := spannerIAMPolicyMember.DeepCopy()
+		spannerIAMPolicyMemberCopy.Finalizers = removeString(spannerIAMPolicyMemberCopy.Finalizers, revokeFinalizer)
+		_, err = c.spannerclientset.IamadminsV1alpha1().SpannerIAMPolicyMembers(namespace).Update(spannerIAMPolicyMemberCopy)
+		return err
+	}
+
+	desired := spannerIAMPolicyMember.Spec.SpannerIAMBinding
+	if err := validateBinding(desired); err != nil {
+		return c.rejectSpec(key, spannerIAMPolicyMember, ErrInvalidBinding, fmt.Sprintf(MessageInvalidBinding, err))
+	}
+
+	// Add the finalizer before granting anything, so a member can never be
+	// granted without being revoked later.
+	if !hasFinalizer(spannerIAMPolicyMember) {
+		spannerIAMPolicyMemberCopy := spannerIAMPolicyMember.DeepCopy()
+		spannerIAMPolicyMemberCopy.Finalizers = append(spannerIAMPolicyMemberCopy.Finalizers, revokeFinalizer)
+		spannerIAMPolicyMember, err = c.spannerclientset.IamadminsV1alpha1().SpannerIAMPolicyMembers(namespace).Update(spannerIAMPolicyMemberCopy)
+		if err != nil {
+			return err
+		}
+	}
+
+	granted := spannerIAMPolicyMember.Status.Granted || spannerIAMPolicyMember.Status.Granting
+	if applied := spannerIAMPolicyMember.Status.Applied; applied != nil && !c.sameBinding(*applied, desired) {
+		if granted {
+			log.Printf("spannerIAMPolicyMember binding changed from %+v to %+v, revoke the previous one", *applied, desired)
+			if err := c.release(ctx, spannerIAMPolicyMember, *applied); err != nil {
+				return err
+			}
+		}
+		granted = false
+	}
+	spannerIAMPolicyMember, added, err := c.grant(ctx, spannerIAMPolicyMember, desired)
+	if err != nil {
+		return err
+	}
+	granted = granted || added
+
+	// Finally, we update the status block of the SpannerIAMPolicyMember resource to reflect the
+	// current state of the world
+	if applied := spannerIAMPolicyMember.Status.Applied; applied == nil || *applied != desired || granted != spannerIAMPolicyMember.Status.Granted || spannerIAMPolicyMember.Status.Granting {
+		spannerIAMPolicyMemberCopy := spannerIAMPolicyMember.DeepCopy()
+		spannerIAMPolicyMemberCopy.Status.Applied = &desired
+		spannerIAMPolicyMemberCopy.Status.Granted = granted
+		spannerIAMPolicyMemberCopy.Status.Granting = false
+		if _, err := c.updateSpannerIAMPolicyMemberStatus(spannerIAMPolicyMemberCopy); err != nil {
+			return err
+		}
+	}
+
+	c.recorder.Event(spannerIAMPolicyMember, corev1.EventTypeNormal, SuccessSynced, MessageResourceSynced)
+	return nil
+}
+
+// grant adds the member of binding, applied by spannerIAMPolicyMember, to its
+// role, leaving every other member of the policy as it is, and reports whether
+// the member was added rather than found in the policy. A stale etag fails the
+// write, and the key is retried with a fresh policy.
+//
+// The binding is recorded as Granting before the policy is written, so the
+// member is revoked later even should its grant never be recorded. grant
+// returns spannerIAMPolicyMember as last updated.
+func (c *Controller) grant(ctx context.Context, spannerIAMPolicyMember *iamv1alpha1.SpannerIAMPolicyMember, binding iamv1alpha1.SpannerIAMBinding) (*iamv1alpha1.SpannerIAMPolicyMember, bool, error) {
+	op, err := c.operatorFor(spannerIAMPolicyMember, binding)
+	if err != nil {
+		return spannerIAMPolicyMember, false, err
+	}
+	policy, err := op.GetIamPolicy(ctx, binding.InstanceId, binding.Database)
+	if err != nil {
+		return spannerIAMPolicyMember, false, err
+	}
+	if !operator.AddIamMember(policy, binding.Role, binding.Member) {
+		return spannerIAMPolicyMember, false, nil
+	}
+	if applied := spannerIAMPolicyMember.Status.Applied; applied == nil || *applied != binding || !spannerIAMPolicyMember.Status.Granting {
+		spannerIAMPolicyMemberCopy := spannerIAMPolicyMember.DeepCopy()
+		if applied == nil || *applied != binding {
+			// Granted belongs to the binding applied before, already
+			// released by the caller.
+			spannerIAMPolicyMemberCopy.Status.Granted = false
+		}
+		spannerIAMPolicyMemberCopy.Status.Applied = &binding
+		spannerIAMPolicyMemberCopy.Status.Granting = true
+		updated, err := c.updateSpannerIAMPolicyMemberStatus(spannerIAMPolicyMemberCopy)
+		if err != nil {
+			return spannerIAMPolicyMember, false, err
+		}
+		spannerIAMPolicyMember = updated
+	}
+	log.Printf("Grant %s to %s on %s", binding.Role, binding.Member, bindingResource(binding))
+	if _, err := op.SetIamPolicy(ctx, binding.InstanceId, binding.Database, policy); err != nil {
+		return spannerIAMPolicyMember, false, err
+	}
+	return spannerIAMPolicyMember, true, nil
+}
+
+// release revokes binding, granted by spannerIAMPolicyMember, unless another
+// SpannerIAMPolicyMember still applies the same binding. That one is then
+// marked as having granted it, so the member is revoked along with the last
+// SpannerIAMPolicyMember applying it.
+func (c *Controller) release(ctx context.Context, spannerIAMPolicyMember *iamv1alpha1.SpannerIAMPolicyMember, binding iamv1alpha1.SpannerIAMBinding) error {
+	holders, err := c.spannerIAMPolicyMemberLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, holder := range holders {
+		if holder.Namespace == spannerIAMPolicyMember.Namespace && holder.Name == spannerIAMPolicyMember.Name {
+			continue
+		}
+		if holder.DeletionTimestamp != nil || holder.Status.Applied == nil || !c.sameBinding(*holder.Status.Applied, binding) {
+			continue
+		}
+		if holder.Status.Granted || holder.Status.Granting {
+			return nil
+		}
+		log.Printf("Hand %s of %s on %s over to spannerIAMPolicyMember %s/%s", binding.Role, binding.Member, bindingResource(binding), holder.Namespace, holder.Name)
+		holderCopy := holder.DeepCopy()
+		holderCopy.Status.Granted = true
+		_, err := c.updateSpannerIAMPolicyMemberStatus(holderCopy)
+		return err
+	}
+	return c.revoke(ctx, spannerIAMPolicyMember, binding)
+}
+
+// revoke removes the member of binding, applied by spannerIAMPolicyMember,
+// from its role. A resource that no longer exists has nothing left to revoke.
+func (c *Controller) revoke(ctx context.Context, spannerIAMPolicyMember *iamv1alpha1.SpannerIAMPolicyMember, binding iamv1alpha1.SpannerIAMBinding) error {
+	op, err := c.operatorFor(spannerIAMPolicyMember, binding)
+	if err != nil {
+		return err
+	}
+	policy, err := op.GetIamPolicy(ctx, binding.InstanceId, binding.Database)
+	if err != nil && op.IsNotFoundError(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !operator.RemoveIamMember(policy, binding.Role, binding.Member) {
+		return nil
+	}
+	log.Printf("Revoke %s from %s on %s", binding.Role, binding.Member, bindingResource(binding))
+	_, err = op.SetIamPolicy(ctx, binding.InstanceId, binding.Database, policy)
+	return err
+}
+
+// operatorFor returns the Operator of the project of binding, built with the
+// credentials the spec of spannerIAMPolicyMember selects. An invalid key is
+// reported as a warning Event.
+func (c *Controller) operatorFor(spannerIAMPolicyMember *iamv1alpha1.SpannerIAMPolicyMember, binding iamv1alpha1.SpannerIAMBinding) (operator.Operator, error) {
+	op, err := credentials.Operator(c.operators, c.secretLister, spannerIAMPolicyMember.Namespace, binding.ProjectId, spannerIAMPolicyMember.Spec.CredentialsSecretRef)
+	if err != nil {
+		c.recorder.Event(spannerIAMPolicyMember, corev1.EventTypeWarning, ErrCredentials, fmt.Sprintf(MessageCredentials, err))
+	}
+	return op, err
+}
+
+// rejectSpec reports an error in the spec of spannerIAMPolicyMember as a warning Event
+// without requeuing key. No retry can succeed before the spec is updated, and
+// updating it enqueues the SpannerIAMPolicyMember again.
+func (c *Controller) rejectSpec(key string, spannerIAMPolicyMember *iamv1alpha1.SpannerIAMPolicyMember, reason, message string) error {
+	c.recorder.Event(spannerIAMPolicyMember, corev1.EventTypeWarning, reason, message)
+	utilruntime.HandleError(fmt.Errorf("%s: %s", key, message))
+	return nil
+}
+
+func (c *Controller) updateSpannerIAMPolicyMemberStatus(spannerIAMPolicyMember *iamv1alpha1.SpannerIAMPolicyMember) (*iamv1alpha1.SpannerIAMPolicyMember, error) {
+	// NEVER modify objects from the store. It's a read-only, local cache.
+	// You can use DeepCopy() to make a deep copy of original object and modify this copy
+	// Or create a copy manually for better performance
+	spannerIAMPolicyMemberCopy := spannerIAMPolicyMember.DeepCopy()
+	// The SpannerIAMPolicyMember CRD enables the status subresource, so the
+	// Status block must be written through UpdateStatus.
+	return c.spannerclientset.IamadminsV1alpha1().SpannerIAMPolicyMembers(spannerIAMPolicyMember.Namespace).UpdateStatus(spannerIAMPolicyMemberCopy)
+}
+
+// validateBinding checks binding names a resource, a role and a member.
+func validateBinding(binding iamv1alpha1.SpannerIAMBinding) error {
+	if binding.InstanceId == "" {
+		return fmt.Errorf("instanceId is required")
+	}
+	if !strings.Contains(binding.Role, "roles/") {
+		return fmt.Errorf("role must be a role name like roles/spanner.databaseReader, got %q", binding.Role)
+	}
+	if !strings.Contains(binding.Member, ":") {
+		return fmt.Errorf("member must be prefixed with its type like user: or serviceAccount:, got %q", binding.Member)
+	}
+	return nil
+}
+
+// sameBinding reports whether a and b grant the same role to the same member
+// on the same resource, an empty ProjectId standing for the default project.
+func (c *Controller) sameBinding(a, b iamv1alpha1.SpannerIAMBinding) bool {
+	a.ProjectId = c.operators.ProjectId(a.ProjectId)
+	b.ProjectId = c.operators.ProjectId(b.ProjectId)
+	return a == b
+}
+
+// bindingResource describes the Spanner resource binding applies to.
+func bindingResource(binding iamv1alpha1.SpannerIAMBinding) string {
+	if binding.Database == "" {
+		return fmt.Sprintf("instance %s", binding.InstanceId)
+	}
+	return fmt.Sprintf("database %s/%s", binding.InstanceId, binding.Database)
+}
+
+func hasFinalizer(spannerIAMPolicyMember *iamv1alpha1.SpannerIAMPolicyMember) bool {
+	for _, f := range spannerIAMPolicyMember.Finalizers {
+		if f == revokeFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// removeString returns a copy of s without any occurrence of r.
+func removeString(s []string, r string) []string {
+	var result []string
+	for _, v := range s {
+		if v != r {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// enqueueSpannerIAMPolicyMember takes a SpannerIAMPolicyMember resource and converts it into a namespace/name
+// string which is then put onto the work queue. This method should *not* be
+// passed resources of any type other than SpannerIAMPolicyMember.
+func (c *Controller) enqueueSpannerIAMPolicyMember(obj interface{}) {
+	var key string
+	var err error
+	if key, err = cache.MetaNamespaceKeyFunc(obj); err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.workqueue.Add(key)
+}
@@ -0,0 +1,617 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package iamadmins
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/diff"
+	kubeinformers "k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	"google.golang.org/genproto/googleapis/iam/v1"
+
+	spannercontroller "github.com/katsew/spanner-operator/pkg/apis/iamadmins/v1alpha1"
+	"github.com/katsew/spanner-operator/pkg/generated/iamadmins/clientset/versioned/fake"
+	informers "github.com/katsew/spanner-operator/pkg/generated/iamadmins/informers/externalversions"
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+var (
+	alwaysReady        = func() bool { return true }
+	noResyncPeriodFunc = func() time.Duration { return 0 }
+)
+
+type fixture struct {
+	t *testing.T
+
+	client     *fake.Clientset
+	kubeclient *k8sfake.Clientset
+	// Operator the controller talks to Spanner through.
+	op *operator.Fake
+	// Pool handing out op for the default project, and a fake of its own
+	// for any other project.
+	operators *operator.Pool
+	// Service account keys operators were built with, in order.
+	keys []string
+	// Objects to put in the store.
+	spannerIAMPolicyMemberLister []*spannercontroller.SpannerIAMPolicyMember
+	secretLister                 []*corev1.Secret
+	// Actions expected to happen on the client.
+	actions []core.Action
+	// Objects from here preloaded into NewSimpleFake.
+	objects []runtime.Object
+}
+
+func newFixture(t *testing.T) *fixture {
+	f := &fixture{}
+	f.t = t
+	f.objects = []runtime.Object{}
+	f.op = operator.NewBuilder().ProjectId("test").BuildFake()
+	f.operators = operator.NewPool("test", func(projectId string, key []byte) (operator.Operator, error) {
+		if key != nil {
+			f.keys = append(f.keys, string(key))
+		}
+		if projectId == "test" {
+			return f.op, nil
+		}
+		return operator.NewBuilder().ProjectId(projectId).BuildFake(), nil
+	})
+	return f
+}
+
+func newSpannerIAMPolicyMember(name string, database string) *spannercontroller.SpannerIAMPolicyMember {
+	return &spannercontroller.SpannerIAMPolicyMember{
+		TypeMeta: metav1.TypeMeta{APIVersion: spannercontroller.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: spannercontroller.SpannerIAMPolicyMemberSpec{
+			SpannerIAMBinding: spannercontroller.SpannerIAMBinding{
+				InstanceId: "test",
+				Database:   database,
+				Role:       "roles/spanner.databaseReader",
+				Member:     "serviceAccount:app@test.iam.gserviceaccount.com",
+			},
+		},
+	}
+}
+
+func (f *fixture) newController() (*Controller, informers.SharedInformerFactory) {
+	f.client = fake.NewSimpleClientset(f.objects...)
+	f.kubeclient = k8sfake.NewSimpleClientset()
+
+	i := informers.NewSharedInformerFactory(f.client, noResyncPeriodFunc())
+	k8sI := kubeinformers.NewSharedInformerFactory(f.kubeclient, noResyncPeriodFunc())
+
+	c := NewController(f.kubeclient, f.client, i.Iamadmins().V1alpha1().SpannerIAMPolicyMembers(),
+		k8sI.Core().V1().Secrets(), f.operators)
+
+	c.spannerIAMPolicyMembersSynced = alwaysReady
+	c.secretsSynced = alwaysReady
+	c.recorder = &record.FakeRecorder{}
+
+	for _, m := range f.spannerIAMPolicyMemberLister {
+		i.Iamadmins().V1alpha1().SpannerIAMPolicyMembers().Informer().GetIndexer().Add(m)
+	}
+
+	for _, s := range f.secretLister {
+		k8sI.Core().V1().Secrets().Informer().GetIndexer().Add(s)
+	}
+
+	return c, i
+}
+
+func (f *fixture) run(spannerIAMPolicyMemberName string) {
+	f.runController(spannerIAMPolicyMemberName, true, false)
+}
+
+func (f *fixture) runExpectError(spannerIAMPolicyMemberName string) {
+	f.runController(spannerIAMPolicyMemberName, true, true)
+}
+
+func (f *fixture) runController(spannerIAMPolicyMemberName string, startInformers bool, expectError bool) {
+	c, i := f.newController()
+	if startInformers {
+		stopCh := make(chan struct{})
+		defer close(stopCh)
+		i.Start(stopCh)
+	}
+
+	err := c.syncHandler(context.Background(), spannerIAMPolicyMemberName)
+	if !expectError && err != nil {
+		f.t.Errorf("error syncing SpannerIAMPolicyMember: %v", err)
+	} else if expectError && err == nil {
+		f.t.Error("expected error syncing SpannerIAMPolicyMember, got nil")
+	}
+
+	actions := filterInformerActions(f.client.Actions())
+	for i, action := range actions {
+		if len(f.actions) < i+1 {
+			f.t.Errorf("%d unexpected actions: %+v", len(actions)-len(f.actions), actions[i:])
+			break
+		}
+
+		expectedAction := f.actions[i]
+		checkAction(expectedAction, action, f.t)
+	}
+
+	if len(f.actions) > len(actions) {
+		f.t.Errorf("%d additional expected actions:%+v", len(f.actions)-len(actions), f.actions[len(actions):])
+	}
+}
+
+// checkAction verifies that expected and actual actions are equal and both have
+// same attached resources
+func checkAction(expected, actual core.Action, t *testing.T) {
+	if !(expected.Matches(actual.GetVerb(), actual.GetResource().Resource) && actual.GetSubresource() == expected.GetSubresource()) {
+		t.Errorf("Expected\n\t%#v\ngot\n\t%#v", expected, actual)
+		return
+	}
+
+	if reflect.TypeOf(actual) != reflect.TypeOf(expected) {
+		t.Errorf("Action has wrong type. Expected: %t. Got: %t", expected, actual)
+		return
+	}
+
+	switch a := actual.(type) {
+	case core.CreateAction:
+		e, _ := expected.(core.CreateAction)
+		expObject := e.GetObject()
+		object := a.GetObject()
+
+		if !reflect.DeepEqual(expObject, object) {
+			t.Errorf("Action %s %s has wrong object\nDiff:\n %s",
+				a.GetVerb(), a.GetResource().Resource, diff.ObjectGoPrintDiff(expObject, object))
+		}
+	case core.UpdateAction:
+		e, _ := expected.(core.UpdateAction)
+		expObject := e.GetObject()
+		object := a.GetObject()
+
+		if !reflect.DeepEqual(expObject, object) {
+			t.Errorf("Action %s %s has wrong object\nDiff:\n %s",
+				a.GetVerb(), a.GetResource().Resource, diff.ObjectGoPrintDiff(expObject, object))
+		}
+	case core.PatchAction:
+		e, _ := expected.(core.PatchAction)
+		expPatch := e.GetPatch()
+		patch := a.GetPatch()
+
+		if !reflect.DeepEqual(expPatch, patch) {
+			t.Errorf("Action %s %s has wrong patch\nDiff:\n %s",
+				a.GetVerb(), a.GetResource().Resource, diff.ObjectGoPrintDiff(expPatch, patch))
+		}
+	}
+}
+
+// filterInformerActions filters list and watch actions for testing resources.
+// Since list and watch don't change resource state we can filter it to lower
+// nose level in our tests.
+func filterInformerActions(actions []core.Action) []core.Action {
+	ret := []core.Action{}
+	for _, action := range actions {
+		if len(action.GetNamespace()) == 0 &&
+			(action.Matches("list", "spanneriampolicymembers") ||
+				action.Matches("watch", "spanneriampolicymembers")) {
+			continue
+		}
+		ret = append(ret, action)
+	}
+
+	return ret
+}
+
+func (f *fixture) expectUpdateSpannerIAMPolicyMemberAction(spannerIAMPolicyMember *spannercontroller.SpannerIAMPolicyMember) {
+	action := core.NewUpdateAction(schema.GroupVersionResource{Resource: "spanneriampolicymembers"}, spannerIAMPolicyMember.Namespace, spannerIAMPolicyMember)
+	f.actions = append(f.actions, action)
+}
+
+func (f *fixture) expectUpdateSpannerIAMPolicyMemberStatusAction(spannerIAMPolicyMember *spannercontroller.SpannerIAMPolicyMember) {
+	action := core.NewUpdateAction(schema.GroupVersionResource{Resource: "spanneriampolicymembers"}, spannerIAMPolicyMember.Namespace, spannerIAMPolicyMember)
+	action.Subresource = "status"
+	f.actions = append(f.actions, action)
+}
+
+// expectGranting expects the binding of spannerIAMPolicyMember to be recorded
+// as Granting before it is granted, and returns the recorded
+// SpannerIAMPolicyMember.
+func (f *fixture) expectGranting(spannerIAMPolicyMember *spannercontroller.SpannerIAMPolicyMember) *spannercontroller.SpannerIAMPolicyMember {
+	granting := spannerIAMPolicyMember.DeepCopy()
+	granting.Status.Applied = &granting.Spec.SpannerIAMBinding
+	granting.Status.Granted = false
+	granting.Status.Granting = true
+	f.expectUpdateSpannerIAMPolicyMemberStatusAction(granting)
+	return granting.DeepCopy()
+}
+
+// createDatabase makes the operator hold the database name in the "test"
+// instance, with a policy granting role to member on the database.
+func (f *fixture) createDatabase(name string, role string, member string) {
+	ctx := context.Background()
+	if _, err := f.op.CreateInstance(ctx, "test", "test", "", operator.Nodes(1)); err != nil {
+		f.t.Fatal(err)
+	}
+	if _, err := f.op.CreateDatabase(ctx, "test", name, nil); err != nil {
+		f.t.Fatal(err)
+	}
+	policy := &iam.Policy{Bindings: []*iam.Binding{{Role: role, Members: []string{member}}}}
+	if _, err := f.op.SetIamPolicy(ctx, "test", name, policy); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// members returns the members of role in the policy of the database name.
+func (f *fixture) members(name string, role string) []string {
+	policy, err := f.op.GetIamPolicy(context.Background(), "test", name)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	for _, b := range policy.Bindings {
+		if b.Role == role {
+			return b.Members
+		}
+	}
+	return nil
+}
+
+func getKey(spannerIAMPolicyMember *spannercontroller.SpannerIAMPolicyMember, t *testing.T) string {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(spannerIAMPolicyMember)
+	if err != nil {
+		t.Errorf("Unexpected error getting key for SpannerIAMPolicyMember %v: %v", spannerIAMPolicyMember.Name, err)
+		return ""
+	}
+	return key
+}
+
+func TestGrantsMemberAdditively(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb", "roles/spanner.databaseReader", "user:someone@example.com")
+	spannerIAMPolicyMember := newSpannerIAMPolicyMember("test", "testdb")
+
+	f.spannerIAMPolicyMemberLister = append(f.spannerIAMPolicyMemberLister, spannerIAMPolicyMember)
+	f.objects = append(f.objects, spannerIAMPolicyMember)
+
+	expMember := spannerIAMPolicyMember.DeepCopy()
+	expMember.Finalizers = []string{revokeFinalizer}
+	f.expectUpdateSpannerIAMPolicyMemberAction(expMember)
+	expMember = f.expectGranting(expMember)
+	expMember.Status.Granted = true
+	expMember.Status.Granting = false
+	f.expectUpdateSpannerIAMPolicyMemberStatusAction(expMember)
+
+	f.run(getKey(spannerIAMPolicyMember, t))
+
+	expMembers := []string{"user:someone@example.com", "serviceAccount:app@test.iam.gserviceaccount.com"}
+	if members := f.members("testdb", "roles/spanner.databaseReader"); !reflect.DeepEqual(members, expMembers) {
+		t.Errorf("expected members %v, got %v", expMembers, members)
+	}
+}
+
+func TestGrantsMemberInProject(t *testing.T) {
+	f := newFixture(t)
+	other, err := f.operators.Get("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := other.CreateInstance(ctx, "test", "test", "", operator.Nodes(1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.CreateDatabase(ctx, "test", "testdb", nil); err != nil {
+		t.Fatal(err)
+	}
+	spannerIAMPolicyMember := newSpannerIAMPolicyMember("test", "testdb")
+	spannerIAMPolicyMember.Spec.ProjectId = "other"
+
+	f.spannerIAMPolicyMemberLister = append(f.spannerIAMPolicyMemberLister, spannerIAMPolicyMember)
+	f.objects = append(f.objects, spannerIAMPolicyMember)
+
+	expMember := spannerIAMPolicyMember.DeepCopy()
+	expMember.Finalizers = []string{revokeFinalizer}
+	f.expectUpdateSpannerIAMPolicyMemberAction(expMember)
+	expMember = f.expectGranting(expMember)
+	expMember.Status.Granted = true
+	expMember.Status.Granting = false
+	f.expectUpdateSpannerIAMPolicyMemberStatusAction(expMember)
+
+	f.run(getKey(spannerIAMPolicyMember, t))
+
+	policy, err := other.GetIamPolicy(ctx, "test", "testdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policy.Bindings) != 1 || policy.Bindings[0].Members[0] != spannerIAMPolicyMember.Spec.Member {
+		t.Errorf("expected the member to be granted in project other, got %v", policy.Bindings)
+	}
+}
+
+func TestGrantsMemberWithSecretCredentials(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb", "roles/spanner.databaseReader", "user:someone@example.com")
+	spannerIAMPolicyMember := newSpannerIAMPolicyMember("test", "testdb")
+	spannerIAMPolicyMember.Spec.CredentialsSecretRef = &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "spanner-key"},
+		Key:                  "key.json",
+	}
+
+	f.spannerIAMPolicyMemberLister = append(f.spannerIAMPolicyMemberLister, spannerIAMPolicyMember)
+	f.objects = append(f.objects, spannerIAMPolicyMember)
+	f.secretLister = append(f.secretLister, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "spanner-key", Namespace: metav1.NamespaceDefault, ResourceVersion: "1"},
+		Data:       map[string][]byte{"key.json": []byte("key-1")},
+	})
+
+	expMember := spannerIAMPolicyMember.DeepCopy()
+	expMember.Finalizers = []string{revokeFinalizer}
+	f.expectUpdateSpannerIAMPolicyMemberAction(expMember)
+	expMember = f.expectGranting(expMember)
+	expMember.Status.Granted = true
+	expMember.Status.Granting = false
+	f.expectUpdateSpannerIAMPolicyMemberStatusAction(expMember)
+
+	f.run(getKey(spannerIAMPolicyMember, t))
+
+	if !reflect.DeepEqual(f.keys, []string{"key-1"}) {
+		t.Errorf("expected an operator built with key-1, got %v", f.keys)
+	}
+}
+
+func TestRevokesPreviousBinding(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb", "roles/spanner.databaseUser", "serviceAccount:app@test.iam.gserviceaccount.com")
+	spannerIAMPolicyMember := newSpannerIAMPolicyMember("test", "testdb")
+	spannerIAMPolicyMember.Finalizers = []string{revokeFinalizer}
+	previous := spannerIAMPolicyMember.Spec.SpannerIAMBinding
+	previous.Role = "roles/spanner.databaseUser"
+	spannerIAMPolicyMember.Status.Applied = &previous
+	spannerIAMPolicyMember.Status.Granted = true
+
+	f.spannerIAMPolicyMemberLister = append(f.spannerIAMPolicyMemberLister, spannerIAMPolicyMember)
+	f.objects = append(f.objects, spannerIAMPolicyMember)
+
+	expMember := f.expectGranting(spannerIAMPolicyMember)
+	expMember.Status.Granted = true
+	expMember.Status.Granting = false
+	f.expectUpdateSpannerIAMPolicyMemberStatusAction(expMember)
+
+	f.run(getKey(spannerIAMPolicyMember, t))
+
+	if members := f.members("testdb", "roles/spanner.databaseUser"); len(members) != 0 {
+		t.Errorf("expected previous role to be revoked, got members %v", members)
+	}
+	if members := f.members("testdb", "roles/spanner.databaseReader"); len(members) != 1 {
+		t.Errorf("expected new role to be granted, got members %v", members)
+	}
+}
+
+func TestRevokesMemberOnDeletion(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb", "roles/spanner.databaseReader", "user:someone@example.com")
+	spannerIAMPolicyMember := newSpannerIAMPolicyMember("test", "testdb")
+	binding := spannerIAMPolicyMember.Spec.SpannerIAMBinding
+	if _, err := f.op.SetIamPolicy(context.Background(), "test", "testdb", &iam.Policy{Bindings: []*iam.Binding{
+		{Role: binding.Role, Members: []string{"user:someone@example.com", binding.Member}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	now := metav1.Now()
+	spannerIAMPolicyMember.DeletionTimestamp = &now
+	spannerIAMPolicyMember.Finalizers = []string{revokeFinalizer}
+	spannerIAMPolicyMember.Status.Applied = &binding
+	spannerIAMPolicyMember.Status.Granted = true
+
+	f.spannerIAMPolicyMemberLister = append(f.spannerIAMPolicyMemberLister, spannerIAMPolicyMember)
+	f.objects = append(f.objects, spannerIAMPolicyMember)
+
+	expMember := spannerIAMPolicyMember.DeepCopy()
+	expMember.Finalizers = nil
+	f.expectUpdateSpannerIAMPolicyMemberAction(expMember)
+
+	f.run(getKey(spannerIAMPolicyMember, t))
+
+	expMembers := []string{"user:someone@example.com"}
+	if members := f.members("testdb", binding.Role); !reflect.DeepEqual(members, expMembers) {
+		t.Errorf("expected members %v, got %v", expMembers, members)
+	}
+}
+
+func TestKeepsMemberGrantedOutsideOnDeletion(t *testing.T) {
+	f := newFixture(t)
+	spannerIAMPolicyMember := newSpannerIAMPolicyMember("test", "testdb")
+	binding := spannerIAMPolicyMember.Spec.SpannerIAMBinding
+	f.createDatabase("testdb", binding.Role, binding.Member)
+
+	f.spannerIAMPolicyMemberLister = append(f.spannerIAMPolicyMemberLister, spannerIAMPolicyMember)
+	f.objects = append(f.objects, spannerIAMPolicyMember)
+
+	expMember := spannerIAMPolicyMember.DeepCopy()
+	expMember.Finalizers = []string{revokeFinalizer}
+	f.expectUpdateSpannerIAMPolicyMemberAction(expMember)
+	expMember = expMember.DeepCopy()
+	expMember.Status.Applied = &binding
+	f.expectUpdateSpannerIAMPolicyMemberStatusAction(expMember)
+
+	f.run(getKey(spannerIAMPolicyMember, t))
+
+	f = newFixture(t)
+	f.createDatabase("testdb", binding.Role, binding.Member)
+	now := metav1.Now()
+	expMember.DeletionTimestamp = &now
+	f.spannerIAMPolicyMemberLister = append(f.spannerIAMPolicyMemberLister, expMember)
+	f.objects = append(f.objects, expMember)
+
+	released := expMember.DeepCopy()
+	released.Finalizers = nil
+	f.expectUpdateSpannerIAMPolicyMemberAction(released)
+
+	f.run(getKey(expMember, t))
+
+	expMembers := []string{binding.Member}
+	if members := f.members("testdb", binding.Role); !reflect.DeepEqual(members, expMembers) {
+		t.Errorf("expected members %v, got %v", expMembers, members)
+	}
+}
+
+func TestHandsSharedMemberOverOnDeletion(t *testing.T) {
+	f := newFixture(t)
+	spannerIAMPolicyMember := newSpannerIAMPolicyMember("test", "testdb")
+	binding := spannerIAMPolicyMember.Spec.SpannerIAMBinding
+	f.createDatabase("testdb", binding.Role, binding.Member)
+	now := metav1.Now()
+	spannerIAMPolicyMember.DeletionTimestamp = &now
+	spannerIAMPolicyMember.Finalizers = []string{revokeFinalizer}
+	spannerIAMPolicyMember.Status.Applied = &binding
+	spannerIAMPolicyMember.Status.Granted = true
+	other := newSpannerIAMPolicyMember("other", "testdb")
+	other.Finalizers = []string{revokeFinalizer}
+	other.Status.Applied = &binding
+
+	f.spannerIAMPolicyMemberLister = append(f.spannerIAMPolicyMemberLister, spannerIAMPolicyMember, other)
+	f.objects = append(f.objects, spannerIAMPolicyMember, other)
+
+	expOther := other.DeepCopy()
+	expOther.Status.Granted = true
+	f.expectUpdateSpannerIAMPolicyMemberStatusAction(expOther)
+	expMember := spannerIAMPolicyMember.DeepCopy()
+	expMember.Finalizers = nil
+	f.expectUpdateSpannerIAMPolicyMemberAction(expMember)
+
+	f.run(getKey(spannerIAMPolicyMember, t))
+
+	expMembers := []string{binding.Member}
+	if members := f.members("testdb", binding.Role); !reflect.DeepEqual(members, expMembers) {
+		t.Errorf("expected members %v, got %v", expMembers, members)
+	}
+}
+
+// A member written to the policy without its grant being recorded is still
+// the controller's to revoke.
+func TestRecordsGrantOfMemberGranting(t *testing.T) {
+	f := newFixture(t)
+	spannerIAMPolicyMember := newSpannerIAMPolicyMember("test", "testdb")
+	binding := spannerIAMPolicyMember.Spec.SpannerIAMBinding
+	f.createDatabase("testdb", binding.Role, binding.Member)
+	spannerIAMPolicyMember.Finalizers = []string{revokeFinalizer}
+	spannerIAMPolicyMember.Status.Applied = &binding
+	spannerIAMPolicyMember.Status.Granting = true
+
+	f.spannerIAMPolicyMemberLister = append(f.spannerIAMPolicyMemberLister, spannerIAMPolicyMember)
+	f.objects = append(f.objects, spannerIAMPolicyMember)
+
+	expMember := spannerIAMPolicyMember.DeepCopy()
+	expMember.Status.Granted = true
+	expMember.Status.Granting = false
+	f.expectUpdateSpannerIAMPolicyMemberStatusAction(expMember)
+
+	f.run(getKey(spannerIAMPolicyMember, t))
+}
+
+func TestRevokesMemberGrantingOnDeletion(t *testing.T) {
+	f := newFixture(t)
+	spannerIAMPolicyMember := newSpannerIAMPolicyMember("test", "testdb")
+	binding := spannerIAMPolicyMember.Spec.SpannerIAMBinding
+	f.createDatabase("testdb", binding.Role, binding.Member)
+	now := metav1.Now()
+	spannerIAMPolicyMember.DeletionTimestamp = &now
+	spannerIAMPolicyMember.Finalizers = []string{revokeFinalizer}
+	spannerIAMPolicyMember.Status.Applied = &binding
+	spannerIAMPolicyMember.Status.Granting = true
+
+	f.spannerIAMPolicyMemberLister = append(f.spannerIAMPolicyMemberLister, spannerIAMPolicyMember)
+	f.objects = append(f.objects, spannerIAMPolicyMember)
+
+	expMember := spannerIAMPolicyMember.DeepCopy()
+	expMember.Finalizers = nil
+	f.expectUpdateSpannerIAMPolicyMemberAction(expMember)
+
+	f.run(getKey(spannerIAMPolicyMember, t))
+
+	if members := f.members("testdb", binding.Role); len(members) != 0 {
+		t.Errorf("expected the member to be revoked, got members %v", members)
+	}
+}
+
+// A binding naming the default project explicitly is the same binding as one
+// leaving the project empty.
+func TestKeepsMemberSharedInDefaultProjectOnDeletion(t *testing.T) {
+	f := newFixture(t)
+	spannerIAMPolicyMember := newSpannerIAMPolicyMember("test", "testdb")
+	binding := spannerIAMPolicyMember.Spec.SpannerIAMBinding
+	f.createDatabase("testdb", binding.Role, binding.Member)
+	now := metav1.Now()
+	spannerIAMPolicyMember.DeletionTimestamp = &now
+	spannerIAMPolicyMember.Finalizers = []string{revokeFinalizer}
+	spannerIAMPolicyMember.Status.Applied = &binding
+	spannerIAMPolicyMember.Status.Granted = true
+	other := newSpannerIAMPolicyMember("other", "testdb")
+	other.Spec.ProjectId = "test"
+	other.Finalizers = []string{revokeFinalizer}
+	other.Status.Applied = &other.Spec.SpannerIAMBinding
+	other.Status.Granted = true
+
+	f.spannerIAMPolicyMemberLister = append(f.spannerIAMPolicyMemberLister, spannerIAMPolicyMember, other)
+	f.objects = append(f.objects, spannerIAMPolicyMember, other)
+
+	expMember := spannerIAMPolicyMember.DeepCopy()
+	expMember.Finalizers = nil
+	f.expectUpdateSpannerIAMPolicyMemberAction(expMember)
+
+	f.run(getKey(spannerIAMPolicyMember, t))
+
+	expMembers := []string{binding.Member}
+	if members := f.members("testdb", binding.Role); !reflect.DeepEqual(members, expMembers) {
+		t.Errorf("expected members %v, got %v", expMembers, members)
+	}
+}
+
+func TestMissingDatabase(t *testing.T) {
+	f := newFixture(t)
+	spannerIAMPolicyMember := newSpannerIAMPolicyMember("test", "testdb")
+
+	f.spannerIAMPolicyMemberLister = append(f.spannerIAMPolicyMemberLister, spannerIAMPolicyMember)
+	f.objects = append(f.objects, spannerIAMPolicyMember)
+
+	expMember := spannerIAMPolicyMember.DeepCopy()
+	expMember.Finalizers = []string{revokeFinalizer}
+	f.expectUpdateSpannerIAMPolicyMemberAction(expMember)
+
+	f.runExpectError(getKey(spannerIAMPolicyMember, t))
+}
+
+func TestRejectsInvalidBinding(t *testing.T) {
+	f := newFixture(t)
+	f.createDatabase("testdb", "roles/spanner.databaseReader", "user:someone@example.com")
+	spannerIAMPolicyMember := newSpannerIAMPolicyMember("test", "testdb")
+	spannerIAMPolicyMember.Spec.Member = "app@test.iam.gserviceaccount.com"
+
+	f.spannerIAMPolicyMemberLister = append(f.spannerIAMPolicyMemberLister, spannerIAMPolicyMember)
+	f.objects = append(f.objects, spannerIAMPolicyMember)
+
+	f.run(getKey(spannerIAMPolicyMember, t))
+}
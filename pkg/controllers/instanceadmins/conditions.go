@@ -0,0 +1,112 @@
+package instanceadmins
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"google.golang.org/genproto/googleapis/spanner/admin/instance/v1"
+
+	instancev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/instanceadmins/v1alpha1"
+)
+
+// Reasons of SpannerInstance conditions. Errors are reported with the reason
+// of the Event emitted along.
+const (
+	// ReasonCreating is the condition reason while the instance is created.
+	ReasonCreating = "Creating"
+	// ReasonScaling is the condition reason while the instance is scaled.
+	ReasonScaling = "Scaling"
+	// ReasonDeleting is the condition reason while the instance is deleted.
+	ReasonDeleting = "Deleting"
+
+	// MessageCreating is the condition message while the instance is created
+	MessageCreating = "Creating instance %q"
+	// MessageScaling is the condition message while the instance is scaled
+	MessageScaling = "Scaling from %s to %s"
+	// MessageDeleting is the condition message while the instance is deleted
+	MessageDeleting = "Deleting instance %q"
+)
+
+// setCondition sets the condition conditionType of status. Its last
+// transition time only moves to now when its status changes. It reports
+// whether the condition changed.
+func setCondition(status *instancev1alpha1.SpannerInstanceStatus, conditionType instancev1alpha1.SpannerInstanceConditionType, conditionStatus corev1.ConditionStatus, reason, message string, now time.Time) bool {
+	for i := range status.Conditions {
+		cond := &status.Conditions[i]
+		if cond.Type != conditionType {
+			continue
+		}
+		if cond.Status == conditionStatus && cond.Reason == reason && cond.Message == message {
+			return false
+		}
+		if cond.Status != conditionStatus {
+			cond.LastTransitionTime = metav1.NewTime(now)
+		}
+		cond.Status = conditionStatus
+		cond.Reason = reason
+		cond.Message = message
+		return true
+	}
+	status.Conditions = append(status.Conditions, instancev1alpha1.SpannerInstanceCondition{
+		Type:               conditionType,
+		Status:             conditionStatus,
+		LastTransitionTime: metav1.NewTime(now),
+		Reason:             reason,
+		Message:            message,
+	})
+	return true
+}
+
+// conditionTrue reports whether the condition conditionType of status is true.
+func conditionTrue(status instancev1alpha1.SpannerInstanceStatus, conditionType instancev1alpha1.SpannerInstanceConditionType) bool {
+	for _, cond := range status.Conditions {
+		if cond.Type == conditionType {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// markCreating sets the conditions of status for the instance instanceName
+// being created.
+func markCreating(status *instancev1alpha1.SpannerInstanceStatus, instanceName string, now time.Time) {
+	message := fmt.Sprintf(MessageCreating, instanceName)
+	setCondition(status, instancev1alpha1.SpannerInstanceReady, corev1.ConditionFalse, ReasonCreating, message, now)
+	setCondition(status, instancev1alpha1.SpannerInstanceProvisioning, corev1.ConditionTrue, ReasonCreating, message, now)
+}
+
+// markSynced sets the conditions of status for inst, which matches the spec.
+func markSynced(status *instancev1alpha1.SpannerInstanceStatus, inst *instance.Instance, now time.Time) {
+	if inst.State == instance.Instance_READY {
+		setCondition(status, instancev1alpha1.SpannerInstanceReady, corev1.ConditionTrue, SuccessSynced, MessageResourceSynced, now)
+		setCondition(status, instancev1alpha1.SpannerInstanceProvisioning, corev1.ConditionFalse, SuccessSynced, MessageResourceSynced, now)
+	} else {
+		markCreating(status, inst.Name, now)
+	}
+	for _, conditionType := range []instancev1alpha1.SpannerInstanceConditionType{
+		instancev1alpha1.SpannerInstanceScaling,
+		instancev1alpha1.SpannerInstanceDegraded,
+		instancev1alpha1.SpannerInstanceError,
+	} {
+		setCondition(status, conditionType, corev1.ConditionFalse, SuccessSynced, MessageResourceSynced, now)
+	}
+}
+
+// markOperationFailed sets the conditions of status for its pending
+// operation having failed with message. A failed creation is an error,
+// other failures leave the instance serving, degraded.
+func markOperationFailed(status *instancev1alpha1.SpannerInstanceStatus, message string, now time.Time) {
+	if conditionTrue(*status, instancev1alpha1.SpannerInstanceProvisioning) {
+		setCondition(status, instancev1alpha1.SpannerInstanceReady, corev1.ConditionFalse, ErrOperationFailed, message, now)
+		setCondition(status, instancev1alpha1.SpannerInstanceProvisioning, corev1.ConditionFalse, ErrOperationFailed, message, now)
+		setCondition(status, instancev1alpha1.SpannerInstanceError, corev1.ConditionTrue, ErrOperationFailed, message, now)
+		return
+	}
+	if conditionTrue(*status, instancev1alpha1.SpannerInstanceScaling) {
+		setCondition(status, instancev1alpha1.SpannerInstanceScaling, corev1.ConditionFalse, ErrOperationFailed, message, now)
+	}
+	setCondition(status, instancev1alpha1.SpannerInstanceDegraded, corev1.ConditionTrue, ErrOperationFailed, message, now)
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package instanceadmins
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
@@ -26,38 +27,132 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
 
+	"google.golang.org/genproto/googleapis/spanner/admin/instance/v1"
+
 	instancev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/instanceadmins/v1alpha1"
+	databaseclientset "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/clientset/versioned"
+	databaseinformers "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/informers/externalversions/databaseadmins/v1alpha1"
 	clientset "github.com/katsew/spanner-operator/pkg/generated/instanceadmins/clientset/versioned"
 	spannerscheme "github.com/katsew/spanner-operator/pkg/generated/instanceadmins/clientset/versioned/scheme"
 	informers "github.com/katsew/spanner-operator/pkg/generated/instanceadmins/informers/externalversions/instanceadmins/v1alpha1"
 	listers "github.com/katsew/spanner-operator/pkg/generated/instanceadmins/listers/instanceadmins/v1alpha1"
 
+	"github.com/katsew/spanner-operator/pkg/controllers/databaseadmins"
+	"github.com/katsew/spanner-operator/pkg/credentials"
 	"github.com/katsew/spanner-operator/pkg/operator"
 )
 
 const controllerAgentName = "spanner-controller"
 
+// syncTimeout bounds a single syncHandler call, including every Spanner admin
+// call it makes, so a hung long-running operation cannot pin a worker forever.
+const syncTimeout = 10 * time.Minute
+
+// operationPollInterval is how long to wait before polling a pending
+// long-running operation again.
+const operationPollInterval = 10 * time.Second
+
 const (
 	// SuccessSynced is used as part of the Event 'reason' when a SpannerInstance is synced
 	SuccessSynced = "Synced"
+	// SuccessAdopted is used as part of the Event 'reason' when a SpannerInstance
+	// takes over a Spanner instance that already existed.
+	SuccessAdopted = "Adopted"
+	// SuccessDeleted is used as part of the Event 'reason' when the Spanner
+	// instance of a deleted SpannerInstance is deleted.
+	SuccessDeleted = "Deleted"
+	// SuccessRetained is used as part of the Event 'reason' when the Spanner
+	// instance of a deleted SpannerInstance is kept.
+	SuccessRetained = "Retained"
 	// ErrResourceExists is used as part of the Event 'reason' when a SpannerInstance fails
-	// to sync due to a Deployment of the same name already existing.
+	// to sync due to a Spanner instance of the same name already existing.
 	ErrResourceExists = "ErrResourceExists"
 
+	// ErrOperationFailed is used as part of the Event 'reason' when a long-running
+	// operation started for a SpannerInstance finishes with an error.
+	ErrOperationFailed = "ErrOperationFailed"
+	// ErrInvalidCapacity is used as part of the Event 'reason' when the
+	// nodeCount or processingUnits of a SpannerInstance is not acceptable.
+	ErrInvalidCapacity = "ErrInvalidCapacity"
+	// ErrInvalidInstanceId is used as part of the Event 'reason' when the
+	// instance ID of a SpannerInstance is not one Spanner accepts.
+	ErrInvalidInstanceId = "ErrInvalidInstanceId"
+	// ErrInvalidInstanceConfig is used as part of the Event 'reason' when the
+	// instanceConfig of a SpannerInstance is not available in the project.
+	ErrInvalidInstanceConfig = "ErrInvalidInstanceConfig"
+	// ErrCredentials is used as part of the Event 'reason' when the
+	// credentials a SpannerInstance selects cannot be read.
+	ErrCredentials = "ErrCredentials"
+	// ErrInvalidDeletionPolicy is used as part of the Event 'reason' when the
+	// deletionPolicy of a SpannerInstance is not acceptable.
+	ErrInvalidDeletionPolicy = "ErrInvalidDeletionPolicy"
+	// ErrDeletionProtected is used as part of the Event 'reason' when a
+	// deleted SpannerInstance is kept by its deletionProtection.
+	ErrDeletionProtected = "ErrDeletionProtected"
+	// ErrInstanceNotEmpty is used as part of the Event 'reason' when the
+	// instance of a deleted SpannerInstance is kept for the databases or
+	// backups still in it.
+	ErrInstanceNotEmpty = "ErrInstanceNotEmpty"
+	// WaitingForDatabases is used as part of the Event 'reason' when the
+	// instance of a deleted SpannerInstance waits for its SpannerDatabases
+	// to be deleted first.
+	WaitingForDatabases = "WaitingForDatabases"
+
 	// MessageResourceExists is the message used for Events when a resource
-	// fails to sync due to a Deployment already existing
-	MessageResourceExists = "Resource %q already exists and is not managed by Spanner"
+	// fails to sync due to a Spanner instance already existing
+	MessageResourceExists = "Resource %q already exists and is not managed by SpannerInstance, set the %s annotation to \"true\" to adopt it"
+	// MessageResourceAdopted is the message used for an Event fired when an
+	// existing Spanner instance is adopted
+	MessageResourceAdopted = "Adopted existing resource %q"
+	// MessageResourceRecorded is the message used for an Event fired when the
+	// instance created by an earlier version of the controller is recorded.
+	MessageResourceRecorded = "Recorded resource %q created by an earlier version of the controller"
+	// MessageResourceDeleted is the message used for an Event fired when the
+	// Spanner instance is deleted along with its SpannerInstance
+	MessageResourceDeleted = "Deleted resource %q"
+	// MessageResourceRetained is the message used for an Event fired when the
+	// Spanner instance is kept after its SpannerInstance is deleted
+	MessageResourceRetained = "Retained resource %q"
 	// MessageResourceSynced is the message used for an Event fired when a Spanner
 	// is synced successfully
 	MessageResourceSynced = "SpannerInstance synced successfully"
+	// MessageOperationFailed is the message used for an Event fired when a
+	// long-running operation fails
+	MessageOperationFailed = "Operation %s failed: %v"
+	// MessageInvalidCapacity is the message used for an Event fired when the
+	// capacity in the spec is rejected
+	MessageInvalidCapacity = "Invalid capacity: %v"
+	// MessageInvalidInstanceId is the message used for an Event fired when the
+	// instance ID in the spec, or the name it defaults to, is rejected
+	MessageInvalidInstanceId = "Invalid instanceId: %v"
+	// MessageInvalidInstanceConfig is the message used for an Event fired when
+	// the instance config in the spec is rejected
+	MessageInvalidInstanceConfig = "Invalid instanceConfig: %v"
+	// MessageCredentials is the message used for an Event fired when the
+	// credentials in the spec cannot be read
+	MessageCredentials = "Invalid credentialsSecretRef: %v"
+	// MessageInvalidDeletionPolicy is the message used for an Event fired when
+	// the deletion policy in the spec is rejected
+	MessageInvalidDeletionPolicy = "Invalid deletionPolicy: %v"
+	// MessageDeletionProtected is the message used for an Event fired when
+	// the deletion of a protected SpannerInstance is refused
+	MessageDeletionProtected = "Refusing to delete %q while deletionProtection is on, set it to false first"
+	// MessageInstanceNotEmpty is the message used for an Event fired when
+	// the deletion of an instance holding databases or backups is refused
+	MessageInstanceNotEmpty = "Refusing to delete %q while it holds %s, delete them or set deletionPolicy to Retain"
+	// MessageWaitingForDatabases is the message used for an Event fired
+	// while the deletion of an instance waits for its SpannerDatabases
+	MessageWaitingForDatabases = "Waiting for SpannerDatabases %s to be deleted before deleting %q"
 )
 
 // Controller is the controller implementation for SpannerInstance resources
@@ -66,10 +161,18 @@ type Controller struct {
 	kubeclientset kubernetes.Interface
 	// spannerclientset is a clientset for our own API group
 	spannerclientset clientset.Interface
+	// databaseclientset deletes the SpannerDatabases of deleted
+	// SpannerInstances.
+	databaseclientset databaseclientset.Interface
 
 	spannerInstanceLister  listers.SpannerInstanceLister
 	spannerInstancesSynced cache.InformerSynced
 
+	// spannerDatabaseIndexer finds the SpannerDatabases of a SpannerInstance
+	// by databaseadmins.InstanceIndex.
+	spannerDatabaseIndexer cache.Indexer
+	spannerDatabasesSynced cache.InformerSynced
+
 	// workqueue is a rate limited work queue. This is used to queue work to be
 	// processed instead of performing it as soon as a change happens. This
 	// means we can ensure we only process a fixed amount of resources at a
@@ -80,7 +183,16 @@ type Controller struct {
 	// Kubernetes API.
 	recorder record.EventRecorder
 
-	operator operator.Operator
+	// operators hands out the Operator of the project of a SpannerInstance.
+	operators *operator.Pool
+
+	secretLister  corelisters.SecretLister
+	secretsSynced cache.InformerSynced
+
+	// syncTimeout is the deadline applied to each syncHandler call.
+	syncTimeout time.Duration
+	// now returns the current time, which conditions are stamped with.
+	now func() time.Time
 }
 
 // NewController returns a new spanner controller
@@ -88,7 +200,10 @@ func NewController(
 	kubeclientset kubernetes.Interface,
 	spannerclientset clientset.Interface,
 	spannerInstanceInformer informers.SpannerInstanceInformer,
-	op operator.Operator) *Controller {
+	databaseclientset databaseclientset.Interface,
+	spannerDatabaseInformer databaseinformers.SpannerDatabaseInformer,
+	secretInformer coreinformers.SecretInformer,
+	operators *operator.Pool) *Controller {
 
 	// Create event broadcaster
 	// Add spanner-controller types to the default Kubernetes Scheme so Events can be
@@ -105,11 +220,20 @@ func NewController(
 		spannerclientset:       spannerclientset,
 		spannerInstanceLister:  spannerInstanceInformer.Lister(),
 		spannerInstancesSynced: spannerInstanceInformer.Informer().HasSynced,
+		databaseclientset:      databaseclientset,
+		spannerDatabaseIndexer: spannerDatabaseInformer.Informer().GetIndexer(),
+		spannerDatabasesSynced: spannerDatabaseInformer.Informer().HasSynced,
 		workqueue:              workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Spanners"),
 		recorder:               recorder,
-		operator:               op,
+		operators:              operators,
+		secretLister:           secretInformer.Lister(),
+		secretsSynced:          secretInformer.Informer().HasSynced,
+		syncTimeout:            syncTimeout,
+		now:                    time.Now,
 	}
 
+	utilruntime.Must(databaseadmins.AddInstanceIndexer(spannerDatabaseInformer.Informer(), operators))
+
 	klog.Info("Setting up event handlers")
 	// Set up an event handler for when SpannerInstance resources change
 	spannerInstanceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -136,14 +260,26 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 
 	// Wait for the caches to be synced before starting workers
 	klog.Info("Waiting for informer caches to sync")
-	if ok := cache.WaitForCacheSync(stopCh, c.spannerInstancesSynced); !ok {
+	if ok := cache.WaitForCacheSync(stopCh, c.spannerInstancesSynced, c.spannerDatabasesSynced, c.secretsSynced); !ok {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
+	// ctx is cancelled once stopCh is closed, which aborts in-flight Spanner
+	// admin calls made by the workers.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
 	klog.Info("Starting workers")
 	// Launch two workers to process SpannerInstance resources
 	for i := 0; i < threadiness; i++ {
-		go wait.Until(c.runWorker, time.Second, stopCh)
+		go wait.Until(func() { c.runWorker(ctx) }, time.Second, stopCh)
 	}
 
 	klog.Info("Started workers")
@@ -156,14 +292,14 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 // runWorker is a long-running function that will continually call the
 // processNextWorkItem function in order to read and process a message on the
 // workqueue.
-func (c *Controller) runWorker() {
-	for c.processNextWorkItem() {
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
 	}
 }
 
 // processNextWorkItem will read a single work item off the workqueue and
 // attempt to process it, by calling the syncHandler.
-func (c *Controller) processNextWorkItem() bool {
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 	obj, shutdown := c.workqueue.Get()
 
 	if shutdown {
@@ -196,7 +332,9 @@ func (c *Controller) processNextWorkItem() bool {
 		}
 		// Run the syncHandler, passing it the namespace/name string of the
 		// SpannerInstance resource to be synced.
-		if err := c.syncHandler(key); err != nil {
+		syncCtx, cancel := context.WithTimeout(ctx, c.syncTimeout)
+		defer cancel()
+		if err := c.syncHandler(syncCtx, key); err != nil {
 			// Put the item back on the workqueue to handle any transient errors.
 			c.workqueue.AddRateLimited(key)
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
@@ -219,7 +357,7 @@ func (c *Controller) processNextWorkItem() bool {
 // syncHandler compares the actual state with the desired, and attempts to
 // converge the two. It then updates the Status block of the SpannerInstance resource
 // with the current status of the resource.
-func (c *Controller) syncHandler(key string) error {
+func (c *Controller) syncHandler(ctx context.Context, key string) error {
 
 	log.Printf("Get key: %s", key)
 	// Convert the namespace/name string into a distinct namespace and name
@@ -234,20 +372,9 @@ func (c *Controller) syncHandler(key string) error {
 	log.Printf("Get spanner instance %+v", spannerInstance)
 	if err != nil {
 		// The SpannerInstance resource may no longer exist, in which case we stop
-		// processing.
+		// processing; its finalizer already deleted the instance.
 		if errors.IsNotFound(err) {
 			log.Printf("spannerInstance '%s' in work queue no longer exists", key)
-			_, err := c.operator.GetInstance(name)
-			if err != nil && c.operator.IsNotFoundError(err) {
-				utilruntime.HandleError(fmt.Errorf("spannerInstance '%s' in work queue no longer exists", key))
-				return nil
-			} else if err != nil {
-				return err
-			}
-			err = c.operator.DeleteInstance(name)
-			if err != nil {
-				return err
-			}
 			utilruntime.HandleError(fmt.Errorf("spannerInstance '%s' in work queue no longer exists", key))
 			return nil
 		}
@@ -255,77 +382,300 @@ func (c *Controller) syncHandler(key string) error {
 		return err
 	}
 
-	inst, err := c.operator.GetInstance(name)
-	if err != nil && c.operator.IsNotFoundError(err) {
-		log.Printf("SpannerInstance does not exists on GCP, create new one with name: %s", spannerInstance.Name)
-		err = c.operator.CreateInstance(spannerInstance.Spec.DisplayName, spannerInstance.Name, spannerInstance.Spec.InstanceConfig, spannerInstance.Spec.NodeCount)
+	// A previous sync started a long-running operation. Poll it instead of
+	// issuing another request; this also resumes waiting after a restart.
+	if spannerInstance.Status.PendingOperation != "" {
+		spannerInstance, err = c.pollPendingOperation(ctx, key, spannerInstance)
+		if err != nil || spannerInstance == nil {
+			return err
+		}
+	}
+
+	deleting := spannerInstance.DeletionTimestamp != nil
+	if deleting && !hasFinalizer(spannerInstance) {
+		return nil
+	}
+	if err := validateDeletionPolicy(spannerInstance.Spec.DeletionPolicy); err != nil {
+		// A deleted SpannerInstance is kept until the policy is fixed rather
+		// than guessing what to do with its instance.
+		return c.rejectSpec(key, spannerInstance, ErrInvalidDeletionPolicy, fmt.Sprintf(MessageInvalidDeletionPolicy, err))
+	}
+	if deleting && spannerInstance.Spec.DeletionProtection {
+		// Turning the protection off requeues the key.
+		message := fmt.Sprintf(MessageDeletionProtected, c.instanceName(spannerInstance))
+		return c.blockDeletion(spannerInstance, corev1.EventTypeWarning, ErrDeletionProtected, message)
+	}
+	if deleting {
+		return c.finalize(ctx, key, spannerInstance)
+	}
+	// Add the finalizer before creating or adopting the instance, so an
+	// instance can never be managed without being cleaned up later.
+	if !hasFinalizer(spannerInstance) {
+		spannerInstanceCopy := spannerInstance.DeepCopy()
+		spannerInstanceCopy.Finalizers = append(spannerInstanceCopy.Finalizers, deleteInstanceFinalizer)
+		spannerInstance, err = c.spannerclientset.InstanceadminsV1alpha1().SpannerInstances(namespace).Update(spannerInstanceCopy)
 		if err != nil {
 			return err
 		}
-		if len(spannerInstance.Labels) > 0 {
-			err = c.operator.UpdateLabels(name, spannerInstance.Labels)
+	}
+
+	instanceId := spannerInstance.InstanceId()
+	if err := operator.ValidateInstanceId(instanceId); err != nil {
+		return c.rejectSpec(key, spannerInstance, ErrInvalidInstanceId, fmt.Sprintf(MessageInvalidInstanceId, err))
+	}
+	op, err := c.operatorFor(spannerInstance)
+	if err != nil {
+		if statusErr := c.reportError(spannerInstance, ErrCredentials, fmt.Sprintf(MessageCredentials, err)); statusErr != nil {
+			utilruntime.HandleError(statusErr)
+		}
+		return err
+	}
+	capacity := specCapacity(spannerInstance.Spec)
+	if err := capacity.Validate(); err != nil {
+		return c.rejectSpec(key, spannerInstance, ErrInvalidCapacity, fmt.Sprintf(MessageInvalidCapacity, err))
+	}
+
+	inst, err := op.GetInstance(ctx, instanceId)
+	if err != nil && op.IsNotFoundError(err) {
+		log.Printf("SpannerInstance does not exists on GCP, create new one with name: %s", instanceId)
+		if _, err := op.GetInstanceConfig(ctx, spannerInstance.Spec.InstanceConfig); op.IsNotFoundError(err) {
+			// Describe the configs the project can use; like an invalid
+			// capacity, only a spec change can fix this.
+			err = operator.CheckInstanceConfig(ctx, op, spannerInstance.Spec.InstanceConfig)
+			return c.rejectSpec(key, spannerInstance, ErrInvalidInstanceConfig, fmt.Sprintf(MessageInvalidInstanceConfig, err))
+		} else if err != nil {
+			return err
+		}
+		// Record the instance as ours before creating it, so it is not
+		// mistaken for somebody else's should the sync fail half way.
+		if instanceName := c.instanceName(spannerInstance); spannerInstance.Status.InstanceName != instanceName {
+			spannerInstanceCopy := spannerInstance.DeepCopy()
+			spannerInstanceCopy.Status.InstanceName = instanceName
+			spannerInstance, err = c.updateSpannerInstanceStatus(spannerInstanceCopy)
 			if err != nil {
 				return err
 			}
 		}
-		inst, err = c.operator.GetInstance(name)
+		opName, err := op.CreateInstance(ctx, spannerInstance.Spec.DisplayName, instanceId, spannerInstance.Spec.InstanceConfig, capacity)
 		if err != nil {
 			return err
 		}
+		spannerInstanceCopy := spannerInstance.DeepCopy()
+		markCreating(&spannerInstanceCopy.Status, spannerInstance.Status.InstanceName, c.now())
+		return c.trackOperation(key, spannerInstanceCopy, opName)
 	} else if err != nil {
 		return err
 	}
 
-	if spannerInstance.Spec.NodeCount != inst.NodeCount {
-		log.Printf("spannerInstance nodeCount: %d is different from actual instance nodeCount: %d, fit to spannerInstance spec", spannerInstance.Spec.NodeCount, inst.NodeCount)
-		err = c.operator.Scale(spannerInstance.Name, spannerInstance.Spec.NodeCount)
+	if spannerInstance.Status.InstanceName != inst.Name {
+		message := fmt.Sprintf(MessageResourceAdopted, inst.Name)
+		if c.createdByEarlierVersion(spannerInstance, inst) {
+			message = fmt.Sprintf(MessageResourceRecorded, inst.Name)
+		} else if spannerInstance.Annotations[instancev1alpha1.AdoptAnnotation] != "true" {
+			// Setting the annotation requeues the key.
+			msg := fmt.Sprintf(MessageResourceExists, inst.Name, instancev1alpha1.AdoptAnnotation)
+			utilruntime.HandleError(fmt.Errorf("%s: %s", key, msg))
+			return c.reportError(spannerInstance, ErrResourceExists, msg)
+		}
+		log.Printf("Adopt existing instance %s", inst.Name)
+		spannerInstanceCopy := spannerInstance.DeepCopy()
+		spannerInstanceCopy.Status.InstanceName = inst.Name
+		spannerInstance, err = c.updateSpannerInstanceStatus(spannerInstanceCopy)
 		if err != nil {
-			return nil
+			return err
 		}
+		c.recorder.Event(spannerInstance, corev1.EventTypeNormal, SuccessAdopted, message)
 	}
 
-	labels := spannerInstance.DeepCopy().Labels
-	dirty := false
-	for k, v := range inst.Labels {
-		if val, ok := labels[k]; ok {
-			if v != val {
-				dirty = true
-			}
-		} else {
-			inst.Labels[k] = v
-			dirty = true
+	if actual := actualCapacity(inst, capacity); actual != capacity {
+		log.Printf("spannerInstance capacity: %s is different from actual instance capacity: %s, fit to spannerInstance spec", capacity, actual)
+		opName, err := op.Scale(ctx, instanceId, capacity)
+		if err != nil {
+			return err
 		}
+		spannerInstanceCopy := spannerInstance.DeepCopy()
+		setCondition(&spannerInstanceCopy.Status, instancev1alpha1.SpannerInstanceScaling, corev1.ConditionTrue, ReasonScaling, fmt.Sprintf(MessageScaling, actual, capacity), c.now())
+		return c.trackOperation(key, spannerInstanceCopy, opName)
 	}
-	if dirty {
+
+	labels := spannerInstance.DeepCopy().Labels
+	if !labelsEqual(labels, inst.Labels) {
 		log.Printf("spec labels and actual labels is different, update labels to %+v", labels)
-		err = c.operator.UpdateLabels(name, labels)
+		opName, err := op.UpdateLabels(ctx, instanceId, labels)
 		if err != nil {
 			return err
 		}
+		return c.trackOperation(key, spannerInstance, opName)
 	}
 
 	// Finally, we update the status block of the SpannerInstance resource to reflect the
 	// current state of the world
-	err = c.updateSpannerInstanceStatus(spannerInstance)
+	spannerInstanceCopy := spannerInstance.DeepCopy()
+	spannerInstanceCopy.Status.AvailableNodes = inst.NodeCount
+	if capacity.InProcessingUnits() {
+		spannerInstanceCopy.Status.Capacity = inst.ProcessingUnits
+		spannerInstanceCopy.Status.CapacityUnit = instancev1alpha1.CapacityUnitProcessingUnits
+	} else {
+		spannerInstanceCopy.Status.Capacity = inst.NodeCount
+		spannerInstanceCopy.Status.CapacityUnit = instancev1alpha1.CapacityUnitNodes
+	}
+	markSynced(&spannerInstanceCopy.Status, inst, c.now())
+	_, err = c.updateSpannerInstanceStatus(spannerInstanceCopy)
 	if err != nil {
 		return err
 	}
+	if inst.State != instance.Instance_READY {
+		// Somebody else is still creating the adopted instance.
+		c.workqueue.AddAfter(key, operationPollInterval)
+	}
 
 	c.recorder.Event(spannerInstance, corev1.EventTypeNormal, SuccessSynced, MessageResourceSynced)
 	return nil
 }
 
-func (c *Controller) updateSpannerInstanceStatus(spannerInstance *instancev1alpha1.SpannerInstance) error {
+// instanceName returns the fully qualified name of the Spanner instance of
+// spannerInstance.
+func (c *Controller) instanceName(spannerInstance *instancev1alpha1.SpannerInstance) string {
+	return operator.InstanceName(c.operators.ProjectId(spannerInstance.Spec.ProjectId), spannerInstance.InstanceId())
+}
+
+// createdByEarlierVersion reports whether inst was created for
+// spannerInstance by a controller from before the instance name was recorded
+// in the status. Those named the instance after the SpannerInstance, in the
+// default project, and created it after the SpannerInstance.
+func (c *Controller) createdByEarlierVersion(spannerInstance *instancev1alpha1.SpannerInstance, inst *instance.Instance) bool {
+	if spannerInstance.Status.InstanceName != "" || spannerInstance.CreationTimestamp.IsZero() || inst.GetCreateTime() == nil {
+		return false
+	}
+	if inst.Name != operator.InstanceName(c.operators.ProjectId(""), spannerInstance.Name) {
+		return false
+	}
+	return !inst.GetCreateTime().AsTime().Before(spannerInstance.CreationTimestamp.Time)
+}
+
+// operatorFor returns the Operator managing spannerInstance, built with the
+// credentials its spec selects.
+func (c *Controller) operatorFor(spannerInstance *instancev1alpha1.SpannerInstance) (operator.Operator, error) {
+	return credentials.Operator(c.operators, c.secretLister, spannerInstance.Namespace, spannerInstance.Spec.ProjectId, spannerInstance.Spec.CredentialsSecretRef)
+}
+
+// reportError sets the Error condition of spannerInstance and emits a
+// warning Event, both with reason and message.
+func (c *Controller) reportError(spannerInstance *instancev1alpha1.SpannerInstance, reason, message string) error {
+	c.recorder.Event(spannerInstance, corev1.EventTypeWarning, reason, message)
+	spannerInstanceCopy := spannerInstance.DeepCopy()
+	changed := setCondition(&spannerInstanceCopy.Status, instancev1alpha1.SpannerInstanceError, corev1.ConditionTrue, reason, message, c.now())
+	if !changed && spannerInstance.Status.ObservedGeneration == spannerInstance.Generation {
+		return nil
+	}
+	_, err := c.updateSpannerInstanceStatus(spannerInstanceCopy)
+	return err
+}
+
+// rejectSpec reports an error in the spec of spannerInstance through
+// reportError without requeuing key. No retry can succeed before the spec is
+// updated, and updating it enqueues the SpannerInstance again.
+func (c *Controller) rejectSpec(key string, spannerInstance *instancev1alpha1.SpannerInstance, reason, message string) error {
+	utilruntime.HandleError(fmt.Errorf("%s: %s", key, message))
+	return c.reportError(spannerInstance, reason, message)
+}
+
+// trackOperation records opName as the pending operation of spannerInstance
+// and schedules the key to be polled until the operation finishes.
+func (c *Controller) trackOperation(key string, spannerInstance *instancev1alpha1.SpannerInstance, opName string) error {
+	spannerInstanceCopy := spannerInstance.DeepCopy()
+	spannerInstanceCopy.Status.PendingOperation = opName
+	if _, err := c.updateSpannerInstanceStatus(spannerInstanceCopy); err != nil {
+		return err
+	}
+	c.workqueue.AddAfter(key, operationPollInterval)
+	return nil
+}
+
+// pollPendingOperation checks the pending operation of spannerInstance. While
+// it is running, the key is requeued and nil is returned. Once it is done, the
+// pending operation is cleared and the updated SpannerInstance is returned so
+// the sync can carry on.
+func (c *Controller) pollPendingOperation(ctx context.Context, key string, spannerInstance *instancev1alpha1.SpannerInstance) (*instancev1alpha1.SpannerInstance, error) {
+	o, err := c.operatorFor(spannerInstance)
+	if err != nil {
+		return nil, err
+	}
+	op, err := o.GetOperation(ctx, spannerInstance.Status.PendingOperation)
+	if err != nil && o.IsNotFoundError(err) {
+		// Spanner keeps operations for a limited time only. One that is gone
+		// can no longer be polled, so it is cleared and the sync carries on
+		// from what Spanner reports.
+		log.Printf("Operation %s no longer exists, reconcile again", spannerInstance.Status.PendingOperation)
+		spannerInstanceCopy := spannerInstance.DeepCopy()
+		spannerInstanceCopy.Status.PendingOperation = ""
+		return c.updateSpannerInstanceStatus(spannerInstanceCopy)
+	} else if err != nil {
+		return nil, err
+	}
+	if !op.Done {
+		log.Printf("Operation %s is still running", op.Name)
+		c.workqueue.AddAfter(key, operationPollInterval)
+		return nil, nil
+	}
+	spannerInstanceCopy := spannerInstance.DeepCopy()
+	spannerInstanceCopy.Status.PendingOperation = ""
+	if op.Err != nil {
+		markOperationFailed(&spannerInstanceCopy.Status, fmt.Sprintf(MessageOperationFailed, op.Name, op.Err), c.now())
+	}
+	updated, err := c.updateSpannerInstanceStatus(spannerInstanceCopy)
+	if err != nil {
+		return nil, err
+	}
+	if op.Err != nil {
+		c.recorder.Event(spannerInstance, corev1.EventTypeWarning, ErrOperationFailed, fmt.Sprintf(MessageOperationFailed, op.Name, op.Err))
+		return nil, op.Err
+	}
+	return updated, nil
+}
+
+func (c *Controller) updateSpannerInstanceStatus(spannerInstance *instancev1alpha1.SpannerInstance) (*instancev1alpha1.SpannerInstance, error) {
 	// NEVER modify objects from the store. It's a read-only, local cache.
 	// You can use DeepCopy() to make a deep copy of original object and modify this copy
 	// Or create a copy manually for better performance
 	spannerInstanceCopy := spannerInstance.DeepCopy()
-	// If the CustomResourceSubresources feature gate is not enabled,
-	// we must use Update instead of UpdateStatus to update the Status block of the SpannerInstance resource.
+	// Every status is written while syncing the spec of this generation.
+	spannerInstanceCopy.Status.ObservedGeneration = spannerInstanceCopy.Generation
+	// The SpannerInstance CRD enables the status subresource, so an Update
+	// would silently drop our changes to the Status block.
 	// UpdateStatus will not allow changes to the Spec of the resource,
 	// which is ideal for ensuring nothing other than resource status has been updated.
-	_, err := c.spannerclientset.InstanceadminsV1alpha1().SpannerInstances(spannerInstance.Namespace).Update(spannerInstanceCopy)
-	return err
+	return c.spannerclientset.InstanceadminsV1alpha1().SpannerInstances(spannerInstance.Namespace).UpdateStatus(spannerInstanceCopy)
+}
+
+// specCapacity returns the capacity requested by spec.
+func specCapacity(spec instancev1alpha1.SpannerInstanceSpec) operator.Capacity {
+	return operator.Capacity{
+		NodeCount:       spec.NodeCount,
+		ProcessingUnits: spec.ProcessingUnits,
+	}
+}
+
+// actualCapacity returns the capacity of inst in the same unit as desired, so
+// the two can be compared.
+func actualCapacity(inst *instance.Instance, desired operator.Capacity) operator.Capacity {
+	if desired.InProcessingUnits() {
+		return operator.ProcessingUnits(inst.ProcessingUnits)
+	}
+	return operator.Nodes(inst.NodeCount)
+}
+
+// labelsEqual reports whether both label sets hold the same keys and values.
+func labelsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if val, ok := b[k]; !ok || val != v {
+			return false
+		}
+	}
+	return true
 }
 
 // enqueueSpannerInstanceInstance takes a SpannerInstance resource and converts it into a namespace/name
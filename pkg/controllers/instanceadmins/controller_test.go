@@ -17,12 +17,18 @@ limitations under the License.
 package instanceadmins
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"google.golang.org/genproto/googleapis/spanner/admin/instance/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -33,9 +39,13 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 
+	databasev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
 	spannercontroller "github.com/katsew/spanner-operator/pkg/apis/instanceadmins/v1alpha1"
+	databasefake "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/clientset/versioned/fake"
+	databaseinformers "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/informers/externalversions"
 	"github.com/katsew/spanner-operator/pkg/generated/instanceadmins/clientset/versioned/fake"
 	informers "github.com/katsew/spanner-operator/pkg/generated/instanceadmins/informers/externalversions"
+	"github.com/katsew/spanner-operator/pkg/operator"
 )
 
 var (
@@ -46,17 +56,31 @@ var (
 type fixture struct {
 	t *testing.T
 
-	client     *fake.Clientset
-	kubeclient *k8sfake.Clientset
+	client         *fake.Clientset
+	kubeclient     *k8sfake.Clientset
+	databaseclient *databasefake.Clientset
+	// Operator the controller talks to Spanner through.
+	op *operator.Fake
+	// Pool handing out op for the default project, and a fake of its own
+	// for any other project or service account key.
+	operators *operator.Pool
+	// Service account keys operators were built with, in order.
+	keys []string
+	// now is the time the controller stamps conditions with.
+	now time.Time
 	// Objects to put in the store.
 	SpannerInstanceLister []*spannercontroller.SpannerInstance
+	SpannerDatabaseLister []*databasev1alpha1.SpannerDatabase
 	deploymentLister      []*apps.Deployment
+	secretLister          []*corev1.Secret
 	// Actions expected to happen on the client.
-	kubeactions []core.Action
-	actions     []core.Action
+	kubeactions     []core.Action
+	actions         []core.Action
+	databaseactions []core.Action
 	// Objects from here preloaded into NewSimpleFake.
-	kubeobjects []runtime.Object
-	objects     []runtime.Object
+	kubeobjects     []runtime.Object
+	objects         []runtime.Object
+	databaseobjects []runtime.Object
 }
 
 func newFixture(t *testing.T) *fixture {
@@ -64,6 +88,16 @@ func newFixture(t *testing.T) *fixture {
 	f.t = t
 	f.objects = []runtime.Object{}
 	f.kubeobjects = []runtime.Object{}
+	f.now = time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	f.op = operator.NewBuilder().ProjectId("test").BuildFake()
+	f.operators = operator.NewPool("test", func(projectId string, key []byte) (operator.Operator, error) {
+		if key != nil {
+			f.keys = append(f.keys, string(key))
+		} else if projectId == "test" {
+			return f.op, nil
+		}
+		return operator.NewBuilder().ProjectId(projectId).BuildFake(), nil
+	})
 	return f
 }
 
@@ -71,37 +105,54 @@ func newSpannerInstance(name string, replicas int32) *spannercontroller.SpannerI
 	return &spannercontroller.SpannerInstance{
 		TypeMeta: metav1.TypeMeta{APIVersion: spannercontroller.SchemeGroupVersion.String()},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: metav1.NamespaceDefault,
+			Name:       name,
+			Namespace:  metav1.NamespaceDefault,
+			Finalizers: []string{deleteInstanceFinalizer},
 		},
 		Spec: spannercontroller.SpannerInstanceSpec{
-			DisplayName: fmt.Sprintf("%s-deployment", name),
-			NodeCount:   replicas,
+			DisplayName:    fmt.Sprintf("%s-deployment", name),
+			InstanceConfig: "regional-asia-northeast1",
+			NodeCount:      replicas,
 		},
 	}
 }
 
-func (f *fixture) newController() (*Controller, informers.SharedInformerFactory, kubeinformers.SharedInformerFactory) {
+func (f *fixture) newController() (*Controller, informers.SharedInformerFactory, databaseinformers.SharedInformerFactory, kubeinformers.SharedInformerFactory) {
 	f.client = fake.NewSimpleClientset(f.objects...)
 	f.kubeclient = k8sfake.NewSimpleClientset(f.kubeobjects...)
+	f.databaseclient = databasefake.NewSimpleClientset(f.databaseobjects...)
 
 	i := informers.NewSharedInformerFactory(f.client, noResyncPeriodFunc())
+	di := databaseinformers.NewSharedInformerFactory(f.databaseclient, noResyncPeriodFunc())
 	k8sI := kubeinformers.NewSharedInformerFactory(f.kubeclient, noResyncPeriodFunc())
 
-	c := NewController(f.kubeclient, f.client, i.Instanceadmins().V1alpha1().SpannerInstances())
+	c := NewController(f.kubeclient, f.client, i.Instanceadmins().V1alpha1().SpannerInstances(),
+		f.databaseclient, di.Databaseadmins().V1alpha1().SpannerDatabases(),
+		k8sI.Core().V1().Secrets(), f.operators)
 
 	c.spannerInstancesSynced = alwaysReady
+	c.spannerDatabasesSynced = alwaysReady
+	c.secretsSynced = alwaysReady
 	c.recorder = &record.FakeRecorder{}
+	c.now = func() time.Time { return f.now }
 
 	for _, f := range f.SpannerInstanceLister {
 		i.Instanceadmins().V1alpha1().SpannerInstances().Informer().GetIndexer().Add(f)
 	}
 
+	for _, d := range f.SpannerDatabaseLister {
+		di.Databaseadmins().V1alpha1().SpannerDatabases().Informer().GetIndexer().Add(d)
+	}
+
 	for _, d := range f.deploymentLister {
 		k8sI.Apps().V1().Deployments().Informer().GetIndexer().Add(d)
 	}
 
-	return c, i, k8sI
+	for _, s := range f.secretLister {
+		k8sI.Core().V1().Secrets().Informer().GetIndexer().Add(s)
+	}
+
+	return c, i, di, k8sI
 }
 
 func (f *fixture) run(SpannerInstanceName string) {
@@ -113,15 +164,16 @@ func (f *fixture) runExpectError(SpannerInstanceName string) {
 }
 
 func (f *fixture) runController(SpannerInstanceName string, startInformers bool, expectError bool) {
-	c, i, k8sI := f.newController()
+	c, i, di, k8sI := f.newController()
 	if startInformers {
 		stopCh := make(chan struct{})
 		defer close(stopCh)
 		i.Start(stopCh)
+		di.Start(stopCh)
 		k8sI.Start(stopCh)
 	}
 
-	err := c.syncHandler(SpannerInstanceName)
+	err := c.syncHandler(context.Background(), SpannerInstanceName)
 	if !expectError && err != nil {
 		f.t.Errorf("error syncing SpannerInstance: %v", err)
 	} else if expectError && err == nil {
@@ -157,6 +209,21 @@ func (f *fixture) runController(SpannerInstanceName string, startInformers bool,
 	if len(f.kubeactions) > len(k8sActions) {
 		f.t.Errorf("%d additional expected actions:%+v", len(f.kubeactions)-len(k8sActions), f.kubeactions[len(k8sActions):])
 	}
+
+	databaseActions := filterInformerActions(f.databaseclient.Actions())
+	for i, action := range databaseActions {
+		if len(f.databaseactions) < i+1 {
+			f.t.Errorf("%d unexpected actions: %+v", len(databaseActions)-len(f.databaseactions), databaseActions[i:])
+			break
+		}
+
+		expectedAction := f.databaseactions[i]
+		checkAction(expectedAction, action, f.t)
+	}
+
+	if len(f.databaseactions) > len(databaseActions) {
+		f.t.Errorf("%d additional expected actions:%+v", len(f.databaseactions)-len(databaseActions), f.databaseactions[len(databaseActions):])
+	}
 }
 
 // checkAction verifies that expected and actual actions are equal and both have
@@ -213,7 +280,11 @@ func filterInformerActions(actions []core.Action) []core.Action {
 			(action.Matches("list", "SpannerInstances") ||
 				action.Matches("watch", "SpannerInstances") ||
 				action.Matches("list", "deployments") ||
-				action.Matches("watch", "deployments")) {
+				action.Matches("watch", "deployments") ||
+				action.Matches("list", "secrets") ||
+				action.Matches("watch", "secrets") ||
+				action.Matches("list", "spannerdatabases") ||
+				action.Matches("watch", "spannerdatabases")) {
 			continue
 		}
 		ret = append(ret, action)
@@ -230,13 +301,69 @@ func (f *fixture) expectUpdateDeploymentAction(d *apps.Deployment) {
 	f.kubeactions = append(f.kubeactions, core.NewUpdateAction(schema.GroupVersionResource{Resource: "deployments"}, d.Namespace, d))
 }
 
+func (f *fixture) expectUpdateAction(SpannerInstance *spannercontroller.SpannerInstance) {
+	f.actions = append(f.actions, core.NewUpdateAction(schema.GroupVersionResource{Resource: "SpannerInstances"}, SpannerInstance.Namespace, SpannerInstance))
+}
+
 func (f *fixture) expectUpdateFooStatusAction(SpannerInstance *spannercontroller.SpannerInstance) {
 	action := core.NewUpdateAction(schema.GroupVersionResource{Resource: "SpannerInstances"}, SpannerInstance.Namespace, SpannerInstance)
-	// TODO: Until #38113 is merged, we can't use Subresource
-	//action.Subresource = "status"
+	action.Subresource = "status"
 	f.actions = append(f.actions, action)
 }
 
+// expectClaimAction expects SpannerInstance to be recorded as managing its
+// instance in projectId, and returns a copy of the updated SpannerInstance.
+func (f *fixture) expectClaimAction(SpannerInstance *spannercontroller.SpannerInstance, projectId string) *spannercontroller.SpannerInstance {
+	claimed := SpannerInstance.DeepCopy()
+	claimed.Status.InstanceName = operator.InstanceName(projectId, SpannerInstance.InstanceId())
+	f.expectUpdateFooStatusAction(claimed)
+	return claimed.DeepCopy()
+}
+
+// createInstance makes the operator already hold an instance matching the
+// spec of SpannerInstance, which is recorded as managing it.
+func (f *fixture) createInstance(SpannerInstance *spannercontroller.SpannerInstance) {
+	f.createUnmanagedInstance(SpannerInstance)
+	SpannerInstance.Status.InstanceName = operator.InstanceName("test", SpannerInstance.Name)
+}
+
+// createUnmanagedInstance makes the operator already hold an instance
+// matching the spec of SpannerInstance, created by somebody else.
+func (f *fixture) createUnmanagedInstance(SpannerInstance *spannercontroller.SpannerInstance) {
+	ctx := context.Background()
+	if _, err := f.op.CreateInstance(ctx, SpannerInstance.Spec.DisplayName, SpannerInstance.Name, SpannerInstance.Spec.InstanceConfig, specCapacity(SpannerInstance.Spec)); err != nil {
+		f.t.Fatal(err)
+	}
+	if _, err := f.op.UpdateLabels(ctx, SpannerInstance.Name, SpannerInstance.Labels); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// creatingConditions are the conditions of a SpannerInstance creating the
+// instance instanceName since now.
+func creatingConditions(instanceName string, now time.Time) []spannercontroller.SpannerInstanceCondition {
+	message := fmt.Sprintf(MessageCreating, instanceName)
+	return []spannercontroller.SpannerInstanceCondition{
+		{Type: spannercontroller.SpannerInstanceReady, Status: corev1.ConditionFalse, LastTransitionTime: metav1.NewTime(now), Reason: ReasonCreating, Message: message},
+		{Type: spannercontroller.SpannerInstanceProvisioning, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(now), Reason: ReasonCreating, Message: message},
+	}
+}
+
+// syncedConditions are the conditions of a SpannerInstance in sync with a
+// ready instance since now.
+func syncedConditions(now time.Time) []spannercontroller.SpannerInstanceCondition {
+	condition := func(conditionType spannercontroller.SpannerInstanceConditionType, status corev1.ConditionStatus) spannercontroller.SpannerInstanceCondition {
+		return spannercontroller.SpannerInstanceCondition{Type: conditionType, Status: status, LastTransitionTime: metav1.NewTime(now), Reason: SuccessSynced, Message: MessageResourceSynced}
+	}
+	return []spannercontroller.SpannerInstanceCondition{
+		condition(spannercontroller.SpannerInstanceReady, corev1.ConditionTrue),
+		condition(spannercontroller.SpannerInstanceProvisioning, corev1.ConditionFalse),
+		condition(spannercontroller.SpannerInstanceScaling, corev1.ConditionFalse),
+		condition(spannercontroller.SpannerInstanceDegraded, corev1.ConditionFalse),
+		condition(spannercontroller.SpannerInstanceError, corev1.ConditionFalse),
+	}
+}
+
 func getKey(SpannerInstance *spannercontroller.SpannerInstance, t *testing.T) string {
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(SpannerInstance)
 	if err != nil {
@@ -253,30 +380,886 @@ func TestCreatesDeployment(t *testing.T) {
 	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
 	f.objects = append(f.objects, SpannerInstance)
 
-	f.expectUpdateFooStatusAction(SpannerInstance)
+	expInstance := f.expectClaimAction(SpannerInstance, "test")
+	expInstance.Status.PendingOperation = "projects/test/instances/test/operations/mock_create_instance"
+	expInstance.Status.Conditions = creatingConditions(expInstance.Status.InstanceName, f.now)
+	f.expectUpdateFooStatusAction(expInstance)
+
+	f.run(getKey(SpannerInstance, t))
+}
+
+func TestCreatesInstanceInSpecProject(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 1)
+	SpannerInstance.Spec.ProjectId = "staging"
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	expInstance := f.expectClaimAction(SpannerInstance, "staging")
+	expInstance.Status.PendingOperation = "projects/staging/instances/test/operations/mock_create_instance"
+	expInstance.Status.Conditions = creatingConditions(expInstance.Status.InstanceName, f.now)
+	f.expectUpdateFooStatusAction(expInstance)
+
+	f.run(getKey(SpannerInstance, t))
+
+	staging, err := f.operators.Get("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := staging.GetInstance(context.Background(), "test"); err != nil {
+		t.Errorf("expected instance in project staging, got %v", err)
+	}
+	if _, err := f.op.GetInstance(context.Background(), "test"); !f.op.IsNotFoundError(err) {
+		t.Errorf("expected no instance in the default project, got %v", err)
+	}
+}
+
+func newCredentialsSecret(name, key, resourceVersion string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       metav1.NamespaceDefault,
+			ResourceVersion: resourceVersion,
+		},
+		Data: map[string][]byte{"key.json": []byte(key)},
+	}
+}
+
+func credentialsSecretRef(name string) *corev1.SecretKeySelector {
+	return &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: name},
+		Key:                  "key.json",
+	}
+}
+
+func TestCreatesInstanceWithSecretCredentials(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 1)
+	SpannerInstance.Spec.CredentialsSecretRef = credentialsSecretRef("spanner-key")
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+	f.secretLister = append(f.secretLister, newCredentialsSecret("spanner-key", "key-1", "1"))
+
+	expInstance := f.expectClaimAction(SpannerInstance, "test")
+	expInstance.Status.PendingOperation = "projects/test/instances/test/operations/mock_create_instance"
+	expInstance.Status.Conditions = creatingConditions(expInstance.Status.InstanceName, f.now)
+	f.expectUpdateFooStatusAction(expInstance)
 
 	f.run(getKey(SpannerInstance, t))
+
+	if !reflect.DeepEqual(f.keys, []string{"key-1"}) {
+		t.Errorf("expected an operator built with key-1, got %v", f.keys)
+	}
+}
+
+func TestMissingCredentialsSecret(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 1)
+	SpannerInstance.Spec.CredentialsSecretRef = credentialsSecretRef("spanner-key")
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	expInstance := SpannerInstance.DeepCopy()
+	setCondition(&expInstance.Status, spannercontroller.SpannerInstanceError, corev1.ConditionTrue, ErrCredentials, fmt.Sprintf(MessageCredentials, `secret "spanner-key" not found`), f.now)
+	f.expectUpdateFooStatusAction(expInstance)
+	f.runExpectError(getKey(SpannerInstance, t))
+
+	if _, err := f.op.GetInstance(context.Background(), "test"); !f.op.IsNotFoundError(err) {
+		t.Errorf("expected no instance without credentials, got %v", err)
+	}
+}
+
+func TestRebuildsOperatorOnSecretRotation(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 1)
+	SpannerInstance.Spec.CredentialsSecretRef = credentialsSecretRef("spanner-key")
+	f.secretLister = append(f.secretLister, newCredentialsSecret("spanner-key", "key-1", "1"))
+
+	c, _, _, k8sI := f.newController()
+	first, err := c.operatorFor(SpannerInstance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op, err := c.operatorFor(SpannerInstance); err != nil || op != first {
+		t.Errorf("expected the operator to be reused while the secret is unchanged, got %v", err)
+	}
+
+	k8sI.Core().V1().Secrets().Informer().GetIndexer().Update(newCredentialsSecret("spanner-key", "key-2", "2"))
+	if _, err := c.operatorFor(SpannerInstance); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f.keys, []string{"key-1", "key-2"}) {
+		t.Errorf("expected the operator to be rebuilt with the rotated key, got %v", f.keys)
+	}
+	if !first.(*operator.Fake).Closed() {
+		t.Error("expected the operator of the old key to be closed")
+	}
 }
 
 func TestDoNothing(t *testing.T) {
 	f := newFixture(t)
 	SpannerInstance := newSpannerInstance("test", 1)
+	f.createInstance(SpannerInstance)
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	expInstance := SpannerInstance.DeepCopy()
+	expInstance.Status.Capacity = 1
+	expInstance.Status.CapacityUnit = spannercontroller.CapacityUnitNodes
+	expInstance.Status.AvailableNodes = 1
+	expInstance.Status.Conditions = syncedConditions(f.now)
+	f.expectUpdateFooStatusAction(expInstance)
+	f.run(getKey(SpannerInstance, t))
+}
+
+func TestCreatesInstanceInProcessingUnits(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 0)
+	SpannerInstance.Spec.ProcessingUnits = 300
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	expInstance := f.expectClaimAction(SpannerInstance, "test")
+	expInstance.Status.PendingOperation = "projects/test/instances/test/operations/mock_create_instance"
+	expInstance.Status.Conditions = creatingConditions(expInstance.Status.InstanceName, f.now)
+	f.expectUpdateFooStatusAction(expInstance)
+
+	f.run(getKey(SpannerInstance, t))
+
+	inst, err := f.op.GetInstance(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inst.ProcessingUnits != 300 || inst.NodeCount != 0 {
+		t.Errorf("expected 300 processing units and 0 nodes, got %d and %d", inst.ProcessingUnits, inst.NodeCount)
+	}
+}
+
+func TestScalesProcessingUnits(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 1)
+	f.createInstance(SpannerInstance)
+	SpannerInstance.Spec.NodeCount = 0
+	SpannerInstance.Spec.ProcessingUnits = 500
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	expInstance := SpannerInstance.DeepCopy()
+	expInstance.Status.PendingOperation = "projects/test/instances/test/operations/mock_scale"
+	expInstance.Status.Conditions = []spannercontroller.SpannerInstanceCondition{{
+		Type:               spannercontroller.SpannerInstanceScaling,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(f.now),
+		Reason:             ReasonScaling,
+		Message:            "Scaling from 1000 processing units to 500 processing units",
+	}}
+	f.expectUpdateFooStatusAction(expInstance)
+
+	f.run(getKey(SpannerInstance, t))
+
+	inst, err := f.op.GetInstance(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inst.ProcessingUnits != 500 {
+		t.Errorf("expected 500 processing units, got %d", inst.ProcessingUnits)
+	}
+}
+
+func TestReportsCapacityInProcessingUnits(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 0)
+	SpannerInstance.Spec.ProcessingUnits = 2000
+	f.createInstance(SpannerInstance)
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	expInstance := SpannerInstance.DeepCopy()
+	expInstance.Status.Capacity = 2000
+	expInstance.Status.CapacityUnit = spannercontroller.CapacityUnitProcessingUnits
+	expInstance.Status.AvailableNodes = 2
+	expInstance.Status.Conditions = syncedConditions(f.now)
+	f.expectUpdateFooStatusAction(expInstance)
+
+	f.run(getKey(SpannerInstance, t))
+}
+
+func TestRejectsUnknownInstanceConfig(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 1)
+	SpannerInstance.Spec.InstanceConfig = "regional-us-wast1"
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	c, _, _, _ := f.newController()
+	recorder := record.NewFakeRecorder(1)
+	c.recorder = recorder
+	if err := c.syncHandler(context.Background(), getKey(SpannerInstance, t)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, ErrInvalidInstanceConfig) || !strings.Contains(event, "regional-us-west1") {
+			t.Errorf("expected an %s event listing the available configs, got %q", ErrInvalidInstanceConfig, event)
+		}
+	default:
+		t.Errorf("expected an %s event", ErrInvalidInstanceConfig)
+	}
+	if _, err := f.op.GetInstance(context.Background(), "test"); !f.op.IsNotFoundError(err) {
+		t.Errorf("expected instance not to be created, got %v", err)
+	}
+}
+
+func TestRejectsInvalidCapacity(t *testing.T) {
+	for name, spec := range map[string]spannercontroller.SpannerInstanceSpec{
+		"both":       {NodeCount: 1, ProcessingUnits: 100},
+		"neither":    {},
+		"not a step": {ProcessingUnits: 150},
+		"not a node": {ProcessingUnits: 1500},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := newFixture(t)
+			SpannerInstance := newSpannerInstance("test", 0)
+			SpannerInstance.Spec.NodeCount = spec.NodeCount
+			SpannerInstance.Spec.ProcessingUnits = spec.ProcessingUnits
+
+			f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+			f.objects = append(f.objects, SpannerInstance)
+
+			expInstance := SpannerInstance.DeepCopy()
+			message := fmt.Sprintf(MessageInvalidCapacity, specCapacity(spec).Validate())
+			setCondition(&expInstance.Status, spannercontroller.SpannerInstanceError, corev1.ConditionTrue, ErrInvalidCapacity, message, f.now)
+			f.expectUpdateFooStatusAction(expInstance)
+			f.run(getKey(SpannerInstance, t))
+
+			if _, err := f.op.GetInstance(context.Background(), "test"); !f.op.IsNotFoundError(err) {
+				t.Errorf("expected instance not to be created, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCreatesInstanceWithSpecInstanceId(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 1)
+	SpannerInstance.Spec.InstanceId = "main-instance"
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	expInstance := f.expectClaimAction(SpannerInstance, "test")
+	expInstance.Status.PendingOperation = "projects/test/instances/main-instance/operations/mock_create_instance"
+	expInstance.Status.Conditions = creatingConditions(expInstance.Status.InstanceName, f.now)
+	f.expectUpdateFooStatusAction(expInstance)
+
+	f.run(getKey(SpannerInstance, t))
+
+	if _, err := f.op.GetInstance(context.Background(), "main-instance"); err != nil {
+		t.Errorf("expected instance main-instance, got %v", err)
+	}
+}
+
+func TestRejectsInvalidInstanceId(t *testing.T) {
+	for name, instanceId := range map[string]string{
+		"too short":       "a",
+		"too long":        strings.Repeat("a", 65),
+		"uppercase":       "Main",
+		"leading digit":   "1main",
+		"trailing hyphen": "main-",
+		"underscore":      "main_instance",
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := newFixture(t)
+			SpannerInstance := newSpannerInstance("test", 1)
+			SpannerInstance.Spec.InstanceId = instanceId
+
+			f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+			f.objects = append(f.objects, SpannerInstance)
+
+			expInstance := SpannerInstance.DeepCopy()
+			message := fmt.Sprintf(MessageInvalidInstanceId, operator.ValidateInstanceId(instanceId))
+			setCondition(&expInstance.Status, spannercontroller.SpannerInstanceError, corev1.ConditionTrue, ErrInvalidInstanceId, message, f.now)
+			f.expectUpdateFooStatusAction(expInstance)
+			f.run(getKey(SpannerInstance, t))
+		})
+	}
+}
+
+func TestResumesPendingOperation(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 1)
+	f.createInstance(SpannerInstance)
+	SpannerInstance.Status.PendingOperation = "projects/test/instances/test/operations/mock_create_instance"
 
 	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
 	f.objects = append(f.objects, SpannerInstance)
 
-	f.expectUpdateFooStatusAction(SpannerInstance)
+	expInstance := SpannerInstance.DeepCopy()
+	expInstance.Status.PendingOperation = ""
+	f.expectUpdateFooStatusAction(expInstance)
+	syncedInstance := expInstance.DeepCopy()
+	syncedInstance.Status.Capacity = 1
+	syncedInstance.Status.CapacityUnit = spannercontroller.CapacityUnitNodes
+	syncedInstance.Status.AvailableNodes = 1
+	syncedInstance.Status.Conditions = syncedConditions(f.now)
+	f.expectUpdateFooStatusAction(syncedInstance)
 	f.run(getKey(SpannerInstance, t))
 }
 
+// An operation Spanner no longer keeps is cleared, and the instance synced.
+func TestClearsMissingOperation(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 1)
+	f.createInstance(SpannerInstance)
+	SpannerInstance.Status.PendingOperation = "projects/test/instances/test/operations/expired"
+	f.op.FailNext("GetOperation", status.Error(codes.NotFound, "operation not found"))
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	expInstance := SpannerInstance.DeepCopy()
+	expInstance.Status.PendingOperation = ""
+	f.expectUpdateFooStatusAction(expInstance)
+	syncedInstance := expInstance.DeepCopy()
+	syncedInstance.Status.Capacity = 1
+	syncedInstance.Status.CapacityUnit = spannercontroller.CapacityUnitNodes
+	syncedInstance.Status.AvailableNodes = 1
+	syncedInstance.Status.Conditions = syncedConditions(f.now)
+	f.expectUpdateFooStatusAction(syncedInstance)
+	f.run(getKey(SpannerInstance, t))
+}
+
+func TestWaitsForRunningOperation(t *testing.T) {
+	f := newFixture(t)
+	f.op.SetOperationLatency(time.Hour)
+	SpannerInstance := newSpannerInstance("test", 1)
+	f.createInstance(SpannerInstance)
+	SpannerInstance.Status.PendingOperation = "projects/test/instances/test/operations/mock_create_instance"
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	f.run(getKey(SpannerInstance, t))
+
+	inst, err := f.op.GetInstance(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inst.State != instance.Instance_CREATING {
+		t.Errorf("expected instance to be creating, got %s", inst.State)
+	}
+
+	f.op.FinishOperations()
+	expInstance := SpannerInstance.DeepCopy()
+	expInstance.Status.PendingOperation = ""
+	f.expectUpdateFooStatusAction(expInstance)
+	syncedInstance := expInstance.DeepCopy()
+	syncedInstance.Status.Capacity = 1
+	syncedInstance.Status.CapacityUnit = spannercontroller.CapacityUnitNodes
+	syncedInstance.Status.AvailableNodes = 1
+	syncedInstance.Status.Conditions = syncedConditions(f.now)
+	f.expectUpdateFooStatusAction(syncedInstance)
+	f.run(getKey(SpannerInstance, t))
+}
+
+func TestReportsFailedOperation(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 1)
+	opErr := status.Error(codes.ResourceExhausted, "not enough nodes in the region")
+	f.op.FailNextOperation(opErr)
+	if _, err := f.op.CreateInstance(context.Background(), SpannerInstance.Spec.DisplayName, SpannerInstance.Name, SpannerInstance.Spec.InstanceConfig, operator.Nodes(1)); err != nil {
+		t.Fatal(err)
+	}
+	SpannerInstance.Status.InstanceName = "projects/test/instances/test"
+	SpannerInstance.Status.PendingOperation = "projects/test/instances/test/operations/mock_create_instance"
+	created := f.now.Add(-time.Minute)
+	SpannerInstance.Status.Conditions = creatingConditions(SpannerInstance.Status.InstanceName, created)
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	expInstance := SpannerInstance.DeepCopy()
+	expInstance.Status.PendingOperation = ""
+	message := fmt.Sprintf(MessageOperationFailed, SpannerInstance.Status.PendingOperation, opErr)
+	expInstance.Status.Conditions = []spannercontroller.SpannerInstanceCondition{
+		{Type: spannercontroller.SpannerInstanceReady, Status: corev1.ConditionFalse, LastTransitionTime: metav1.NewTime(created), Reason: ErrOperationFailed, Message: message},
+		{Type: spannercontroller.SpannerInstanceProvisioning, Status: corev1.ConditionFalse, LastTransitionTime: metav1.NewTime(f.now), Reason: ErrOperationFailed, Message: message},
+		{Type: spannercontroller.SpannerInstanceError, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(f.now), Reason: ErrOperationFailed, Message: message},
+	}
+	f.expectUpdateFooStatusAction(expInstance)
+	f.runExpectError(getKey(SpannerInstance, t))
+
+	if _, err := f.op.GetInstance(context.Background(), "test"); !f.op.IsNotFoundError(err) {
+		t.Errorf("expected failed creation to leave no instance, got %v", err)
+	}
+}
+
+func TestRetriesUnavailable(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 1)
+	f.createInstance(SpannerInstance)
+	f.op.FailNext("GetInstance", status.Error(codes.Unavailable, "connection reset"))
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	f.runExpectError(getKey(SpannerInstance, t))
+}
+
+func TestRetriesFailedCreate(t *testing.T) {
+	f := newFixture(t)
+	f.op.FailNext("CreateInstance", status.Error(codes.ResourceExhausted, "quota exceeded"))
+	SpannerInstance := newSpannerInstance("test", 1)
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	f.expectClaimAction(SpannerInstance, "test")
+	f.runExpectError(getKey(SpannerInstance, t))
+}
+
 func TestNotControlledByUs(t *testing.T) {
 	f := newFixture(t)
 	SpannerInstance := newSpannerInstance("test", 1)
+	f.createUnmanagedInstance(SpannerInstance)
+	SpannerInstance.Spec.NodeCount = 3
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	c, _, _, _ := f.newController()
+	recorder := record.NewFakeRecorder(1)
+	c.recorder = recorder
+	if err := c.syncHandler(context.Background(), getKey(SpannerInstance, t)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, ErrResourceExists) {
+			t.Errorf("expected an %s event, got %q", ErrResourceExists, event)
+		}
+	default:
+		t.Errorf("expected an %s event", ErrResourceExists)
+	}
+	actions := filterInformerActions(f.client.Actions())
+	if len(actions) != 1 {
+		t.Fatalf("expected only the status to be updated, got %+v", actions)
+	}
+	updated := actions[0].(core.UpdateAction).GetObject().(*spannercontroller.SpannerInstance)
+	if !conditionTrue(updated.Status, spannercontroller.SpannerInstanceError) || updated.Status.Conditions[0].Reason != ErrResourceExists {
+		t.Errorf("expected an Error condition with reason %s, got %+v", ErrResourceExists, updated.Status.Conditions)
+	}
+	inst, err := f.op.GetInstance(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inst.NodeCount != 1 {
+		t.Errorf("expected the instance not to be scaled, got %d nodes", inst.NodeCount)
+	}
+}
+
+func TestAdoptsExistingInstance(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 1)
+	SpannerInstance.Annotations = map[string]string{spannercontroller.AdoptAnnotation: "true"}
+	f.createUnmanagedInstance(SpannerInstance)
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	expInstance := f.expectClaimAction(SpannerInstance, "test")
+	expInstance.Status.Capacity = 1
+	expInstance.Status.CapacityUnit = spannercontroller.CapacityUnitNodes
+	expInstance.Status.AvailableNodes = 1
+	expInstance.Status.Conditions = syncedConditions(f.now)
+	f.expectUpdateFooStatusAction(expInstance)
+	f.run(getKey(SpannerInstance, t))
+}
+
+func TestRecordsInstanceOfEarlierVersion(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 1)
+	SpannerInstance.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+	f.createUnmanagedInstance(SpannerInstance)
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	expInstance := f.expectClaimAction(SpannerInstance, "test")
+	expInstance.Status.Capacity = 1
+	expInstance.Status.CapacityUnit = spannercontroller.CapacityUnitNodes
+	expInstance.Status.AvailableNodes = 1
+	expInstance.Status.Conditions = syncedConditions(f.now)
+	f.expectUpdateFooStatusAction(expInstance)
+	f.run(getKey(SpannerInstance, t))
+}
+
+// An instance older than the SpannerInstance was not created for it, and
+// needs the adopt annotation even with the ID an earlier version would have
+// used.
+func TestRefusesInstanceOlderThanSpannerInstance(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 1)
+	f.createUnmanagedInstance(SpannerInstance)
+	SpannerInstance.CreationTimestamp = metav1.NewTime(time.Now().Add(time.Minute))
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	expInstance := SpannerInstance.DeepCopy()
+	message := fmt.Sprintf(MessageResourceExists, "projects/test/instances/test", spannercontroller.AdoptAnnotation)
+	setCondition(&expInstance.Status, spannercontroller.SpannerInstanceError, corev1.ConditionTrue, ErrResourceExists, message, f.now)
+	f.expectUpdateFooStatusAction(expInstance)
+	f.run(getKey(SpannerInstance, t))
+}
+
+func TestReportsDegradedOnFailedScale(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 1)
+	f.createInstance(SpannerInstance)
+	opErr := status.Error(codes.ResourceExhausted, "not enough nodes in the region")
+	f.op.FailNextOperation(opErr)
+	if _, err := f.op.Scale(context.Background(), "test", operator.Nodes(3)); err != nil {
+		t.Fatal(err)
+	}
+	SpannerInstance.Spec.NodeCount = 3
+	SpannerInstance.Status.PendingOperation = "projects/test/instances/test/operations/mock_scale"
+	SpannerInstance.Status.Conditions = syncedConditions(f.now.Add(-time.Hour))
+	setCondition(&SpannerInstance.Status, spannercontroller.SpannerInstanceScaling, corev1.ConditionTrue, ReasonScaling, "Scaling from 1 nodes to 3 nodes", f.now.Add(-time.Minute))
 
 	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
 	f.objects = append(f.objects, SpannerInstance)
 
+	expInstance := SpannerInstance.DeepCopy()
+	expInstance.Status.PendingOperation = ""
+	message := fmt.Sprintf(MessageOperationFailed, SpannerInstance.Status.PendingOperation, opErr)
+	setCondition(&expInstance.Status, spannercontroller.SpannerInstanceScaling, corev1.ConditionFalse, ErrOperationFailed, message, f.now)
+	setCondition(&expInstance.Status, spannercontroller.SpannerInstanceDegraded, corev1.ConditionTrue, ErrOperationFailed, message, f.now)
+	f.expectUpdateFooStatusAction(expInstance)
 	f.runExpectError(getKey(SpannerInstance, t))
 }
 
+func TestReportsObservedGeneration(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 2)
+	f.createInstance(SpannerInstance)
+	SpannerInstance.Generation = 4
+	SpannerInstance.Status.ObservedGeneration = 3
+	SpannerInstance.Status.Conditions = syncedConditions(f.now.Add(-time.Hour))
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	expInstance := SpannerInstance.DeepCopy()
+	expInstance.Status.ObservedGeneration = 4
+	expInstance.Status.Capacity = 2
+	expInstance.Status.CapacityUnit = spannercontroller.CapacityUnitNodes
+	expInstance.Status.AvailableNodes = 2
+	f.expectUpdateFooStatusAction(expInstance)
+	f.run(getKey(SpannerInstance, t))
+}
+
+func TestAddsFinalizer(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 1)
+	f.createInstance(SpannerInstance)
+	SpannerInstance.Finalizers = nil
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	withFinalizer := SpannerInstance.DeepCopy()
+	withFinalizer.Finalizers = []string{deleteInstanceFinalizer}
+	f.expectUpdateAction(withFinalizer)
+	expInstance := withFinalizer.DeepCopy()
+	expInstance.Status.Capacity = 1
+	expInstance.Status.CapacityUnit = spannercontroller.CapacityUnitNodes
+	expInstance.Status.AvailableNodes = 1
+	expInstance.Status.Conditions = syncedConditions(f.now)
+	f.expectUpdateFooStatusAction(expInstance)
+	f.run(getKey(SpannerInstance, t))
+}
+
+// newDeletedSpannerInstance returns a SpannerInstance marked for deletion,
+// still held by its finalizer.
+func newDeletedSpannerInstance(name string, replicas int32, deletionPolicy string) *spannercontroller.SpannerInstance {
+	SpannerInstance := newSpannerInstance(name, replicas)
+	deletionTimestamp := metav1.NewTime(time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC))
+	SpannerInstance.DeletionTimestamp = &deletionTimestamp
+	SpannerInstance.Spec.DeletionPolicy = deletionPolicy
+	return SpannerInstance
+}
+
+func TestDeletesManagedInstance(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newDeletedSpannerInstance("test", 1, "")
+	f.createInstance(SpannerInstance)
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	deleting := SpannerInstance.DeepCopy()
+	setCondition(&deleting.Status, spannercontroller.SpannerInstanceReady, corev1.ConditionFalse, ReasonDeleting, fmt.Sprintf(MessageDeleting, "projects/test/instances/test"), f.now)
+	f.expectUpdateFooStatusAction(deleting)
+	released := deleting.DeepCopy()
+	released.Finalizers = nil
+	f.expectUpdateAction(released)
+	f.run(getKey(SpannerInstance, t))
+
+	if _, err := f.op.GetInstance(context.Background(), "test"); !f.op.IsNotFoundError(err) {
+		t.Errorf("expected instance to be deleted, got %v", err)
+	}
+}
+
+func TestDeletesInstanceOfChangedInstanceId(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newDeletedSpannerInstance("test", 1, "")
+	f.createInstance(SpannerInstance)
+	// The instance created before the ID changed is the one deleted.
+	SpannerInstance.Spec.InstanceId = "renamed"
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	deleting := SpannerInstance.DeepCopy()
+	setCondition(&deleting.Status, spannercontroller.SpannerInstanceReady, corev1.ConditionFalse, ReasonDeleting, fmt.Sprintf(MessageDeleting, "projects/test/instances/test"), f.now)
+	f.expectUpdateFooStatusAction(deleting)
+	released := deleting.DeepCopy()
+	released.Finalizers = nil
+	f.expectUpdateAction(released)
+	f.run(getKey(SpannerInstance, t))
+
+	if _, err := f.op.GetInstance(context.Background(), "test"); !f.op.IsNotFoundError(err) {
+		t.Errorf("expected instance test to be deleted, got %v", err)
+	}
+}
+
+func TestRetainsInstance(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newDeletedSpannerInstance("test", 1, spannercontroller.DeletionPolicyRetain)
+	f.createInstance(SpannerInstance)
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	released := SpannerInstance.DeepCopy()
+	released.Finalizers = nil
+	f.expectUpdateAction(released)
+	f.run(getKey(SpannerInstance, t))
+
+	if _, err := f.op.GetInstance(context.Background(), "test"); err != nil {
+		t.Errorf("expected instance to be kept, got %v", err)
+	}
+}
+
+func TestKeepsUnmanagedInstance(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newDeletedSpannerInstance("test", 1, "")
+	f.createUnmanagedInstance(SpannerInstance)
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	released := SpannerInstance.DeepCopy()
+	released.Finalizers = nil
+	f.expectUpdateAction(released)
+	f.run(getKey(SpannerInstance, t))
+
+	if _, err := f.op.GetInstance(context.Background(), "test"); err != nil {
+		t.Errorf("expected instance to be kept, got %v", err)
+	}
+}
+
+func TestBlocksDeletionOfProtectedInstance(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newDeletedSpannerInstance("test", 1, "")
+	SpannerInstance.Spec.DeletionProtection = true
+	f.createInstance(SpannerInstance)
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	expInstance := SpannerInstance.DeepCopy()
+	message := fmt.Sprintf(MessageDeletionProtected, "projects/test/instances/test")
+	setCondition(&expInstance.Status, spannercontroller.SpannerInstanceDeletionBlocked, corev1.ConditionTrue, ErrDeletionProtected, message, f.now)
+	f.expectUpdateFooStatusAction(expInstance)
+	f.run(getKey(SpannerInstance, t))
+
+	if _, err := f.op.GetInstance(context.Background(), "test"); err != nil {
+		t.Errorf("expected protected instance to be kept, got %v", err)
+	}
+}
+
+func TestDeletesInstanceOnceUnprotected(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newDeletedSpannerInstance("test", 1, "")
+	f.createInstance(SpannerInstance)
+	setCondition(&SpannerInstance.Status, spannercontroller.SpannerInstanceDeletionBlocked, corev1.ConditionTrue, ErrDeletionProtected, "blocked", f.now.Add(-time.Hour))
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	deleting := SpannerInstance.DeepCopy()
+	message := fmt.Sprintf(MessageDeleting, "projects/test/instances/test")
+	setCondition(&deleting.Status, spannercontroller.SpannerInstanceDeletionBlocked, corev1.ConditionFalse, ReasonDeleting, message, f.now)
+	setCondition(&deleting.Status, spannercontroller.SpannerInstanceReady, corev1.ConditionFalse, ReasonDeleting, message, f.now)
+	f.expectUpdateFooStatusAction(deleting)
+	released := deleting.DeepCopy()
+	released.Finalizers = nil
+	f.expectUpdateAction(released)
+	f.run(getKey(SpannerInstance, t))
+
+	if _, err := f.op.GetInstance(context.Background(), "test"); !f.op.IsNotFoundError(err) {
+		t.Errorf("expected instance to be deleted, got %v", err)
+	}
+}
+
+// newHoldingSpannerDatabase returns a SpannerDatabase in namespace, still
+// holding its database.
+func newHoldingSpannerDatabase(namespace, name string) *databasev1alpha1.SpannerDatabase {
+	return &databasev1alpha1.SpannerDatabase{
+		TypeMeta: metav1.TypeMeta{APIVersion: databasev1alpha1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			Finalizers: []string{"databaseadmins.spanner-operator.io/drop-database"},
+		},
+	}
+}
+
+func TestDeletesSpannerDatabasesOfDeletedInstance(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newDeletedSpannerInstance("test", 1, "")
+	SpannerInstance.UID = "test-uid"
+	f.createInstance(SpannerInstance)
+	// Owned through spec.instanceRef, deleted by the controller.
+	owned := newHoldingSpannerDatabase(metav1.NamespaceDefault, "orders")
+	owned.Spec.InstanceRef = &databasev1alpha1.SpannerInstanceReference{Name: "test"}
+	owned.OwnerReferences = []metav1.OwnerReference{{APIVersion: spannercontroller.SchemeGroupVersion.String(), Kind: "SpannerInstance", Name: "test", UID: "test-uid"}}
+	// Naming the instance by ID from another namespace, already deleted.
+	other := newHoldingSpannerDatabase("other", "users")
+	other.Spec.InstanceId = "test"
+	deletionTimestamp := metav1.NewTime(f.now)
+	other.DeletionTimestamp = &deletionTimestamp
+	// Done with its database.
+	released := newHoldingSpannerDatabase(metav1.NamespaceDefault, "logs")
+	released.Spec.InstanceRef = &databasev1alpha1.SpannerInstanceReference{Name: "test"}
+	released.Finalizers = nil
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+	f.SpannerDatabaseLister = append(f.SpannerDatabaseLister, owned, other, released)
+	f.databaseobjects = append(f.databaseobjects, owned, other, released)
+
+	f.databaseactions = append(f.databaseactions, core.NewDeleteAction(schema.GroupVersionResource{Resource: "spannerdatabases"}, metav1.NamespaceDefault, "orders"))
+	waiting := SpannerInstance.DeepCopy()
+	message := fmt.Sprintf(MessageWaitingForDatabases, "default/orders, other/users", "projects/test/instances/test")
+	setCondition(&waiting.Status, spannercontroller.SpannerInstanceDeletionBlocked, corev1.ConditionTrue, WaitingForDatabases, message, f.now)
+	f.expectUpdateFooStatusAction(waiting)
+	f.run(getKey(SpannerInstance, t))
+
+	if _, err := f.op.GetInstance(context.Background(), "test"); err != nil {
+		t.Errorf("expected instance to be kept until its SpannerDatabases are deleted, got %v", err)
+	}
+}
+
+func TestKeepsInstanceHoldingDatabases(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newDeletedSpannerInstance("test", 1, "")
+	f.createInstance(SpannerInstance)
+	// Retained by its deleted SpannerDatabase, or never managed by one.
+	if _, err := f.op.CreateDatabase(context.Background(), "test", "orders", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	blocked := SpannerInstance.DeepCopy()
+	message := fmt.Sprintf(MessageInstanceNotEmpty, "projects/test/instances/test", `database "projects/test/instances/test/databases/orders"`)
+	setCondition(&blocked.Status, spannercontroller.SpannerInstanceDeletionBlocked, corev1.ConditionTrue, ErrInstanceNotEmpty, message, f.now)
+	f.expectUpdateFooStatusAction(blocked)
+	f.run(getKey(SpannerInstance, t))
+
+	if _, err := f.op.GetDatabase(context.Background(), "test", "orders"); err != nil {
+		t.Errorf("expected database to be kept, got %v", err)
+	}
+}
+
+func TestKeepsInstanceHoldingBackups(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newDeletedSpannerInstance("test", 1, "")
+	f.createInstance(SpannerInstance)
+	// Left by a SpannerDatabase deleted with BackupThenDelete.
+	ctx := context.Background()
+	if _, err := f.op.CreateDatabase(ctx, "test", "orders", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.op.CreateBackup(ctx, "test", "orders-deleted", "orders", f.now.Add(time.Hour), time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.op.DropDatabase(ctx, "test", "orders"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.op.DeleteInstance(ctx, "test"); err == nil {
+		t.Fatal("expected Spanner to refuse deleting an instance with backups")
+	}
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	blocked := SpannerInstance.DeepCopy()
+	message := fmt.Sprintf(MessageInstanceNotEmpty, "projects/test/instances/test", `backup "projects/test/instances/test/backups/orders-deleted"`)
+	setCondition(&blocked.Status, spannercontroller.SpannerInstanceDeletionBlocked, corev1.ConditionTrue, ErrInstanceNotEmpty, message, f.now)
+	f.expectUpdateFooStatusAction(blocked)
+	f.run(getKey(SpannerInstance, t))
+}
+
+func TestRejectsBackupThenDeleteInstance(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newDeletedSpannerInstance("test", 1, "BackupThenDelete")
+	f.createInstance(SpannerInstance)
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	expInstance := SpannerInstance.DeepCopy()
+	message := fmt.Sprintf(MessageInvalidDeletionPolicy, validateDeletionPolicy("BackupThenDelete"))
+	setCondition(&expInstance.Status, spannercontroller.SpannerInstanceError, corev1.ConditionTrue, ErrInvalidDeletionPolicy, message, f.now)
+	f.expectUpdateFooStatusAction(expInstance)
+	f.run(getKey(SpannerInstance, t))
+
+	if _, err := f.op.GetInstance(context.Background(), "test"); err != nil {
+		t.Errorf("expected instance to be kept until the policy is fixed, got %v", err)
+	}
+}
+
+func TestSyncAbortsOnCancelledContext(t *testing.T) {
+	f := newFixture(t)
+	SpannerInstance := newSpannerInstance("test", 1)
+
+	f.SpannerInstanceLister = append(f.SpannerInstanceLister, SpannerInstance)
+	f.objects = append(f.objects, SpannerInstance)
+
+	c, _, _, _ := f.newController()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.syncHandler(ctx, getKey(SpannerInstance, t)); err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	if _, err := f.op.GetInstance(context.Background(), "test"); !f.op.IsNotFoundError(err) {
+		t.Errorf("expected instance not to be created, got %v", err)
+	}
+}
+
 func int32Ptr(i int32) *int32 { return &i }
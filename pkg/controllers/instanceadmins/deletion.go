@@ -0,0 +1,215 @@
+package instanceadmins
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	databasev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+	instancev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/instanceadmins/v1alpha1"
+
+	"github.com/katsew/spanner-operator/pkg/controllers/databaseadmins"
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+// deleteInstanceFinalizer keeps a SpannerInstance around until its instance
+// has been deleted, or kept as its deletion policy tells.
+const deleteInstanceFinalizer = "instanceadmins.spanner-operator.io/delete-instance"
+
+// validateDeletionPolicy checks the deletion policy of a SpannerInstance.
+func validateDeletionPolicy(policy string) error {
+	switch policy {
+	case "", instancev1alpha1.DeletionPolicyDelete, instancev1alpha1.DeletionPolicyRetain:
+		return nil
+	case "BackupThenDelete":
+		return fmt.Errorf("%s is only supported by SpannerDatabase, backups cannot outlive their instance", policy)
+	}
+	return fmt.Errorf("%q is not one of %s or %s", policy, instancev1alpha1.DeletionPolicyDelete, instancev1alpha1.DeletionPolicyRetain)
+}
+
+// finalize cleans up the Spanner instance of the deleted spannerInstance as
+// its deletion policy tells, then removes the finalizer. Instances the
+// SpannerInstance never created nor adopted are left alone.
+//
+// Deleting an instance drops every database in it, so the SpannerDatabases of
+// the instance are deleted first and handle their databases as their own
+// deletion policy tells. The instance is then only deleted once it holds no
+// database or backup, which they may have kept.
+func (c *Controller) finalize(ctx context.Context, key string, spannerInstance *instancev1alpha1.SpannerInstance) error {
+	// The instance is found through the status, which keeps the instance
+	// created even if spec.instanceId changed since.
+	instanceName := spannerInstance.Status.InstanceName
+	_, instanceId, ok := operator.ParseInstanceName(instanceName)
+	if !ok {
+		return c.removeFinalizer(spannerInstance)
+	}
+	if spannerInstance.Spec.DeletionPolicy == instancev1alpha1.DeletionPolicyRetain {
+		c.recorder.Event(spannerInstance, corev1.EventTypeNormal, SuccessRetained, fmt.Sprintf(MessageResourceRetained, instanceName))
+		return c.removeFinalizer(spannerInstance)
+	}
+
+	op, err := c.operatorFor(spannerInstance)
+	if err != nil {
+		return err
+	}
+	_, err = op.GetInstance(ctx, instanceId)
+	if err != nil && op.IsNotFoundError(err) {
+		return c.removeFinalizer(spannerInstance)
+	} else if err != nil {
+		return err
+	}
+
+	waiting, err := c.deleteSpannerDatabases(spannerInstance)
+	if err != nil {
+		return err
+	}
+	if len(waiting) > 0 {
+		message := fmt.Sprintf(MessageWaitingForDatabases, strings.Join(waiting, ", "), instanceName)
+		if err := c.blockDeletion(spannerInstance, corev1.EventTypeNormal, WaitingForDatabases, message); err != nil {
+			return err
+		}
+		c.workqueue.AddAfter(key, operationPollInterval)
+		return nil
+	}
+	remaining, err := remainingResources(ctx, op, instanceId)
+	if err != nil {
+		return err
+	}
+	if remaining != "" {
+		// Dropping the databases or backups out of band, or switching to
+		// Retain, lets the deletion go on.
+		message := fmt.Sprintf(MessageInstanceNotEmpty, instanceName, remaining)
+		if err := c.blockDeletion(spannerInstance, corev1.EventTypeWarning, ErrInstanceNotEmpty, message); err != nil {
+			return err
+		}
+		c.workqueue.AddAfter(key, operationPollInterval)
+		return nil
+	}
+
+	spannerInstanceCopy := spannerInstance.DeepCopy()
+	message := fmt.Sprintf(MessageDeleting, instanceName)
+	changed := setCondition(&spannerInstanceCopy.Status, instancev1alpha1.SpannerInstanceReady, corev1.ConditionFalse, ReasonDeleting, message, c.now())
+	if conditionTrue(spannerInstanceCopy.Status, instancev1alpha1.SpannerInstanceDeletionBlocked) {
+		changed = setCondition(&spannerInstanceCopy.Status, instancev1alpha1.SpannerInstanceDeletionBlocked, corev1.ConditionFalse, ReasonDeleting, message, c.now()) || changed
+	}
+	if changed {
+		spannerInstance, err = c.updateSpannerInstanceStatus(spannerInstanceCopy)
+		if err != nil {
+			return err
+		}
+	}
+	log.Printf("Delete instance %s", instanceName)
+	if err := op.DeleteInstance(ctx, instanceId); err != nil {
+		return err
+	}
+	c.recorder.Event(spannerInstance, corev1.EventTypeNormal, SuccessDeleted, fmt.Sprintf(MessageResourceDeleted, instanceName))
+	return c.removeFinalizer(spannerInstance)
+}
+
+// deleteSpannerDatabases deletes the SpannerDatabases spannerInstance owns,
+// which the garbage collector leaves alone while spannerInstance is kept by
+// its finalizer. It returns the namespace/name keys of the SpannerDatabases of
+// the instance that still hold their database.
+func (c *Controller) deleteSpannerDatabases(spannerInstance *instancev1alpha1.SpannerInstance) ([]string, error) {
+	var waiting []string
+	for _, indexKey := range []string{spannerInstance.Namespace + "/" + spannerInstance.Name, spannerInstance.Status.InstanceName} {
+		objs, err := c.spannerDatabaseIndexer.ByIndex(databaseadmins.InstanceIndex, indexKey)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			spannerDatabase, ok := obj.(*databasev1alpha1.SpannerDatabase)
+			if !ok || !databaseadmins.HasFinalizer(spannerDatabase) {
+				continue
+			}
+			waiting = append(waiting, spannerDatabase.Namespace+"/"+spannerDatabase.Name)
+			if spannerDatabase.DeletionTimestamp != nil || !ownedBy(spannerDatabase, spannerInstance) {
+				continue
+			}
+			log.Printf("Delete SpannerDatabase %s/%s of deleted SpannerInstance %s", spannerDatabase.Namespace, spannerDatabase.Name, spannerInstance.Name)
+			err := c.databaseclientset.DatabaseadminsV1alpha1().SpannerDatabases(spannerDatabase.Namespace).Delete(spannerDatabase.Name, &metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				return nil, err
+			}
+		}
+	}
+	return waiting, nil
+}
+
+// ownedBy reports whether spannerInstance is an owner of spannerDatabase.
+func ownedBy(spannerDatabase *databasev1alpha1.SpannerDatabase, spannerInstance *instancev1alpha1.SpannerInstance) bool {
+	for _, owner := range spannerDatabase.OwnerReferences {
+		if owner.UID == spannerInstance.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// remainingResources describes a database or backup left in the instance
+// instanceId, which deleting it would destroy or Spanner refuses to delete
+// it for, or returns an empty string when there is none.
+func remainingResources(ctx context.Context, op operator.Operator, instanceId string) (string, error) {
+	databases, _, err := op.ListDatabases(ctx, instanceId, 1, "")
+	if err != nil {
+		return "", err
+	}
+	if len(databases) > 0 {
+		return fmt.Sprintf("database %q", databases[0].Name), nil
+	}
+	backups, err := op.ListBackups(ctx, instanceId, "")
+	if err != nil {
+		return "", err
+	}
+	if len(backups) > 0 {
+		return fmt.Sprintf("backup %q", backups[0].Name), nil
+	}
+	return "", nil
+}
+
+// blockDeletion reports why the deleted spannerInstance is kept, through an
+// Event and the DeletionBlocked condition, both with reason and message.
+func (c *Controller) blockDeletion(spannerInstance *instancev1alpha1.SpannerInstance, eventType, reason, message string) error {
+	c.recorder.Event(spannerInstance, eventType, reason, message)
+	spannerInstanceCopy := spannerInstance.DeepCopy()
+	changed := setCondition(&spannerInstanceCopy.Status, instancev1alpha1.SpannerInstanceDeletionBlocked, corev1.ConditionTrue, reason, message, c.now())
+	if !changed && spannerInstance.Status.ObservedGeneration == spannerInstance.Generation {
+		return nil
+	}
+	_, err := c.updateSpannerInstanceStatus(spannerInstanceCopy)
+	return err
+}
+
+// removeFinalizer lets the API server delete spannerInstance.
+func (c *Controller) removeFinalizer(spannerInstance *instancev1alpha1.SpannerInstance) error {
+	spannerInstanceCopy := spannerInstance.DeepCopy()
+	spannerInstanceCopy.Finalizers = removeString(spannerInstanceCopy.Finalizers, deleteInstanceFinalizer)
+	_, err := c.spannerclientset.InstanceadminsV1alpha1().SpannerInstances(spannerInstance.Namespace).Update(spannerInstanceCopy)
+	return err
+}
+
+// hasFinalizer reports whether spannerInstance holds deleteInstanceFinalizer.
+func hasFinalizer(spannerInstance *instancev1alpha1.SpannerInstance) bool {
+	for _, f := range spannerInstance.Finalizers {
+		if f == deleteInstanceFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// removeString returns a copy of s without any occurrence of r.
+func removeString(s []string, r string) []string {
+	var result []string
+	for _, v := range s {
+		if v != r {
+			result = append(result, v)
+		}
+	}
+	return result
+}
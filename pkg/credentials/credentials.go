@@ -0,0 +1,35 @@
+// Package credentials resolves the service account keys that Spanner
+// resources select in Secrets into Operators.
+package credentials
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+// Operator returns the Operator of projectId from operators, authenticating
+// with the key ref selects in a Secret of namespace, or with the default
+// credentials when ref is nil. The Operator is rebuilt once the Secret
+// changes, so rotated keys take effect on the next sync.
+func Operator(operators *operator.Pool, secrets corelisters.SecretLister, namespace string, projectId string, ref *corev1.SecretKeySelector) (operator.Operator, error) {
+	if ref == nil {
+		return operators.Get(projectId)
+	}
+	secret, err := secrets.Secrets(namespace).Get(ref.Name)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := secret.Data[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s has no key %q", namespace, ref.Name, ref.Key)
+	}
+	return operators.GetWithKey(projectId, &operator.ServiceAccountKey{
+		Source:  fmt.Sprintf("%s/%s/%s", namespace, ref.Name, ref.Key),
+		Version: secret.ResourceVersion,
+		Data:    data,
+	})
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2019 The Kubernetes spanner-controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1alpha1 "github.com/katsew/spanner-operator/pkg/apis/backupadmins/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeSpannerBackups implements SpannerBackupInterface
+type FakeSpannerBackups struct {
+	Fake *FakeBackupadminsV1alpha1
+	ns   string
+}
+
+var spannerbackupsResource = schema.GroupVersionResource{Group: "backupadmins.spanner-operator.io", Version: "v1alpha1", Resource: "spannerbackups"}
+
+var spannerbackupsKind = schema.GroupVersionKind{Group: "backupadmins.spanner-operator.io", Version: "v1alpha1", Kind: "SpannerBackup"}
+
+// Get takes name of the spannerBackup, and returns the corresponding spannerBackup object, and an error if there is any.
+func (c *FakeSpannerBackups) Get(name string, options v1.GetOptions) (result *v1alpha1.SpannerBackup, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(spannerbackupsResource, c.ns, name), &v1alpha1.SpannerBackup{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerBackup), err
+}
+
+// List takes label and field selectors, and returns the list of SpannerBackups that match those selectors.
+func (c *FakeSpannerBackups) List(opts v1.ListOptions) (result *v1alpha1.SpannerBackupList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(spannerbackupsResource, spannerbackupsKind, c.ns, opts), &v1alpha1.SpannerBackupList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.SpannerBackupList{ListMeta: obj.(*v1alpha1.SpannerBackupList).ListMeta}
+	for _, item := range obj.(*v1alpha1.SpannerBackupList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested spannerBackups.
+func (c *FakeSpannerBackups) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(spannerbackupsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a spannerBackup and creates it.  Returns the server's representation of the spannerBackup, and an error, if there is any.
+func (c *FakeSpannerBackups) Create(spannerBackup *v1alpha1.SpannerBackup) (result *v1alpha1.SpannerBackup, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(spannerbackupsResource, c.ns, spannerBackup), &v1alpha1.SpannerBackup{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerBackup), err
+}
+
+// Update takes the representation of a spannerBackup and updates it. Returns the server's representation of the spannerBackup, and an error, if there is any.
+func (c *FakeSpannerBackups) Update(spannerBackup *v1alpha1.SpannerBackup) (result *v1alpha1.SpannerBackup, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(spannerbackupsResource, c.ns, spannerBackup), &v1alpha1.SpannerBackup{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerBackup), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeSpannerBackups) UpdateStatus(spannerBackup *v1alpha1.SpannerBackup) (*v1alpha1.SpannerBackup, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(spannerbackupsResource, "status", c.ns, spannerBackup), &v1alpha1.SpannerBackup{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerBackup), err
+}
+
+// Delete takes name of the spannerBackup and deletes it. Returns an error if one occurs.
+func (c *FakeSpannerBackups) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(spannerbackupsResource, c.ns, name), &v1alpha1.SpannerBackup{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeSpannerBackups) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(spannerbackupsResource, c.ns, listOptions)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.SpannerBackupList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched spannerBackup.
+func (c *FakeSpannerBackups) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SpannerBackup, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(spannerbackupsResource, c.ns, name, pt, data, subresources...), &v1alpha1.SpannerBackup{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerBackup), err
+}
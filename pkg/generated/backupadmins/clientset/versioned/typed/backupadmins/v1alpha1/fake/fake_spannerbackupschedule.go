@@ -0,0 +1,140 @@
+/*
+Copyright 2019 The Kubernetes spanner-controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1alpha1 "github.com/katsew/spanner-operator/pkg/apis/backupadmins/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeSpannerBackupSchedules implements SpannerBackupScheduleInterface
+type FakeSpannerBackupSchedules struct {
+	Fake *FakeBackupadminsV1alpha1
+	ns   string
+}
+
+var spannerbackupschedulesResource = schema.GroupVersionResource{Group: "backupadmins.spanner-operator.io", Version: "v1alpha1", Resource: "spannerbackupschedules"}
+
+var spannerbackupschedulesKind = schema.GroupVersionKind{Group: "backupadmins.spanner-operator.io", Version: "v1alpha1", Kind: "SpannerBackupSchedule"}
+
+// Get takes name of the spannerBackupSchedule, and returns the corresponding spannerBackupSchedule object, and an error if there is any.
+func (c *FakeSpannerBackupSchedules) Get(name string, options v1.GetOptions) (result *v1alpha1.SpannerBackupSchedule, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(spannerbackupschedulesResource, c.ns, name), &v1alpha1.SpannerBackupSchedule{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerBackupSchedule), err
+}
+
+// List takes label and field selectors, and returns the list of SpannerBackupSchedules that match those selectors.
+func (c *FakeSpannerBackupSchedules) List(opts v1.ListOptions) (result *v1alpha1.SpannerBackupScheduleList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(spannerbackupschedulesResource, spannerbackupschedulesKind, c.ns, opts), &v1alpha1.SpannerBackupScheduleList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.SpannerBackupScheduleList{ListMeta: obj.(*v1alpha1.SpannerBackupScheduleList).ListMeta}
+	for _, item := range obj.(*v1alpha1.SpannerBackupScheduleList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested spannerBackupSchedules.
+func (c *FakeSpannerBackupSchedules) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(spannerbackupschedulesResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a spannerBackupSchedule and creates it.  Returns the server's representation of the spannerBackupSchedule, and an error, if there is any.
+func (c *FakeSpannerBackupSchedules) Create(spannerBackupSchedule *v1alpha1.SpannerBackupSchedule) (result *v1alpha1.SpannerBackupSchedule, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(spannerbackupschedulesResource, c.ns, spannerBackupSchedule), &v1alpha1.SpannerBackupSchedule{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerBackupSchedule), err
+}
+
+// Update takes the representation of a spannerBackupSchedule and updates it. Returns the server's representation of the spannerBackupSchedule, and an error, if there is any.
+func (c *FakeSpannerBackupSchedules) Update(spannerBackupSchedule *v1alpha1.SpannerBackupSchedule) (result *v1alpha1.SpannerBackupSchedule, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(spannerbackupschedulesResource, c.ns, spannerBackupSchedule), &v1alpha1.SpannerBackupSchedule{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerBackupSchedule), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeSpannerBackupSchedules) UpdateStatus(spannerBackupSchedule *v1alpha1.SpannerBackupSchedule) (*v1alpha1.SpannerBackupSchedule, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(spannerbackupschedulesResource, "status", c.ns, spannerBackupSchedule), &v1alpha1.SpannerBackupSchedule{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerBackupSchedule), err
+}
+
+// Delete takes name of the spannerBackupSchedule and deletes it. Returns an error if one occurs.
+func (c *FakeSpannerBackupSchedules) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(spannerbackupschedulesResource, c.ns, name), &v1alpha1.SpannerBackupSchedule{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeSpannerBackupSchedules) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(spannerbackupschedulesResource, c.ns, listOptions)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.SpannerBackupScheduleList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched spannerBackupSchedule.
+func (c *FakeSpannerBackupSchedules) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SpannerBackupSchedule, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(spannerbackupschedulesResource, c.ns, name, pt, data, subresources...), &v1alpha1.SpannerBackupSchedule{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerBackupSchedule), err
+}
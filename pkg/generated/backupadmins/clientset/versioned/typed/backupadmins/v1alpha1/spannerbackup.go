@@ -0,0 +1,191 @@
+/*
+Copyright 2019 The Kubernetes spanner-controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"time"
+
+	v1alpha1 "github.com/katsew/spanner-operator/pkg/apis/backupadmins/v1alpha1"
+	scheme "github.com/katsew/spanner-operator/pkg/generated/backupadmins/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// SpannerBackupsGetter has a method to return a SpannerBackupInterface.
+// A group's client should implement this interface.
+type SpannerBackupsGetter interface {
+	SpannerBackups(namespace string) SpannerBackupInterface
+}
+
+// SpannerBackupInterface has methods to work with SpannerBackup resources.
+type SpannerBackupInterface interface {
+	Create(*v1alpha1.SpannerBackup) (*v1alpha1.SpannerBackup, error)
+	Update(*v1alpha1.SpannerBackup) (*v1alpha1.SpannerBackup, error)
+	UpdateStatus(*v1alpha1.SpannerBackup) (*v1alpha1.SpannerBackup, error)
+	Delete(name string, options *v1.DeleteOptions) error
+	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
+	Get(name string, options v1.GetOptions) (*v1alpha1.SpannerBackup, error)
+	List(opts v1.ListOptions) (*v1alpha1.SpannerBackupList, error)
+	Watch(opts v1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SpannerBackup, err error)
+	SpannerBackupExpansion
+}
+
+// spannerBackups implements SpannerBackupInterface
+type spannerBackups struct {
+	client rest.Interface
+	ns     string
+}
+
+// newSpannerBackups returns a SpannerBackups
+func newSpannerBackups(c *BackupadminsV1alpha1Client, namespace string) *spannerBackups {
+	return &spannerBackups{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the spannerBackup, and returns the corresponding spannerBackup object, and an error if there is any.
+func (c *spannerBackups) Get(name string, options v1.GetOptions) (result *v1alpha1.SpannerBackup, err error) {
+	result = &v1alpha1.SpannerBackup{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("spannerbackups").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of SpannerBackups that match those selectors.
+func (c *spannerBackups) List(opts v1.ListOptions) (result *v1alpha1.SpannerBackupList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.SpannerBackupList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("spannerbackups").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested spannerBackups.
+func (c *spannerBackups) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("spannerbackups").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch()
+}
+
+// Create takes the representation of a spannerBackup and creates it.  Returns the server's representation of the spannerBackup, and an error, if there is any.
+func (c *spannerBackups) Create(spannerBackup *v1alpha1.SpannerBackup) (result *v1alpha1.SpannerBackup, err error) {
+	result = &v1alpha1.SpannerBackup{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("spannerbackups").
+		Body(spannerBackup).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a spannerBackup and updates it. Returns the server's representation of the spannerBackup, and an error, if there is any.
+func (c *spannerBackups) Update(spannerBackup *v1alpha1.SpannerBackup) (result *v1alpha1.SpannerBackup, err error) {
+	result = &v1alpha1.SpannerBackup{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("spannerbackups").
+		Name(spannerBackup.Name).
+		Body(spannerBackup).
+		Do().
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *spannerBackups) UpdateStatus(spannerBackup *v1alpha1.SpannerBackup) (result *v1alpha1.SpannerBackup, err error) {
+	result = &v1alpha1.SpannerBackup{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("spannerbackups").
+		Name(spannerBackup.Name).
+		SubResource("status").
+		Body(spannerBackup).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the spannerBackup and deletes it. Returns an error if one occurs.
+func (c *spannerBackups) Delete(name string, options *v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("spannerbackups").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *spannerBackups) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	var timeout time.Duration
+	if listOptions.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOptions.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("spannerbackups").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched spannerBackup.
+func (c *spannerBackups) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SpannerBackup, err error) {
+	result = &v1alpha1.SpannerBackup{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("spannerbackups").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
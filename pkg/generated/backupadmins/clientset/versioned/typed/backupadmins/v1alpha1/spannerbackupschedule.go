@@ -0,0 +1,191 @@
+/*
+Copyright 2019 The Kubernetes spanner-controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"time"
+
+	v1alpha1 "github.com/katsew/spanner-operator/pkg/apis/backupadmins/v1alpha1"
+	scheme "github.com/katsew/spanner-operator/pkg/generated/backupadmins/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// SpannerBackupSchedulesGetter has a method to return a SpannerBackupScheduleInterface.
+// A group's client should implement this interface.
+type SpannerBackupSchedulesGetter interface {
+	SpannerBackupSchedules(namespace string) SpannerBackupScheduleInterface
+}
+
+// SpannerBackupScheduleInterface has methods to work with SpannerBackupSchedule resources.
+type SpannerBackupScheduleInterface interface {
+	Create(*v1alpha1.SpannerBackupSchedule) (*v1alpha1.SpannerBackupSchedule, error)
+	Update(*v1alpha1.SpannerBackupSchedule) (*v1alpha1.SpannerBackupSchedule, error)
+	UpdateStatus(*v1alpha1.SpannerBackupSchedule) (*v1alpha1.SpannerBackupSchedule, error)
+	Delete(name string, options *v1.DeleteOptions) error
+	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
+	Get(name string, options v1.GetOptions) (*v1alpha1.SpannerBackupSchedule, error)
+	List(opts v1.ListOptions) (*v1alpha1.SpannerBackupScheduleList, error)
+	Watch(opts v1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SpannerBackupSchedule, err error)
+	SpannerBackupScheduleExpansion
+}
+
+// spannerBackupSchedules implements SpannerBackupScheduleInterface
+type spannerBackupSchedules struct {
+	client rest.Interface
+	ns     string
+}
+
+// newSpannerBackupSchedules returns a SpannerBackupSchedules
+func newSpannerBackupSchedules(c *BackupadminsV1alpha1Client, namespace string) *spannerBackupSchedules {
+	return &spannerBackupSchedules{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the spannerBackupSchedule, and returns the corresponding spannerBackupSchedule object, and an error if there is any.
+func (c *spannerBackupSchedules) Get(name string, options v1.GetOptions) (result *v1alpha1.SpannerBackupSchedule, err error) {
+	result = &v1alpha1.SpannerBackupSchedule{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("spannerbackupschedules").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of SpannerBackupSchedules that match those selectors.
+func (c *spannerBackupSchedules) List(opts v1.ListOptions) (result *v1alpha1.SpannerBackupScheduleList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.SpannerBackupScheduleList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("spannerbackupschedules").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested spannerBackupSchedules.
+func (c *spannerBackupSchedules) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("spannerbackupschedules").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch()
+}
+
+// Create takes the representation of a spannerBackupSchedule and creates it.  Returns the server's representation of the spannerBackupSchedule, and an error, if there is any.
+func (c *spannerBackupSchedules) Create(spannerBackupSchedule *v1alpha1.SpannerBackupSchedule) (result *v1alpha1.SpannerBackupSchedule, err error) {
+	result = &v1alpha1.SpannerBackupSchedule{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("spannerbackupschedules").
+		Body(spannerBackupSchedule).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a spannerBackupSchedule and updates it. Returns the server's representation of the spannerBackupSchedule, and an error, if there is any.
+func (c *spannerBackupSchedules) Update(spannerBackupSchedule *v1alpha1.SpannerBackupSchedule) (result *v1alpha1.SpannerBackupSchedule, err error) {
+	result = &v1alpha1.SpannerBackupSchedule{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("spannerbackupschedules").
+		Name(spannerBackupSchedule.Name).
+		Body(spannerBackupSchedule).
+		Do().
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *spannerBackupSchedules) UpdateStatus(spannerBackupSchedule *v1alpha1.SpannerBackupSchedule) (result *v1alpha1.SpannerBackupSchedule, err error) {
+	result = &v1alpha1.SpannerBackupSchedule{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("spannerbackupschedules").
+		Name(spannerBackupSchedule.Name).
+		SubResource("status").
+		Body(spannerBackupSchedule).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the spannerBackupSchedule and deletes it. Returns an error if one occurs.
+func (c *spannerBackupSchedules) Delete(name string, options *v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("spannerbackupschedules").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *spannerBackupSchedules) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	var timeout time.Duration
+	if listOptions.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOptions.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("spannerbackupschedules").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched spannerBackupSchedule.
+func (c *spannerBackupSchedules) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SpannerBackupSchedule, err error) {
+	result = &v1alpha1.SpannerBackupSchedule{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("spannerbackupschedules").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2019 The Kubernetes spanner-controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	time "time"
+
+	backupadminsv1alpha1 "github.com/katsew/spanner-operator/pkg/apis/backupadmins/v1alpha1"
+	versioned "github.com/katsew/spanner-operator/pkg/generated/backupadmins/clientset/versioned"
+	internalinterfaces "github.com/katsew/spanner-operator/pkg/generated/backupadmins/informers/externalversions/internalinterfaces"
+	v1alpha1 "github.com/katsew/spanner-operator/pkg/generated/backupadmins/listers/backupadmins/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// SpannerBackupScheduleInformer provides access to a shared informer and lister for
+// SpannerBackupSchedules.
+type SpannerBackupScheduleInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1alpha1.SpannerBackupScheduleLister
+}
+
+type spannerBackupScheduleInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewSpannerBackupScheduleInformer constructs a new informer for SpannerBackupSchedule type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewSpannerBackupScheduleInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredSpannerBackupScheduleInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredSpannerBackupScheduleInformer constructs a new informer for SpannerBackupSchedule type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredSpannerBackupScheduleInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.BackupadminsV1alpha1().SpannerBackupSchedules(namespace).List(options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.BackupadminsV1alpha1().SpannerBackupSchedules(namespace).Watch(options)
+			},
+		},
+		&backupadminsv1alpha1.SpannerBackupSchedule{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *spannerBackupScheduleInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredSpannerBackupScheduleInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *spannerBackupScheduleInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&backupadminsv1alpha1.SpannerBackupSchedule{}, f.defaultInformer)
+}
+
+func (f *spannerBackupScheduleInformer) Lister() v1alpha1.SpannerBackupScheduleLister {
+	return v1alpha1.NewSpannerBackupScheduleLister(f.Informer().GetIndexer())
+}
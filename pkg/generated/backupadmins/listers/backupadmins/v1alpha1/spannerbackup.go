@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The Kubernetes spanner-controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/katsew/spanner-operator/pkg/apis/backupadmins/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// SpannerBackupLister helps list SpannerBackups.
+type SpannerBackupLister interface {
+	// List lists all SpannerBackups in the indexer.
+	List(selector labels.Selector) (ret []*v1alpha1.SpannerBackup, err error)
+	// SpannerBackups returns an object that can list and get SpannerBackups.
+	SpannerBackups(namespace string) SpannerBackupNamespaceLister
+	SpannerBackupListerExpansion
+}
+
+// spannerBackupLister implements the SpannerBackupLister interface.
+type spannerBackupLister struct {
+	indexer cache.Indexer
+}
+
+// NewSpannerBackupLister returns a new SpannerBackupLister.
+func NewSpannerBackupLister(indexer cache.Indexer) SpannerBackupLister {
+	return &spannerBackupLister{indexer: indexer}
+}
+
+// List lists all SpannerBackups in the indexer.
+func (s *spannerBackupLister) List(selector labels.Selector) (ret []*v1alpha1.SpannerBackup, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.SpannerBackup))
+	})
+	return ret, err
+}
+
+// SpannerBackups returns an object that can list and get SpannerBackups.
+func (s *spannerBackupLister) SpannerBackups(namespace string) SpannerBackupNamespaceLister {
+	return spannerBackupNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// SpannerBackupNamespaceLister helps list and get SpannerBackups.
+type SpannerBackupNamespaceLister interface {
+	// List lists all SpannerBackups in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*v1alpha1.SpannerBackup, err error)
+	// Get retrieves the SpannerBackup from the indexer for a given namespace and name.
+	Get(name string) (*v1alpha1.SpannerBackup, error)
+	SpannerBackupNamespaceListerExpansion
+}
+
+// spannerBackupNamespaceLister implements the SpannerBackupNamespaceLister
+// interface.
+type spannerBackupNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all SpannerBackups in the indexer for a given namespace.
+func (s spannerBackupNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.SpannerBackup, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.SpannerBackup))
+	})
+	return ret, err
+}
+
+// Get retrieves the SpannerBackup from the indexer for a given namespace and name.
+func (s spannerBackupNamespaceLister) Get(name string) (*v1alpha1.SpannerBackup, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("spannerbackup"), name)
+	}
+	return obj.(*v1alpha1.SpannerBackup), nil
+}
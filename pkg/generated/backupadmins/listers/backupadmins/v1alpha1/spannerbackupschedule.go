@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The Kubernetes spanner-controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/katsew/spanner-operator/pkg/apis/backupadmins/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// SpannerBackupScheduleLister helps list SpannerBackupSchedules.
+type SpannerBackupScheduleLister interface {
+	// List lists all SpannerBackupSchedules in the indexer.
+	List(selector labels.Selector) (ret []*v1alpha1.SpannerBackupSchedule, err error)
+	// SpannerBackupSchedules returns an object that can list and get SpannerBackupSchedules.
+	SpannerBackupSchedules(namespace string) SpannerBackupScheduleNamespaceLister
+	SpannerBackupScheduleListerExpansion
+}
+
+// spannerBackupScheduleLister implements the SpannerBackupScheduleLister interface.
+type spannerBackupScheduleLister struct {
+	indexer cache.Indexer
+}
+
+// NewSpannerBackupScheduleLister returns a new SpannerBackupScheduleLister.
+func NewSpannerBackupScheduleLister(indexer cache.Indexer) SpannerBackupScheduleLister {
+	return &spannerBackupScheduleLister{indexer: indexer}
+}
+
+// List lists all SpannerBackupSchedules in the indexer.
+func (s *spannerBackupScheduleLister) List(selector labels.Selector) (ret []*v1alpha1.SpannerBackupSchedule, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.SpannerBackupSchedule))
+	})
+	return ret, err
+}
+
+// SpannerBackupSchedules returns an object that can list and get SpannerBackupSchedules.
+func (s *spannerBackupScheduleLister) SpannerBackupSchedules(namespace string) SpannerBackupScheduleNamespaceLister {
+	return spannerBackupScheduleNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// SpannerBackupScheduleNamespaceLister helps list and get SpannerBackupSchedules.
+type SpannerBackupScheduleNamespaceLister interface {
+	// List lists all SpannerBackupSchedules in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*v1alpha1.SpannerBackupSchedule, err error)
+	// Get retrieves the SpannerBackupSchedule from the indexer for a given namespace and name.
+	Get(name string) (*v1alpha1.SpannerBackupSchedule, error)
+	SpannerBackupScheduleNamespaceListerExpansion
+}
+
+// spannerBackupScheduleNamespaceLister implements the SpannerBackupScheduleNamespaceLister
+// interface.
+type spannerBackupScheduleNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all SpannerBackupSchedules in the indexer for a given namespace.
+func (s spannerBackupScheduleNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.SpannerBackupSchedule, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.SpannerBackupSchedule))
+	})
+	return ret, err
+}
+
+// Get retrieves the SpannerBackupSchedule from the indexer for a given namespace and name.
+func (s spannerBackupScheduleNamespaceLister) Get(name string) (*v1alpha1.SpannerBackupSchedule, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("spannerbackupschedule"), name)
+	}
+	return obj.(*v1alpha1.SpannerBackupSchedule), nil
+}
@@ -27,6 +27,7 @@ import (
 type DatabaseadminsV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	SpannerDatabasesGetter
+	SpannerDatabaseRolesGetter
 }
 
 // DatabaseadminsV1alpha1Client is used to interact with features provided by the databaseadmins.spanner-operator.io group.
@@ -38,6 +39,10 @@ func (c *DatabaseadminsV1alpha1Client) SpannerDatabases(namespace string) Spanne
 	return newSpannerDatabases(c, namespace)
 }
 
+func (c *DatabaseadminsV1alpha1Client) SpannerDatabaseRoles(namespace string) SpannerDatabaseRoleInterface {
+	return newSpannerDatabaseRoles(c, namespace)
+}
+
 // NewForConfig creates a new DatabaseadminsV1alpha1Client for the given config.
 func NewForConfig(c *rest.Config) (*DatabaseadminsV1alpha1Client, error) {
 	config := *c
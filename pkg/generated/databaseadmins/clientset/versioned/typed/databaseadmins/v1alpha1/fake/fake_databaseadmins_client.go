@@ -32,6 +32,10 @@ func (c *FakeDatabaseadminsV1alpha1) SpannerDatabases(namespace string) v1alpha1
 	return &FakeSpannerDatabases{c, namespace}
 }
 
+func (c *FakeDatabaseadminsV1alpha1) SpannerDatabaseRoles(namespace string) v1alpha1.SpannerDatabaseRoleInterface {
+	return &FakeSpannerDatabaseRoles{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeDatabaseadminsV1alpha1) RESTClient() rest.Interface {
@@ -0,0 +1,140 @@
+/*
+Copyright 2019 The Kubernetes spanner-controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeSpannerDatabaseRoles implements SpannerDatabaseRoleInterface
+type FakeSpannerDatabaseRoles struct {
+	Fake *FakeDatabaseadminsV1alpha1
+	ns   string
+}
+
+var spannerdatabaserolesResource = schema.GroupVersionResource{Group: "databaseadmins.spanner-operator.io", Version: "v1alpha1", Resource: "spannerdatabaseroles"}
+
+var spannerdatabaserolesKind = schema.GroupVersionKind{Group: "databaseadmins.spanner-operator.io", Version: "v1alpha1", Kind: "SpannerDatabaseRole"}
+
+// Get takes name of the spannerDatabaseRole, and returns the corresponding spannerDatabaseRole object, and an error if there is any.
+func (c *FakeSpannerDatabaseRoles) Get(name string, options v1.GetOptions) (result *v1alpha1.SpannerDatabaseRole, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(spannerdatabaserolesResource, c.ns, name), &v1alpha1.SpannerDatabaseRole{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerDatabaseRole), err
+}
+
+// List takes label and field selectors, and returns the list of SpannerDatabaseRoles that match those selectors.
+func (c *FakeSpannerDatabaseRoles) List(opts v1.ListOptions) (result *v1alpha1.SpannerDatabaseRoleList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(spannerdatabaserolesResource, spannerdatabaserolesKind, c.ns, opts), &v1alpha1.SpannerDatabaseRoleList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.SpannerDatabaseRoleList{ListMeta: obj.(*v1alpha1.SpannerDatabaseRoleList).ListMeta}
+	for _, item := range obj.(*v1alpha1.SpannerDatabaseRoleList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested spannerDatabaseRoles.
+func (c *FakeSpannerDatabaseRoles) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(spannerdatabaserolesResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a spannerDatabaseRole and creates it.  Returns the server's representation of the spannerDatabaseRole, and an error, if there is any.
+func (c *FakeSpannerDatabaseRoles) Create(spannerDatabaseRole *v1alpha1.SpannerDatabaseRole) (result *v1alpha1.SpannerDatabaseRole, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(spannerdatabaserolesResource, c.ns, spannerDatabaseRole), &v1alpha1.SpannerDatabaseRole{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerDatabaseRole), err
+}
+
+// Update takes the representation of a spannerDatabaseRole and updates it. Returns the server's representation of the spannerDatabaseRole, and an error, if there is any.
+func (c *FakeSpannerDatabaseRoles) Update(spannerDatabaseRole *v1alpha1.SpannerDatabaseRole) (result *v1alpha1.SpannerDatabaseRole, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(spannerdatabaserolesResource, c.ns, spannerDatabaseRole), &v1alpha1.SpannerDatabaseRole{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerDatabaseRole), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeSpannerDatabaseRoles) UpdateStatus(spannerDatabaseRole *v1alpha1.SpannerDatabaseRole) (*v1alpha1.SpannerDatabaseRole, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(spannerdatabaserolesResource, "status", c.ns, spannerDatabaseRole), &v1alpha1.SpannerDatabaseRole{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerDatabaseRole), err
+}
+
+// Delete takes name of the spannerDatabaseRole and deletes it. Returns an error if one occurs.
+func (c *FakeSpannerDatabaseRoles) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(spannerdatabaserolesResource, c.ns, name), &v1alpha1.SpannerDatabaseRole{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeSpannerDatabaseRoles) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(spannerdatabaserolesResource, c.ns, listOptions)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.SpannerDatabaseRoleList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched spannerDatabaseRole.
+func (c *FakeSpannerDatabaseRoles) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SpannerDatabaseRole, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(spannerdatabaserolesResource, c.ns, name, pt, data, subresources...), &v1alpha1.SpannerDatabaseRole{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerDatabaseRole), err
+}
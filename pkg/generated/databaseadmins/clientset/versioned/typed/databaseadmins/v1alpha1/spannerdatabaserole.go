@@ -0,0 +1,191 @@
+/*
+Copyright 2019 The Kubernetes spanner-controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"time"
+
+	v1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+	scheme "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// SpannerDatabaseRolesGetter has a method to return a SpannerDatabaseRoleInterface.
+// A group's client should implement this interface.
+type SpannerDatabaseRolesGetter interface {
+	SpannerDatabaseRoles(namespace string) SpannerDatabaseRoleInterface
+}
+
+// SpannerDatabaseRoleInterface has methods to work with SpannerDatabaseRole resources.
+type SpannerDatabaseRoleInterface interface {
+	Create(*v1alpha1.SpannerDatabaseRole) (*v1alpha1.SpannerDatabaseRole, error)
+	Update(*v1alpha1.SpannerDatabaseRole) (*v1alpha1.SpannerDatabaseRole, error)
+	UpdateStatus(*v1alpha1.SpannerDatabaseRole) (*v1alpha1.SpannerDatabaseRole, error)
+	Delete(name string, options *v1.DeleteOptions) error
+	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
+	Get(name string, options v1.GetOptions) (*v1alpha1.SpannerDatabaseRole, error)
+	List(opts v1.ListOptions) (*v1alpha1.SpannerDatabaseRoleList, error)
+	Watch(opts v1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SpannerDatabaseRole, err error)
+	SpannerDatabaseRoleExpansion
+}
+
+// spannerDatabaseRoles implements SpannerDatabaseRoleInterface
+type spannerDatabaseRoles struct {
+	client rest.Interface
+	ns     string
+}
+
+// newSpannerDatabaseRoles returns a SpannerDatabaseRoles
+func newSpannerDatabaseRoles(c *DatabaseadminsV1alpha1Client, namespace string) *spannerDatabaseRoles {
+	return &spannerDatabaseRoles{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the spannerDatabaseRole, and returns the corresponding spannerDatabaseRole object, and an error if there is any.
+func (c *spannerDatabaseRoles) Get(name string, options v1.GetOptions) (result *v1alpha1.SpannerDatabaseRole, err error) {
+	result = &v1alpha1.SpannerDatabaseRole{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("spannerdatabaseroles").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of SpannerDatabaseRoles that match those selectors.
+func (c *spannerDatabaseRoles) List(opts v1.ListOptions) (result *v1alpha1.SpannerDatabaseRoleList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.SpannerDatabaseRoleList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("spannerdatabaseroles").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested spannerDatabaseRoles.
+func (c *spannerDatabaseRoles) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("spannerdatabaseroles").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch()
+}
+
+// Create takes the representation of a spannerDatabaseRole and creates it.  Returns the server's representation of the spannerDatabaseRole, and an error, if there is any.
+func (c *spannerDatabaseRoles) Create(spannerDatabaseRole *v1alpha1.SpannerDatabaseRole) (result *v1alpha1.SpannerDatabaseRole, err error) {
+	result = &v1alpha1.SpannerDatabaseRole{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("spannerdatabaseroles").
+		Body(spannerDatabaseRole).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a spannerDatabaseRole and updates it. Returns the server's representation of the spannerDatabaseRole, and an error, if there is any.
+func (c *spannerDatabaseRoles) Update(spannerDatabaseRole *v1alpha1.SpannerDatabaseRole) (result *v1alpha1.SpannerDatabaseRole, err error) {
+	result = &v1alpha1.SpannerDatabaseRole{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("spannerdatabaseroles").
+		Name(spannerDatabaseRole.Name).
+		Body(spannerDatabaseRole).
+		Do().
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *spannerDatabaseRoles) UpdateStatus(spannerDatabaseRole *v1alpha1.SpannerDatabaseRole) (result *v1alpha1.SpannerDatabaseRole, err error) {
+	result = &v1alpha1.SpannerDatabaseRole{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("spannerdatabaseroles").
+		Name(spannerDatabaseRole.Name).
+		SubResource("status").
+		Body(spannerDatabaseRole).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the spannerDatabaseRole and deletes it. Returns an error if one occurs.
+func (c *spannerDatabaseRoles) Delete(name string, options *v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("spannerdatabaseroles").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *spannerDatabaseRoles) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	var timeout time.Duration
+	if listOptions.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOptions.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("spannerdatabaseroles").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched spannerDatabaseRole.
+func (c *spannerDatabaseRoles) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SpannerDatabaseRole, err error) {
+	result = &v1alpha1.SpannerDatabaseRole{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("spannerdatabaseroles").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
@@ -26,6 +26,8 @@ import (
 type Interface interface {
 	// SpannerDatabases returns a SpannerDatabaseInformer.
 	SpannerDatabases() SpannerDatabaseInformer
+	// SpannerDatabaseRoles returns a SpannerDatabaseRoleInformer.
+	SpannerDatabaseRoles() SpannerDatabaseRoleInformer
 }
 
 type version struct {
@@ -43,3 +45,8 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 func (v *version) SpannerDatabases() SpannerDatabaseInformer {
 	return &spannerDatabaseInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// SpannerDatabaseRoles returns a SpannerDatabaseRoleInformer.
+func (v *version) SpannerDatabaseRoles() SpannerDatabaseRoleInformer {
+	return &spannerDatabaseRoleInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
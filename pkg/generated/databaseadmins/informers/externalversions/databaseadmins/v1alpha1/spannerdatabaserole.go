@@ -0,0 +1,89 @@
+/*
+Copyright 2019 The Kubernetes spanner-controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	time "time"
+
+	databaseadminsv1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+	versioned "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/clientset/versioned"
+	internalinterfaces "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/informers/externalversions/internalinterfaces"
+	v1alpha1 "github.com/katsew/spanner-operator/pkg/generated/databaseadmins/listers/databaseadmins/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// SpannerDatabaseRoleInformer provides access to a shared informer and lister for
+// SpannerDatabaseRoles.
+type SpannerDatabaseRoleInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1alpha1.SpannerDatabaseRoleLister
+}
+
+type spannerDatabaseRoleInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewSpannerDatabaseRoleInformer constructs a new informer for SpannerDatabaseRole type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewSpannerDatabaseRoleInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredSpannerDatabaseRoleInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredSpannerDatabaseRoleInformer constructs a new informer for SpannerDatabaseRole type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredSpannerDatabaseRoleInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.DatabaseadminsV1alpha1().SpannerDatabaseRoles(namespace).List(options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.DatabaseadminsV1alpha1().SpannerDatabaseRoles(namespace).Watch(options)
+			},
+		},
+		&databaseadminsv1alpha1.SpannerDatabaseRole{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *spannerDatabaseRoleInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredSpannerDatabaseRoleInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *spannerDatabaseRoleInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&databaseadminsv1alpha1.SpannerDatabaseRole{}, f.defaultInformer)
+}
+
+func (f *spannerDatabaseRoleInformer) Lister() v1alpha1.SpannerDatabaseRoleLister {
+	return v1alpha1.NewSpannerDatabaseRoleLister(f.Informer().GetIndexer())
+}
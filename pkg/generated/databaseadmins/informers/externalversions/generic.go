@@ -55,6 +55,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	// Group=databaseadmins.spanner-operator.io, Version=v1alpha1
 	case v1alpha1.SchemeGroupVersion.WithResource("spannerdatabases"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Databaseadmins().V1alpha1().SpannerDatabases().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("spannerdatabaseroles"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Databaseadmins().V1alpha1().SpannerDatabaseRoles().Informer()}, nil
 
 	}
 
@@ -25,3 +25,11 @@ type SpannerDatabaseListerExpansion interface{}
 // SpannerDatabaseNamespaceListerExpansion allows custom methods to be added to
 // SpannerDatabaseNamespaceLister.
 type SpannerDatabaseNamespaceListerExpansion interface{}
+
+// SpannerDatabaseRoleListerExpansion allows custom methods to be added to
+// SpannerDatabaseRoleLister.
+type SpannerDatabaseRoleListerExpansion interface{}
+
+// SpannerDatabaseRoleNamespaceListerExpansion allows custom methods to be added to
+// SpannerDatabaseRoleNamespaceLister.
+type SpannerDatabaseRoleNamespaceListerExpansion interface{}
@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The Kubernetes spanner-controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// SpannerDatabaseRoleLister helps list SpannerDatabaseRoles.
+type SpannerDatabaseRoleLister interface {
+	// List lists all SpannerDatabaseRoles in the indexer.
+	List(selector labels.Selector) (ret []*v1alpha1.SpannerDatabaseRole, err error)
+	// SpannerDatabaseRoles returns an object that can list and get SpannerDatabaseRoles.
+	SpannerDatabaseRoles(namespace string) SpannerDatabaseRoleNamespaceLister
+	SpannerDatabaseRoleListerExpansion
+}
+
+// spannerDatabaseRoleLister implements the SpannerDatabaseRoleLister interface.
+type spannerDatabaseRoleLister struct {
+	indexer cache.Indexer
+}
+
+// NewSpannerDatabaseRoleLister returns a new SpannerDatabaseRoleLister.
+func NewSpannerDatabaseRoleLister(indexer cache.Indexer) SpannerDatabaseRoleLister {
+	return &spannerDatabaseRoleLister{indexer: indexer}
+}
+
+// List lists all SpannerDatabaseRoles in the indexer.
+func (s *spannerDatabaseRoleLister) List(selector labels.Selector) (ret []*v1alpha1.SpannerDatabaseRole, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.SpannerDatabaseRole))
+	})
+	return ret, err
+}
+
+// SpannerDatabaseRoles returns an object that can list and get SpannerDatabaseRoles.
+func (s *spannerDatabaseRoleLister) SpannerDatabaseRoles(namespace string) SpannerDatabaseRoleNamespaceLister {
+	return spannerDatabaseRoleNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// SpannerDatabaseRoleNamespaceLister helps list and get SpannerDatabaseRoles.
+type SpannerDatabaseRoleNamespaceLister interface {
+	// List lists all SpannerDatabaseRoles in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*v1alpha1.SpannerDatabaseRole, err error)
+	// Get retrieves the SpannerDatabaseRole from the indexer for a given namespace and name.
+	Get(name string) (*v1alpha1.SpannerDatabaseRole, error)
+	SpannerDatabaseRoleNamespaceListerExpansion
+}
+
+// spannerDatabaseRoleNamespaceLister implements the SpannerDatabaseRoleNamespaceLister
+// interface.
+type spannerDatabaseRoleNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all SpannerDatabaseRoles in the indexer for a given namespace.
+func (s spannerDatabaseRoleNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.SpannerDatabaseRole, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.SpannerDatabaseRole))
+	})
+	return ret, err
+}
+
+// Get retrieves the SpannerDatabaseRole from the indexer for a given namespace and name.
+func (s spannerDatabaseRoleNamespaceLister) Get(name string) (*v1alpha1.SpannerDatabaseRole, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("spannerdatabaserole"), name)
+	}
+	return obj.(*v1alpha1.SpannerDatabaseRole), nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2019 The Kubernetes spanner-controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v1alpha1 "github.com/katsew/spanner-operator/pkg/apis/iamadmins/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeSpannerIAMPolicyMembers implements SpannerIAMPolicyMemberInterface
+type FakeSpannerIAMPolicyMembers struct {
+	Fake *FakeIamadminsV1alpha1
+	ns   string
+}
+
+var spanneriampolicymembersResource = schema.GroupVersionResource{Group: "iamadmins.spanner-operator.io", Version: "v1alpha1", Resource: "spanneriampolicymembers"}
+
+var spanneriampolicymembersKind = schema.GroupVersionKind{Group: "iamadmins.spanner-operator.io", Version: "v1alpha1", Kind: "SpannerIAMPolicyMember"}
+
+// Get takes name of the spannerIAMPolicyMember, and returns the corresponding spannerIAMPolicyMember object, and an error if there is any.
+func (c *FakeSpannerIAMPolicyMembers) Get(name string, options v1.GetOptions) (result *v1alpha1.SpannerIAMPolicyMember, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(spanneriampolicymembersResource, c.ns, name), &v1alpha1.SpannerIAMPolicyMember{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerIAMPolicyMember), err
+}
+
+// List takes label and field selectors, and returns the list of SpannerIAMPolicyMembers that match those selectors.
+func (c *FakeSpannerIAMPolicyMembers) List(opts v1.ListOptions) (result *v1alpha1.SpannerIAMPolicyMemberList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(spanneriampolicymembersResource, spanneriampolicymembersKind, c.ns, opts), &v1alpha1.SpannerIAMPolicyMemberList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.SpannerIAMPolicyMemberList{ListMeta: obj.(*v1alpha1.SpannerIAMPolicyMemberList).ListMeta}
+	for _, item := range obj.(*v1alpha1.SpannerIAMPolicyMemberList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested spannerIAMPolicyMembers.
+func (c *FakeSpannerIAMPolicyMembers) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(spanneriampolicymembersResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a spannerIAMPolicyMember and creates it.  Returns the server's representation of the spannerIAMPolicyMember, and an error, if there is any.
+func (c *FakeSpannerIAMPolicyMembers) Create(spannerIAMPolicyMember *v1alpha1.SpannerIAMPolicyMember) (result *v1alpha1.SpannerIAMPolicyMember, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(spanneriampolicymembersResource, c.ns, spannerIAMPolicyMember), &v1alpha1.SpannerIAMPolicyMember{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerIAMPolicyMember), err
+}
+
+// Update takes the representation of a spannerIAMPolicyMember and updates it. Returns the server's representation of the spannerIAMPolicyMember, and an error, if there is any.
+func (c *FakeSpannerIAMPolicyMembers) Update(spannerIAMPolicyMember *v1alpha1.SpannerIAMPolicyMember) (result *v1alpha1.SpannerIAMPolicyMember, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(spanneriampolicymembersResource, c.ns, spannerIAMPolicyMember), &v1alpha1.SpannerIAMPolicyMember{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerIAMPolicyMember), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeSpannerIAMPolicyMembers) UpdateStatus(spannerIAMPolicyMember *v1alpha1.SpannerIAMPolicyMember) (*v1alpha1.SpannerIAMPolicyMember, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(spanneriampolicymembersResource, "status", c.ns, spannerIAMPolicyMember), &v1alpha1.SpannerIAMPolicyMember{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerIAMPolicyMember), err
+}
+
+// Delete takes name of the spannerIAMPolicyMember and deletes it. Returns an error if one occurs.
+func (c *FakeSpannerIAMPolicyMembers) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(spanneriampolicymembersResource, c.ns, name), &v1alpha1.SpannerIAMPolicyMember{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeSpannerIAMPolicyMembers) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(spanneriampolicymembersResource, c.ns, listOptions)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.SpannerIAMPolicyMemberList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched spannerIAMPolicyMember.
+func (c *FakeSpannerIAMPolicyMembers) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SpannerIAMPolicyMember, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(spanneriampolicymembersResource, c.ns, name, pt, data, subresources...), &v1alpha1.SpannerIAMPolicyMember{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.SpannerIAMPolicyMember), err
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2019 The Kubernetes spanner-controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"time"
+
+	v1alpha1 "github.com/katsew/spanner-operator/pkg/apis/iamadmins/v1alpha1"
+	scheme "github.com/katsew/spanner-operator/pkg/generated/iamadmins/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// SpannerIAMPolicyMembersGetter has a method to return a SpannerIAMPolicyMemberInterface.
+// A group's client should implement this interface.
+type SpannerIAMPolicyMembersGetter interface {
+	SpannerIAMPolicyMembers(namespace string) SpannerIAMPolicyMemberInterface
+}
+
+// SpannerIAMPolicyMemberInterface has methods to work with SpannerIAMPolicyMember resources.
+type SpannerIAMPolicyMemberInterface interface {
+	Create(*v1alpha1.SpannerIAMPolicyMember) (*v1alpha1.SpannerIAMPolicyMember, error)
+	Update(*v1alpha1.SpannerIAMPolicyMember) (*v1alpha1.SpannerIAMPolicyMember, error)
+	UpdateStatus(*v1alpha1.SpannerIAMPolicyMember) (*v1alpha1.SpannerIAMPolicyMember, error)
+	Delete(name string, options *v1.DeleteOptions) error
+	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
+	Get(name string, options v1.GetOptions) (*v1alpha1.SpannerIAMPolicyMember, error)
+	List(opts v1.ListOptions) (*v1alpha1.SpannerIAMPolicyMemberList, error)
+	Watch(opts v1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SpannerIAMPolicyMember, err error)
+	SpannerIAMPolicyMemberExpansion
+}
+
+// spannerIAMPolicyMembers implements SpannerIAMPolicyMemberInterface
+type spannerIAMPolicyMembers struct {
+	client rest.Interface
+	ns     string
+}
+
+// newSpannerIAMPolicyMembers returns a SpannerIAMPolicyMembers
+func newSpannerIAMPolicyMembers(c *IamadminsV1alpha1Client, namespace string) *spannerIAMPolicyMembers {
+	return &spannerIAMPolicyMembers{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the spannerIAMPolicyMember, and returns the corresponding spannerIAMPolicyMember object, and an error if there is any.
+func (c *spannerIAMPolicyMembers) Get(name string, options v1.GetOptions) (result *v1alpha1.SpannerIAMPolicyMember, err error) {
+	result = &v1alpha1.SpannerIAMPolicyMember{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("spanneriampolicymembers").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of SpannerIAMPolicyMembers that match those selectors.
+func (c *spannerIAMPolicyMembers) List(opts v1.ListOptions) (result *v1alpha1.SpannerIAMPolicyMemberList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.SpannerIAMPolicyMemberList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("spanneriampolicymembers").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested spannerIAMPolicyMembers.
+func (c *spannerIAMPolicyMembers) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("spanneriampolicymembers").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch()
+}
+
+// Create takes the representation of a spannerIAMPolicyMember and creates it.  Returns the server's representation of the spannerIAMPolicyMember, and an error, if there is any.
+func (c *spannerIAMPolicyMembers) Create(spannerIAMPolicyMember *v1alpha1.SpannerIAMPolicyMember) (result *v1alpha1.SpannerIAMPolicyMember, err error) {
+	result = &v1alpha1.SpannerIAMPolicyMember{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("spanneriampolicymembers").
+		Body(spannerIAMPolicyMember).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a spannerIAMPolicyMember and updates it. Returns the server's representation of the spannerIAMPolicyMember, and an error, if there is any.
+func (c *spannerIAMPolicyMembers) Update(spannerIAMPolicyMember *v1alpha1.SpannerIAMPolicyMember) (result *v1alpha1.SpannerIAMPolicyMember, err error) {
+	result = &v1alpha1.SpannerIAMPolicyMember{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("spanneriampolicymembers").
+		Name(spannerIAMPolicyMember.Name).
+		Body(spannerIAMPolicyMember).
+		Do().
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *spannerIAMPolicyMembers) UpdateStatus(spannerIAMPolicyMember *v1alpha1.SpannerIAMPolicyMember) (result *v1alpha1.SpannerIAMPolicyMember, err error) {
+	result = &v1alpha1.SpannerIAMPolicyMember{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("spanneriampolicymembers").
+		Name(spannerIAMPolicyMember.Name).
+		SubResource("status").
+		Body(spannerIAMPolicyMember).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the spannerIAMPolicyMember and deletes it. Returns an error if one occurs.
+func (c *spannerIAMPolicyMembers) Delete(name string, options *v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("spanneriampolicymembers").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *spannerIAMPolicyMembers) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	var timeout time.Duration
+	if listOptions.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOptions.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("spanneriampolicymembers").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched spannerIAMPolicyMember.
+func (c *spannerIAMPolicyMembers) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.SpannerIAMPolicyMember, err error) {
+	result = &v1alpha1.SpannerIAMPolicyMember{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("spanneriampolicymembers").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2019 The Kubernetes spanner-controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	time "time"
+
+	iamadminsv1alpha1 "github.com/katsew/spanner-operator/pkg/apis/iamadmins/v1alpha1"
+	versioned "github.com/katsew/spanner-operator/pkg/generated/iamadmins/clientset/versioned"
+	internalinterfaces "github.com/katsew/spanner-operator/pkg/generated/iamadmins/informers/externalversions/internalinterfaces"
+	v1alpha1 "github.com/katsew/spanner-operator/pkg/generated/iamadmins/listers/iamadmins/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// SpannerIAMPolicyMemberInformer provides access to a shared informer and lister for
+// SpannerIAMPolicyMembers.
+type SpannerIAMPolicyMemberInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1alpha1.SpannerIAMPolicyMemberLister
+}
+
+type spannerIAMPolicyMemberInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewSpannerIAMPolicyMemberInformer constructs a new informer for SpannerIAMPolicyMember type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewSpannerIAMPolicyMemberInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredSpannerIAMPolicyMemberInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredSpannerIAMPolicyMemberInformer constructs a new informer for SpannerIAMPolicyMember type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredSpannerIAMPolicyMemberInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.IamadminsV1alpha1().SpannerIAMPolicyMembers(namespace).List(options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.IamadminsV1alpha1().SpannerIAMPolicyMembers(namespace).Watch(options)
+			},
+		},
+		&iamadminsv1alpha1.SpannerIAMPolicyMember{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *spannerIAMPolicyMemberInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredSpannerIAMPolicyMemberInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *spannerIAMPolicyMemberInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&iamadminsv1alpha1.SpannerIAMPolicyMember{}, f.defaultInformer)
+}
+
+func (f *spannerIAMPolicyMemberInformer) Lister() v1alpha1.SpannerIAMPolicyMemberLister {
+	return v1alpha1.NewSpannerIAMPolicyMemberLister(f.Informer().GetIndexer())
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The Kubernetes spanner-controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/katsew/spanner-operator/pkg/apis/iamadmins/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// SpannerIAMPolicyMemberLister helps list SpannerIAMPolicyMembers.
+type SpannerIAMPolicyMemberLister interface {
+	// List lists all SpannerIAMPolicyMembers in the indexer.
+	List(selector labels.Selector) (ret []*v1alpha1.SpannerIAMPolicyMember, err error)
+	// SpannerIAMPolicyMembers returns an object that can list and get SpannerIAMPolicyMembers.
+	SpannerIAMPolicyMembers(namespace string) SpannerIAMPolicyMemberNamespaceLister
+	SpannerIAMPolicyMemberListerExpansion
+}
+
+// spannerIAMPolicyMemberLister implements the SpannerIAMPolicyMemberLister interface.
+type spannerIAMPolicyMemberLister struct {
+	indexer cache.Indexer
+}
+
+// NewSpannerIAMPolicyMemberLister returns a new SpannerIAMPolicyMemberLister.
+func NewSpannerIAMPolicyMemberLister(indexer cache.Indexer) SpannerIAMPolicyMemberLister {
+	return &spannerIAMPolicyMemberLister{indexer: indexer}
+}
+
+// List lists all SpannerIAMPolicyMembers in the indexer.
+func (s *spannerIAMPolicyMemberLister) List(selector labels.Selector) (ret []*v1alpha1.SpannerIAMPolicyMember, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.SpannerIAMPolicyMember))
+	})
+	return ret, err
+}
+
+// SpannerIAMPolicyMembers returns an object that can list and get SpannerIAMPolicyMembers.
+func (s *spannerIAMPolicyMemberLister) SpannerIAMPolicyMembers(namespace string) SpannerIAMPolicyMemberNamespaceLister {
+	return spannerIAMPolicyMemberNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// SpannerIAMPolicyMemberNamespaceLister helps list and get SpannerIAMPolicyMembers.
+type SpannerIAMPolicyMemberNamespaceLister interface {
+	// List lists all SpannerIAMPolicyMembers in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*v1alpha1.SpannerIAMPolicyMember, err error)
+	// Get retrieves the SpannerIAMPolicyMember from the indexer for a given namespace and name.
+	Get(name string) (*v1alpha1.SpannerIAMPolicyMember, error)
+	SpannerIAMPolicyMemberNamespaceListerExpansion
+}
+
+// spannerIAMPolicyMemberNamespaceLister implements the SpannerIAMPolicyMemberNamespaceLister
+// interface.
+type spannerIAMPolicyMemberNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all SpannerIAMPolicyMembers in the indexer for a given namespace.
+func (s spannerIAMPolicyMemberNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.SpannerIAMPolicyMember, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.SpannerIAMPolicyMember))
+	})
+	return ret, err
+}
+
+// Get retrieves the SpannerIAMPolicyMember from the indexer for a given namespace and name.
+func (s spannerIAMPolicyMemberNamespaceLister) Get(name string) (*v1alpha1.SpannerIAMPolicyMember, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("spanneriampolicymember"), name)
+	}
+	return obj.(*v1alpha1.SpannerIAMPolicyMember), nil
+}
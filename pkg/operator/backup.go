@@ -0,0 +1,109 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"google.golang.org/api/iterator"
+	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (o *operator) CreateBackup(ctx context.Context, instanceId string, backupId string, databaseName string, expireTime time.Time, versionTime time.Time) (string, error) {
+	backup := &database.Backup{
+		Database:   fmt.Sprintf("projects/%s/instances/%s/databases/%s", o.projectId, instanceId, databaseName),
+		ExpireTime: timestamppb.New(expireTime),
+	}
+	if !versionTime.IsZero() {
+		backup.VersionTime = timestamppb.New(versionTime)
+	}
+	req := &database.CreateBackupRequest{
+		Parent:   fmt.Sprintf("projects/%s/instances/%s", o.projectId, instanceId),
+		BackupId: backupId,
+		Backup:   backup,
+	}
+	op, err := o.databaseAdminClient.CreateBackup(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Create backup started: %s", op.Name())
+	return op.Name(), nil
+}
+
+func (o *operator) GetBackup(ctx context.Context, instanceId string, backupId string) (*database.Backup, error) {
+	req := &database.GetBackupRequest{
+		Name: fmt.Sprintf("projects/%s/instances/%s/backups/%s", o.projectId, instanceId, backupId),
+	}
+	return o.databaseAdminClient.GetBackup(ctx, req)
+}
+
+func (o *operator) ListBackups(ctx context.Context, instanceId string, databaseName string) ([]*database.Backup, error) {
+	req := &database.ListBackupsRequest{
+		Parent: fmt.Sprintf("projects/%s/instances/%s", o.projectId, instanceId),
+	}
+	fullName := ""
+	if databaseName != "" {
+		fullName = fmt.Sprintf("projects/%s/instances/%s/databases/%s", o.projectId, instanceId, databaseName)
+		req.Filter = fmt.Sprintf("database=%q", fullName)
+	}
+	var backups []*database.Backup
+	it := o.databaseAdminClient.ListBackups(ctx, req)
+	for {
+		backup, err := it.Next()
+		if err == iterator.Done {
+			return backups, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isBackupOf(backup, fullName) {
+			backups = append(backups, backup)
+		}
+	}
+}
+
+// isBackupOf reports whether backup was taken of the database with the
+// fully qualified name databaseName, or of any database when it is empty.
+// Names are compared whole, so the backups of app2 are not those of app.
+func isBackupOf(backup *database.Backup, databaseName string) bool {
+	return databaseName == "" || backup.GetDatabase() == databaseName
+}
+
+func (o *operator) DeleteBackup(ctx context.Context, instanceId string, backupId string) error {
+	req := &database.DeleteBackupRequest{
+		Name: fmt.Sprintf("projects/%s/instances/%s/backups/%s", o.projectId, instanceId, backupId),
+	}
+	return o.databaseAdminClient.DeleteBackup(ctx, req)
+}
+
+func (o *operator) UpdateBackupExpireTime(ctx context.Context, instanceId string, backupId string, expireTime time.Time) (*database.Backup, error) {
+	req := &database.UpdateBackupRequest{
+		Backup: &database.Backup{
+			Name:       fmt.Sprintf("projects/%s/instances/%s/backups/%s", o.projectId, instanceId, backupId),
+			ExpireTime: timestamppb.New(expireTime),
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"expire_time"},
+		},
+	}
+	return o.databaseAdminClient.UpdateBackup(ctx, req)
+}
+
+func (o *operator) RestoreDatabase(ctx context.Context, instanceId string, name string, backupInstanceId string, backupId string) (string, error) {
+	req := &database.RestoreDatabaseRequest{
+		Parent:     fmt.Sprintf("projects/%s/instances/%s", o.projectId, instanceId),
+		DatabaseId: name,
+		Source: &database.RestoreDatabaseRequest_Backup{
+			Backup: fmt.Sprintf("projects/%s/instances/%s/backups/%s", o.projectId, backupInstanceId, backupId),
+		},
+	}
+	op, err := o.databaseAdminClient.RestoreDatabase(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Restore database started: %s", op.Name())
+	return op.Name(), nil
+}
@@ -0,0 +1,69 @@
+package operator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+)
+
+func TestIsBackupOf(t *testing.T) {
+	app := "projects/test/instances/testing/databases/app"
+	for _, tc := range []struct {
+		database     string
+		databaseName string
+		expected     bool
+	}{
+		{app, app, true},
+		{app, "", true},
+		{app + "2", app, false},
+		{app + "-staging", app, false},
+		{"projects/test/instances/staging/databases/app", app, false},
+	} {
+		backup := &database.Backup{Database: tc.database}
+		if actual := isBackupOf(backup, tc.databaseName); actual != tc.expected {
+			t.Errorf("isBackupOf(%s, %q): expected %t, got %t", tc.database, tc.databaseName, tc.expected, actual)
+		}
+	}
+}
+
+// The backups of a database must not include those of databases its name
+// prefixes, as backup schedules delete what they list.
+func TestListBackupsOfPrefixedDatabase(t *testing.T) {
+	om, cleanup := newTestMock(t)
+	defer cleanup()
+	for name, op := range map[string]Operator{
+		"fake": NewBuilder().ProjectId("test").BuildFake(),
+		"mock": om,
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if _, err := op.CreateInstance(ctx, "testing", "testing", "regional-asia-northeast1", Nodes(1)); err != nil {
+				t.Fatal(err)
+			}
+			if fake, ok := op.(*Fake); ok {
+				fake.FinishOperations()
+			}
+			for _, databaseId := range []string{"app", "app2", "app-staging"} {
+				if _, err := op.CreateDatabase(ctx, "testing", databaseId, nil); err != nil {
+					t.Fatal(err)
+				}
+				if fake, ok := op.(*Fake); ok {
+					fake.FinishOperations()
+				}
+				if _, err := op.CreateBackup(ctx, "testing", databaseId+"-backup", databaseId, time.Now().Add(time.Hour), time.Time{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			backups, err := op.ListBackups(ctx, "testing", "app")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(backups) != 1 || backups[0].Name != "projects/test/instances/testing/backups/app-backup" {
+				t.Errorf("expected only the backup of app, got %v", backups)
+			}
+		})
+	}
+}
@@ -9,39 +9,132 @@ import (
 	"github.com/labstack/gommon/log"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/option"
+	"google.golang.org/genproto/googleapis/iam/v1"
 	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
 	"google.golang.org/genproto/googleapis/spanner/admin/instance/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"time"
 )
 
+// EmulatorHostEnv is the environment variable holding the address of a
+// Cloud Spanner emulator, used when no emulator host is given to the Builder.
+const EmulatorHostEnv = "SPANNER_EMULATOR_HOST"
+
+// MockDataPathEnv is the environment variable holding the directory the
+// mock keeps its data in, shared by spnadm and the controller.
+const MockDataPathEnv = "MOCK_DATA_PATH"
+
+// DefaultMockDataPath is the directory the mock keeps its data in when
+// MockDataPathEnv is not set.
+const DefaultMockDataPath = "/tmp/spanner-operator"
+
 type Builder interface {
 	ProjectId(projectId string) Builder
 	ServiceAccountPath(path string) Builder
-	Build() Operator
-	BuildMock(dataDir string) *operatorMock
+	// ServiceAccountKey sets the JSON key of the service account to
+	// authenticate with, taking precedence over ServiceAccountPath.
+	ServiceAccountKey(key []byte) Builder
+	// EmulatorHost makes Build connect every client to the Cloud Spanner
+	// emulator at host, e.g. localhost:9010, without credentials.
+	EmulatorHost(host string) Builder
+	Build() (Operator, error)
+	BuildMock(dataDir string) (Operator, error)
+	// BuildFake returns an in-memory Operator for tests.
+	BuildFake() *Fake
 }
 
 type builder struct {
 	projectId          string
 	serviceAccountPath string
+	serviceAccountKey  []byte
+	emulatorHost       string
 }
 
 type Operator interface {
 	// InstanceAdmin method
-	CreateInstance(displayName string, instanceId string, instanceConfig string, nodeCount int32) error
-	GetInstance(instanceId string) (*instance.Instance, error)
-	Scale(instanceId string, nodeCount int32) error
-	DeleteInstance(instanceId string) error
-	UpdateLabels(instanceId string, labels map[string]string) error
+	// CreateInstance, Scale and UpdateLabels start a long-running operation
+	// and return its name without waiting for it to finish.
+	CreateInstance(ctx context.Context, displayName string, instanceId string, instanceConfig string, capacity Capacity) (string, error)
+	GetInstance(ctx context.Context, instanceId string) (*instance.Instance, error)
+	// ListInstances returns a page of at most pageSize instances of the
+	// project, DefaultPageSize when pageSize is 0, and the token of the next
+	// page, empty on the last one. An empty pageToken starts from the first.
+	ListInstances(ctx context.Context, pageSize int32, pageToken string) ([]*instance.Instance, string, error)
+	Scale(ctx context.Context, instanceId string, capacity Capacity) (string, error)
+	DeleteInstance(ctx context.Context, instanceId string) error
+	UpdateLabels(ctx context.Context, instanceId string, labels map[string]string) (string, error)
+	// ListInstanceConfigs and GetInstanceConfig answer from a cache that is
+	// refreshed hourly. GetInstanceConfig takes the config ID, e.g.
+	// regional-asia-northeast1, and fails with a not found error for
+	// configs the project cannot use.
+	ListInstanceConfigs(ctx context.Context) ([]*instance.InstanceConfig, error)
+	GetInstanceConfig(ctx context.Context, configId string) (*instance.InstanceConfig, error)
 
 	// DatabaseAdmin method
-	CreateDatabase(instanceId string, name string) error
-	GetDatabase(instanceId string, name string) (*database.Database, error)
-	DropDatabase(instanceId string, name string) error
+	// CreateDatabase and UpdateDatabaseDdl start a long-running operation and
+	// return its name without waiting for it to finish.
+	CreateDatabase(ctx context.Context, instanceId string, name string, extraStatements []string) (string, error)
+	GetDatabase(ctx context.Context, instanceId string, name string) (*database.Database, error)
+	// ListDatabases pages through the databases of instanceId like
+	// ListInstances does through instances.
+	ListDatabases(ctx context.Context, instanceId string, pageSize int32, pageToken string) ([]*database.Database, string, error)
+	UpdateDatabaseDdl(ctx context.Context, instanceId string, name string, statements []string) (string, error)
+	// SetDropProtection starts a long-running operation setting the
+	// enable_drop_protection field of the database and returns its name.
+	// Spanner refuses to drop a protected database, or to delete its instance.
+	SetDropProtection(ctx context.Context, instanceId string, name string, enabled bool) (string, error)
+	DropDatabase(ctx context.Context, instanceId string, name string) error
+
+	// Backup method
+	// CreateBackup starts a long-running operation and returns its name
+	// without waiting for it to finish. A zero versionTime backs up the
+	// current state of the database. ListBackups lists every backup of the
+	// instance when databaseName is empty.
+	CreateBackup(ctx context.Context, instanceId string, backupId string, databaseName string, expireTime time.Time, versionTime time.Time) (string, error)
+	GetBackup(ctx context.Context, instanceId string, backupId string) (*database.Backup, error)
+	ListBackups(ctx context.Context, instanceId string, databaseName string) ([]*database.Backup, error)
+	DeleteBackup(ctx context.Context, instanceId string, backupId string) error
+	UpdateBackupExpireTime(ctx context.Context, instanceId string, backupId string, expireTime time.Time) (*database.Backup, error)
+	// RestoreDatabase starts a long-running operation creating the database
+	// name from a backup and returns its name. Once it is done, Spanner keeps
+	// optimizing the database while it reports the READY_OPTIMIZING state.
+	RestoreDatabase(ctx context.Context, instanceId string, name string, backupInstanceId string, backupId string) (string, error)
+
+	// DatabaseRole method
+	// GetDatabaseRoleGrants returns the privileges role holds in the
+	// database name, sorted, and fails with a not found error when the role
+	// does not exist. Roles are changed with UpdateDatabaseDdl.
+	GetDatabaseRoleGrants(ctx context.Context, instanceId string, name string, role string) ([]DatabaseRoleGrant, error)
+
+	// Migration method
+	// ApplyMigration starts a long-running operation applying the statements
+	// of migration, and RecordMigration stores it as applied once that is done.
+	GetAppliedMigrations(ctx context.Context, instanceId string, name string) ([]Migration, error)
+	ApplyMigration(ctx context.Context, instanceId string, name string, migration Migration) (string, error)
+	RecordMigration(ctx context.Context, instanceId string, name string, migration Migration) error
+
+	// IAM method
+	// The IAM methods act on the database databaseName of instanceId, or on
+	// the instance itself when databaseName is empty. SetIamPolicy fails
+	// with an Aborted error when the etag of policy is stale.
+	GetIamPolicy(ctx context.Context, instanceId string, databaseName string) (*iam.Policy, error)
+	SetIamPolicy(ctx context.Context, instanceId string, databaseName string, policy *iam.Policy) (*iam.Policy, error)
+	TestIamPermissions(ctx context.Context, instanceId string, databaseName string, permissions []string) ([]string, error)
+
+	// Operation method
+	GetOperation(ctx context.Context, name string) (*Operation, error)
+	WaitOperation(ctx context.Context, name string) error
 
 	// Error handle method
 	IsNotFoundError(err error) bool
+
+	// Close releases the clients of the Operator, which cannot be used
+	// afterwards.
+	Close() error
 }
 
 type operator struct {
@@ -51,6 +144,7 @@ type operator struct {
 	instanceAdminClient *instanceAdmin.InstanceAdminClient
 	databaseAdminClient *databaseAdmin.DatabaseAdminClient
 	client              *spanner.Client
+	instanceConfigs     instanceConfigCache
 }
 
 func NewBuilder() *builder {
@@ -67,37 +161,56 @@ func (b *builder) ServiceAccountPath(path string) Builder {
 	return b
 }
 
-func (b *builder) Build() Operator {
-
-	instanceAdminCtx := context.Background()
-	databaseAdminCtx := context.Background()
-	clientCtx := context.Background()
-	var instanceAdminClient *instanceAdmin.InstanceAdminClient
-	var databaseAdminClient *databaseAdmin.DatabaseAdminClient
-	var client *spanner.Client
-	var err error
-	if b.serviceAccountPath != "" {
-		data, err := ioutil.ReadFile(b.serviceAccountPath)
-		if err != nil {
-			panic(err)
-		}
-		conf, err := google.JWTConfigFromJSON(data, "https://www.googleapis.com/auth/spanner.admin", "https://www.googleapis.com/auth/spanner.data")
-		if err != nil {
-			panic(err)
-		}
-		ctx := context.Background()
-		opt := option.WithTokenSource(conf.TokenSource(ctx))
-		instanceAdminClient, err = instanceAdmin.NewInstanceAdminClient(instanceAdminCtx, opt)
-		databaseAdminClient, err = databaseAdmin.NewDatabaseAdminClient(databaseAdminCtx, opt)
-		client, err = spanner.NewClient(clientCtx, opt)
-	} else {
-		instanceAdminClient, err = instanceAdmin.NewInstanceAdminClient(instanceAdminCtx)
-		databaseAdminClient, err = databaseAdmin.NewDatabaseAdminClient(databaseAdminCtx)
-		client, err = spanner.NewClient(clientCtx)
+func (b *builder) ServiceAccountKey(key []byte) Builder {
+	b.serviceAccountKey = key
+	return b
+}
+
+func (b *builder) EmulatorHost(host string) Builder {
+	b.emulatorHost = host
+	return b
+}
+
+// emulatorOptions returns the client options reaching the emulator at host
+// over plain text, without credentials.
+func emulatorOptions(host string) []option.ClientOption {
+	return []option.ClientOption{
+		option.WithEndpoint(host),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
 	}
+}
 
+// The client constructors of Build, replaced in tests to make it fail part
+// way.
+var (
+	newInstanceAdminClient = instanceAdmin.NewInstanceAdminClient
+	newDatabaseAdminClient = databaseAdmin.NewDatabaseAdminClient
+	newSpannerClient       = spanner.NewClient
+)
+
+// Build returns an Operator talking to Cloud Spanner. Clients created
+// before a failure are closed again.
+func (b *builder) Build() (Operator, error) {
+	opts, err := b.clientOptions()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	instanceAdminClient, err := newInstanceAdminClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating instance admin client: %v", err)
+	}
+	databaseAdminClient, err := newDatabaseAdminClient(ctx, opts...)
+	if err != nil {
+		instanceAdminClient.Close()
+		return nil, fmt.Errorf("creating database admin client: %v", err)
+	}
+	client, err := newSpannerClient(ctx, opts...)
 	if err != nil {
-		panic(err)
+		databaseAdminClient.Close()
+		instanceAdminClient.Close()
+		return nil, fmt.Errorf("creating spanner client: %v", err)
 	}
 
 	return &operator{
@@ -105,19 +218,47 @@ func (b *builder) Build() Operator {
 		instanceAdminClient: instanceAdminClient,
 		databaseAdminClient: databaseAdminClient,
 		client:              client,
-	}
+	}, nil
 }
 
-func (b *builder) BuildMock(dataPath string) *operatorMock {
-	dataDir := fmt.Sprintf("%s/%s", dataPath, b.projectId)
-	err := os.MkdirAll(dataDir, 0755)
-	log.Printf("make directory to: %s", dataDir)
-	if err != nil {
-		log.Print(err.Error())
-		log.Printf("If you use mock client, you should create directory to dataDir: %s", dataDir)
+// clientOptions returns the options connecting the clients to the emulator,
+// or authenticating them with the service account key, the key file or the
+// application default credentials, in that order.
+func (b *builder) clientOptions() ([]option.ClientOption, error) {
+	emulatorHost := b.emulatorHost
+	if emulatorHost == "" {
+		emulatorHost = os.Getenv(EmulatorHostEnv)
 	}
-	return &operatorMock{
-		projectId: b.projectId,
-		dataDir:   dataDir,
+	if emulatorHost != "" {
+		log.Printf("Using Cloud Spanner emulator at %s", emulatorHost)
+		return emulatorOptions(emulatorHost), nil
 	}
+	data := b.serviceAccountKey
+	if data == nil && b.serviceAccountPath != "" {
+		var err error
+		data, err = ioutil.ReadFile(b.serviceAccountPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading service account key: %v", err)
+		}
+	}
+	if data == nil {
+		return nil, nil
+	}
+	conf, err := google.JWTConfigFromJSON(data, "https://www.googleapis.com/auth/spanner.admin", "https://www.googleapis.com/auth/spanner.data")
+	if err != nil {
+		return nil, fmt.Errorf("parsing service account key: %v", err)
+	}
+	return []option.ClientOption{option.WithTokenSource(conf.TokenSource(context.Background()))}, nil
+}
+
+// BuildMock returns an Operator keeping its data below dataPath, and fails
+// when the data there has another format version.
+func (b *builder) BuildMock(dataPath string) (Operator, error) {
+	dataDir := filepath.Join(dataPath, b.projectId)
+	log.Printf("Using mock data in: %s", dataDir)
+	return newOperatorMock(b.projectId, dataDir)
+}
+
+func (b *builder) BuildFake() *Fake {
+	return newFake(b.projectId)
 }
@@ -0,0 +1,149 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	databaseAdmin "cloud.google.com/go/spanner/admin/database/apiv1"
+	instanceAdmin "cloud.google.com/go/spanner/admin/instance/apiv1"
+	"cloud.google.com/go/spanner/apiv1"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// unsetEmulatorHost clears EmulatorHostEnv for a test, and returns a func
+// restoring it.
+func unsetEmulatorHost() func() {
+	host, ok := os.LookupEnv(EmulatorHostEnv)
+	os.Unsetenv(EmulatorHostEnv)
+	return func() {
+		if ok {
+			os.Setenv(EmulatorHostEnv, host)
+		}
+	}
+}
+
+// restoreClientConstructors returns a func putting back the client
+// constructors a test replaces.
+func restoreClientConstructors() func() {
+	i, d, s := newInstanceAdminClient, newDatabaseAdminClient, newSpannerClient
+	return func() {
+		newInstanceAdminClient, newDatabaseAdminClient, newSpannerClient = i, d, s
+	}
+}
+
+func TestBuildRejectsBadCredentials(t *testing.T) {
+	defer unsetEmulatorHost()()
+	defer restoreClientConstructors()()
+	newInstanceAdminClient = func(ctx context.Context, opts ...option.ClientOption) (*instanceAdmin.InstanceAdminClient, error) {
+		t.Errorf("expected no client to be created with bad credentials")
+		return instanceAdmin.NewInstanceAdminClient(ctx, opts...)
+	}
+
+	dataDir, err := ioutil.TempDir("", "operator-builder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dataDir)
+
+	for name, b := range map[string]Builder{
+		"missing key file": NewBuilder().ProjectId("test").ServiceAccountPath(filepath.Join(dataDir, "missing.json")),
+		"malformed key":    NewBuilder().ProjectId("test").ServiceAccountKey([]byte("{")),
+		"user credentials": NewBuilder().ProjectId("test").ServiceAccountKey([]byte(`{"type":"authorized_user"}`)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			op, err := b.Build()
+			if err == nil {
+				op.Close()
+				t.Fatalf("expected building with bad credentials to fail")
+			}
+			if op != nil {
+				t.Errorf("expected no Operator with the error, got %v", op)
+			}
+		})
+	}
+}
+
+func TestBuildClosesClientsOnFailure(t *testing.T) {
+	for name, tc := range map[string]struct {
+		failing string
+		created int
+	}{
+		"database admin client": {"database admin", 1},
+		"spanner client":        {"spanner", 2},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer restoreClientConstructors()()
+			failure := errors.New("no client")
+			var conns []*grpc.ClientConn
+			newInstanceAdminClient = func(ctx context.Context, opts ...option.ClientOption) (*instanceAdmin.InstanceAdminClient, error) {
+				c, err := instanceAdmin.NewInstanceAdminClient(ctx, opts...)
+				if err == nil {
+					conns = append(conns, c.Connection())
+				}
+				return c, err
+			}
+			newDatabaseAdminClient = func(ctx context.Context, opts ...option.ClientOption) (*databaseAdmin.DatabaseAdminClient, error) {
+				if tc.failing == "database admin" {
+					return nil, failure
+				}
+				c, err := databaseAdmin.NewDatabaseAdminClient(ctx, opts...)
+				if err == nil {
+					conns = append(conns, c.Connection())
+				}
+				return c, err
+			}
+			newSpannerClient = func(ctx context.Context, opts ...option.ClientOption) (*spanner.Client, error) {
+				return nil, failure
+			}
+
+			op, err := NewBuilder().ProjectId("test").EmulatorHost("localhost:9010").Build()
+			if err == nil {
+				op.Close()
+				t.Fatalf("expected building to fail")
+			}
+			if len(conns) != tc.created {
+				t.Fatalf("expected %d clients to be created, got %d", tc.created, len(conns))
+			}
+			for _, conn := range conns {
+				if state := conn.GetState(); state != connectivity.Shutdown {
+					t.Errorf("expected the clients created before the failure to be closed, got a connection in state %s", state)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildWithEmulator(t *testing.T) {
+	op, err := NewBuilder().ProjectId("test").EmulatorHost("localhost:9010").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := op.Close(); err != nil {
+		t.Errorf("expected the clients to close, got %v", err)
+	}
+}
+
+func TestBuildMock(t *testing.T) {
+	dataPath, err := ioutil.TempDir("", "operator-builder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dataPath)
+	file := filepath.Join(dataPath, "file")
+	if err := ioutil.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewBuilder().ProjectId("test").BuildMock(dataPath); err != nil {
+		t.Errorf("expected a mock in %s, got %v", dataPath, err)
+	}
+	if _, err := NewBuilder().ProjectId("test").BuildMock(file); err == nil {
+		t.Errorf("expected building a mock below the file %s to fail", file)
+	}
+}
@@ -0,0 +1,53 @@
+package operator
+
+import "fmt"
+
+// ProcessingUnitsPerNode is the number of processing units in one node.
+const ProcessingUnitsPerNode = 1000
+
+// Capacity is the compute capacity of an instance, either in nodes or in
+// processing units. Exactly one of the fields is set.
+type Capacity struct {
+	NodeCount       int32
+	ProcessingUnits int32
+}
+
+// Nodes returns a Capacity of nodeCount nodes.
+func Nodes(nodeCount int32) Capacity {
+	return Capacity{NodeCount: nodeCount}
+}
+
+// ProcessingUnits returns a Capacity of processingUnits processing units.
+func ProcessingUnits(processingUnits int32) Capacity {
+	return Capacity{ProcessingUnits: processingUnits}
+}
+
+// InProcessingUnits reports whether the capacity is given in processing units.
+func (c Capacity) InProcessingUnits() bool {
+	return c.ProcessingUnits != 0
+}
+
+// Validate checks the capacity is one Spanner accepts: a positive number of
+// nodes, or 100 to 900 processing units in steps of 100, or a multiple of
+// 1000 processing units.
+func (c Capacity) Validate() error {
+	switch {
+	case c.NodeCount != 0 && c.ProcessingUnits != 0:
+		return fmt.Errorf("nodeCount and processingUnits are mutually exclusive")
+	case c.InProcessingUnits():
+		pu := c.ProcessingUnits
+		if pu < 100 || (pu < ProcessingUnitsPerNode && pu%100 != 0) || (pu >= ProcessingUnitsPerNode && pu%ProcessingUnitsPerNode != 0) {
+			return fmt.Errorf("processingUnits must be 100 to 900 in steps of 100, or a multiple of 1000, got %d", pu)
+		}
+	case c.NodeCount < 1:
+		return fmt.Errorf("nodeCount must be positive, got %d", c.NodeCount)
+	}
+	return nil
+}
+
+func (c Capacity) String() string {
+	if c.InProcessingUnits() {
+		return fmt.Sprintf("%d processing units", c.ProcessingUnits)
+	}
+	return fmt.Sprintf("%d nodes", c.NodeCount)
+}
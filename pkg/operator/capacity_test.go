@@ -0,0 +1,42 @@
+package operator
+
+import "testing"
+
+func TestCapacityValidate(t *testing.T) {
+	for _, tc := range []struct {
+		capacity Capacity
+		valid    bool
+	}{
+		{Nodes(1), true},
+		{Nodes(12), true},
+		{Nodes(0), false},
+		{Nodes(-1), false},
+		{ProcessingUnits(100), true},
+		{ProcessingUnits(900), true},
+		{ProcessingUnits(1000), true},
+		{ProcessingUnits(3000), true},
+		{ProcessingUnits(50), false},
+		{ProcessingUnits(150), false},
+		{ProcessingUnits(1100), false},
+		{ProcessingUnits(1500), false},
+		{ProcessingUnits(-100), false},
+		{Capacity{NodeCount: 1, ProcessingUnits: 1000}, false},
+		{Capacity{NodeCount: 1, ProcessingUnits: 100}, false},
+	} {
+		err := tc.capacity.Validate()
+		if tc.valid && err != nil {
+			t.Errorf("expected %+v to be valid, got %v", tc.capacity, err)
+		} else if !tc.valid && err == nil {
+			t.Errorf("expected %+v to be invalid", tc.capacity)
+		}
+	}
+}
+
+func TestCapacityString(t *testing.T) {
+	if s := Nodes(2).String(); s != "2 nodes" {
+		t.Errorf("expected 2 nodes, got %s", s)
+	}
+	if s := ProcessingUnits(300).String(); s != "300 processing units" {
+		t.Errorf("expected 300 processing units, got %s", s)
+	}
+}
@@ -0,0 +1,206 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	structpb "github.com/golang/protobuf/ptypes/struct"
+	spannerpb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DatabaseRoleGrant is a single privilege a database role holds on a table,
+// or on one column of it.
+type DatabaseRoleGrant struct {
+	// Privilege is SELECT, INSERT, UPDATE or DELETE.
+	Privilege string
+	Table     string
+	// Column is empty for a privilege on the whole table.
+	Column string
+}
+
+func (g DatabaseRoleGrant) String() string {
+	if g.Column == "" {
+		return fmt.Sprintf("%s ON TABLE %s", g.Privilege, g.Table)
+	}
+	return fmt.Sprintf("%s(%s) ON TABLE %s", g.Privilege, g.Column, g.Table)
+}
+
+// SortDatabaseRoleGrants sorts grants by table, privilege and column.
+func SortDatabaseRoleGrants(grants []DatabaseRoleGrant) {
+	sort.Slice(grants, func(i, j int) bool {
+		a, b := grants[i], grants[j]
+		if a.Table != b.Table {
+			return a.Table < b.Table
+		}
+		if a.Privilege != b.Privilege {
+			return a.Privilege < b.Privilege
+		}
+		return a.Column < b.Column
+	})
+}
+
+// DatabaseRoleDdl returns the DDL statements creating role when create is
+// set, then revoking revokes and granting grants. Column grants of the same
+// privilege on the same table share one statement.
+func DatabaseRoleDdl(role string, create bool, grants []DatabaseRoleGrant, revokes []DatabaseRoleGrant) []string {
+	var statements []string
+	if create {
+		statements = append(statements, fmt.Sprintf("CREATE ROLE %s", role))
+	}
+	for _, p := range groupDatabaseRoleGrants(revokes) {
+		statements = append(statements, fmt.Sprintf("REVOKE %s FROM ROLE %s", p, role))
+	}
+	for _, p := range groupDatabaseRoleGrants(grants) {
+		statements = append(statements, fmt.Sprintf("GRANT %s TO ROLE %s", p, role))
+	}
+	return statements
+}
+
+// DropDatabaseRoleDdl returns the DDL statements revoking grants from role
+// and dropping it. Spanner refuses to drop a role that still holds privileges.
+func DropDatabaseRoleDdl(role string, grants []DatabaseRoleGrant) []string {
+	return append(DatabaseRoleDdl(role, false, nil, grants), fmt.Sprintf("DROP ROLE %s", role))
+}
+
+// groupDatabaseRoleGrants renders grants as "<privilege> ON TABLE <table>"
+// clauses, merging the columns of a privilege on a table.
+func groupDatabaseRoleGrants(grants []DatabaseRoleGrant) []string {
+	sorted := append([]DatabaseRoleGrant(nil), grants...)
+	SortDatabaseRoleGrants(sorted)
+	var clauses []string
+	for i := 0; i < len(sorted); {
+		g := sorted[i]
+		j := i + 1
+		if g.Column == "" {
+			clauses = append(clauses, g.String())
+			i = j
+			continue
+		}
+		columns := []string{g.Column}
+		for ; j < len(sorted) && sorted[j].Table == g.Table && sorted[j].Privilege == g.Privilege && sorted[j].Column != ""; j++ {
+			columns = append(columns, sorted[j].Column)
+		}
+		clauses = append(clauses, fmt.Sprintf("%s(%s) ON TABLE %s", g.Privilege, strings.Join(columns, ", "), g.Table))
+		i = j
+	}
+	return clauses
+}
+
+func (o *operator) GetDatabaseRoleGrants(ctx context.Context, instanceId string, name string, role string) ([]DatabaseRoleGrant, error) {
+	databaseName := fmt.Sprintf("projects/%s/instances/%s/databases/%s", o.projectId, instanceId, name)
+	params := &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"role": {Kind: &structpb.Value_StringValue{StringValue: role}},
+		},
+	}
+	rs, err := o.executeSql(ctx, databaseName, &spannerpb.ExecuteSqlRequest{
+		Sql:    "SELECT COUNT(*) FROM information_schema.roles WHERE role_name = @role",
+		Params: params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(rs.Rows) != 1 || rs.Rows[0].Values[0].GetStringValue() == "0" {
+		return nil, status.Errorf(codes.NotFound, "database role %s not found in %s", role, databaseName)
+	}
+	rs, err = o.executeSql(ctx, databaseName, &spannerpb.ExecuteSqlRequest{
+		Sql: `SELECT privilege_type, table_name, '' FROM information_schema.table_privileges
+WHERE grantee = @role AND table_schema = ''
+UNION ALL
+SELECT privilege_type, table_name, column_name FROM information_schema.column_privileges
+WHERE grantee = @role AND table_schema = ''`,
+		Params: params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	grants := make([]DatabaseRoleGrant, 0, len(rs.Rows))
+	for _, row := range rs.Rows {
+		grants = append(grants, DatabaseRoleGrant{
+			Privilege: row.Values[0].GetStringValue(),
+			Table:     row.Values[1].GetStringValue(),
+			Column:    row.Values[2].GetStringValue(),
+		})
+	}
+	SortDatabaseRoleGrants(grants)
+	return grants, nil
+}
+
+var (
+	createRoleRegexp = regexp.MustCompile(`(?i)^\s*CREATE\s+ROLE\s+(\w+)\s*$`)
+	dropRoleRegexp   = regexp.MustCompile(`(?i)^\s*DROP\s+ROLE\s+(\w+)\s*$`)
+	grantRegexp      = regexp.MustCompile(`(?is)^\s*(GRANT|REVOKE)\s+(.+?)\s+ON\s+TABLE\s+(\w+)\s+(?:TO|FROM)\s+ROLE\s+(\w+)\s*$`)
+	privilegeRegexp  = regexp.MustCompile(`^\s*(\w+)\s*(?:\(([^)]*)\))?\s*$`)
+)
+
+// replayDatabaseRoles returns the roles statements create, with the grants
+// each of them holds once every statement is applied in order. Statements
+// other than CREATE ROLE, DROP ROLE, GRANT and REVOKE are ignored.
+func replayDatabaseRoles(statements []string) map[string]map[DatabaseRoleGrant]bool {
+	roles := map[string]map[DatabaseRoleGrant]bool{}
+	for _, s := range statements {
+		if m := createRoleRegexp.FindStringSubmatch(s); m != nil {
+			roles[m[1]] = map[DatabaseRoleGrant]bool{}
+			continue
+		}
+		if m := dropRoleRegexp.FindStringSubmatch(s); m != nil {
+			delete(roles, m[1])
+			continue
+		}
+		m := grantRegexp.FindStringSubmatch(s)
+		if m == nil {
+			continue
+		}
+		grants, ok := roles[m[4]]
+		if !ok {
+			continue
+		}
+		grant := strings.EqualFold(m[1], "GRANT")
+		for _, p := range splitPrivileges(m[2]) {
+			pm := privilegeRegexp.FindStringSubmatch(p)
+			if pm == nil {
+				continue
+			}
+			g := DatabaseRoleGrant{Privilege: strings.ToUpper(pm[1]), Table: m[3]}
+			columns := []string{""}
+			if pm[2] != "" {
+				columns = strings.Split(pm[2], ",")
+			}
+			for _, c := range columns {
+				g.Column = strings.TrimSpace(c)
+				if grant {
+					grants[g] = true
+				} else {
+					delete(grants, g)
+				}
+			}
+		}
+	}
+	return roles
+}
+
+// splitPrivileges splits a privilege list like "SELECT(a, b), INSERT" on the
+// commas outside of parentheses.
+func splitPrivileges(s string) []string {
+	var privileges []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				privileges = append(privileges, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(privileges, s[start:])
+}
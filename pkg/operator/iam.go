@@ -0,0 +1,109 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/iam/v1"
+)
+
+// iamPolicyVersion is the policy version requested from Spanner, the only one
+// that can hold conditional role bindings. Asking for it keeps conditions
+// intact when a policy is read, modified and written back.
+const iamPolicyVersion = 3
+
+// iamResource returns the name of the database databaseName in instanceId,
+// or of the instance itself when databaseName is empty.
+func iamResource(projectId string, instanceId string, databaseName string) string {
+	instanceName := fmt.Sprintf("projects/%s/instances/%s", projectId, instanceId)
+	if databaseName == "" {
+		return instanceName
+	}
+	return fmt.Sprintf("%s/databases/%s", instanceName, databaseName)
+}
+
+func (o *operator) GetIamPolicy(ctx context.Context, instanceId string, databaseName string) (*iam.Policy, error) {
+	req := &iam.GetIamPolicyRequest{
+		Resource: iamResource(o.projectId, instanceId, databaseName),
+		Options: &iam.GetPolicyOptions{
+			RequestedPolicyVersion: iamPolicyVersion,
+		},
+	}
+	if databaseName == "" {
+		return o.instanceAdminClient.GetIamPolicy(ctx, req)
+	}
+	return o.databaseAdminClient.GetIamPolicy(ctx, req)
+}
+
+func (o *operator) SetIamPolicy(ctx context.Context, instanceId string, databaseName string, policy *iam.Policy) (*iam.Policy, error) {
+	req := &iam.SetIamPolicyRequest{
+		Resource: iamResource(o.projectId, instanceId, databaseName),
+		Policy:   policy,
+	}
+	if databaseName == "" {
+		return o.instanceAdminClient.SetIamPolicy(ctx, req)
+	}
+	return o.databaseAdminClient.SetIamPolicy(ctx, req)
+}
+
+func (o *operator) TestIamPermissions(ctx context.Context, instanceId string, databaseName string, permissions []string) ([]string, error) {
+	req := &iam.TestIamPermissionsRequest{
+		Resource:    iamResource(o.projectId, instanceId, databaseName),
+		Permissions: permissions,
+	}
+	var resp *iam.TestIamPermissionsResponse
+	var err error
+	if databaseName == "" {
+		resp, err = o.instanceAdminClient.TestIamPermissions(ctx, req)
+	} else {
+		resp, err = o.databaseAdminClient.TestIamPermissions(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPermissions(), nil
+}
+
+// AddIamMember adds member to the unconditional binding of role in policy,
+// creating the binding when needed. It reports whether policy changed.
+func AddIamMember(policy *iam.Policy, role string, member string) bool {
+	for _, b := range policy.Bindings {
+		if b.Role != role || b.Condition != nil {
+			continue
+		}
+		for _, m := range b.Members {
+			if m == member {
+				return false
+			}
+		}
+		b.Members = append(b.Members, member)
+		return true
+	}
+	policy.Bindings = append(policy.Bindings, &iam.Binding{
+		Role:    role,
+		Members: []string{member},
+	})
+	return true
+}
+
+// RemoveIamMember removes member from the unconditional binding of role in
+// policy, dropping the binding once it is empty. It reports whether policy
+// changed.
+func RemoveIamMember(policy *iam.Policy, role string, member string) bool {
+	for i, b := range policy.Bindings {
+		if b.Role != role || b.Condition != nil {
+			continue
+		}
+		for j, m := range b.Members {
+			if m != member {
+				continue
+			}
+			b.Members = append(b.Members[:j], b.Members[j+1:]...)
+			if len(b.Members) == 0 {
+				policy.Bindings = append(policy.Bindings[:i], policy.Bindings[i+1:]...)
+			}
+			return true
+		}
+	}
+	return false
+}
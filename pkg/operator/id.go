@@ -0,0 +1,42 @@
+package operator
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var (
+	instanceIdPattern = regexp.MustCompile(`^[a-z][-a-z0-9]*[a-z0-9]$`)
+	databaseIdPattern = regexp.MustCompile(`^[a-z][-_a-z0-9]*[a-z0-9]$`)
+	backupIdPattern   = databaseIdPattern
+)
+
+// ValidateInstanceId checks instanceId is one Spanner accepts: 2 to 64
+// lowercase letters, digits and hyphens, starting with a letter and not
+// ending with a hyphen.
+func ValidateInstanceId(instanceId string) error {
+	if len(instanceId) < 2 || len(instanceId) > 64 || !instanceIdPattern.MatchString(instanceId) {
+		return fmt.Errorf("instance ID must be 2 to 64 lowercase letters, digits and hyphens, starting with a letter and ending with a letter or digit, got %q", instanceId)
+	}
+	return nil
+}
+
+// ValidateDatabaseId checks databaseId is one Spanner accepts: 2 to 30
+// lowercase letters, digits, underscores and hyphens, starting with a letter
+// and ending with a letter or digit.
+func ValidateDatabaseId(databaseId string) error {
+	if len(databaseId) < 2 || len(databaseId) > 30 || !databaseIdPattern.MatchString(databaseId) {
+		return fmt.Errorf("database ID must be 2 to 30 lowercase letters, digits, underscores and hyphens, starting with a letter and ending with a letter or digit, got %q", databaseId)
+	}
+	return nil
+}
+
+// ValidateBackupId checks backupId is one Spanner accepts: 2 to 60 lowercase
+// letters, digits, underscores and hyphens, starting with a letter and ending
+// with a letter or digit.
+func ValidateBackupId(backupId string) error {
+	if len(backupId) < 2 || len(backupId) > 60 || !backupIdPattern.MatchString(backupId) {
+		return fmt.Errorf("backup ID must be 2 to 60 lowercase letters, digits, underscores and hyphens, starting with a letter and ending with a letter or digit, got %q", backupId)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package operator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateInstanceId(t *testing.T) {
+	for _, tc := range []struct {
+		instanceId string
+		valid      bool
+	}{
+		{"ab", true},
+		{"test-instance-1", true},
+		{"a" + strings.Repeat("b", 63), true},
+		{"a", false},
+		{"", false},
+		{"a" + strings.Repeat("b", 64), false},
+		{"1test", false},
+		{"-test", false},
+		{"test-", false},
+		{"test_instance", false},
+		{"Test", false},
+		{"tesT", false},
+		{"test.instance", false},
+	} {
+		err := ValidateInstanceId(tc.instanceId)
+		if tc.valid && err != nil {
+			t.Errorf("expected instance ID %q to be valid, got %v", tc.instanceId, err)
+		} else if !tc.valid && err == nil {
+			t.Errorf("expected instance ID %q to be invalid", tc.instanceId)
+		}
+	}
+}
+
+func TestValidateDatabaseId(t *testing.T) {
+	for _, tc := range []struct {
+		databaseId string
+		valid      bool
+	}{
+		{"ab", true},
+		{"orders_v2", true},
+		{"orders-v2", true},
+		{"a" + strings.Repeat("b", 29), true},
+		{"a", false},
+		{"", false},
+		{"a" + strings.Repeat("b", 30), false},
+		{"2orders", false},
+		{"_orders", false},
+		{"orders_", false},
+		{"orders-", false},
+		{"Orders", false},
+		{"ordErs", false},
+		{"orders.v2", false},
+	} {
+		err := ValidateDatabaseId(tc.databaseId)
+		if tc.valid && err != nil {
+			t.Errorf("expected database ID %q to be valid, got %v", tc.databaseId, err)
+		} else if !tc.valid && err == nil {
+			t.Errorf("expected database ID %q to be invalid", tc.databaseId)
+		}
+	}
+}
+
+func TestValidateBackupId(t *testing.T) {
+	for _, tc := range []struct {
+		backupId string
+		valid    bool
+	}{
+		{"ab", true},
+		{"daily-20190101-030000", true},
+		{"orders_v2", true},
+		{"a" + strings.Repeat("b", 59), true},
+		{"a", false},
+		{"a" + strings.Repeat("b", 60), false},
+		{"2019-daily", false},
+		{"daily-", false},
+		{"Daily", false},
+		{"daily.orders-20190101-030000", false},
+	} {
+		err := ValidateBackupId(tc.backupId)
+		if tc.valid && err != nil {
+			t.Errorf("expected backup ID %q to be valid, got %v", tc.backupId, err)
+		} else if !tc.valid && err == nil {
+			t.Errorf("expected backup ID %q to be invalid", tc.backupId)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/iterator"
+	"google.golang.org/genproto/googleapis/spanner/admin/instance/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// instanceConfigCacheTTL is how long the instance configs of a project are
+// kept before they are listed again. They change rarely, when Google adds a
+// region, so a stale list costs little.
+const instanceConfigCacheTTL = time.Hour
+
+// instanceConfigCache keeps the instance configs listed for a project.
+type instanceConfigCache struct {
+	mu      sync.Mutex
+	configs []*instance.InstanceConfig
+	expires time.Time
+}
+
+// get returns the cached configs, calling list when they are missing or
+// expired. Failures are not cached.
+func (c *instanceConfigCache) get(ctx context.Context, list func(ctx context.Context) ([]*instance.InstanceConfig, error)) ([]*instance.InstanceConfig, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.configs != nil && time.Now().Before(c.expires) {
+		return c.configs, nil
+	}
+	configs, err := list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.configs = configs
+	c.expires = time.Now().Add(instanceConfigCacheTTL)
+	return configs, nil
+}
+
+// instanceConfigId returns the last segment of an instance config name,
+// e.g. regional-asia-northeast1 for
+// projects/p/instanceConfigs/regional-asia-northeast1.
+func instanceConfigId(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+func (o *operator) listInstanceConfigs(ctx context.Context) ([]*instance.InstanceConfig, error) {
+	req := &instance.ListInstanceConfigsRequest{
+		Parent: fmt.Sprintf("projects/%s", o.projectId),
+	}
+	it := o.instanceAdminClient.ListInstanceConfigs(ctx, req)
+	var configs []*instance.InstanceConfig
+	for {
+		c, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, c)
+	}
+	return configs, nil
+}
+
+func (o *operator) ListInstanceConfigs(ctx context.Context) ([]*instance.InstanceConfig, error) {
+	return o.instanceConfigs.get(ctx, o.listInstanceConfigs)
+}
+
+func (o *operator) GetInstanceConfig(ctx context.Context, configId string) (*instance.InstanceConfig, error) {
+	configs, err := o.ListInstanceConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range configs {
+		if instanceConfigId(c.Name) == configId {
+			return c, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "instance config %s not found in project %s", configId, o.projectId)
+}
+
+// CheckInstanceConfig returns an error naming the available configs when
+// configId is not an instance config of the project of o.
+func CheckInstanceConfig(ctx context.Context, o Operator, configId string) error {
+	if configId == "" {
+		return fmt.Errorf("no instance config given")
+	}
+	_, err := o.GetInstanceConfig(ctx, configId)
+	if err == nil || !o.IsNotFoundError(err) {
+		return err
+	}
+	configs, err := o.ListInstanceConfigs(ctx)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(configs))
+	for _, c := range configs {
+		ids = append(ids, instanceConfigId(c.Name))
+	}
+	sort.Strings(ids)
+	return fmt.Errorf("unknown instance config %q, available configs are: %s", configId, strings.Join(ids, ", "))
+}
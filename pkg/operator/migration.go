@@ -0,0 +1,190 @@
+package operator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	structpb "github.com/golang/protobuf/ptypes/struct"
+	"github.com/labstack/gommon/log"
+	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+	spannerpb "google.golang.org/genproto/googleapis/spanner/v1"
+)
+
+// MigrationTable is the table that tracks the migrations applied to a database.
+const MigrationTable = "SchemaMigrations"
+
+var migrationTableDdl = fmt.Sprintf(`CREATE TABLE %s (
+	Version INT64 NOT NULL,
+	Description STRING(MAX),
+	Checksum STRING(64) NOT NULL,
+	AppliedAt TIMESTAMP NOT NULL OPTIONS (allow_commit_timestamp=true),
+) PRIMARY KEY (Version)`, MigrationTable)
+
+// Migration is a numbered schema change of a database.
+type Migration struct {
+	Version     int64
+	Description string
+	// Statements are the DDL statements of the migration. They are not
+	// stored in the tracking table, so they are empty on applied migrations.
+	Statements []string
+	// Checksum identifies the statements, so edits to an applied migration
+	// can be detected.
+	Checksum string
+}
+
+// NewMigration returns a Migration with its checksum computed from statements.
+func NewMigration(version int64, description string, statements []string) Migration {
+	return Migration{
+		Version:     version,
+		Description: description,
+		Statements:  statements,
+		Checksum:    MigrationChecksum(statements),
+	}
+}
+
+// MigrationChecksum returns the hex encoded SHA-256 of statements.
+func MigrationChecksum(statements []string) string {
+	h := sha256.New()
+	for _, s := range statements {
+		h.Write([]byte(strings.TrimSpace(s)))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// executeSql runs a read-only query against the database in a throwaway session.
+func (o *operator) executeSql(ctx context.Context, databaseName string, req *spannerpb.ExecuteSqlRequest) (*spannerpb.ResultSet, error) {
+	session, err := o.client.CreateSession(ctx, &spannerpb.CreateSessionRequest{
+		Database: databaseName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer o.deleteSession(session.Name)
+	req.Session = session.Name
+	return o.client.ExecuteSql(ctx, req)
+}
+
+func (o *operator) deleteSession(name string) {
+	err := o.client.DeleteSession(context.Background(), &spannerpb.DeleteSessionRequest{
+		Name: name,
+	})
+	if err != nil {
+		log.Printf("Failed to delete session %s: %s", name, err.Error())
+	}
+}
+
+func (o *operator) hasMigrationTable(ctx context.Context, databaseName string) (bool, error) {
+	rs, err := o.executeSql(ctx, databaseName, &spannerpb.ExecuteSqlRequest{
+		Sql: "SELECT COUNT(*) FROM information_schema.tables WHERE table_catalog = '' AND table_schema = '' AND table_name = @name",
+		Params: &structpb.Struct{
+			Fields: map[string]*structpb.Value{
+				"name": {Kind: &structpb.Value_StringValue{StringValue: MigrationTable}},
+			},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(rs.Rows) == 1 && rs.Rows[0].Values[0].GetStringValue() != "0", nil
+}
+
+func (o *operator) GetAppliedMigrations(ctx context.Context, instanceId string, name string) ([]Migration, error) {
+	databaseName := fmt.Sprintf("projects/%s/instances/%s/databases/%s", o.projectId, instanceId, name)
+	ok, err := o.hasMigrationTable(ctx, databaseName)
+	if err != nil || !ok {
+		return nil, err
+	}
+	rs, err := o.executeSql(ctx, databaseName, &spannerpb.ExecuteSqlRequest{
+		Sql: fmt.Sprintf("SELECT Version, Description, Checksum FROM %s ORDER BY Version", MigrationTable),
+	})
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]Migration, 0, len(rs.Rows))
+	for _, row := range rs.Rows {
+		version, err := strconv.ParseInt(row.Values[0].GetStringValue(), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{
+			Version:     version,
+			Description: row.Values[1].GetStringValue(),
+			Checksum:    row.Values[2].GetStringValue(),
+		})
+	}
+	return migrations, nil
+}
+
+func (o *operator) ApplyMigration(ctx context.Context, instanceId string, name string, migration Migration) (string, error) {
+	databaseName := fmt.Sprintf("projects/%s/instances/%s/databases/%s", o.projectId, instanceId, name)
+	ok, err := o.hasMigrationTable(ctx, databaseName)
+	if err != nil {
+		return "", err
+	}
+	statements := migration.Statements
+	if !ok {
+		statements = append([]string{migrationTableDdl}, statements...)
+	}
+	op, err := o.databaseAdminClient.UpdateDatabaseDdl(ctx, &database.UpdateDatabaseDdlRequest{
+		Database:   databaseName,
+		Statements: statements,
+	})
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Apply migration %d started: %s", migration.Version, op.Name())
+	return op.Name(), nil
+}
+
+func (o *operator) RecordMigration(ctx context.Context, instanceId string, name string, migration Migration) error {
+	databaseName := fmt.Sprintf("projects/%s/instances/%s/databases/%s", o.projectId, instanceId, name)
+	session, err := o.client.CreateSession(ctx, &spannerpb.CreateSessionRequest{
+		Database: databaseName,
+	})
+	if err != nil {
+		return err
+	}
+	defer o.deleteSession(session.Name)
+	_, err = o.client.Commit(ctx, &spannerpb.CommitRequest{
+		Session: session.Name,
+		Transaction: &spannerpb.CommitRequest_SingleUseTransaction{
+			SingleUseTransaction: &spannerpb.TransactionOptions{
+				Mode: &spannerpb.TransactionOptions_ReadWrite_{
+					ReadWrite: &spannerpb.TransactionOptions_ReadWrite{},
+				},
+			},
+		},
+		Mutations: []*spannerpb.Mutation{
+			{
+				// InsertOrUpdate keeps recording idempotent when a caller
+				// retries after a failure.
+				Operation: &spannerpb.Mutation_InsertOrUpdate{
+					InsertOrUpdate: &spannerpb.Mutation_Write{
+						Table:   MigrationTable,
+						Columns: []string{"Version", "Description", "Checksum", "AppliedAt"},
+						Values: []*structpb.ListValue{
+							{
+								Values: []*structpb.Value{
+									{Kind: &structpb.Value_StringValue{StringValue: strconv.FormatInt(migration.Version, 10)}},
+									{Kind: &structpb.Value_StringValue{StringValue: migration.Description}},
+									{Kind: &structpb.Value_StringValue{StringValue: migration.Checksum}},
+									{Kind: &structpb.Value_StringValue{StringValue: "spanner.commit_timestamp()"}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Recorded migration %d of database %s", migration.Version, databaseName)
+	return nil
+}
@@ -0,0 +1,38 @@
+package operator
+
+import "testing"
+
+const (
+	createUsers      = "CREATE TABLE Users (Id INT64) PRIMARY KEY (Id)"
+	createUsersIndex = "CREATE INDEX UsersById ON Users (Id)"
+)
+
+// The checksums of applied migrations are stored in the databases, so they
+// must never change for the same statements.
+func TestMigrationChecksum(t *testing.T) {
+	for _, tc := range []struct {
+		statements []string
+		checksum   string
+	}{
+		{nil, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{[]string{createUsers}, "b47d57e10b4027fb656b70bbcf00b069a0427dad5fac7ade361419437dabd9b7"},
+		{[]string{"\n  " + createUsers + "\t\n"}, "b47d57e10b4027fb656b70bbcf00b069a0427dad5fac7ade361419437dabd9b7"},
+		{[]string{createUsers, createUsersIndex}, "ec87f1c14da07fd04b96e1f999860fc4578fe156cde96b930762f39c544b094b"},
+		// Statements are separated, so splitting them differently changes the checksum.
+		{[]string{createUsers + createUsersIndex}, "f39cefd788fe77393982c3f3863396af2c8f245b9f933d4b6cd9b9fd9aeb7505"},
+	} {
+		if checksum := MigrationChecksum(tc.statements); checksum != tc.checksum {
+			t.Errorf("expected checksum of %q to be %s, got %s", tc.statements, tc.checksum, checksum)
+		}
+	}
+}
+
+func TestNewMigration(t *testing.T) {
+	migration := NewMigration(1, "create users", []string{createUsers})
+	if migration.Checksum != MigrationChecksum([]string{createUsers}) {
+		t.Errorf("expected the checksum of the statements, got %s", migration.Checksum)
+	}
+	if edited := NewMigration(1, "create users", []string{createUsers, createUsersIndex}); edited.Checksum == migration.Checksum {
+		t.Errorf("expected edited statements to change the checksum")
+	}
+}
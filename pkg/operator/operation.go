@@ -0,0 +1,87 @@
+package operator
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/longrunning"
+	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+	"google.golang.org/grpc/status"
+)
+
+// operationPollInterval is how often WaitOperation polls a running operation.
+const operationPollInterval = 2 * time.Second
+
+// Operation is a snapshot of a Spanner long-running operation.
+type Operation struct {
+	// Name is the fully qualified operation name, which can be stored and
+	// passed to GetOperation later, even from another process.
+	Name string
+	// Done reports whether the operation has finished.
+	Done bool
+	// Progress is the completion percentage reported in the metadata of the
+	// operation, 0 when it does not report one.
+	Progress int32
+	// Err is the error the operation finished with. It is nil while the
+	// operation is still running or when it succeeded.
+	Err error
+}
+
+func (o *operator) GetOperation(ctx context.Context, name string) (*Operation, error) {
+	req := &longrunning.GetOperationRequest{
+		Name: name,
+	}
+	lroClient := o.instanceAdminClient.LROClient
+	if strings.Contains(name, "/databases/") || strings.Contains(name, "/backups/") {
+		lroClient = o.databaseAdminClient.LROClient
+	}
+	op, err := lroClient.GetOperation(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	result := &Operation{
+		Name: op.GetName(),
+		Done: op.GetDone(),
+	}
+	if s := op.GetError(); s != nil {
+		result.Err = status.ErrorProto(s)
+	}
+	if op.GetMetadata() != nil {
+		// Backup and restore operations carry their progress in the metadata.
+		metadata, err := op.GetMetadata().UnmarshalNew()
+		if err != nil {
+			return nil, err
+		}
+		if m, ok := metadata.(interface {
+			GetProgress() *database.OperationProgress
+		}); ok {
+			result.Progress = m.GetProgress().GetProgressPercent()
+		}
+	}
+	return result, nil
+}
+
+func (o *operator) WaitOperation(ctx context.Context, name string) error {
+	return waitOperation(ctx, o, name)
+}
+
+// waitOperation polls the named operation until it is done or ctx expires.
+func waitOperation(ctx context.Context, o Operator, name string) error {
+	ticker := time.NewTicker(operationPollInterval)
+	defer ticker.Stop()
+	for {
+		op, err := o.GetOperation(ctx, name)
+		if err != nil {
+			return err
+		}
+		if op.Done {
+			return op.Err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package operator
 
 import (
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
 	"context"
 	"fmt"
 	"github.com/labstack/gommon/log"
+	"google.golang.org/api/iterator"
 	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
 	"google.golang.org/genproto/googleapis/spanner/admin/instance/v1"
 	"google.golang.org/genproto/protobuf/field_mask"
@@ -11,51 +13,44 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-func (o *operator) updateInstance(req *instance.UpdateInstanceRequest) error {
-	ctx := context.Background()
+func (o *operator) updateInstance(ctx context.Context, req *instance.UpdateInstanceRequest) (string, error) {
 	op, err := o.instanceAdminClient.UpdateInstance(ctx, req)
 	if err != nil {
-		return err
+		return "", err
 	}
-	if _, err = op.Wait(ctx); err == nil {
-		log.Print("Update instance done!")
-	}
-	return err
+	return op.Name(), nil
 }
 
 func (o *operator) CreateInstance(
+	ctx context.Context,
 	displayName string,
 	instanceId string,
 	instanceConfig string,
-	nodeCount int32,
-) error {
+	capacity Capacity,
+) (string, error) {
 
-	ctx := context.Background()
 	instanceName := fmt.Sprintf("projects/%s/instances/%s", o.projectId, instanceId)
 	instanceInfo := &instance.Instance{
-		Config:      fmt.Sprintf("projects/%s/instanceConfigs/%s", o.projectId, instanceConfig),
-		DisplayName: displayName,
-		Name:        instanceName,
-		NodeCount:   nodeCount,
+		Config:          fmt.Sprintf("projects/%s/instanceConfigs/%s", o.projectId, instanceConfig),
+		DisplayName:     displayName,
+		Name:            instanceName,
+		NodeCount:       capacity.NodeCount,
+		ProcessingUnits: capacity.ProcessingUnits,
 	}
 	req := &instance.CreateInstanceRequest{
 		Parent:     fmt.Sprintf("projects/%s", o.projectId),
-		InstanceId: o.instanceId,
+		InstanceId: instanceId,
 		Instance:   instanceInfo,
 	}
 	op, err := o.instanceAdminClient.CreateInstance(ctx, req)
 	if err != nil {
-		return err
+		return "", err
 	}
-	if _, err := op.Wait(ctx); err == nil {
-		log.Print("Create instance done!")
-	}
-
-	return err
+	log.Printf("Create instance started: %s", op.Name())
+	return op.Name(), nil
 }
 
-func (o *operator) GetInstance(instanceId string) (*instance.Instance, error) {
-	ctx := context.Background()
+func (o *operator) GetInstance(ctx context.Context, instanceId string) (*instance.Instance, error) {
 	instanceName := fmt.Sprintf("projects/%s/instances/%s", o.projectId, instanceId)
 	req := &instance.GetInstanceRequest{
 		Name: instanceName,
@@ -67,28 +62,44 @@ func (o *operator) GetInstance(instanceId string) (*instance.Instance, error) {
 	return i, nil
 }
 
-func (o *operator) Scale(instanceId string, nodeCount int32) error {
+func (o *operator) ListInstances(ctx context.Context, pageSize int32, pageToken string) ([]*instance.Instance, string, error) {
+	it := o.instanceAdminClient.ListInstances(ctx, &instance.ListInstancesRequest{
+		Parent: fmt.Sprintf("projects/%s", o.projectId),
+	})
+	var instances []*instance.Instance
+	next, err := iterator.NewPager(it, int(pageSizeOrDefault(pageSize)), pageToken).NextPage(&instances)
+	if err != nil {
+		return nil, "", err
+	}
+	return instances, next, nil
+}
+
+func (o *operator) Scale(ctx context.Context, instanceId string, capacity Capacity) (string, error) {
 	instanceName := fmt.Sprintf("projects/%s/instances/%s", o.projectId, instanceId)
 	instanceInfo := &instance.Instance{
-		Name:      instanceName,
-		NodeCount: nodeCount,
+		Name:            instanceName,
+		NodeCount:       capacity.NodeCount,
+		ProcessingUnits: capacity.ProcessingUnits,
+	}
+	path := "node_count"
+	if capacity.InProcessingUnits() {
+		path = "processing_units"
 	}
 	req := &instance.UpdateInstanceRequest{
 		Instance: instanceInfo,
 		FieldMask: &field_mask.FieldMask{
-			Paths: []string{"node_count"},
+			Paths: []string{path},
 		},
 	}
-	err := o.updateInstance(req)
+	opName, err := o.updateInstance(ctx, req)
 	if err != nil {
-		return err
+		return "", err
 	}
-	log.Printf("Update node count to %d", nodeCount)
-	return nil
+	log.Printf("Update capacity to %s started: %s", capacity, opName)
+	return opName, nil
 }
 
-func (o *operator) DeleteInstance(instanceId string) error {
-	ctx := context.Background()
+func (o *operator) DeleteInstance(ctx context.Context, instanceId string) error {
 	instanceName := fmt.Sprintf("projects/%s/instances/%s", o.projectId, instanceId)
 	err := o.instanceAdminClient.DeleteInstance(ctx, &instance.DeleteInstanceRequest{
 		Name: instanceName,
@@ -96,7 +107,7 @@ func (o *operator) DeleteInstance(instanceId string) error {
 	return err
 }
 
-func (o *operator) UpdateLabels(instanceId string, labels map[string]string) error {
+func (o *operator) UpdateLabels(ctx context.Context, instanceId string, labels map[string]string) (string, error) {
 	instanceName := fmt.Sprintf("projects/%s/instances/%s", o.projectId, instanceId)
 	instanceInfo := &instance.Instance{
 		Name:   instanceName,
@@ -108,33 +119,30 @@ func (o *operator) UpdateLabels(instanceId string, labels map[string]string) err
 			Paths: []string{"labels"},
 		},
 	}
-	err := o.updateInstance(req)
+	opName, err := o.updateInstance(ctx, req)
 	if err != nil {
-		return err
+		return "", err
 	}
-	log.Printf("Update labels to %+v", labels)
-	return nil
+	log.Printf("Update labels to %+v started: %s", labels, opName)
+	return opName, nil
 }
 
-func (o *operator) CreateDatabase(instanceId string, name string) error {
-	ctx := context.Background()
+func (o *operator) CreateDatabase(ctx context.Context, instanceId string, name string, extraStatements []string) (string, error) {
 	instanceName := fmt.Sprintf("projects/%s/instances/%s", o.projectId, instanceId)
 	req := &database.CreateDatabaseRequest{
 		Parent:          instanceName,
-		CreateStatement: fmt.Sprintf("CREATE DATABASE `%s`;", name),
+		CreateStatement: fmt.Sprintf("CREATE DATABASE `%s`", name),
+		ExtraStatements: extraStatements,
 	}
 	op, err := o.databaseAdminClient.CreateDatabase(ctx, req)
 	if err != nil {
-		return err
-	}
-	if _, err = op.Wait(ctx); err == nil {
-		log.Print("Create database done!")
+		return "", err
 	}
-	return nil
+	log.Printf("Create database started: %s", op.Name())
+	return op.Name(), nil
 }
 
-func (o *operator) GetDatabase(instanceId string, name string) (*database.Database, error) {
-	ctx := context.Background()
+func (o *operator) GetDatabase(ctx context.Context, instanceId string, name string) (*database.Database, error) {
 	databaseName := fmt.Sprintf("projects/%s/instances/%s/databases/%s", o.projectId, instanceId, name)
 	req := &database.GetDatabaseRequest{
 		Name: databaseName,
@@ -142,8 +150,52 @@ func (o *operator) GetDatabase(instanceId string, name string) (*database.Databa
 	return o.databaseAdminClient.GetDatabase(ctx, req)
 }
 
-func (o *operator) DropDatabase(instanceId string, name string) error {
-	ctx := context.Background()
+func (o *operator) ListDatabases(ctx context.Context, instanceId string, pageSize int32, pageToken string) ([]*database.Database, string, error) {
+	it := o.databaseAdminClient.ListDatabases(ctx, &database.ListDatabasesRequest{
+		Parent: fmt.Sprintf("projects/%s/instances/%s", o.projectId, instanceId),
+	})
+	var databases []*database.Database
+	next, err := iterator.NewPager(it, int(pageSizeOrDefault(pageSize)), pageToken).NextPage(&databases)
+	if err != nil {
+		return nil, "", err
+	}
+	return databases, next, nil
+}
+
+func (o *operator) UpdateDatabaseDdl(ctx context.Context, instanceId string, name string, statements []string) (string, error) {
+	databaseName := fmt.Sprintf("projects/%s/instances/%s/databases/%s", o.projectId, instanceId, name)
+	req := &database.UpdateDatabaseDdlRequest{
+		Database:   databaseName,
+		Statements: statements,
+	}
+	op, err := o.databaseAdminClient.UpdateDatabaseDdl(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Update database ddl started: %s", op.Name())
+	return op.Name(), nil
+}
+
+func (o *operator) SetDropProtection(ctx context.Context, instanceId string, name string, enabled bool) (string, error) {
+	// The deprecated genproto package has no alias of UpdateDatabaseRequest.
+	req := &databasepb.UpdateDatabaseRequest{
+		Database: &database.Database{
+			Name:                 fmt.Sprintf("projects/%s/instances/%s/databases/%s", o.projectId, instanceId, name),
+			EnableDropProtection: enabled,
+		},
+		UpdateMask: &field_mask.FieldMask{
+			Paths: []string{"enable_drop_protection"},
+		},
+	}
+	op, err := o.databaseAdminClient.UpdateDatabase(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Set drop protection started: %s", op.Name())
+	return op.Name(), nil
+}
+
+func (o *operator) DropDatabase(ctx context.Context, instanceId string, name string) error {
 	databaseName := fmt.Sprintf("projects/%s/instances/%s/databases/%s", o.projectId, instanceId, name)
 	req := &database.DropDatabaseRequest{
 		Database: databaseName,
@@ -155,3 +207,14 @@ func (o *operator) IsNotFoundError(err error) bool {
 	s, ok := status.FromError(err)
 	return ok && s.Code() == codes.NotFound
 }
+
+// Close closes the three clients, returning the first error.
+func (o *operator) Close() error {
+	var first error
+	for _, closeClient := range []func() error{o.client.Close, o.databaseAdminClient.Close, o.instanceAdminClient.Close} {
+		if err := closeClient(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,808 @@
+package operator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/iam/v1"
+	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+	"google.golang.org/genproto/googleapis/spanner/admin/instance/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Fake is an in-memory Operator for tests. It is safe for concurrent use by
+// several controller workers.
+//
+// Long-running operations take the latency set with SetOperationLatency,
+// immediately finishing by default. Instances, databases and backups stay
+// in the CREATING state until the operation creating them is done, and
+// updates such as Scale or UpdateDatabaseDdl only show once theirs is.
+// Operations are named like the ones of the file mock, so a newer operation
+// of the same kind on a resource replaces the older one.
+type Fake struct {
+	mu        sync.Mutex
+	projectId string
+	latency   time.Duration
+	etag      int64
+	closed    bool
+
+	instances  map[string]*fakeInstance
+	databases  map[string]*fakeDatabase
+	backups    map[string]*mockBackup
+	operations map[string]*fakeOperation
+	errors     map[string][]error
+	opErrors   []error
+}
+
+type fakeInstance struct {
+	instance *instance.Instance
+	iam      *iam.Policy
+}
+
+type fakeDatabase struct {
+	database   *database.Database
+	ddl        []string
+	migrations []Migration
+	iam        *iam.Policy
+}
+
+type fakeOperation struct {
+	name  string
+	start time.Time
+	end   time.Time
+	done  bool
+	err   error
+	// commit applies the change of the operation once it succeeds, and
+	// rollback undoes what was done when it started once it fails.
+	commit   func()
+	rollback func()
+}
+
+func newFake(projectId string) *Fake {
+	return &Fake{
+		projectId:  projectId,
+		instances:  map[string]*fakeInstance{},
+		databases:  map[string]*fakeDatabase{},
+		backups:    map[string]*mockBackup{},
+		operations: map[string]*fakeOperation{},
+		errors:     map[string][]error{},
+	}
+}
+
+// SetOperationLatency makes the operations started from now on run for d.
+func (f *Fake) SetOperationLatency(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latency = d
+}
+
+// FinishOperations finishes every running operation now.
+func (f *Fake) FinishOperations() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, op := range f.operations {
+		f.finish(op)
+	}
+}
+
+// FailNext makes the next call to method, e.g. "GetInstance", fail with err
+// before it has any effect. Errors queued for the same method are returned
+// by successive calls.
+func (f *Fake) FailNext(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errors[method] = append(f.errors[method], err)
+}
+
+// FailNextOperation makes the next operation started finish with err, with
+// none of its changes applied.
+func (f *Fake) FailNextOperation(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.opErrors = append(f.opErrors, err)
+}
+
+// Closed reports whether Close has been called.
+func (f *Fake) Closed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+// Close makes every later call fail like the methods of a closed client.
+func (f *Fake) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+// begin is called with f.mu held at the start of every Operator method. It
+// finishes the operations that are due and returns the error the call
+// should fail with, if any.
+func (f *Fake) begin(ctx context.Context, method string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if f.closed {
+		return status.Error(codes.Canceled, "operator is closed")
+	}
+	now := time.Now()
+	for _, op := range f.operations {
+		if !op.end.After(now) {
+			f.finish(op)
+		}
+	}
+	if errs := f.errors[method]; len(errs) > 0 {
+		f.errors[method] = errs[1:]
+		return errs[0]
+	}
+	return nil
+}
+
+// startOperation starts the operation verb on resourceName and returns its
+// name. commit is run once it succeeds, rollback once it fails.
+func (f *Fake) startOperation(resourceName string, verb string, commit func(), rollback func()) string {
+	now := time.Now()
+	op := &fakeOperation{
+		name:     mockOperationName(resourceName, verb),
+		start:    now,
+		end:      now.Add(f.latency),
+		commit:   commit,
+		rollback: rollback,
+	}
+	if len(f.opErrors) > 0 {
+		op.err = f.opErrors[0]
+		f.opErrors = f.opErrors[1:]
+	}
+	// Finish the operation being replaced, so its changes are not lost.
+	if previous, ok := f.operations[op.name]; ok {
+		f.finish(previous)
+	}
+	f.operations[op.name] = op
+	if f.latency <= 0 {
+		f.finish(op)
+	}
+	return op.name
+}
+
+func (f *Fake) finish(op *fakeOperation) {
+	if op.done {
+		return
+	}
+	op.done = true
+	if op.err == nil && op.commit != nil {
+		op.commit()
+	} else if op.err != nil && op.rollback != nil {
+		op.rollback()
+	}
+}
+
+func fakeNotFound(kind string, name string) error {
+	return status.Errorf(codes.NotFound, "%s %s not found", kind, name)
+}
+
+func fakeAlreadyExists(kind string, name string) error {
+	return status.Errorf(codes.AlreadyExists, "%s %s already exists", kind, name)
+}
+
+func (f *Fake) IsNotFoundError(err error) bool {
+	s, ok := status.FromError(err)
+	return ok && s.Code() == codes.NotFound
+}
+
+// GetOperation reports the operations the fake did not start, such as ones
+// recorded by an earlier process, as done.
+func (f *Fake) GetOperation(ctx context.Context, name string) (*Operation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "GetOperation"); err != nil {
+		return nil, err
+	}
+	op, ok := f.operations[name]
+	if !ok || op.done {
+		result := &Operation{Name: name, Done: true, Progress: 100}
+		if ok {
+			result.Err = op.err
+		}
+		return result, nil
+	}
+	progress := int32(100 * time.Since(op.start) / op.end.Sub(op.start))
+	return &Operation{Name: name, Progress: progress}, nil
+}
+
+func (f *Fake) WaitOperation(ctx context.Context, name string) error {
+	return waitOperation(ctx, f, name)
+}
+
+func (f *Fake) instanceName(instanceId string) string {
+	return fmt.Sprintf("projects/%s/instances/%s", f.projectId, instanceId)
+}
+
+func (f *Fake) databaseName(instanceId string, name string) string {
+	return fmt.Sprintf("%s/databases/%s", f.instanceName(instanceId), name)
+}
+
+func (f *Fake) backupName(instanceId string, backupId string) string {
+	return fmt.Sprintf("%s/backups/%s", f.instanceName(instanceId), backupId)
+}
+
+func (f *Fake) CreateInstance(ctx context.Context, displayName string, instanceId string, instanceConfig string, capacity Capacity) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "CreateInstance"); err != nil {
+		return "", err
+	}
+	if _, ok := f.instances[instanceId]; ok {
+		return "", fakeAlreadyExists("instance", instanceId)
+	}
+	instanceInfo := &instance.Instance{
+		Name:        f.instanceName(instanceId),
+		Config:      instanceConfig,
+		DisplayName: displayName,
+		State:       instance.Instance_CREATING,
+		Labels:      map[string]string{"mock": "true"},
+		CreateTime:  timestamppb.Now(),
+	}
+	mockCapacity(instanceInfo, capacity)
+	f.instances[instanceId] = &fakeInstance{instance: instanceInfo}
+	return f.startOperation(instanceInfo.Name, "create_instance", func() {
+		instanceInfo.State = instance.Instance_READY
+	}, func() {
+		delete(f.instances, instanceId)
+	}), nil
+}
+
+func (f *Fake) GetInstance(ctx context.Context, instanceId string) (*instance.Instance, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "GetInstance"); err != nil {
+		return nil, err
+	}
+	i, ok := f.instances[instanceId]
+	if !ok {
+		return nil, fakeNotFound("instance", instanceId)
+	}
+	return proto.Clone(i.instance).(*instance.Instance), nil
+}
+
+func (f *Fake) ListInstances(ctx context.Context, pageSize int32, pageToken string) ([]*instance.Instance, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "ListInstances"); err != nil {
+		return nil, "", err
+	}
+	var ids []string
+	for id := range f.instances {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	lo, hi, next := page(ids, pageSize, pageToken)
+	var instances []*instance.Instance
+	for _, id := range ids[lo:hi] {
+		instances = append(instances, proto.Clone(f.instances[id].instance).(*instance.Instance))
+	}
+	return instances, next, nil
+}
+
+func (f *Fake) Scale(ctx context.Context, instanceId string, capacity Capacity) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "Scale"); err != nil {
+		return "", err
+	}
+	i, ok := f.instances[instanceId]
+	if !ok {
+		return "", fakeNotFound("instance", instanceId)
+	}
+	return f.startOperation(i.instance.Name, "scale", func() {
+		mockCapacity(i.instance, capacity)
+	}, nil), nil
+}
+
+func (f *Fake) DeleteInstance(ctx context.Context, instanceId string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "DeleteInstance"); err != nil {
+		return err
+	}
+	if _, ok := f.instances[instanceId]; !ok {
+		return fakeNotFound("instance", instanceId)
+	}
+	prefix := instanceId + "/"
+	for key, db := range f.databases {
+		if strings.HasPrefix(key, prefix) && db.database.EnableDropProtection {
+			return fakeDropProtected(db.database.Name)
+		}
+	}
+	for key, backup := range f.backups {
+		if strings.HasPrefix(key, prefix) {
+			return fakeHasBackups(f.instances[instanceId].instance.Name, backup.Backup.Name)
+		}
+	}
+	delete(f.instances, instanceId)
+	// Like Spanner, deleting an instance deletes its databases, and is
+	// refused while it has backups.
+	for key := range f.databases {
+		if strings.HasPrefix(key, prefix) {
+			delete(f.databases, key)
+		}
+	}
+	return nil
+}
+
+func fakeHasBackups(name string, backupName string) error {
+	return status.Errorf(codes.FailedPrecondition, "instance %s has backups, such as %s", name, backupName)
+}
+
+func (f *Fake) UpdateLabels(ctx context.Context, instanceId string, labels map[string]string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "UpdateLabels"); err != nil {
+		return "", err
+	}
+	i, ok := f.instances[instanceId]
+	if !ok {
+		return "", fakeNotFound("instance", instanceId)
+	}
+	updated := make(map[string]string, len(labels))
+	for k, v := range labels {
+		updated[k] = v
+	}
+	return f.startOperation(i.instance.Name, "update_labels", func() {
+		i.instance.Labels = updated
+	}, nil), nil
+}
+
+func (f *Fake) ListInstanceConfigs(ctx context.Context) ([]*instance.InstanceConfig, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "ListInstanceConfigs"); err != nil {
+		return nil, err
+	}
+	return f.instanceConfigs(), nil
+}
+
+func (f *Fake) instanceConfigs() []*instance.InstanceConfig {
+	configs := make([]*instance.InstanceConfig, 0, len(mockInstanceConfigs))
+	for _, id := range mockInstanceConfigs {
+		configs = append(configs, &instance.InstanceConfig{
+			Name:        fmt.Sprintf("projects/%s/instanceConfigs/%s", f.projectId, id),
+			DisplayName: id,
+		})
+	}
+	return configs
+}
+
+func (f *Fake) GetInstanceConfig(ctx context.Context, configId string) (*instance.InstanceConfig, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "GetInstanceConfig"); err != nil {
+		return nil, err
+	}
+	for _, c := range f.instanceConfigs() {
+		if instanceConfigId(c.Name) == configId {
+			return c, nil
+		}
+	}
+	return nil, fakeNotFound("instance config", configId)
+}
+
+// database returns the database name of instanceId, or a not found error.
+func (f *Fake) database(instanceId string, name string) (*fakeDatabase, error) {
+	db, ok := f.databases[instanceId+"/"+name]
+	if !ok {
+		return nil, fakeNotFound("database", f.databaseName(instanceId, name))
+	}
+	return db, nil
+}
+
+func (f *Fake) CreateDatabase(ctx context.Context, instanceId string, name string, extraStatements []string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "CreateDatabase"); err != nil {
+		return "", err
+	}
+	return f.createDatabase(instanceId, name, "create_database", &fakeDatabase{
+		ddl: append([]string(nil), extraStatements...),
+	}, database.Database_READY)
+}
+
+// createDatabase adds db as the database name of instanceId, creating until
+// the operation verb leaves it in the ready state.
+func (f *Fake) createDatabase(instanceId string, name string, verb string, db *fakeDatabase, ready database.Database_State) (string, error) {
+	key := instanceId + "/" + name
+	if _, ok := f.databases[key]; ok {
+		return "", fakeAlreadyExists("database", f.databaseName(instanceId, name))
+	}
+	if db.database == nil {
+		db.database = &database.Database{}
+	}
+	db.database.Name = f.databaseName(instanceId, name)
+	db.database.State = database.Database_CREATING
+	db.database.CreateTime = timestamppb.Now()
+	f.databases[key] = db
+	return f.startOperation(db.database.Name, verb, func() {
+		db.database.State = ready
+	}, func() {
+		delete(f.databases, key)
+	}), nil
+}
+
+func (f *Fake) GetDatabase(ctx context.Context, instanceId string, name string) (*database.Database, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "GetDatabase"); err != nil {
+		return nil, err
+	}
+	db, err := f.database(instanceId, name)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Clone(db.database).(*database.Database), nil
+}
+
+func (f *Fake) ListDatabases(ctx context.Context, instanceId string, pageSize int32, pageToken string) ([]*database.Database, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "ListDatabases"); err != nil {
+		return nil, "", err
+	}
+	if _, ok := f.instances[instanceId]; !ok {
+		return nil, "", fakeNotFound("instance", instanceId)
+	}
+	prefix := instanceId + "/"
+	var names []string
+	for key := range f.databases {
+		if strings.HasPrefix(key, prefix) {
+			names = append(names, strings.TrimPrefix(key, prefix))
+		}
+	}
+	sort.Strings(names)
+	lo, hi, next := page(names, pageSize, pageToken)
+	var databases []*database.Database
+	for _, name := range names[lo:hi] {
+		databases = append(databases, proto.Clone(f.databases[prefix+name].database).(*database.Database))
+	}
+	return databases, next, nil
+}
+
+func (f *Fake) UpdateDatabaseDdl(ctx context.Context, instanceId string, name string, statements []string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "UpdateDatabaseDdl"); err != nil {
+		return "", err
+	}
+	return f.updateDatabaseDdl(instanceId, name, "update_database_ddl", statements)
+}
+
+func (f *Fake) updateDatabaseDdl(instanceId string, name string, verb string, statements []string) (string, error) {
+	db, err := f.database(instanceId, name)
+	if err != nil {
+		return "", err
+	}
+	statements = append([]string(nil), statements...)
+	return f.startOperation(db.database.Name, verb, func() {
+		db.ddl = append(db.ddl, statements...)
+	}, nil), nil
+}
+
+func (f *Fake) SetDropProtection(ctx context.Context, instanceId string, name string, enabled bool) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "SetDropProtection"); err != nil {
+		return "", err
+	}
+	db, err := f.database(instanceId, name)
+	if err != nil {
+		return "", err
+	}
+	return f.startOperation(db.database.Name, "update_database", func() {
+		db.database.EnableDropProtection = enabled
+	}, nil), nil
+}
+
+func fakeDropProtected(name string) error {
+	return status.Errorf(codes.FailedPrecondition, "database %s has drop protection enabled", name)
+}
+
+func (f *Fake) DropDatabase(ctx context.Context, instanceId string, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "DropDatabase"); err != nil {
+		return err
+	}
+	db, err := f.database(instanceId, name)
+	if err != nil {
+		return err
+	}
+	if db.database.EnableDropProtection {
+		return fakeDropProtected(db.database.Name)
+	}
+	delete(f.databases, instanceId+"/"+name)
+	return nil
+}
+
+func (f *Fake) GetAppliedMigrations(ctx context.Context, instanceId string, name string) ([]Migration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "GetAppliedMigrations"); err != nil {
+		return nil, err
+	}
+	db, err := f.database(instanceId, name)
+	if err != nil {
+		return nil, err
+	}
+	return append([]Migration(nil), db.migrations...), nil
+}
+
+func (f *Fake) ApplyMigration(ctx context.Context, instanceId string, name string, migration Migration) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "ApplyMigration"); err != nil {
+		return "", err
+	}
+	return f.updateDatabaseDdl(instanceId, name, "apply_migration", migration.Statements)
+}
+
+func (f *Fake) RecordMigration(ctx context.Context, instanceId string, name string, migration Migration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "RecordMigration"); err != nil {
+		return err
+	}
+	db, err := f.database(instanceId, name)
+	if err != nil {
+		return err
+	}
+	migration.Statements = nil
+	for i, m := range db.migrations {
+		if m.Version == migration.Version {
+			db.migrations[i] = migration
+			return nil
+		}
+	}
+	db.migrations = append(db.migrations, migration)
+	return nil
+}
+
+func (f *Fake) CreateBackup(ctx context.Context, instanceId string, backupId string, databaseName string, expireTime time.Time, versionTime time.Time) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "CreateBackup"); err != nil {
+		return "", err
+	}
+	db, err := f.database(instanceId, databaseName)
+	if err != nil {
+		return "", err
+	}
+	key := instanceId + "/" + backupId
+	if _, ok := f.backups[key]; ok {
+		return "", fakeAlreadyExists("backup", f.backupName(instanceId, backupId))
+	}
+	now := time.Now()
+	if versionTime.IsZero() {
+		versionTime = now
+	}
+	backup := &mockBackup{
+		Backup: &database.Backup{
+			Name:        f.backupName(instanceId, backupId),
+			Database:    db.database.Name,
+			ExpireTime:  timestamppb.New(expireTime),
+			VersionTime: timestamppb.New(versionTime),
+			CreateTime:  timestamppb.New(now),
+			State:       database.Backup_CREATING,
+		},
+		Ddl:        append([]string(nil), db.ddl...),
+		Migrations: append([]Migration(nil), db.migrations...),
+	}
+	f.backups[key] = backup
+	return f.startOperation(backup.Backup.Name, "create_backup", func() {
+		backup.Backup.State = database.Backup_READY
+	}, func() {
+		delete(f.backups, key)
+	}), nil
+}
+
+// backup returns the backup backupId of instanceId, or a not found error.
+func (f *Fake) backup(instanceId string, backupId string) (*mockBackup, error) {
+	backup, ok := f.backups[instanceId+"/"+backupId]
+	if !ok {
+		return nil, fakeNotFound("backup", f.backupName(instanceId, backupId))
+	}
+	return backup, nil
+}
+
+func (f *Fake) GetBackup(ctx context.Context, instanceId string, backupId string) (*database.Backup, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "GetBackup"); err != nil {
+		return nil, err
+	}
+	backup, err := f.backup(instanceId, backupId)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Clone(backup.Backup).(*database.Backup), nil
+}
+
+func (f *Fake) ListBackups(ctx context.Context, instanceId string, databaseName string) ([]*database.Backup, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "ListBackups"); err != nil {
+		return nil, err
+	}
+	if _, ok := f.instances[instanceId]; !ok {
+		return nil, fakeNotFound("instance", instanceId)
+	}
+	prefix := instanceId + "/"
+	var backups []*database.Backup
+	for key, backup := range f.backups {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if databaseName != "" && backup.Backup.Database != f.databaseName(instanceId, databaseName) {
+			continue
+		}
+		backups = append(backups, proto.Clone(backup.Backup).(*database.Backup))
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Name < backups[j].Name
+	})
+	return backups, nil
+}
+
+func (f *Fake) DeleteBackup(ctx context.Context, instanceId string, backupId string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "DeleteBackup"); err != nil {
+		return err
+	}
+	if _, err := f.backup(instanceId, backupId); err != nil {
+		return err
+	}
+	delete(f.backups, instanceId+"/"+backupId)
+	return nil
+}
+
+func (f *Fake) UpdateBackupExpireTime(ctx context.Context, instanceId string, backupId string, expireTime time.Time) (*database.Backup, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "UpdateBackupExpireTime"); err != nil {
+		return nil, err
+	}
+	backup, err := f.backup(instanceId, backupId)
+	if err != nil {
+		return nil, err
+	}
+	backup.Backup.ExpireTime = timestamppb.New(expireTime)
+	return proto.Clone(backup.Backup).(*database.Backup), nil
+}
+
+func (f *Fake) RestoreDatabase(ctx context.Context, instanceId string, name string, backupInstanceId string, backupId string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "RestoreDatabase"); err != nil {
+		return "", err
+	}
+	backup, err := f.backup(backupInstanceId, backupId)
+	if err != nil {
+		return "", err
+	}
+	return f.createDatabase(instanceId, name, "restore_database", &fakeDatabase{
+		database: &database.Database{
+			RestoreInfo: &database.RestoreInfo{
+				SourceType: database.RestoreSourceType_BACKUP,
+				SourceInfo: &database.RestoreInfo_BackupInfo{
+					BackupInfo: &database.BackupInfo{
+						Backup:         backup.Backup.Name,
+						VersionTime:    backup.Backup.VersionTime,
+						CreateTime:     backup.Backup.CreateTime,
+						SourceDatabase: backup.Backup.Database,
+					},
+				},
+			},
+		},
+		ddl:        append([]string(nil), backup.Ddl...),
+		migrations: append([]Migration(nil), backup.Migrations...),
+	}, database.Database_READY)
+}
+
+func (f *Fake) GetDatabaseRoleGrants(ctx context.Context, instanceId string, name string, role string) ([]DatabaseRoleGrant, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "GetDatabaseRoleGrants"); err != nil {
+		return nil, err
+	}
+	db, err := f.database(instanceId, name)
+	if err != nil {
+		return nil, err
+	}
+	held, ok := replayDatabaseRoles(db.ddl)[role]
+	if !ok {
+		return nil, fakeNotFound("database role", role)
+	}
+	grants := make([]DatabaseRoleGrant, 0, len(held))
+	for g := range held {
+		grants = append(grants, g)
+	}
+	SortDatabaseRoleGrants(grants)
+	return grants, nil
+}
+
+// iamPolicy returns a pointer to where the policy of the instance, or of its
+// database databaseName, is stored.
+func (f *Fake) iamPolicy(instanceId string, databaseName string) (**iam.Policy, error) {
+	if databaseName != "" {
+		db, err := f.database(instanceId, databaseName)
+		if err != nil {
+			return nil, err
+		}
+		return &db.iam, nil
+	}
+	i, ok := f.instances[instanceId]
+	if !ok {
+		return nil, fakeNotFound("instance", instanceId)
+	}
+	return &i.iam, nil
+}
+
+func (f *Fake) GetIamPolicy(ctx context.Context, instanceId string, databaseName string) (*iam.Policy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "GetIamPolicy"); err != nil {
+		return nil, err
+	}
+	policy, err := f.iamPolicy(instanceId, databaseName)
+	if err != nil {
+		return nil, err
+	}
+	if *policy == nil {
+		return &iam.Policy{}, nil
+	}
+	return proto.Clone(*policy).(*iam.Policy), nil
+}
+
+func (f *Fake) SetIamPolicy(ctx context.Context, instanceId string, databaseName string, policy *iam.Policy) (*iam.Policy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "SetIamPolicy"); err != nil {
+		return nil, err
+	}
+	current, err := f.iamPolicy(instanceId, databaseName)
+	if err != nil {
+		return nil, err
+	}
+	// Like Spanner, refuse a policy read before the last write.
+	if len(policy.Etag) > 0 && (*current == nil || !bytes.Equal(policy.Etag, (*current).Etag)) {
+		return nil, status.Errorf(codes.Aborted, "etag of the IAM policy of %s is stale", iamResource(f.projectId, instanceId, databaseName))
+	}
+	f.etag++
+	updated := proto.Clone(policy).(*iam.Policy)
+	updated.Etag = []byte(strconv.FormatInt(f.etag, 36))
+	*current = updated
+	return proto.Clone(updated).(*iam.Policy), nil
+}
+
+// TestIamPermissions grants every permission, the fake has no notion of the
+// caller.
+func (f *Fake) TestIamPermissions(ctx context.Context, instanceId string, databaseName string, permissions []string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(ctx, "TestIamPermissions"); err != nil {
+		return nil, err
+	}
+	if _, err := f.iamPolicy(instanceId, databaseName); err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
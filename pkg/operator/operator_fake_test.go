@@ -0,0 +1,188 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+	"google.golang.org/genproto/googleapis/spanner/admin/instance/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFakeFailNext(t *testing.T) {
+	f := NewBuilder().ProjectId("test").BuildFake()
+	ctx := context.Background()
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	exhausted := status.Error(codes.ResourceExhausted, "exhausted")
+	f.FailNext("CreateInstance", unavailable)
+	f.FailNext("CreateInstance", exhausted)
+
+	// Queued errors are returned in order, one per call, without effect.
+	for _, expected := range []error{unavailable, exhausted} {
+		if _, err := f.CreateInstance(ctx, "testing", "testing", "regional-asia-northeast1", Nodes(1)); err != expected {
+			t.Fatalf("expected %v, got %v", expected, err)
+		}
+		if _, err := f.GetInstance(ctx, "testing"); !f.IsNotFoundError(err) {
+			t.Fatalf("expected a failed call to create no instance, got %v", err)
+		}
+	}
+	if _, err := f.CreateInstance(ctx, "testing", "testing", "regional-asia-northeast1", Nodes(1)); err != nil {
+		t.Fatalf("expected the errors to be used up, got %v", err)
+	}
+}
+
+func TestFakeFailNextOperation(t *testing.T) {
+	f := NewBuilder().ProjectId("test").BuildFake()
+	ctx := context.Background()
+	opErr := status.Error(codes.ResourceExhausted, "not enough nodes in the region")
+	f.FailNextOperation(opErr)
+
+	name, err := f.CreateInstance(ctx, "testing", "testing", "regional-asia-northeast1", Nodes(1))
+	if err != nil {
+		t.Fatalf("expected the operation to start, got %v", err)
+	}
+	op, err := f.GetOperation(ctx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !op.Done || op.Err != opErr {
+		t.Errorf("expected the operation to be done with %v, got %+v", opErr, op)
+	}
+	if _, err := f.GetInstance(ctx, "testing"); !f.IsNotFoundError(err) {
+		t.Errorf("expected the failed creation to be rolled back, got %v", err)
+	}
+
+	name, err = f.CreateInstance(ctx, "testing", "testing", "regional-asia-northeast1", Nodes(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op, err := f.GetOperation(ctx, name); err != nil || !op.Done || op.Err != nil {
+		t.Errorf("expected the error to be used up, got %+v and %v", op, err)
+	}
+}
+
+func TestFakeOperationLatency(t *testing.T) {
+	f := NewBuilder().ProjectId("test").BuildFake()
+	ctx := context.Background()
+	f.SetOperationLatency(time.Hour)
+
+	name, err := f.CreateInstance(ctx, "testing", "testing", "regional-asia-northeast1", Nodes(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i, err := f.GetInstance(ctx, "testing"); err != nil || i.State != instance.Instance_CREATING {
+		t.Fatalf("expected the instance to be creating, got %v and %v", i, err)
+	}
+	if op, err := f.GetOperation(ctx, name); err != nil || op.Done {
+		t.Fatalf("expected the operation to be running, got %+v and %v", op, err)
+	}
+	f.FinishOperations()
+	if i, err := f.GetInstance(ctx, "testing"); err != nil || i.State != instance.Instance_READY {
+		t.Fatalf("expected the instance to be ready, got %v and %v", i, err)
+	}
+
+	// Updates only show once their operation is done.
+	if _, err := f.Scale(ctx, "testing", Nodes(3)); err != nil {
+		t.Fatal(err)
+	}
+	if i, err := f.GetInstance(ctx, "testing"); err != nil || i.NodeCount != 1 {
+		t.Errorf("expected the instance to keep 1 node while scaling, got %v and %v", i, err)
+	}
+
+	// Operations finish by themselves once their latency has passed.
+	f.SetOperationLatency(10 * time.Millisecond)
+	if _, err := f.CreateDatabase(ctx, "testing", "test", nil); err != nil {
+		t.Fatal(err)
+	}
+	if db, err := f.GetDatabase(ctx, "testing", "test"); err != nil || db.State != database.Database_CREATING {
+		t.Fatalf("expected the database to be creating, got %v and %v", db, err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if db, err := f.GetDatabase(ctx, "testing", "test"); err != nil || db.State != database.Database_READY {
+		t.Errorf("expected the database to be ready, got %v and %v", db, err)
+	}
+	// The scale started before, with an hour to run, is still pending.
+	if i, err := f.GetInstance(ctx, "testing"); err != nil || i.NodeCount != 1 {
+		t.Errorf("expected the instance to keep 1 node while scaling, got %v and %v", i, err)
+	}
+	f.FinishOperations()
+	if i, err := f.GetInstance(ctx, "testing"); err != nil || i.NodeCount != 3 {
+		t.Errorf("expected the instance to be scaled to 3 nodes, got %v and %v", i, err)
+	}
+}
+
+// The fake is shared by the workers of a controller. Run with -race.
+func TestFakeConcurrentWorkers(t *testing.T) {
+	f := NewBuilder().ProjectId("test").BuildFake()
+	ctx := context.Background()
+	f.SetOperationLatency(time.Millisecond)
+	if _, err := f.CreateInstance(ctx, "testing", "testing", "regional-asia-northeast1", Nodes(1)); err != nil {
+		t.Fatal(err)
+	}
+	f.FinishOperations()
+
+	const workers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			name := fmt.Sprintf("test%d", w)
+			f.FailNext("GetDatabase", status.Error(codes.Unavailable, "unavailable"))
+			op, err := f.CreateDatabase(ctx, "testing", name, []string{createUsers})
+			if err != nil {
+				errs <- err
+				return
+			}
+			if _, err := f.Scale(ctx, "testing", Nodes(int32(w+1))); err != nil {
+				errs <- err
+				return
+			}
+			for {
+				o, err := f.GetOperation(ctx, op)
+				if err != nil || o.Err != nil {
+					errs <- fmt.Errorf("operation %s failed: %v %v", op, err, o)
+					return
+				}
+				if o.Done {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+			for {
+				_, err := f.GetDatabase(ctx, "testing", name)
+				if err == nil {
+					break
+				}
+				if status.Code(err) != codes.Unavailable {
+					errs <- err
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	f.FinishOperations()
+	databases, _, err := f.ListDatabases(ctx, "testing", 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(databases) != workers {
+		t.Errorf("expected %d databases, got %d", workers, len(databases))
+	}
+	for _, db := range databases {
+		if db.State != database.Database_READY {
+			t.Errorf("expected database %s to be ready, got %s", db.Name, db.State)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package operator
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
@@ -8,125 +10,984 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/iam/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// mockFormatVersion is the version of the on-disk layout of the mock. Bump
+// it whenever the layout changes, so a dataset written by an older spnadm or
+// controller is refused instead of misread.
+const mockFormatVersion = 1
+
+// mockFormatFile records the format version in the data directory.
+const mockFormatFile = "format.json"
+
+// operatorMock keeps the resources of a project in a tree of files below
+// dataDir, so spnadm and the controller can share it:
+//
+//	format.json
+//	instances/<instanceId>/instance.json
+//	instances/<instanceId>/iam.json
+//	instances/<instanceId>/databases/<name>/{database,ddl,migrations,iam}.json
+//	instances/<instanceId>/backups/<backupId>/{backup,ddl,migrations}.json
+//
+// Resources are only created below an existing parent, and deleting an
+// instance deletes its databases and is refused while it has backups, like
+// Spanner does.
 type operatorMock struct {
 	projectId string
 	dataDir   string
+	// mu serializes the changes of the controller workers sharing the mock.
+	mu sync.Mutex
+}
+
+type mockFormat struct {
+	Version int `json:"version"`
+}
+
+// newOperatorMock returns a mock keeping its files in dataDir, which is
+// created with the current format version when it does not exist yet. It
+// fails when dataDir holds a dataset of another format version.
+func newOperatorMock(projectId string, dataDir string) (*operatorMock, error) {
+	var format mockFormat
+	err := readMockJSON(filepath.Join(dataDir, mockFormatFile), &format)
+	if os.IsNotExist(err) {
+		format.Version, err = unversionedMockFormat(dataDir)
+		if err == nil && format.Version == mockFormatVersion {
+			err = writeMockJSON(filepath.Join(dataDir, mockFormatFile), format)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if format.Version != mockFormatVersion {
+		return nil, fmt.Errorf("mock data in %s has format version %d, expected %d: remove it to start over", dataDir, format.Version, mockFormatVersion)
+	}
+	return &operatorMock{
+		projectId: projectId,
+		dataDir:   dataDir,
+	}, nil
+}
+
+// unversionedMockFormat returns the format version of dataDir without a
+// format file: 0 when it holds the flat instance_<id>.json and
+// database_<name>.json files of the first mock, the current version when it
+// holds no dataset yet.
+func unversionedMockFormat(dataDir string) (int, error) {
+	for _, pattern := range []string{"instance_*.json", "database_*.json"} {
+		matches, err := filepath.Glob(filepath.Join(dataDir, pattern))
+		if err != nil {
+			return 0, err
+		}
+		if len(matches) > 0 {
+			return 0, nil
+		}
+	}
+	return mockFormatVersion, nil
+}
+
+// begin returns the error a method should fail with before doing anything.
+func (om *operatorMock) begin(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func mockNotExist(op string, name string) error {
+	return &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+}
+
+func mockExist(op string, name string) error {
+	return &os.PathError{Op: op, Path: name, Err: os.ErrExist}
+}
+
+func mockDropProtected(op string, name string) error {
+	return &os.PathError{Op: op, Path: name, Err: os.ErrPermission}
+}
+
+func mockHasBackups(op string, name string, backupName string) error {
+	return &os.PathError{Op: op, Path: name, Err: fmt.Errorf("instance has backups, such as %s", backupName)}
 }
 
 func (om *operatorMock) IsNotFoundError(err error) bool {
 	return os.IsNotExist(err)
 }
 
-func (om *operatorMock) CreateInstance(displayName string, instanceId string, instanceConfig string, nodeCount int32) error {
+// Close is a no-op, the mock holds no open files between calls.
+func (om *operatorMock) Close() error {
+	return nil
+}
+
+func (om *operatorMock) instanceName(instanceId string) string {
+	return fmt.Sprintf("projects/%s/instances/%s", om.projectId, instanceId)
+}
+
+func (om *operatorMock) databaseName(instanceId string, name string) string {
+	return fmt.Sprintf("%s/databases/%s", om.instanceName(instanceId), name)
+}
+
+func (om *operatorMock) backupName(instanceId string, backupId string) string {
+	return fmt.Sprintf("%s/backups/%s", om.instanceName(instanceId), backupId)
+}
+
+func (om *operatorMock) instanceDir(instanceId string) string {
+	return filepath.Join(om.dataDir, "instances", instanceId)
+}
+
+func (om *operatorMock) databaseDir(instanceId string, name string) string {
+	return filepath.Join(om.instanceDir(instanceId), "databases", name)
+}
+
+func (om *operatorMock) backupDir(instanceId string, backupId string) string {
+	return filepath.Join(om.instanceDir(instanceId), "backups", backupId)
+}
+
+// requireInstance fails with a not found error unless instanceId exists.
+func (om *operatorMock) requireInstance(op string, instanceId string) error {
+	if _, err := os.Stat(filepath.Join(om.instanceDir(instanceId), "instance.json")); os.IsNotExist(err) {
+		return mockNotExist(op, om.instanceName(instanceId))
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// requireDatabase fails with a not found error unless the database name of
+// instanceId exists.
+func (om *operatorMock) requireDatabase(op string, instanceId string, name string) error {
+	if _, err := os.Stat(filepath.Join(om.databaseDir(instanceId, name), "database.json")); os.IsNotExist(err) {
+		return mockNotExist(op, om.databaseName(instanceId, name))
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// writeMockFile replaces path with data through a rename, so a process sharing
+// the dataset never reads a partially written file.
+func writeMockFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func writeMockJSON(path string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeMockFile(path, b)
+}
+
+func readMockJSON(path string, v interface{}) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// writeMockProto and readMockProto store messages with protojson, as encoding/json
+// cannot decode oneofs such as the restore info of a database.
+func writeMockProto(path string, m proto.Message) error {
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return writeMockFile(path, b)
+}
+
+func readMockProto(path string, m proto.Message) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return protojson.Unmarshal(b, m)
+}
+
+// mockOperationName returns the name of the operation the mock reports for
+// verb on the resource. Mock operations finish before they are returned.
+func mockOperationName(resourceName string, verb string) string {
+	return fmt.Sprintf("%s/operations/mock_%s", resourceName, verb)
+}
+
+func (om *operatorMock) GetOperation(ctx context.Context, name string) (*Operation, error) {
+	if err := om.begin(ctx); err != nil {
+		return nil, err
+	}
+	return &Operation{
+		Name:     name,
+		Done:     true,
+		Progress: 100,
+	}, nil
+}
+
+func (om *operatorMock) WaitOperation(ctx context.Context, name string) error {
+	return waitOperation(ctx, om, name)
+}
+
+// mockCapacity sets the node count and processing units of instanceInfo the
+// way Spanner reports them: both for whole nodes, only processing units below
+// one node.
+func mockCapacity(instanceInfo *instance.Instance, capacity Capacity) {
+	if capacity.InProcessingUnits() {
+		instanceInfo.NodeCount = capacity.ProcessingUnits / ProcessingUnitsPerNode
+		instanceInfo.ProcessingUnits = capacity.ProcessingUnits
+		return
+	}
+	instanceInfo.NodeCount = capacity.NodeCount
+	instanceInfo.ProcessingUnits = capacity.NodeCount * ProcessingUnitsPerNode
+}
+
+func (om *operatorMock) CreateInstance(ctx context.Context, displayName string, instanceId string, instanceConfig string, capacity Capacity) (string, error) {
 	log.Print("Create instance...")
-	instanceName := fmt.Sprintf("projects/%s/instances/%s", om.projectId, instanceId)
-	b, err := json.Marshal(instance.Instance{
+	if err := om.begin(ctx); err != nil {
+		return "", err
+	}
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	instanceName := om.instanceName(instanceId)
+	if err := om.requireInstance("create instance", instanceId); err == nil {
+		return "", mockExist("create instance", instanceName)
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+	instanceInfo := &instance.Instance{
 		Name:        instanceName,
 		Config:      instanceConfig,
 		DisplayName: displayName,
-		NodeCount:   nodeCount,
 		State:       instance.Instance_READY,
 		Labels:      map[string]string{"mock": "true"},
+		CreateTime:  timestamppb.Now(),
+	}
+	mockCapacity(instanceInfo, capacity)
+	if err := writeMockProto(filepath.Join(om.instanceDir(instanceId), "instance.json"), instanceInfo); err != nil {
+		return "", err
+	}
+	return mockOperationName(instanceName, "create_instance"), nil
+}
+
+func (om *operatorMock) GetInstance(ctx context.Context, instanceId string) (*instance.Instance, error) {
+	log.Print("Get instance...")
+	if err := om.begin(ctx); err != nil {
+		return nil, err
+	}
+	return om.readInstance("get instance", instanceId)
+}
+
+func (om *operatorMock) ListInstances(ctx context.Context, pageSize int32, pageToken string) ([]*instance.Instance, string, error) {
+	log.Print("List instances...")
+	if err := om.begin(ctx); err != nil {
+		return nil, "", err
+	}
+	ids, err := mockDirNames(filepath.Join(om.dataDir, "instances"))
+	if err != nil {
+		return nil, "", err
+	}
+	lo, hi, next := page(ids, pageSize, pageToken)
+	var instances []*instance.Instance
+	for _, id := range ids[lo:hi] {
+		instanceInfo, err := om.readInstance("list instances", id)
+		if os.IsNotExist(err) {
+			// Deleted while listing.
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		instances = append(instances, instanceInfo)
+	}
+	return instances, next, nil
+}
+
+// mockDirNames returns the sorted names of the directories in dir, none when
+// dir does not exist.
+func mockDirNames(dir string) ([]string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, info := range infos {
+		if info.IsDir() {
+			names = append(names, info.Name())
+		}
+	}
+	return names, nil
+}
+
+func (om *operatorMock) readInstance(op string, instanceId string) (*instance.Instance, error) {
+	instanceInfo := &instance.Instance{}
+	err := readMockProto(filepath.Join(om.instanceDir(instanceId), "instance.json"), instanceInfo)
+	if os.IsNotExist(err) {
+		return nil, mockNotExist(op, om.instanceName(instanceId))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return instanceInfo, nil
+}
+
+// updateInstance applies update to the stored instance.
+func (om *operatorMock) updateInstance(op string, instanceId string, update func(*instance.Instance)) (*instance.Instance, error) {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	instanceInfo, err := om.readInstance(op, instanceId)
+	if err != nil {
+		return nil, err
+	}
+	update(instanceInfo)
+	if err := writeMockProto(filepath.Join(om.instanceDir(instanceId), "instance.json"), instanceInfo); err != nil {
+		return nil, err
+	}
+	return instanceInfo, nil
+}
+
+func (om *operatorMock) Scale(ctx context.Context, instanceId string, capacity Capacity) (string, error) {
+	log.Printf("Scale to %s...", capacity)
+	if err := om.begin(ctx); err != nil {
+		return "", err
+	}
+	instanceInfo, err := om.updateInstance("scale", instanceId, func(instanceInfo *instance.Instance) {
+		mockCapacity(instanceInfo, capacity)
 	})
+	if err != nil {
+		return "", err
+	}
+	return mockOperationName(instanceInfo.Name, "scale"), nil
+}
+
+// DeleteInstance deletes the databases of the instance with it, and fails
+// while the instance has backups.
+func (om *operatorMock) DeleteInstance(ctx context.Context, instanceId string) error {
+	log.Print("Delete instance...")
+	if err := om.begin(ctx); err != nil {
+		return err
+	}
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	if err := om.requireInstance("delete instance", instanceId); err != nil {
+		return err
+	}
+	names, err := mockDirNames(filepath.Join(om.instanceDir(instanceId), "databases"))
 	if err != nil {
 		return err
 	}
-	err = ioutil.WriteFile(fmt.Sprintf("%s/instance_%s.json", om.dataDir, instanceId), b, 0755)
-	return err
+	for _, name := range names {
+		databaseInfo, err := om.readDatabase("delete instance", instanceId, name)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if databaseInfo.EnableDropProtection {
+			return mockDropProtected("delete instance", databaseInfo.Name)
+		}
+	}
+	backupIds, err := mockDirNames(filepath.Join(om.instanceDir(instanceId), "backups"))
+	if err != nil {
+		return err
+	}
+	if len(backupIds) > 0 {
+		return mockHasBackups("delete instance", om.instanceName(instanceId), om.backupName(instanceId, backupIds[0]))
+	}
+	return os.RemoveAll(om.instanceDir(instanceId))
 }
 
-func (om *operatorMock) GetInstance(instanceId string) (*instance.Instance, error) {
-	log.Print("Get instance...")
-	instanceName := fmt.Sprintf("%s/instance_%s.json", om.dataDir, instanceId)
-	_, err := os.Stat(instanceName)
+func (om *operatorMock) UpdateLabels(ctx context.Context, instanceId string, labels map[string]string) (string, error) {
+	log.Printf("Update labels to %+v...", labels)
+	if err := om.begin(ctx); err != nil {
+		return "", err
+	}
+	instanceInfo, err := om.updateInstance("update labels", instanceId, func(instanceInfo *instance.Instance) {
+		instanceInfo.Labels = labels
+	})
+	if err != nil {
+		return "", err
+	}
+	return mockOperationName(instanceInfo.Name, "update_labels"), nil
+}
+
+// mockInstanceConfigs are the instance configs every mock project can use.
+var mockInstanceConfigs = []string{
+	"eur3",
+	"nam-eur-asia1",
+	"nam3",
+	"nam6",
+	"regional-asia-east1",
+	"regional-asia-east2",
+	"regional-asia-northeast1",
+	"regional-asia-northeast2",
+	"regional-asia-south1",
+	"regional-asia-southeast1",
+	"regional-australia-southeast1",
+	"regional-europe-north1",
+	"regional-europe-west1",
+	"regional-europe-west2",
+	"regional-europe-west4",
+	"regional-europe-west6",
+	"regional-northamerica-northeast1",
+	"regional-us-central1",
+	"regional-us-east1",
+	"regional-us-east4",
+	"regional-us-west1",
+}
+
+func (om *operatorMock) ListInstanceConfigs(ctx context.Context) ([]*instance.InstanceConfig, error) {
+	if err := om.begin(ctx); err != nil {
+		return nil, err
+	}
+	configs := make([]*instance.InstanceConfig, 0, len(mockInstanceConfigs))
+	for _, id := range mockInstanceConfigs {
+		configs = append(configs, &instance.InstanceConfig{
+			Name:        fmt.Sprintf("projects/%s/instanceConfigs/%s", om.projectId, id),
+			DisplayName: id,
+		})
+	}
+	return configs, nil
+}
+
+func (om *operatorMock) GetInstanceConfig(ctx context.Context, configId string) (*instance.InstanceConfig, error) {
+	configs, err := om.ListInstanceConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range configs {
+		if instanceConfigId(c.Name) == configId {
+			return c, nil
+		}
+	}
+	return nil, mockNotExist("get instance config", configId)
+}
+
+func (om *operatorMock) CreateDatabase(ctx context.Context, instanceId string, name string, extraStatements []string) (string, error) {
+	log.Print("Create database...")
+	if err := om.begin(ctx); err != nil {
+		return "", err
+	}
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	databaseName := om.databaseName(instanceId, name)
+	err := om.createDatabase("create database", instanceId, name, &database.Database{
+		Name:  databaseName,
+		State: database.Database_READY,
+	}, extraStatements, nil)
+	if err != nil {
+		return "", err
+	}
+	return mockOperationName(databaseName, "create_database"), nil
+}
+
+// createDatabase stores a new database in an existing instance.
+func (om *operatorMock) createDatabase(op string, instanceId string, name string, databaseInfo *database.Database, ddl []string, migrations []Migration) error {
+	if err := om.requireInstance(op, instanceId); err != nil {
+		return err
+	}
+	if err := om.requireDatabase(op, instanceId, name); err == nil {
+		return mockExist(op, om.databaseName(instanceId, name))
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	databaseInfo.CreateTime = timestamppb.Now()
+	dir := om.databaseDir(instanceId, name)
+	// Write the database last, as its presence marks a complete database.
+	if err := writeMockJSON(filepath.Join(dir, "ddl.json"), ddl); err != nil {
+		return err
+	}
+	if err := writeMockJSON(filepath.Join(dir, "migrations.json"), migrations); err != nil {
+		return err
+	}
+	return writeMockProto(filepath.Join(dir, "database.json"), databaseInfo)
+}
+
+func (om *operatorMock) UpdateDatabaseDdl(ctx context.Context, instanceId string, name string, statements []string) (string, error) {
+	log.Printf("Update database ddl with %d statements...", len(statements))
+	if err := om.begin(ctx); err != nil {
+		return "", err
+	}
+	return om.updateDatabaseDdl("update database ddl", instanceId, name, "update_database_ddl", statements)
+}
+
+func (om *operatorMock) updateDatabaseDdl(op string, instanceId string, name string, verb string, statements []string) (string, error) {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	applied, err := om.readDdl(op, instanceId, name)
+	if err != nil {
+		return "", err
+	}
+	err = writeMockJSON(filepath.Join(om.databaseDir(instanceId, name), "ddl.json"), append(applied, statements...))
 	if err != nil {
+		return "", err
+	}
+	return mockOperationName(om.databaseName(instanceId, name), verb), nil
+}
+
+// readDdl returns the DDL statements applied to the database so far.
+func (om *operatorMock) readDdl(op string, instanceId string, name string) ([]string, error) {
+	if err := om.requireDatabase(op, instanceId, name); err != nil {
 		return nil, err
 	}
-	b, err := ioutil.ReadFile(instanceName)
+	var statements []string
+	err := readMockJSON(filepath.Join(om.databaseDir(instanceId, name), "ddl.json"), &statements)
 	if err != nil {
 		return nil, err
 	}
-	var instanceInfo *instance.Instance
-	err = json.Unmarshal(b, &instanceInfo)
+	return statements, nil
+}
+
+func (om *operatorMock) GetDatabase(ctx context.Context, instanceId string, name string) (*database.Database, error) {
+	log.Print("Get database...")
+	if err := om.begin(ctx); err != nil {
+		return nil, err
+	}
+	return om.readDatabase("get database", instanceId, name)
+}
+
+// readDatabase returns the stored database, or a not exist error.
+func (om *operatorMock) readDatabase(op string, instanceId string, name string) (*database.Database, error) {
+	databaseInfo := &database.Database{}
+	err := readMockProto(filepath.Join(om.databaseDir(instanceId, name), "database.json"), databaseInfo)
+	if os.IsNotExist(err) {
+		return nil, mockNotExist(op, om.databaseName(instanceId, name))
+	}
 	if err != nil {
 		return nil, err
 	}
-	return instanceInfo, nil
+	return databaseInfo, nil
 }
 
-func (om *operatorMock) Scale(instanceId string, nodeCount int32) error {
-	log.Printf("Scale node to %d...", nodeCount)
-	b, err := ioutil.ReadFile(fmt.Sprintf("%s/instance_%s.json", om.dataDir, instanceId))
+func (om *operatorMock) ListDatabases(ctx context.Context, instanceId string, pageSize int32, pageToken string) ([]*database.Database, string, error) {
+	log.Print("List databases...")
+	if err := om.begin(ctx); err != nil {
+		return nil, "", err
+	}
+	if err := om.requireInstance("list databases", instanceId); err != nil {
+		return nil, "", err
+	}
+	names, err := mockDirNames(filepath.Join(om.instanceDir(instanceId), "databases"))
 	if err != nil {
+		return nil, "", err
+	}
+	lo, hi, next := page(names, pageSize, pageToken)
+	var databases []*database.Database
+	for _, name := range names[lo:hi] {
+		databaseInfo := &database.Database{}
+		err := readMockProto(filepath.Join(om.databaseDir(instanceId, name), "database.json"), databaseInfo)
+		if os.IsNotExist(err) {
+			// Dropped while listing.
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		databases = append(databases, databaseInfo)
+	}
+	return databases, next, nil
+}
+
+func (om *operatorMock) SetDropProtection(ctx context.Context, instanceId string, name string, enabled bool) (string, error) {
+	log.Printf("Set drop protection to %t...", enabled)
+	if err := om.begin(ctx); err != nil {
+		return "", err
+	}
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	databaseInfo, err := om.readDatabase("set drop protection", instanceId, name)
+	if err != nil {
+		return "", err
+	}
+	databaseInfo.EnableDropProtection = enabled
+	if err := writeMockProto(filepath.Join(om.databaseDir(instanceId, name), "database.json"), databaseInfo); err != nil {
+		return "", err
+	}
+	return mockOperationName(databaseInfo.Name, "update_database"), nil
+}
+
+func (om *operatorMock) DropDatabase(ctx context.Context, instanceId string, name string) error {
+	log.Print("Drop database...")
+	if err := om.begin(ctx); err != nil {
 		return err
 	}
-	var instanceInfo *instance.Instance
-	err = json.Unmarshal(b, &instanceInfo)
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	databaseInfo, err := om.readDatabase("drop database", instanceId, name)
 	if err != nil {
 		return err
 	}
-	instanceInfo.NodeCount = nodeCount
-	b, err = json.Marshal(instanceInfo)
-	err = ioutil.WriteFile(fmt.Sprintf("%s/instance_%s.json", om.dataDir, instanceId), b, 0755)
-	return nil
+	if databaseInfo.EnableDropProtection {
+		return mockDropProtected("drop database", databaseInfo.Name)
+	}
+	return os.RemoveAll(om.databaseDir(instanceId, name))
 }
 
-func (om *operatorMock) DeleteInstance(instanceId string) error {
-	log.Print("Delete instance...")
-	err := os.Remove(fmt.Sprintf("%s/instance_%s.json", om.dataDir, instanceId))
-	return err
+func (om *operatorMock) GetAppliedMigrations(ctx context.Context, instanceId string, name string) ([]Migration, error) {
+	log.Print("Get applied migrations...")
+	if err := om.begin(ctx); err != nil {
+		return nil, err
+	}
+	return om.readMigrations("get applied migrations", instanceId, name)
 }
 
-func (om *operatorMock) UpdateLabels(instanceId string, labels map[string]string) error {
-	log.Printf("Update labels to %+v...", labels)
-	b, err := ioutil.ReadFile(fmt.Sprintf("%s/instance_%s.json", om.dataDir, instanceId))
+func (om *operatorMock) readMigrations(op string, instanceId string, name string) ([]Migration, error) {
+	if err := om.requireDatabase(op, instanceId, name); err != nil {
+		return nil, err
+	}
+	var migrations []Migration
+	err := readMockJSON(filepath.Join(om.databaseDir(instanceId, name), "migrations.json"), &migrations)
 	if err != nil {
+		return nil, err
+	}
+	return migrations, nil
+}
+
+func (om *operatorMock) ApplyMigration(ctx context.Context, instanceId string, name string, migration Migration) (string, error) {
+	log.Printf("Apply migration %d...", migration.Version)
+	if err := om.begin(ctx); err != nil {
+		return "", err
+	}
+	return om.updateDatabaseDdl("apply migration", instanceId, name, "apply_migration", migration.Statements)
+}
+
+func (om *operatorMock) RecordMigration(ctx context.Context, instanceId string, name string, migration Migration) error {
+	log.Printf("Record migration %d...", migration.Version)
+	if err := om.begin(ctx); err != nil {
 		return err
 	}
-	var instanceInfo *instance.Instance
-	err = json.Unmarshal(b, &instanceInfo)
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	migrations, err := om.readMigrations("record migration", instanceId, name)
 	if err != nil {
 		return err
 	}
-	instanceInfo.Labels = labels
-	b, err = json.Marshal(instanceInfo)
-	err = ioutil.WriteFile(fmt.Sprintf("%s/instance_%s.json", om.dataDir, instanceId), b, 0755)
-	return nil
+	migration.Statements = nil
+	recorded := false
+	for i, m := range migrations {
+		if m.Version == migration.Version {
+			migrations[i] = migration
+			recorded = true
+		}
+	}
+	if !recorded {
+		migrations = append(migrations, migration)
+	}
+	return writeMockJSON(filepath.Join(om.databaseDir(instanceId, name), "migrations.json"), migrations)
 }
 
-func (om *operatorMock) CreateDatabase(instanceId string, name string) error {
-	log.Print("Create database...")
-	databaseName := fmt.Sprintf("projects/%s/instances/%s/databases/%s", om.projectId, instanceId, name)
-	b, err := json.Marshal(database.Database{
-		Name: databaseName,
+// mockBackup is a backup along with a copy of the schema and migrations of
+// the database, which are handed to restored databases.
+type mockBackup struct {
+	Backup     *database.Backup
+	Ddl        []string
+	Migrations []Migration
+}
+
+func (om *operatorMock) CreateBackup(ctx context.Context, instanceId string, backupId string, databaseName string, expireTime time.Time, versionTime time.Time) (string, error) {
+	log.Printf("Create backup of database %s...", databaseName)
+	if err := om.begin(ctx); err != nil {
+		return "", err
+	}
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	migrations, err := om.readMigrations("create backup", instanceId, databaseName)
+	if err != nil {
+		return "", err
+	}
+	ddl, err := om.readDdl("create backup", instanceId, databaseName)
+	if err != nil {
+		return "", err
+	}
+	backupName := om.backupName(instanceId, backupId)
+	if _, err := os.Stat(om.backupDir(instanceId, backupId)); err == nil {
+		return "", mockExist("create backup", backupName)
+	}
+	now := time.Now()
+	if versionTime.IsZero() {
+		versionTime = now
+	}
+	err = om.writeBackup(instanceId, backupId, &mockBackup{
+		Backup: &database.Backup{
+			Name:        backupName,
+			Database:    om.databaseName(instanceId, databaseName),
+			ExpireTime:  timestamppb.New(expireTime),
+			VersionTime: timestamppb.New(versionTime),
+			CreateTime:  timestamppb.New(now),
+			State:       database.Backup_READY,
+		},
+		Ddl:        ddl,
+		Migrations: migrations,
 	})
-	err = ioutil.WriteFile(fmt.Sprintf("%s/database_%s.json", om.dataDir, name), b, 0755)
-	return err
+	if err != nil {
+		return "", err
+	}
+	return mockOperationName(backupName, "create_backup"), nil
 }
 
-func (om *operatorMock) GetDatabase(instanceId string, name string) (*database.Database, error) {
-	log.Print("Get database...")
-	databaseName := fmt.Sprintf("%s/database_%s.json", om.dataDir, name)
-	_, err := os.Stat(databaseName)
+func (om *operatorMock) GetBackup(ctx context.Context, instanceId string, backupId string) (*database.Backup, error) {
+	log.Print("Get backup...")
+	if err := om.begin(ctx); err != nil {
+		return nil, err
+	}
+	backup, err := om.readBackup("get backup", instanceId, backupId)
 	if err != nil {
 		return nil, err
 	}
-	b, err := ioutil.ReadFile(databaseName)
+	return backup.Backup, nil
+}
+
+func (om *operatorMock) ListBackups(ctx context.Context, instanceId string, databaseName string) ([]*database.Backup, error) {
+	log.Print("List backups...")
+	if err := om.begin(ctx); err != nil {
+		return nil, err
+	}
+	if err := om.requireInstance("list backups", instanceId); err != nil {
+		return nil, err
+	}
+	dirs, err := ioutil.ReadDir(filepath.Join(om.instanceDir(instanceId), "backups"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
-	var databaseInfo *database.Database
-	err = json.Unmarshal(b, &databaseInfo)
+	var backups []*database.Backup
+	for _, dir := range dirs {
+		backup, err := om.readBackup("list backups", instanceId, dir.Name())
+		if os.IsNotExist(err) {
+			// Deleted while listing.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if databaseName != "" && backup.Backup.Database != om.databaseName(instanceId, databaseName) {
+			continue
+		}
+		backups = append(backups, backup.Backup)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Name < backups[j].Name
+	})
+	return backups, nil
+}
+
+func (om *operatorMock) DeleteBackup(ctx context.Context, instanceId string, backupId string) error {
+	log.Print("Delete backup...")
+	if err := om.begin(ctx); err != nil {
+		return err
+	}
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	if _, err := om.readBackup("delete backup", instanceId, backupId); err != nil {
+		return err
+	}
+	return os.RemoveAll(om.backupDir(instanceId, backupId))
+}
+
+func (om *operatorMock) UpdateBackupExpireTime(ctx context.Context, instanceId string, backupId string, expireTime time.Time) (*database.Backup, error) {
+	log.Printf("Update backup expire time to %s...", expireTime)
+	if err := om.begin(ctx); err != nil {
+		return nil, err
+	}
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	backup, err := om.readBackup("update backup", instanceId, backupId)
 	if err != nil {
 		return nil, err
 	}
-	return databaseInfo, nil
+	backup.Backup.ExpireTime = timestamppb.New(expireTime)
+	err = writeMockProto(filepath.Join(om.backupDir(instanceId, backupId), "backup.json"), backup.Backup)
+	if err != nil {
+		return nil, err
+	}
+	return backup.Backup, nil
 }
 
-func (om *operatorMock) DropDatabase(instanceId string, name string) error {
-	log.Print("Drop database...")
-	err := os.Remove(fmt.Sprintf("%s/database_%s.json", om.dataDir, name))
-	return err
+func (om *operatorMock) RestoreDatabase(ctx context.Context, instanceId string, name string, backupInstanceId string, backupId string) (string, error) {
+	log.Printf("Restore database from backup %s...", backupId)
+	if err := om.begin(ctx); err != nil {
+		return "", err
+	}
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	backup, err := om.readBackup("restore database", backupInstanceId, backupId)
+	if err != nil {
+		return "", err
+	}
+	databaseName := om.databaseName(instanceId, name)
+	err = om.createDatabase("restore database", instanceId, name, &database.Database{
+		Name:  databaseName,
+		State: database.Database_READY,
+		RestoreInfo: &database.RestoreInfo{
+			SourceType: database.RestoreSourceType_BACKUP,
+			SourceInfo: &database.RestoreInfo_BackupInfo{
+				BackupInfo: &database.BackupInfo{
+					Backup:         backup.Backup.Name,
+					VersionTime:    backup.Backup.VersionTime,
+					CreateTime:     backup.Backup.CreateTime,
+					SourceDatabase: backup.Backup.Database,
+				},
+			},
+		},
+	}, backup.Ddl, backup.Migrations)
+	if err != nil {
+		return "", err
+	}
+	return mockOperationName(databaseName, "restore_database"), nil
+}
+
+func (om *operatorMock) readBackup(op string, instanceId string, backupId string) (*mockBackup, error) {
+	dir := om.backupDir(instanceId, backupId)
+	backup := &mockBackup{Backup: &database.Backup{}}
+	err := readMockProto(filepath.Join(dir, "backup.json"), backup.Backup)
+	if os.IsNotExist(err) {
+		return nil, mockNotExist(op, om.backupName(instanceId, backupId))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := readMockJSON(filepath.Join(dir, "ddl.json"), &backup.Ddl); err != nil {
+		return nil, err
+	}
+	if err := readMockJSON(filepath.Join(dir, "migrations.json"), &backup.Migrations); err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
+
+func (om *operatorMock) writeBackup(instanceId string, backupId string, backup *mockBackup) error {
+	dir := om.backupDir(instanceId, backupId)
+	// Write the backup last, as its presence marks a complete backup.
+	if err := writeMockJSON(filepath.Join(dir, "ddl.json"), backup.Ddl); err != nil {
+		return err
+	}
+	if err := writeMockJSON(filepath.Join(dir, "migrations.json"), backup.Migrations); err != nil {
+		return err
+	}
+	return writeMockProto(filepath.Join(dir, "backup.json"), backup.Backup)
+}
+
+// iamPolicyPath returns the file the policy of the resource the IAM methods
+// act on is stored in, after checking that the resource exists.
+func (om *operatorMock) iamPolicyPath(op string, instanceId string, databaseName string) (string, error) {
+	if databaseName != "" {
+		if err := om.requireDatabase(op, instanceId, databaseName); err != nil {
+			return "", err
+		}
+		return filepath.Join(om.databaseDir(instanceId, databaseName), "iam.json"), nil
+	}
+	if err := om.requireInstance(op, instanceId); err != nil {
+		return "", err
+	}
+	return filepath.Join(om.instanceDir(instanceId), "iam.json"), nil
+}
+
+// readIamPolicy returns the stored policy of the resource, an empty one when
+// none was set yet.
+func (om *operatorMock) readIamPolicy(op string, instanceId string, databaseName string) (*iam.Policy, string, error) {
+	policyPath, err := om.iamPolicyPath(op, instanceId, databaseName)
+	if err != nil {
+		return nil, "", err
+	}
+	policy := &iam.Policy{}
+	err = readMockProto(policyPath, policy)
+	if os.IsNotExist(err) {
+		return policy, policyPath, nil
+	} else if err != nil {
+		return nil, "", err
+	}
+	return policy, policyPath, nil
+}
+
+func (om *operatorMock) GetIamPolicy(ctx context.Context, instanceId string, databaseName string) (*iam.Policy, error) {
+	log.Print("Get IAM policy...")
+	if err := om.begin(ctx); err != nil {
+		return nil, err
+	}
+	policy, _, err := om.readIamPolicy("get iam policy", instanceId, databaseName)
+	return policy, err
+}
+
+func (om *operatorMock) SetIamPolicy(ctx context.Context, instanceId string, databaseName string, policy *iam.Policy) (*iam.Policy, error) {
+	log.Print("Set IAM policy...")
+	if err := om.begin(ctx); err != nil {
+		return nil, err
+	}
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	current, policyPath, err := om.readIamPolicy("set iam policy", instanceId, databaseName)
+	if err != nil {
+		return nil, err
+	}
+	// Like Spanner, refuse a policy read before the last write.
+	if len(policy.Etag) > 0 && !bytes.Equal(policy.Etag, current.Etag) {
+		return nil, status.Errorf(codes.Aborted, "etag of the IAM policy of %s is stale", iamResource(om.projectId, instanceId, databaseName))
+	}
+	updated := proto.Clone(policy).(*iam.Policy)
+	updated.Etag = []byte(strconv.FormatInt(time.Now().UnixNano(), 36))
+	if err := writeMockProto(policyPath, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// TestIamPermissions grants every permission, the mock has no notion of the
+// caller.
+func (om *operatorMock) TestIamPermissions(ctx context.Context, instanceId string, databaseName string, permissions []string) ([]string, error) {
+	if err := om.begin(ctx); err != nil {
+		return nil, err
+	}
+	if _, err := om.iamPolicyPath("test iam permissions", instanceId, databaseName); err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (om *operatorMock) GetDatabaseRoleGrants(ctx context.Context, instanceId string, name string, role string) ([]DatabaseRoleGrant, error) {
+	log.Printf("Get grants of database role %s...", role)
+	if err := om.begin(ctx); err != nil {
+		return nil, err
+	}
+	statements, err := om.readDdl("get database role", instanceId, name)
+	if err != nil {
+		return nil, err
+	}
+	held, ok := replayDatabaseRoles(statements)[role]
+	if !ok {
+		return nil, mockNotExist("get database role", role)
+	}
+	grants := make([]DatabaseRoleGrant, 0, len(held))
+	for g := range held {
+		grants = append(grants, g)
+	}
+	SortDatabaseRoleGrants(grants)
+	return grants, nil
 }
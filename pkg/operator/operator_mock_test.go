@@ -0,0 +1,222 @@
+package operator
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestMock returns a mock keeping its files in a new temporary directory,
+// and a func removing it.
+func newTestMock(t *testing.T) (*operatorMock, func()) {
+	dataDir, err := ioutil.TempDir("", "operator-mock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	om, err := newOperatorMock("test", dataDir)
+	if err != nil {
+		os.RemoveAll(dataDir)
+		t.Fatal(err)
+	}
+	return om, func() { os.RemoveAll(dataDir) }
+}
+
+func TestNewOperatorMockFormat(t *testing.T) {
+	for name, tc := range map[string]struct {
+		files map[string]string
+		valid bool
+	}{
+		"empty":          {nil, true},
+		"current":        {map[string]string{mockFormatFile: `{"version":1}`}, true},
+		"newer":          {map[string]string{mockFormatFile: `{"version":2}`}, false},
+		"older":          {map[string]string{mockFormatFile: `{"version":0}`}, false},
+		"unreadable":     {map[string]string{mockFormatFile: `{`}, false},
+		"flat instances": {map[string]string{"instance_test.json": `{}`}, false},
+		"flat databases": {map[string]string{"database_test.json": `{}`}, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dataDir, err := ioutil.TempDir("", "operator-mock")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dataDir)
+			for file, content := range tc.files {
+				if err := ioutil.WriteFile(filepath.Join(dataDir, file), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err = newOperatorMock("test", dataDir)
+			if !tc.valid {
+				if err == nil {
+					t.Fatalf("expected the dataset to be refused")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var format mockFormat
+			if err := readMockJSON(filepath.Join(dataDir, mockFormatFile), &format); err != nil {
+				t.Fatal(err)
+			}
+			if format.Version != mockFormatVersion {
+				t.Errorf("expected format version %d to be recorded, got %d", mockFormatVersion, format.Version)
+			}
+		})
+	}
+}
+
+func TestOperatorMockRequiresParent(t *testing.T) {
+	om, cleanup := newTestMock(t)
+	defer cleanup()
+	ctx := context.Background()
+	if _, err := om.CreateInstance(ctx, "testing", "testing", "regional-asia-northeast1", Nodes(1)); err != nil {
+		t.Fatal(err)
+	}
+	expireTime := time.Now().Add(24 * time.Hour)
+
+	for name, call := range map[string]func() error{
+		"scale missing instance": func() error {
+			_, err := om.Scale(ctx, "missing", Nodes(2))
+			return err
+		},
+		"create database in missing instance": func() error {
+			_, err := om.CreateDatabase(ctx, "missing", "test", nil)
+			return err
+		},
+		"update ddl of missing database": func() error {
+			_, err := om.UpdateDatabaseDdl(ctx, "testing", "missing", []string{createUsers})
+			return err
+		},
+		"back up missing database": func() error {
+			_, err := om.CreateBackup(ctx, "testing", "backup", "missing", expireTime, time.Time{})
+			return err
+		},
+		"restore from missing backup": func() error {
+			_, err := om.RestoreDatabase(ctx, "testing", "test", "testing", "missing")
+			return err
+		},
+		"get policy of missing database": func() error {
+			_, err := om.GetIamPolicy(ctx, "testing", "missing")
+			return err
+		},
+		"get policy of missing instance": func() error {
+			_, err := om.GetIamPolicy(ctx, "missing", "")
+			return err
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := call(); !om.IsNotFoundError(err) {
+				t.Errorf("expected a not found error, got %v", err)
+			}
+		})
+	}
+	if _, err := om.GetDatabase(ctx, "missing", "test"); !om.IsNotFoundError(err) {
+		t.Errorf("expected no database to be created in a missing instance, got %v", err)
+	}
+}
+
+func TestOperatorMockKeepsDatabasesPerInstance(t *testing.T) {
+	om, cleanup := newTestMock(t)
+	defer cleanup()
+	ctx := context.Background()
+	for _, instanceId := range []string{"testing", "staging"} {
+		if _, err := om.CreateInstance(ctx, instanceId, instanceId, "regional-asia-northeast1", Nodes(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := om.CreateDatabase(ctx, "testing", "test", []string{createUsers}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := om.CreateDatabase(ctx, "staging", "test", nil); err != nil {
+		t.Fatal(err)
+	}
+	ddl, err := om.readDdl("get database ddl", "testing", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ddl) != 1 || ddl[0] != createUsers {
+		t.Errorf("expected the database of staging to leave the one of testing alone, got ddl %v", ddl)
+	}
+}
+
+func TestOperatorMockDeleteInstance(t *testing.T) {
+	for name, tc := range map[string]struct {
+		backupId string
+		deleted  bool
+	}{
+		"cascades to databases": {"", true},
+		"refused with backups":  {"test-backup", false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			om, cleanup := newTestMock(t)
+			defer cleanup()
+			ctx := context.Background()
+			for _, instanceId := range []string{"testing", "staging"} {
+				if _, err := om.CreateInstance(ctx, instanceId, instanceId, "regional-asia-northeast1", Nodes(1)); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := om.CreateDatabase(ctx, instanceId, "test", nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.backupId != "" {
+				if _, err := om.CreateBackup(ctx, "testing", tc.backupId, "test", time.Now().Add(24*time.Hour), time.Time{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := om.DeleteInstance(ctx, "testing")
+			if tc.deleted {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := om.GetDatabase(ctx, "testing", "test"); !om.IsNotFoundError(err) {
+					t.Errorf("expected the database to be deleted with its instance, got %v", err)
+				}
+				if _, err := om.GetInstance(ctx, "testing"); !om.IsNotFoundError(err) {
+					t.Errorf("expected the instance to be deleted, got %v", err)
+				}
+			} else {
+				if err == nil || om.IsNotFoundError(err) {
+					t.Fatalf("expected the deletion to be refused, got %v", err)
+				}
+				if backupName := om.backupName("testing", tc.backupId); !strings.Contains(err.Error(), backupName) {
+					t.Errorf("expected the error to name backup %s, got %v", backupName, err)
+				}
+				if _, err := om.GetDatabase(ctx, "testing", "test"); err != nil {
+					t.Errorf("expected the database to be kept, got %v", err)
+				}
+			}
+			if _, err := om.GetDatabase(ctx, "staging", "test"); err != nil {
+				t.Errorf("expected the database of another instance to be kept, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOperatorMockDeleteInstanceRefusesDropProtection(t *testing.T) {
+	om, cleanup := newTestMock(t)
+	defer cleanup()
+	ctx := context.Background()
+	if _, err := om.CreateInstance(ctx, "testing", "testing", "regional-asia-northeast1", Nodes(1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := om.CreateDatabase(ctx, "testing", "test", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := om.SetDropProtection(ctx, "testing", "test", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := om.DeleteInstance(ctx, "testing"); !os.IsPermission(err) {
+		t.Errorf("expected a permission error, got %v", err)
+	}
+	if _, err := om.GetDatabase(ctx, "testing", "test"); err != nil {
+		t.Errorf("expected the database to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package operator
+
+import "sort"
+
+// DefaultPageSize is the number of resources a list method returns when no
+// page size is given.
+const DefaultPageSize = 100
+
+func pageSizeOrDefault(pageSize int32) int32 {
+	if pageSize <= 0 {
+		return DefaultPageSize
+	}
+	return pageSize
+}
+
+// page returns the bounds of the page of the sorted ids starting after
+// pageToken, and the token of the next page. The mock and the fake use the
+// last ID of a page as the token of the next one, so listing stays
+// consistent while resources are added or removed.
+func page(ids []string, pageSize int32, pageToken string) (int, int, string) {
+	lo := 0
+	if pageToken != "" {
+		lo = sort.Search(len(ids), func(i int) bool {
+			return ids[i] > pageToken
+		})
+	}
+	hi := lo + int(pageSizeOrDefault(pageSize))
+	if hi >= len(ids) {
+		return lo, len(ids), ""
+	}
+	return lo, hi, ids[hi-1]
+}
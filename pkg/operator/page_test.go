@@ -0,0 +1,116 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPage(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e"}
+	for _, tc := range []struct {
+		pageSize  int32
+		pageToken string
+		lo, hi    int
+		next      string
+	}{
+		{2, "", 0, 2, "b"},
+		{2, "b", 2, 4, "d"},
+		{2, "d", 4, 5, ""},
+		{5, "", 0, 5, ""},
+		{0, "", 0, 5, ""},
+		// A token of a resource removed since still starts after it.
+		{2, "bb", 2, 4, "d"},
+		{2, "e", 5, 5, ""},
+	} {
+		lo, hi, next := page(ids, tc.pageSize, tc.pageToken)
+		if lo != tc.lo || hi != tc.hi || next != tc.next {
+			t.Errorf("page(%d, %q): expected [%d:%d] and %q, got [%d:%d] and %q",
+				tc.pageSize, tc.pageToken, tc.lo, tc.hi, tc.next, lo, hi, next)
+		}
+	}
+}
+
+// listInstanceIds pages through the instances of op like spnadm does,
+// stopping at the first error.
+func listInstanceIds(op Operator, pageSize int32) ([]string, error) {
+	var ids []string
+	pageToken := ""
+	for {
+		instances, next, err := op.ListInstances(context.Background(), pageSize, pageToken)
+		if err != nil {
+			return ids, err
+		}
+		for _, i := range instances {
+			ids = append(ids, i.Name)
+		}
+		if next == "" {
+			return ids, nil
+		}
+		pageToken = next
+	}
+}
+
+func newFakeWithInstances(t *testing.T, instanceIds ...string) *Fake {
+	fake := NewBuilder().ProjectId("test").BuildFake()
+	for _, instanceId := range instanceIds {
+		if _, err := fake.CreateInstance(context.Background(), instanceId, instanceId, "regional-asia-northeast1", Nodes(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fake
+}
+
+func TestListInstancesPages(t *testing.T) {
+	fake := newFakeWithInstances(t, "c", "a", "e", "b", "d")
+	ids, err := listInstanceIds(fake, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"projects/test/instances/a",
+		"projects/test/instances/b",
+		"projects/test/instances/c",
+		"projects/test/instances/d",
+		"projects/test/instances/e",
+	}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+}
+
+func TestListInstancesStopsOnError(t *testing.T) {
+	fake := newFakeWithInstances(t, "a", "b", "c")
+	instances, next, err := fake.ListInstances(context.Background(), 2, "")
+	if err != nil || len(instances) != 2 {
+		t.Fatalf("expected the first page, got %v and %v", instances, err)
+	}
+
+	unavailable := errors.New("unavailable")
+	fake.FailNext("ListInstances", unavailable)
+	instances, next, err = fake.ListInstances(context.Background(), 2, next)
+	if err != unavailable {
+		t.Errorf("expected %v, got %v", unavailable, err)
+	}
+	if len(instances) != 0 || next != "" {
+		t.Errorf("expected no page after an error, got %v and token %q", instances, next)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := fake.ListInstances(ctx, 2, ""); err == nil {
+		t.Errorf("expected listing with a cancelled context to fail")
+	}
+}
+
+func TestListDatabasesOfMissingInstance(t *testing.T) {
+	fake := newFakeWithInstances(t)
+	databases, next, err := fake.ListDatabases(context.Background(), "missing", 2, "")
+	if !fake.IsNotFoundError(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+	if len(databases) != 0 || next != "" {
+		t.Errorf("expected no page, got %v and token %q", databases, next)
+	}
+}
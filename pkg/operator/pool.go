@@ -0,0 +1,176 @@
+package operator
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// ServiceAccountKey is a service account key, in JSON, that resources can
+// name to be managed with instead of the controller's own credentials.
+type ServiceAccountKey struct {
+	// Source identifies where the key is kept, e.g. the namespace, name and
+	// key of a Secret.
+	Source string
+	// Version changes whenever the key at Source does, e.g. the
+	// resourceVersion of the Secret.
+	Version string
+	Data    []byte
+}
+
+// Pool hands out one Operator per GCP project and credentials, so a single
+// controller can manage resources in several projects. Operators are built
+// on first use and kept until the pool is closed, except that an Operator
+// built from a ServiceAccountKey is closed and rebuilt once the key has a
+// new version.
+type Pool struct {
+	defaultProjectId string
+	build            func(projectId string, key []byte) (Operator, error)
+
+	mu        sync.Mutex
+	operators map[poolKey]*poolEntry
+}
+
+type poolKey struct {
+	projectId string
+	source    string
+}
+
+type poolEntry struct {
+	operator Operator
+	version  string
+}
+
+// NewPool returns a Pool building the Operator of a project with build,
+// which is passed a nil key for the default credentials. Resources that name
+// no project belong to defaultProjectId.
+func NewPool(defaultProjectId string, build func(projectId string, key []byte) (Operator, error)) *Pool {
+	return &Pool{
+		defaultProjectId: defaultProjectId,
+		build:            build,
+		operators:        map[poolKey]*poolEntry{},
+	}
+}
+
+// ProjectId returns projectId, or the default project when it is empty.
+func (p *Pool) ProjectId(projectId string) string {
+	if projectId == "" {
+		return p.defaultProjectId
+	}
+	return projectId
+}
+
+// Get returns the Operator of projectId, or of the default project when
+// projectId is empty, using the default credentials.
+func (p *Pool) Get(projectId string) (Operator, error) {
+	return p.GetWithKey(projectId, nil)
+}
+
+// GetWithKey returns the Operator of projectId authenticating with key, or
+// with the default credentials when key is nil. Calls still running on the
+// Operator replaced by a rotated key fail once it is closed, and are
+// expected to be retried.
+func (p *Pool) GetWithKey(projectId string, key *ServiceAccountKey) (Operator, error) {
+	k := poolKey{projectId: p.ProjectId(projectId)}
+	var version string
+	var data []byte
+	if key != nil {
+		k.source = key.Source
+		version = key.Version
+		data = key.Data
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.operators[k]
+	if ok && entry.version == version {
+		return entry.operator, nil
+	}
+	op, err := p.build(k.projectId, data)
+	if err != nil {
+		return nil, fmt.Errorf("building operator of project %s: %v", k.projectId, err)
+	}
+	if ok {
+		if err := entry.operator.Close(); err != nil {
+			log.Printf("Closing the operator of project %s: %v", k.projectId, err)
+		}
+	}
+	p.operators[k] = &poolEntry{operator: op, version: version}
+	return op, nil
+}
+
+// Close closes every Operator of the pool, returning the first error.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var first error
+	for k, entry := range p.operators {
+		if err := entry.operator.Close(); err != nil && first == nil {
+			first = err
+		}
+		delete(p.operators, k)
+	}
+	return first
+}
+
+// InstanceName returns the fully qualified name of the instance instanceId
+// of projectId.
+func InstanceName(projectId string, instanceId string) string {
+	return fmt.Sprintf("projects/%s/instances/%s", projectId, instanceId)
+}
+
+// DatabaseName returns the fully qualified name of the database name of
+// instanceId in projectId.
+func DatabaseName(projectId string, instanceId string, name string) string {
+	return fmt.Sprintf("%s/databases/%s", InstanceName(projectId, instanceId), name)
+}
+
+// BackupName returns the fully qualified name of the backup backupId of
+// instanceId in projectId.
+func BackupName(projectId string, instanceId string, backupId string) string {
+	return fmt.Sprintf("%s/backups/%s", InstanceName(projectId, instanceId), backupId)
+}
+
+// ParseInstanceName splits the fully qualified name of an instance, as made
+// by InstanceName, into its project and instance IDs. ok is false when name
+// is not one.
+func ParseInstanceName(name string) (projectId string, instanceId string, ok bool) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 4 || parts[0] != "projects" || parts[2] != "instances" {
+		return "", "", false
+	}
+	return parts[1], parts[3], true
+}
+
+// ParseDatabaseName splits the fully qualified name of a database, as made
+// by DatabaseName, into its project, instance and database IDs. ok is false
+// when name is not one.
+func ParseDatabaseName(name string) (projectId string, instanceId string, databaseId string, ok bool) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 6 || parts[0] != "projects" || parts[2] != "instances" || parts[4] != "databases" {
+		return "", "", "", false
+	}
+	return parts[1], parts[3], parts[5], true
+}
+
+// ParseBackupName splits the fully qualified name of a backup, as made by
+// BackupName, into its project, instance and backup IDs. ok is false when
+// name is not one.
+func ParseBackupName(name string) (projectId string, instanceId string, backupId string, ok bool) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 6 || parts[0] != "projects" || parts[2] != "instances" || parts[4] != "backups" {
+		return "", "", "", false
+	}
+	return parts[1], parts[3], parts[5], true
+}
+
+// ProjectIdOf returns the project of a fully qualified resource or operation
+// name such as projects/p/instances/i/operations/o, empty when name is not
+// one.
+func ProjectIdOf(name string) string {
+	parts := strings.SplitN(name, "/", 3)
+	if len(parts) < 2 || parts[0] != "projects" {
+		return ""
+	}
+	return parts[1]
+}
@@ -0,0 +1,134 @@
+// Package webhook serves the validating admission webhook rejecting
+// SpannerInstances and SpannerDatabases Spanner would refuse, before they are
+// stored.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	databasev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+	instancev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/instanceadmins/v1alpha1"
+)
+
+// Paths the webhook is served on, as registered in the
+// ValidatingWebhookConfiguration.
+const (
+	SpannerInstancePath = "/validate/spannerinstances"
+	SpannerDatabasePath = "/validate/spannerdatabases"
+)
+
+// reviewTimeout bounds the Spanner calls made to review a single request,
+// well within the 30 seconds the API server waits for the webhook.
+const reviewTimeout = 10 * time.Second
+
+// NewHandler returns the http.Handler serving the reviews of validator.
+func NewHandler(validator *Validator) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(SpannerInstancePath, func(w http.ResponseWriter, r *http.Request) {
+		serve(w, r, func(ctx context.Context, req *admissionv1beta1.AdmissionRequest) (field.ErrorList, error) {
+			var spannerInstance, old *instancev1alpha1.SpannerInstance
+			if err := decodeObjects(req, &spannerInstance, &old); err != nil {
+				return nil, err
+			}
+			return validator.ValidateSpannerInstance(ctx, spannerInstance, old), nil
+		})
+	})
+	mux.HandleFunc(SpannerDatabasePath, func(w http.ResponseWriter, r *http.Request) {
+		serve(w, r, func(ctx context.Context, req *admissionv1beta1.AdmissionRequest) (field.ErrorList, error) {
+			var spannerDatabase, old *databasev1alpha1.SpannerDatabase
+			if err := decodeObjects(req, &spannerDatabase, &old); err != nil {
+				return nil, err
+			}
+			return validator.ValidateSpannerDatabase(spannerDatabase, old), nil
+		})
+	})
+	return mux
+}
+
+// Run serves handler over HTTPS on addr with the certificate and key in
+// certFile and keyFile, until ctx is done.
+func Run(ctx context.Context, addr, certFile, keyFile string, handler http.Handler) error {
+	server := &http.Server{Addr: addr, Handler: handler}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), reviewTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Error shutting down webhook server: %s", err.Error())
+		}
+	}()
+	log.Printf("Serving webhook on %s", addr)
+	if err := server.ListenAndServeTLS(certFile, keyFile); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// serve answers the AdmissionReview of r with the errors validate finds in
+// its request.
+func serve(w http.ResponseWriter, r *http.Request, validate func(context.Context, *admissionv1beta1.AdmissionRequest) (field.ErrorList, error)) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var review admissionv1beta1.AdmissionReview
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(w, fmt.Sprintf("expected an AdmissionReview request, got %s", body), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), reviewTimeout)
+	defer cancel()
+	response := &admissionv1beta1.AdmissionResponse{UID: review.Request.UID, Allowed: true}
+	errs, err := validate(ctx, review.Request)
+	if err != nil {
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: err.Error(),
+			Reason:  metav1.StatusReasonBadRequest,
+			Code:    http.StatusBadRequest,
+		}
+	} else if len(errs) > 0 {
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: errs.ToAggregate().Error(),
+			Reason:  metav1.StatusReasonInvalid,
+			Code:    http.StatusUnprocessableEntity,
+		}
+	}
+
+	review.Request = nil
+	review.Response = response
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&review); err != nil {
+		log.Printf("Error writing admission review: %s", err.Error())
+	}
+}
+
+// decodeObjects decodes the object of req into obj, and the object it
+// replaces, for updates, into old.
+func decodeObjects(req *admissionv1beta1.AdmissionRequest, obj, old interface{}) error {
+	if err := json.Unmarshal(req.Object.Raw, obj); err != nil {
+		return fmt.Errorf("decoding %s %s: %v", req.Kind.Kind, req.Name, err)
+	}
+	if req.Operation != admissionv1beta1.Update {
+		return nil
+	}
+	if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+		return fmt.Errorf("decoding old %s %s: %v", req.Kind.Kind, req.Name, err)
+	}
+	return nil
+}
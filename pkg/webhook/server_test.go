@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	databasev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+	instancev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/instanceadmins/v1alpha1"
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+func newHandler() http.Handler {
+	operators := operator.NewPool("test", func(projectId string, key []byte) (operator.Operator, error) {
+		return operator.NewBuilder().ProjectId(projectId).BuildFake(), nil
+	})
+	k8sI := kubeinformers.NewSharedInformerFactory(k8sfake.NewSimpleClientset(), 0)
+	return NewHandler(NewValidator(operators, k8sI.Core().V1().Secrets().Lister()))
+}
+
+func newSpannerInstance(name string) *instancev1alpha1.SpannerInstance {
+	return &instancev1alpha1.SpannerInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceDefault},
+		Spec: instancev1alpha1.SpannerInstanceSpec{
+			InstanceConfig: "regional-asia-northeast1",
+			NodeCount:      1,
+		},
+	}
+}
+
+func newSpannerDatabase(name string) *databasev1alpha1.SpannerDatabase {
+	return &databasev1alpha1.SpannerDatabase{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceDefault},
+		Spec:       databasev1alpha1.SpannerDatabaseSpec{InstanceId: "testing"},
+	}
+}
+
+// review posts the AdmissionReview of obj, replacing old when old is not
+// nil, to path and returns the response.
+func review(t *testing.T, path string, obj, old runtime.Object) *admissionv1beta1.AdmissionResponse {
+	request := &admissionv1beta1.AdmissionRequest{UID: "uid", Operation: admissionv1beta1.Create}
+	request.Object.Object = obj
+	if old != nil {
+		request.Operation = admissionv1beta1.Update
+		request.OldObject.Object = old
+	}
+	body, err := json.Marshal(&admissionv1beta1.AdmissionReview{Request: request})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	newHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body)
+	}
+	var response admissionv1beta1.AdmissionReview
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Response == nil || response.Response.UID != "uid" {
+		t.Fatalf("expected a response to request uid, got %+v", response.Response)
+	}
+	return response.Response
+}
+
+// expectAllowed checks response allows the request.
+func expectAllowed(t *testing.T, response *admissionv1beta1.AdmissionResponse) {
+	if !response.Allowed {
+		t.Errorf("expected the request to be allowed, got %v", response.Result.Message)
+	}
+}
+
+// expectDenied checks response denies the request with a message holding
+// each of messages.
+func expectDenied(t *testing.T, response *admissionv1beta1.AdmissionResponse, messages ...string) {
+	if response.Allowed {
+		t.Fatalf("expected the request to be denied")
+	}
+	for _, message := range messages {
+		if !strings.Contains(response.Result.Message, message) {
+			t.Errorf("expected the denial to mention %q, got %q", message, response.Result.Message)
+		}
+	}
+}
+
+func TestAllowsValidSpannerInstance(t *testing.T) {
+	expectAllowed(t, review(t, SpannerInstancePath, newSpannerInstance("testing"), nil))
+}
+
+func TestDeniesInvalidSpannerInstance(t *testing.T) {
+	spannerInstance := newSpannerInstance("testing")
+	spannerInstance.Spec.InstanceId = "Testing"
+	spannerInstance.Spec.NodeCount = 0
+	spannerInstance.Spec.InstanceConfig = "regional-us-wast1"
+
+	expectDenied(t, review(t, SpannerInstancePath, spannerInstance, nil),
+		"spec.instanceId", "spec.nodeCount", "spec.instanceConfig", "regional-us-west1")
+}
+
+func TestDeniesChangedInstanceOnceCreated(t *testing.T) {
+	old := newSpannerInstance("testing")
+	old.Status.InstanceName = "projects/test/instances/testing"
+	spannerInstance := old.DeepCopy()
+	spannerInstance.Spec.InstanceId = "renamed"
+	spannerInstance.Spec.InstanceConfig = "regional-us-west1"
+
+	expectDenied(t, review(t, SpannerInstancePath, spannerInstance, old),
+		"spec.instanceId: Forbidden", "spec.instanceConfig: Forbidden")
+}
+
+func TestAllowsScalingInstance(t *testing.T) {
+	old := newSpannerInstance("testing")
+	old.Status.InstanceName = "projects/test/instances/testing"
+	spannerInstance := old.DeepCopy()
+	spannerInstance.Spec.NodeCount = 3
+
+	expectAllowed(t, review(t, SpannerInstancePath, spannerInstance, old))
+}
+
+func TestAllowsChangedInstanceBeforeCreation(t *testing.T) {
+	old := newSpannerInstance("testing")
+	old.Spec.InstanceConfig = "regional-us-wast1"
+	spannerInstance := old.DeepCopy()
+	spannerInstance.Spec.InstanceConfig = "regional-us-west1"
+
+	expectAllowed(t, review(t, SpannerInstancePath, spannerInstance, old))
+}
+
+func TestAllowsReleasingDeletedInstance(t *testing.T) {
+	// Created before the webhook, with an ID Spanner refuses.
+	old := newSpannerInstance("Testing")
+	old.Spec.NodeCount = 0
+	deletionTimestamp := metav1.Now()
+	old.DeletionTimestamp = &deletionTimestamp
+	old.Finalizers = []string{"instanceadmins.spanner-operator.io/delete-instance"}
+	spannerInstance := old.DeepCopy()
+	spannerInstance.Finalizers = nil
+
+	expectAllowed(t, review(t, SpannerInstancePath, spannerInstance, old))
+}
+
+func TestAllowsValidSpannerDatabase(t *testing.T) {
+	spannerDatabase := newSpannerDatabase("orders")
+	expectAllowed(t, review(t, SpannerDatabasePath, spannerDatabase, nil))
+
+	spannerDatabase.Spec.InstanceId = ""
+	spannerDatabase.Spec.InstanceRef = &databasev1alpha1.SpannerInstanceReference{Name: "testing"}
+	spannerDatabase.Spec.DatabaseId = "orders_v2"
+	expectAllowed(t, review(t, SpannerDatabasePath, spannerDatabase, nil))
+}
+
+func TestDeniesInvalidSpannerDatabase(t *testing.T) {
+	spannerDatabase := newSpannerDatabase("orders")
+	spannerDatabase.Spec.InstanceId = ""
+	spannerDatabase.Spec.DatabaseId = "-orders"
+
+	expectDenied(t, review(t, SpannerDatabasePath, spannerDatabase, nil), "spec.instanceId", "spec.databaseId")
+
+	spannerDatabase = newSpannerDatabase("orders.v2")
+	expectDenied(t, review(t, SpannerDatabasePath, spannerDatabase, nil), "metadata.name")
+}
+
+func TestDeniesChangedDatabaseOnceCreated(t *testing.T) {
+	old := newSpannerDatabase("orders")
+	old.Status.DatabaseName = "projects/test/instances/testing/databases/orders"
+	spannerDatabase := old.DeepCopy()
+	spannerDatabase.Spec.InstanceId = "staging"
+	spannerDatabase.Spec.DatabaseId = "orders_v2"
+
+	expectDenied(t, review(t, SpannerDatabasePath, spannerDatabase, old),
+		"spec.instanceId: Forbidden", "spec.databaseId: Forbidden")
+}
+
+func TestRejectsMalformedReview(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	newHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, SpannerInstancePath, strings.NewReader("{}")))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", recorder.Code)
+	}
+}
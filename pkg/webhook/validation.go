@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"context"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	corelisters "k8s.io/client-go/listers/core/v1"
+
+	databasev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/databaseadmins/v1alpha1"
+	instancev1alpha1 "github.com/katsew/spanner-operator/pkg/apis/instanceadmins/v1alpha1"
+
+	"github.com/katsew/spanner-operator/pkg/credentials"
+	"github.com/katsew/spanner-operator/pkg/operator"
+)
+
+// Validator checks SpannerInstances and SpannerDatabases against the rules
+// Spanner would otherwise only enforce once the controllers act on them.
+type Validator struct {
+	operators    *operator.Pool
+	secretLister corelisters.SecretLister
+}
+
+// NewValidator returns a Validator looking instance configs up through the
+// Operators of operators, authenticated as the specs select in the Secrets
+// of secretLister.
+func NewValidator(operators *operator.Pool, secretLister corelisters.SecretLister) *Validator {
+	return &Validator{operators: operators, secretLister: secretLister}
+}
+
+// ValidateSpannerInstance checks spannerInstance, updated from old when old
+// is not nil. The Spanner instance it was created as cannot be changed.
+func (v *Validator) ValidateSpannerInstance(ctx context.Context, spannerInstance, old *instancev1alpha1.SpannerInstance) field.ErrorList {
+	spec := field.NewPath("spec")
+	var errs field.ErrorList
+	if old != nil {
+		if spannerInstance.DeletionTimestamp != nil || reflect.DeepEqual(spannerInstance.Spec, old.Spec) {
+			// Finalizers are removed from deleted SpannerInstances and
+			// metadata updated whatever the spec, which was checked before.
+			return nil
+		}
+		if old.Status.InstanceName != "" {
+			errs = append(errs, immutable(spec.Child("projectId"), spannerInstance.Spec.ProjectId, old.Spec.ProjectId)...)
+			errs = append(errs, immutable(instanceIdPath(spannerInstance), spannerInstance.InstanceId(), old.InstanceId())...)
+			errs = append(errs, immutable(spec.Child("instanceConfig"), spannerInstance.Spec.InstanceConfig, old.Spec.InstanceConfig)...)
+		}
+	}
+
+	instanceId := spannerInstance.InstanceId()
+	if err := operator.ValidateInstanceId(instanceId); err != nil {
+		errs = append(errs, field.Invalid(instanceIdPath(spannerInstance), instanceId, err.Error()))
+	}
+	capacity := operator.Capacity{NodeCount: spannerInstance.Spec.NodeCount, ProcessingUnits: spannerInstance.Spec.ProcessingUnits}
+	if err := capacity.Validate(); err != nil {
+		if capacity.InProcessingUnits() {
+			errs = append(errs, field.Invalid(spec.Child("processingUnits"), capacity.ProcessingUnits, err.Error()))
+		} else {
+			errs = append(errs, field.Invalid(spec.Child("nodeCount"), capacity.NodeCount, err.Error()))
+		}
+	}
+	if old == nil || spannerInstance.Spec.InstanceConfig != old.Spec.InstanceConfig {
+		errs = append(errs, v.validateInstanceConfig(ctx, spannerInstance)...)
+	}
+	return errs
+}
+
+// validateInstanceConfig checks the instance config of spannerInstance is
+// one of its project. Configs that cannot be looked up, for lack of
+// credentials or because Spanner is unavailable, are left to the controller.
+func (v *Validator) validateInstanceConfig(ctx context.Context, spannerInstance *instancev1alpha1.SpannerInstance) field.ErrorList {
+	path := field.NewPath("spec", "instanceConfig")
+	configId := spannerInstance.Spec.InstanceConfig
+	if configId == "" {
+		return field.ErrorList{field.Required(path, "")}
+	}
+	op, err := credentials.Operator(v.operators, v.secretLister, spannerInstance.Namespace, spannerInstance.Spec.ProjectId, spannerInstance.Spec.CredentialsSecretRef)
+	if err != nil {
+		return nil
+	}
+	if _, err := op.GetInstanceConfig(ctx, configId); err == nil || !op.IsNotFoundError(err) {
+		return nil
+	}
+	if err := operator.CheckInstanceConfig(ctx, op, configId); err != nil {
+		return field.ErrorList{field.Invalid(path, configId, err.Error())}
+	}
+	return nil
+}
+
+// ValidateSpannerDatabase checks spannerDatabase, updated from old when old
+// is not nil. The Spanner database it was created as cannot be changed.
+func (v *Validator) ValidateSpannerDatabase(spannerDatabase, old *databasev1alpha1.SpannerDatabase) field.ErrorList {
+	spec := field.NewPath("spec")
+	var errs field.ErrorList
+	if old != nil {
+		if spannerDatabase.DeletionTimestamp != nil || reflect.DeepEqual(spannerDatabase.Spec, old.Spec) {
+			return nil
+		}
+		if old.Status.DatabaseName != "" {
+			errs = append(errs, immutable(spec.Child("projectId"), spannerDatabase.Spec.ProjectId, old.Spec.ProjectId)...)
+			errs = append(errs, immutable(spec.Child("instanceId"), spannerDatabase.Spec.InstanceId, old.Spec.InstanceId)...)
+			errs = append(errs, immutable(spec.Child("instanceRef"), spannerDatabase.Spec.InstanceRef, old.Spec.InstanceRef)...)
+			errs = append(errs, immutable(databaseIdPath(spannerDatabase), spannerDatabase.DatabaseId(), old.DatabaseId())...)
+		}
+	}
+
+	if ref := spannerDatabase.Spec.InstanceRef; ref != nil {
+		if ref.Name == "" {
+			errs = append(errs, field.Required(spec.Child("instanceRef", "name"), ""))
+		}
+	} else if err := operator.ValidateInstanceId(spannerDatabase.Spec.InstanceId); err != nil {
+		errs = append(errs, field.Invalid(spec.Child("instanceId"), spannerDatabase.Spec.InstanceId, err.Error()))
+	}
+	databaseId := spannerDatabase.DatabaseId()
+	if err := operator.ValidateDatabaseId(databaseId); err != nil {
+		errs = append(errs, field.Invalid(databaseIdPath(spannerDatabase), databaseId, err.Error()))
+	}
+	return errs
+}
+
+// immutable returns an error at path when value differs from old.
+func immutable(path *field.Path, value, old interface{}) field.ErrorList {
+	if reflect.DeepEqual(value, old) {
+		return nil
+	}
+	return field.ErrorList{field.Forbidden(path, "field is immutable once the Spanner resource is created")}
+}
+
+// instanceIdPath returns the path of the field SpannerInstance.InstanceId
+// reads.
+func instanceIdPath(spannerInstance *instancev1alpha1.SpannerInstance) *field.Path {
+	if spannerInstance.Spec.InstanceId != "" {
+		return field.NewPath("spec", "instanceId")
+	}
+	return field.NewPath("metadata", "name")
+}
+
+// databaseIdPath returns the path of the field SpannerDatabase.DatabaseId
+// reads.
+func databaseIdPath(spannerDatabase *databasev1alpha1.SpannerDatabase) *field.Path {
+	if spannerDatabase.Spec.DatabaseId != "" {
+		return field.NewPath("spec", "databaseId")
+	}
+	return field.NewPath("metadata", "name")
+}